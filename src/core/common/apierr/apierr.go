@@ -94,9 +94,47 @@ func Wrap(err error, message string) error {
 	return &StatusError{Message: message, Cause: err}
 }
 
-// Code maps err to an HTTP status code (404, 409, or 500).
+// Forbidden returns an error that maps to HTTP 403.
+func Forbidden(message string) error {
+	return &StatusError{StatusCode: http.StatusForbidden, Message: message}
+}
+
+// ValidationError rejects a request before any CSP call, carrying structured
+// details (e.g., guardrail violations) that REST handlers return alongside the message.
+type ValidationError struct {
+	Message string
+	Details any
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+// Invalid returns an error that maps to HTTP 400 with structured details.
+func Invalid(message string, details any) error {
+	return &ValidationError{Message: message, Details: details}
+}
+
+// IsInvalid reports whether err is a request rejected by server-side validation.
+func IsInvalid(err error) bool {
+	var ve *ValidationError
+	return errors.As(err, &ve)
+}
+
+// DetailsOf returns the structured details of a ValidationError in err, if any.
+func DetailsOf(err error) any {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return ve.Details
+	}
+	return nil
+}
+
+// Code maps err to an HTTP status code (400, 403, 404, 409, or 500).
 func Code(err error) int {
 	switch {
+	case IsInvalid(err):
+		return http.StatusBadRequest
+	case IsForbidden(err):
+		return http.StatusForbidden
 	case IsNotFound(err):
 		return http.StatusNotFound
 	case IsConflict(err):
@@ -150,6 +188,13 @@ func IsConflict(err error) bool {
 	return errors.As(err, &se) && se.StatusCode == http.StatusConflict
 }
 
+// IsForbidden reports whether err represents a permission denial.
+// Only the status code is checked; CSP messages are not classified as forbidden.
+func IsForbidden(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.StatusCode == http.StatusForbidden
+}
+
 func containsAny(s string, patterns []string) bool {
 	lower := strings.ToLower(s)
 	for _, p := range patterns {
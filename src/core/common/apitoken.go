@@ -0,0 +1,316 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to include common methods for managing multi-cloud infra
+package common
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+)
+
+const (
+	apiTokenKeyPrefix = "/apitoken/"
+
+	apiTokenDefaultTTL = 30 * 24 * time.Hour
+	apiTokenMaxTTL     = 365 * 24 * time.Hour
+
+	// apiTokenLastUsedInterval limits how often last-used time is written to kvstore
+	apiTokenLastUsedInterval = time.Minute
+)
+
+var apiTokenScopes = []string{model.ApiTokenScopeReadOnly, model.ApiTokenScopeProvision, model.ApiTokenScopeCommandExec}
+
+// apiTokenRecord is what is stored in kvstore: the token info and the secret hash.
+type apiTokenRecord struct {
+	model.ApiTokenInfo
+	SecretHash string `json:"secretHash"`
+}
+
+// apiTokenLastUsed keeps the last persisted use time per token id.
+var apiTokenLastUsed sync.Map
+
+func hashApiTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func getApiTokenRecord(tokenId string) (apiTokenRecord, bool, error) {
+	val, exists, err := kvstore.Get(apiTokenKeyPrefix + tokenId)
+	if err != nil || !exists {
+		return apiTokenRecord{}, exists, err
+	}
+	rec := apiTokenRecord{}
+	if err := json.Unmarshal([]byte(val), &rec); err != nil {
+		return apiTokenRecord{}, false, err
+	}
+	return rec, true, nil
+}
+
+func putApiTokenRecord(rec apiTokenRecord) error {
+	val, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put(apiTokenKeyPrefix+rec.Id, string(val))
+}
+
+// CreateApiToken issues a namespace-scoped API token. The returned token string is
+// shown only once; only the SHA-256 hash of its secret part is stored.
+func CreateApiToken(nsId string, req *model.ApiTokenReq, createdBy string) (model.ApiTokenIssued, error) {
+	if check, err := CheckNs(nsId); err != nil || !check {
+		return model.ApiTokenIssued{}, fmt.Errorf("namespace '%s' does not exist", nsId)
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return model.ApiTokenIssued{}, fmt.Errorf("name is required")
+	}
+	if len(req.Scopes) == 0 {
+		return model.ApiTokenIssued{}, fmt.Errorf("at least one scope is required (available: %s)", strings.Join(apiTokenScopes, ", "))
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(apiTokenScopes, scope) {
+			return model.ApiTokenIssued{}, fmt.Errorf("unknown scope '%s' (available: %s)", scope, strings.Join(apiTokenScopes, ", "))
+		}
+	}
+	ttl := apiTokenDefaultTTL
+	if req.ExpiresInHours < 0 {
+		return model.ApiTokenIssued{}, fmt.Errorf("expiresInHours must not be negative")
+	}
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if ttl > apiTokenMaxTTL {
+		return model.ApiTokenIssued{}, fmt.Errorf("expiresInHours must not exceed %d", int(apiTokenMaxTTL.Hours()))
+	}
+
+	tokenId, err := randomHex(8)
+	if err != nil {
+		return model.ApiTokenIssued{}, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return model.ApiTokenIssued{}, err
+	}
+
+	now := time.Now().UTC()
+	rec := apiTokenRecord{
+		ApiTokenInfo: model.ApiTokenInfo{
+			Id:          tokenId,
+			NsId:        nsId,
+			Name:        req.Name,
+			Description: req.Description,
+			Scopes:      slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+			CreatedBy:   createdBy,
+			CreatedAt:   now.Format(time.RFC3339),
+			ExpiresAt:   now.Add(ttl).Format(time.RFC3339),
+		},
+		SecretHash: hashApiTokenSecret(secret),
+	}
+	if err := putApiTokenRecord(rec); err != nil {
+		log.Error().Err(err).Msg("")
+		return model.ApiTokenIssued{}, err
+	}
+
+	log.Info().Msgf("API token '%s' (%s) issued for namespace '%s' with scopes %v", rec.Name, rec.Id, nsId, rec.Scopes)
+	return model.ApiTokenIssued{
+		ApiTokenInfo: rec.ApiTokenInfo,
+		Token:        model.ApiTokenPrefix + tokenId + "_" + secret,
+	}, nil
+}
+
+// GetApiToken returns an API token of a namespace (without secret).
+func GetApiToken(nsId, tokenId string) (model.ApiTokenInfo, error) {
+	rec, exists, err := getApiTokenRecord(tokenId)
+	if err != nil {
+		return model.ApiTokenInfo{}, err
+	}
+	if !exists || rec.NsId != nsId {
+		return model.ApiTokenInfo{}, fmt.Errorf("API token '%s' does not exist in namespace '%s'", tokenId, nsId)
+	}
+	return rec.ApiTokenInfo, nil
+}
+
+// ListApiTokens returns the API tokens of a namespace (without secrets), newest first.
+func ListApiTokens(nsId string) ([]model.ApiTokenInfo, error) {
+	vals, err := kvstore.GetList(apiTokenKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	tokens := []model.ApiTokenInfo{}
+	for _, val := range vals {
+		rec := apiTokenRecord{}
+		if err := json.Unmarshal([]byte(val), &rec); err != nil {
+			log.Warn().Err(err).Msg("Skipping malformed API token")
+			continue
+		}
+		if rec.NsId == nsId {
+			tokens = append(tokens, rec.ApiTokenInfo)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt > tokens[j].CreatedAt })
+	return tokens, nil
+}
+
+// RevokeApiToken revokes an API token. The record is kept for auditing.
+func RevokeApiToken(nsId, tokenId string) error {
+	rec, exists, err := getApiTokenRecord(tokenId)
+	if err != nil {
+		return err
+	}
+	if !exists || rec.NsId != nsId {
+		return fmt.Errorf("API token '%s' does not exist in namespace '%s'", tokenId, nsId)
+	}
+	if rec.Revoked {
+		return nil
+	}
+	rec.Revoked = true
+	rec.RevokedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := putApiTokenRecord(rec); err != nil {
+		return err
+	}
+	log.Info().Msgf("API token '%s' (%s) of namespace '%s' revoked", rec.Name, rec.Id, nsId)
+	return nil
+}
+
+// DeleteAllApiTokens removes every API token of a namespace.
+func DeleteAllApiTokens(nsId string) error {
+	tokens, err := ListApiTokens(nsId)
+	if err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		if err := kvstore.Delete(apiTokenKeyPrefix + t.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsApiToken reports whether a bearer credential looks like a TB-issued API token.
+func IsApiToken(token string) bool {
+	return strings.HasPrefix(token, model.ApiTokenPrefix)
+}
+
+// ValidateApiToken verifies a token string ("tbt_<id>_<secret>") and returns its info.
+// Expired and revoked tokens are rejected. Last-used time is updated at most once a minute.
+func ValidateApiToken(token string) (model.ApiTokenInfo, error) {
+	invalid := fmt.Errorf("invalid API token")
+
+	tokenId, secret, ok := strings.Cut(strings.TrimPrefix(token, model.ApiTokenPrefix), "_")
+	if !ok || tokenId == "" || secret == "" {
+		return model.ApiTokenInfo{}, invalid
+	}
+	rec, exists, err := getApiTokenRecord(tokenId)
+	if err != nil {
+		return model.ApiTokenInfo{}, err
+	}
+	if !exists || subtle.ConstantTimeCompare([]byte(rec.SecretHash), []byte(hashApiTokenSecret(secret))) != 1 {
+		return model.ApiTokenInfo{}, invalid
+	}
+	if rec.Revoked {
+		return model.ApiTokenInfo{}, fmt.Errorf("API token '%s' has been revoked", rec.Id)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, rec.ExpiresAt)
+	if err != nil || time.Now().After(expiresAt) {
+		return model.ApiTokenInfo{}, fmt.Errorf("API token '%s' has expired", rec.Id)
+	}
+
+	now := time.Now()
+	if last, ok := apiTokenLastUsed.Load(rec.Id); !ok || now.Sub(last.(time.Time)) >= apiTokenLastUsedInterval {
+		apiTokenLastUsed.Store(rec.Id, now)
+		rec.LastUsedAt = now.UTC().Format(time.RFC3339)
+		if err := putApiTokenRecord(rec); err != nil {
+			log.Warn().Err(err).Msgf("Failed to record last use of API token '%s'", rec.Id)
+		}
+	}
+	return rec.ApiTokenInfo, nil
+}
+
+// apiTokenCommandSegments are /ns/:nsId/<segment> routes that run commands on Nodes
+var apiTokenCommandSegments = []string{"cmd", "transferFile", "transferFileAndCmd", "downloadFile", "installBenchmarkAgent"}
+
+// AuthorizeApiToken checks whether an API token may call a route.
+// Tokens are confined to their namespace (plus the explicitly listed catalog queries
+// such as spec lookup): read-only allows reads, provision adds writes on Infra/resources/
+// K8s clusters, and command-exec allows remote commands and file transfer. The verb
+// comes from RbacVerbOf, so control actions (e.g., terminate) count as writes or deletes.
+func AuthorizeApiToken(token model.ApiTokenInfo, route, method, action, nsId string) error {
+	group := RbacGroupOf(route, method)
+	verb := RbacVerbOf(route, method, action)
+	has := func(scope string) bool { return slices.Contains(token.Scopes, scope) }
+	canRead := has(model.ApiTokenScopeReadOnly) || has(model.ApiTokenScopeProvision)
+
+	deny := func(reason string) error {
+		return apierr.Forbidden(fmt.Sprintf("permission denied for API token '%s': %s", token.Id, reason))
+	}
+
+	if group == model.RbacGroupCatalog {
+		if canRead {
+			return nil
+		}
+		return deny("scope 'read-only' or 'provision' is required for catalog queries")
+	}
+	if nsId == "" || nsId != token.NsId {
+		return deny(fmt.Sprintf("token is bound to namespace '%s'", token.NsId))
+	}
+
+	seg := ""
+	if rest, ok := strings.CutPrefix(strings.TrimPrefix(route, "/tumblebug"), "/ns/:nsId/"); ok {
+		seg, _, _ = strings.Cut(rest, "/")
+	}
+	if slices.Contains(apiTokenCommandSegments, seg) {
+		if has(model.ApiTokenScopeCommandExec) {
+			return nil
+		}
+		return deny("scope 'command-exec' is required")
+	}
+
+	switch group {
+	case model.RbacGroupInfra, model.RbacGroupResource, model.RbacGroupK8s, model.RbacGroupNamespace:
+	default:
+		return deny(fmt.Sprintf("route group '%s' is not available to API tokens", group))
+	}
+	if verb == model.RbacVerbRead {
+		if canRead {
+			return nil
+		}
+		return deny(fmt.Sprintf("scope 'read-only' or 'provision' is required to read %s", group))
+	}
+	if group != model.RbacGroupNamespace && has(model.ApiTokenScopeProvision) {
+		return nil
+	}
+	return deny(fmt.Sprintf("scope 'provision' is required to %s %s", verb, group))
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to include common methods for managing multi-cloud infra
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+)
+
+const (
+	approvalPolicyKey       = "/approval/policy"
+	changeRequestKeyPrefix  = "/approval/request/"
+	changeRequestIdPrefix   = "cr-"
+	changeRequestSystemUser = "system"
+)
+
+// ApprovalExecutor runs the operation of an approved change request.
+type ApprovalExecutor func(ctx context.Context, cr model.ChangeRequest) (any, error)
+
+// approvalExecutors are registered per operation by the package that implements it
+// (e.g., infra registers Infra creation and termination; common cannot import it).
+var (
+	approvalMu        sync.Mutex
+	approvalExecutors = map[string]ApprovalExecutor{}
+)
+
+func init() {
+	RegisterApprovalExecutor(model.ApprovalOpDeleteAllNs, func(ctx context.Context, cr model.ChangeRequest) (any, error) {
+		if err := DelAllNs(); err != nil {
+			return nil, err
+		}
+		return model.SimpleMsg{Message: "All namespaces has been deleted"}, nil
+	})
+}
+
+// RegisterApprovalExecutor registers the function executing an approved operation.
+func RegisterApprovalExecutor(operation string, fn ApprovalExecutor) {
+	approvalMu.Lock()
+	defer approvalMu.Unlock()
+	approvalExecutors[operation] = fn
+}
+
+// SetApprovalPolicy sets (replaces) the approval policy.
+func SetApprovalPolicy(policy model.ApprovalPolicy) (model.ApprovalPolicy, error) {
+	if policy.CostThresholdPerHour < 0 {
+		return model.ApprovalPolicy{}, fmt.Errorf("costThresholdPerHour must not be negative")
+	}
+	// Without per-user authentication the requester and the approver cannot be told apart,
+	// so parked requests could never be decided.
+	if policy.Enabled && !RbacAuthActive() {
+		return model.ApprovalPolicy{}, apierr.Invalid("the approval workflow requires JWT auth with RBAC (TB_AUTH_ENABLED=true, TB_AUTH_MODE=jwt)", nil)
+	}
+	val, err := json.Marshal(policy)
+	if err != nil {
+		return model.ApprovalPolicy{}, err
+	}
+	if err := kvstore.Put(approvalPolicyKey, string(val)); err != nil {
+		log.Error().Err(err).Msg("")
+		return model.ApprovalPolicy{}, err
+	}
+	log.Info().Msgf("Approval policy updated: %+v", policy)
+	return policy, nil
+}
+
+// GetApprovalPolicy returns the approval policy (disabled if not set).
+func GetApprovalPolicy() (model.ApprovalPolicy, error) {
+	policy := model.ApprovalPolicy{}
+	val, exists, err := kvstore.Get(approvalPolicyKey)
+	if err != nil || !exists {
+		return policy, err
+	}
+	if err := json.Unmarshal([]byte(val), &policy); err != nil {
+		return model.ApprovalPolicy{}, err
+	}
+	return policy, nil
+}
+
+// ApprovalRequired reports whether an operation requires approval under the current policy.
+// For the cost-based operations, costPerHour is the estimated cost of the capacity the request adds.
+func ApprovalRequired(operation string, costPerHour float64) bool {
+	policy, err := GetApprovalPolicy()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read approval policy; approval is not required")
+		return false
+	}
+	if !policy.Enabled {
+		return false
+	}
+	if !RbacAuthActive() {
+		log.Warn().Msg("Approval policy is enabled but JWT auth with RBAC is off; approval is not required")
+		return false
+	}
+	switch operation {
+	case model.ApprovalOpInfraTerminate, model.ApprovalOpAllInfraTerminate:
+		return policy.InfraTerminate
+	case model.ApprovalOpDeleteAllNs:
+		return policy.DeleteAllNs
+	case model.ApprovalOpInfraCreate, model.ApprovalOpInfraCreateStatic, model.ApprovalOpNodeGroupAdd,
+		model.ApprovalOpNodeGroupScaleOut, model.ApprovalOpInfraDesiredState, model.ApprovalOpNodeResize:
+		return policy.CostThresholdPerHour > 0 && costPerHour > policy.CostThresholdPerHour
+	}
+	return false
+}
+
+// ApprovalCostThreshold returns the cost threshold (USD/hour) of Infra creation
+// requiring approval, or 0 if cost-based approval is off.
+func ApprovalCostThreshold() float64 {
+	policy, err := GetApprovalPolicy()
+	if err != nil || !policy.Enabled || !RbacAuthActive() {
+		return 0
+	}
+	return policy.CostThresholdPerHour
+}
+
+func getChangeRequest(id string) (model.ChangeRequest, error) {
+	val, exists, err := kvstore.Get(changeRequestKeyPrefix + id)
+	if err != nil {
+		return model.ChangeRequest{}, err
+	}
+	if !exists {
+		return model.ChangeRequest{}, &apierr.StatusError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("change request '%s' does not exist", id)}
+	}
+	cr := model.ChangeRequest{}
+	if err := json.Unmarshal([]byte(val), &cr); err != nil {
+		return model.ChangeRequest{}, err
+	}
+	return cr, nil
+}
+
+// putChangeRequest records an event and stores the change request.
+func putChangeRequest(cr *model.ChangeRequest, actor, action, comment string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	cr.UpdatedAt = now
+	cr.History = append(cr.History, model.ChangeRequestEvent{Time: now, Actor: actor, Action: action, Comment: comment})
+	val, err := json.Marshal(cr)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put(changeRequestKeyPrefix+cr.Id, string(val))
+}
+
+// SubmitChangeRequest parks an operation as a pending change request.
+func SubmitChangeRequest(cr model.ChangeRequest) (model.ChangeRequest, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return model.ChangeRequest{}, err
+	}
+	cr.Id = changeRequestIdPrefix + id
+	cr.Status = model.ChangeRequestPending
+	cr.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	cr.History = []model.ChangeRequestEvent{}
+	if err := putChangeRequest(&cr, cr.Requester, "submitted", cr.Impact.Summary); err != nil {
+		log.Error().Err(err).Msg("")
+		return model.ChangeRequest{}, err
+	}
+	log.Info().Msgf("Change request '%s' (%s %s/%s) submitted by '%s' and pending approval: %s",
+		cr.Id, cr.Operation, cr.NsId, cr.TargetId, cr.Requester, cr.Impact.Summary)
+	return cr, nil
+}
+
+// GetChangeRequest returns a change request.
+func GetChangeRequest(id string) (model.ChangeRequest, error) {
+	return getChangeRequest(id)
+}
+
+// ListChangeRequests returns change requests, newest first, optionally filtered by status and namespace.
+func ListChangeRequests(status, nsId string) ([]model.ChangeRequest, error) {
+	vals, err := kvstore.GetList(changeRequestKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	list := []model.ChangeRequest{}
+	for _, val := range vals {
+		cr := model.ChangeRequest{}
+		if err := json.Unmarshal([]byte(val), &cr); err != nil {
+			log.Warn().Err(err).Msg("Skipping malformed change request")
+			continue
+		}
+		if (status == "" || cr.Status == status) && (nsId == "" || cr.NsId == nsId) {
+			list = append(list, cr)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt > list[j].CreatedAt })
+	return list, nil
+}
+
+// decideChangeRequest moves a pending change request to its next status,
+// enforcing that the approver is not the requester.
+func decideChangeRequest(id, approver string) (model.ChangeRequest, error) {
+	cr, err := getChangeRequest(id)
+	if err != nil {
+		return cr, err
+	}
+	if cr.Status != model.ChangeRequestPending {
+		return cr, &apierr.StatusError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("change request '%s' is not pending (status: %s)", id, cr.Status)}
+	}
+	if approver == "" {
+		return cr, apierr.Invalid("approver is required", nil)
+	}
+	if approver == cr.Requester {
+		return cr, apierr.Forbidden(fmt.Sprintf("change request '%s' must be decided by someone other than the requester", id))
+	}
+	return cr, nil
+}
+
+// ApproveChangeRequest approves a pending change request and executes its operation
+// in the background. The change request records the result or error.
+func ApproveChangeRequest(id, approver, comment string) (model.ChangeRequest, error) {
+	approvalMu.Lock()
+	cr, err := decideChangeRequest(id, approver)
+	if err != nil {
+		approvalMu.Unlock()
+		return cr, err
+	}
+	executor, ok := approvalExecutors[cr.Operation]
+	if !ok {
+		approvalMu.Unlock()
+		return cr, fmt.Errorf("no executor is registered for operation '%s'", cr.Operation)
+	}
+	cr.Status = model.ChangeRequestExecuting
+	cr.Approver = approver
+	err = putChangeRequest(&cr, approver, "approved", comment)
+	approvalMu.Unlock()
+	if err != nil {
+		return cr, err
+	}
+	log.Info().Msgf("Change request '%s' (%s %s/%s) approved by '%s'", cr.Id, cr.Operation, cr.NsId, cr.TargetId, approver)
+
+	go func(cr model.ChangeRequest) {
+		ctx := WithCredentialHolder(context.Background(), cr.CredentialHolder)
+		result, err := executor(ctx, cr)
+		action := "executed"
+		cr.Status = model.ChangeRequestExecuted
+		cr.Result = result
+		if err != nil {
+			action = "failed"
+			cr.Status = model.ChangeRequestFailed
+			cr.Error = err.Error()
+			log.Error().Err(err).Msgf("Change request '%s' (%s %s/%s) failed", cr.Id, cr.Operation, cr.NsId, cr.TargetId)
+		} else {
+			log.Info().Msgf("Change request '%s' (%s %s/%s) executed", cr.Id, cr.Operation, cr.NsId, cr.TargetId)
+		}
+		if err := putChangeRequest(&cr, changeRequestSystemUser, action, cr.Error); err != nil {
+			log.Error().Err(err).Msgf("Failed to record the result of change request '%s'", cr.Id)
+		}
+	}(cr)
+
+	return cr, nil
+}
+
+// RejectChangeRequest rejects a pending change request.
+func RejectChangeRequest(id, approver, comment string) (model.ChangeRequest, error) {
+	approvalMu.Lock()
+	defer approvalMu.Unlock()
+
+	cr, err := decideChangeRequest(id, approver)
+	if err != nil {
+		return cr, err
+	}
+	cr.Status = model.ChangeRequestRejected
+	cr.Approver = approver
+	if err := putChangeRequest(&cr, approver, "rejected", comment); err != nil {
+		return cr, err
+	}
+	log.Info().Msgf("Change request '%s' (%s %s/%s) rejected by '%s'", cr.Id, cr.Operation, cr.NsId, cr.TargetId, approver)
+	return cr, nil
+}
+
+// RequestDeleteAllNsApproval parks the deletion of all namespaces as a change request
+// if the approval policy requires it. It returns nil if the deletion can proceed.
+func RequestDeleteAllNsApproval(requester string) (*model.ChangeRequest, error) {
+	if !ApprovalRequired(model.ApprovalOpDeleteAllNs, 0) {
+		return nil, nil
+	}
+	nsIds, err := ListNsId()
+	if err != nil {
+		return nil, err
+	}
+	cr, err := SubmitChangeRequest(model.ChangeRequest{
+		Operation: model.ApprovalOpDeleteAllNs,
+		Requester: requester,
+		Impact: model.ChangeRequestImpact{
+			Summary: fmt.Sprintf("delete all %d namespaces %v", len(nsIds), nsIds),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
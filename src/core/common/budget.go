@@ -0,0 +1,49 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to include common methods for managing multi-cloud infra
+package common
+
+import (
+	"fmt"
+	"sync"
+)
+
+// BudgetBlockFunc rejects provisioning in a namespace whose exhausted budgets block it.
+// The labels of the request select label budgets.
+type BudgetBlockFunc func(nsId string, labels ...map[string]string) error
+
+// budgetBlockFunc is registered by the infra package, which evaluates budgets
+// against Node usage (resource and common cannot import it).
+var (
+	budgetBlockMu   sync.RWMutex
+	budgetBlockFunc BudgetBlockFunc
+)
+
+// RegisterBudgetBlockFunc registers the function used to check budget blocks.
+func RegisterBudgetBlockFunc(fn BudgetBlockFunc) {
+	budgetBlockMu.Lock()
+	defer budgetBlockMu.Unlock()
+	budgetBlockFunc = fn
+}
+
+// CheckBudgetBlock rejects provisioning in the scope of an exhausted budget that blocks it.
+func CheckBudgetBlock(nsId string, labels ...map[string]string) error {
+	budgetBlockMu.RLock()
+	fn := budgetBlockFunc
+	budgetBlockMu.RUnlock()
+	if fn == nil {
+		return fmt.Errorf("budget checker is not registered")
+	}
+	return fn(nsId, labels...)
+}
@@ -16,6 +16,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -31,10 +32,13 @@ import (
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/logfilter"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/go-resty/resty/v2"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // InvalidateGetCache removes the cached GET response for url (+ optional request body)
@@ -288,6 +292,9 @@ func recordCircuitBreakerFailure(requestKey string) {
 	breaker.LastFailure = time.Now()
 
 	if breaker.FailureCount >= circuitBreakerFailureThreshold {
+		if !breaker.IsOpen {
+			outboundCircuitBreakerTrips.WithLabelValues(outboundTarget(requestKey)).Inc()
+		}
 		breaker.IsOpen = true
 		log.Warn().Msgf("API protection activated due to consecutive failures: %s (failures: %d, blocked for 30 seconds)", requestKey, breaker.FailureCount)
 	}
@@ -347,6 +354,38 @@ func ExecuteHttpRequest[B any, T any](
 	result *T, // Generic type
 	cacheDuration time.Duration,
 ) (*resty.Response, error) {
+	return ExecuteHttpRequestWithContext(context.Background(), client, method, url, headers, useBody, body, result, cacheDuration)
+}
+
+// ExecuteHttpRequestWithContext is ExecuteHttpRequest with a context carrying the trace.
+// A client span is recorded and its W3C trace context is propagated to the callee
+// (e.g., CB-Spider, mc-terrarium). ctx is not used for cancellation.
+func ExecuteHttpRequestWithContext[B any, T any](
+	ctx context.Context,
+	client *resty.Client,
+	method string,
+	url string,
+	headers map[string]string,
+	useBody bool,
+	body *B,
+	result *T,
+	cacheDuration time.Duration,
+) (resp *resty.Response, err error) {
+
+	ctx, span := tracing.Tracer().Start(ctx, method+" "+outboundTarget(url),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.full", cleanURL(url)),
+			attribute.String("peer.service", outboundTarget(url)),
+		),
+	)
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode()))
+		}
+		tracing.End(span, err)
+	}()
 
 	// Perform the HTTP request using Resty
 	setRestyDebug := false // Disable Resty debug, use custom logging instead
@@ -411,6 +450,7 @@ func ExecuteHttpRequest[B any, T any](
 			if time.Now().Before(cachedItem.ExpiresAt) {
 				log.Trace().Msgf("Cache hit! Expires: %v", time.Since(cachedItem.ExpiresAt))
 				*result = cachedItem.Response
+				outboundRequestsShortCircuited.WithLabelValues(outboundTarget(url), "cache_hit").Inc()
 				//val := reflect.ValueOf(result).Elem()
 				//cachedVal := reflect.ValueOf(cachedItem.Response)
 				//val.Set(cachedVal)
@@ -424,6 +464,7 @@ func ExecuteHttpRequest[B any, T any](
 
 		// Check circuit breaker before making actual requests
 		if checkCircuitBreaker(requestKey) {
+			outboundRequestsShortCircuited.WithLabelValues(outboundTarget(url), "circuit_open").Inc()
 			return nil, fmt.Errorf("API call temporarily blocked due to circuit breaker protection (repeated failures detected), please try again later (API: %s)", requestKey)
 		}
 
@@ -471,14 +512,12 @@ func ExecuteHttpRequest[B any, T any](
 	if headers != nil {
 		req = req.SetHeaders(headers)
 	}
+	tracing.Inject(ctx, req.Header)
 
 	if useBody {
 		req = req.SetBody(body)
 	}
 
-	var resp *resty.Response
-	var err error
-
 	switch method {
 	case "GET", "POST", "PUT", "DELETE", "HEAD":
 	default:
@@ -527,6 +566,7 @@ func ExecuteHttpRequest[B any, T any](
 			method, cleanURL(url), attempt, maxAttempts, wait.Round(time.Millisecond), cleanErrorMessage(failureMsg))
 		time.Sleep(wait)
 	}
+	observeOutboundRequest(method, url, resp, err, time.Since(requestStartTime))
 
 	if err != nil {
 		// Log error response in zerologger style (use trace for GET, debug for others)
@@ -691,12 +731,19 @@ type RequestInfo struct {
 
 // RequestDetails contains detailed information about an HTTP request and its processing status.
 type RequestDetails struct {
-	StartTime     time.Time   `json:"startTime"`     // The time when the request was received by the server.
-	EndTime       time.Time   `json:"endTime"`       // The time when the request was fully processed.
-	Status        string      `json:"status"`        // The current status of the request (e.g., "Handling", "Error", "Success").
-	RequestInfo   RequestInfo `json:"requestInfo"`   // Extracted information about the request.
-	ResponseData  any         `json:"responseData"`  // The data sent back in response to the request.
-	ErrorResponse string      `json:"errorResponse"` // A message describing any error that occurred during request processing.
+	RequestId     string        `json:"requestId,omitempty"`     // The X-Request-Id of the request.
+	StartTime     time.Time     `json:"startTime"`               // The time when the request was received by the server.
+	EndTime       time.Time     `json:"endTime"`                 // The time when the request was fully processed.
+	Status        string        `json:"status"`                  // The current status of the request (e.g., "Handling", "Error", "Success").
+	Route         string        `json:"route,omitempty"`         // The matched route (e.g., "/tumblebug/ns/:nsId/infra").
+	NsId          string        `json:"nsId,omitempty"`          // The namespace of the request, if the route has one.
+	User          string        `json:"user,omitempty"`          // The authenticated user who sent the request.
+	HttpStatus    int           `json:"httpStatus,omitempty"`    // The HTTP status code of the response.
+	RequestInfo   RequestInfo   `json:"requestInfo"`             // Extracted information about the request.
+	ResponseData  any           `json:"responseData"`            // The data sent back in response to the request.
+	ErrorResponse string        `json:"errorResponse"`           // A message describing any error that occurred during request processing.
+	BodyTruncated bool          `json:"bodyTruncated,omitempty"` // Whether bodies were dropped from the request history (too large).
+	Links         []RequestLink `json:"links,omitempty"`         // Records spawned by the request (e.g., provisioning events, commands).
 }
 
 // RequestMap is a map for request details
@@ -734,6 +781,7 @@ func init() {
 			select {
 			case <-cleanupTicker.C:
 				cleanupRequestMap()
+				pruneRequestHistory()
 			case <-dumpTicker.C:
 				autoSaveRequestMap()
 			}
@@ -998,6 +1046,15 @@ func ExtractRequestInfo(r *http.Request) RequestInfo {
 // 	return reqID, nil
 // }
 
+// errorResponseBody is the JSON body of a failed request: the message, plus the
+// structured details of a validation error (e.g., guardrail violations).
+func errorResponseBody(err error) any {
+	if details := apierr.DetailsOf(err); details != nil {
+		return map[string]any{"message": err.Error(), "details": details}
+	}
+	return map[string]string{"message": err.Error()}
+}
+
 // EndRequestWithLog updates the request details and sends the final response.
 func EndRequestWithLog(c echo.Context, err error, responseData any) error {
 
@@ -1009,9 +1066,9 @@ func EndRequestWithLog(c echo.Context, err error, responseData any) error {
 	if reqID == "" {
 		if err != nil {
 			if responseData == nil {
-				return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+				return c.JSON(http.StatusBadRequest, errorResponseBody(err))
 			}
-			return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+			return c.JSON(http.StatusInternalServerError, errorResponseBody(err))
 		}
 		return c.JSON(http.StatusOK, responseData)
 	}
@@ -1027,9 +1084,9 @@ func EndRequestWithLog(c echo.Context, err error, responseData any) error {
 			details.ErrorResponse = err.Error()
 			RequestMap.Store(reqID, details)
 			if responseData == nil {
-				return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+				return c.JSON(http.StatusBadRequest, errorResponseBody(err))
 			} else {
-				return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+				return c.JSON(http.StatusInternalServerError, errorResponseBody(err))
 			}
 		}
 
@@ -1043,9 +1100,9 @@ func EndRequestWithLog(c echo.Context, err error, responseData any) error {
 	log.Warn().Str("reqID", reqID).Msg("Request ID not found in RequestMap, returning response without tracking")
 	if err != nil {
 		if responseData == nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return c.JSON(http.StatusBadRequest, errorResponseBody(err))
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+		return c.JSON(http.StatusInternalServerError, errorResponseBody(err))
 	}
 	return c.JSON(http.StatusOK, responseData)
 }
@@ -1057,7 +1114,7 @@ func EndRequestWithLogAndStatus(c echo.Context, err error, responseData any, sta
 
 	body := responseData
 	if err != nil {
-		body = map[string]string{"message": err.Error()}
+		body = errorResponseBody(err)
 	}
 
 	if reqID != "" {
@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/go-resty/resty/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outbound call targets used as the "target" metric label
+const (
+	targetSpider    = "spider"
+	targetTerrarium = "terrarium"
+	targetDragonfly = "dragonfly"
+	targetOther     = "other"
+)
+
+var (
+	outboundRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "tumblebug",
+		Subsystem: "outbound",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of outbound calls made through ExecuteHttpRequest (including retries), by target and status code.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 1200},
+	}, []string{"target", "method", "code"})
+
+	outboundRequestsShortCircuited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tumblebug",
+		Subsystem: "outbound",
+		Name:      "requests_short_circuited_total",
+		Help:      "Outbound GET calls answered without a network call, by reason (cache_hit, circuit_open).",
+	}, []string{"target", "reason"})
+
+	outboundCircuitBreakerTrips = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tumblebug",
+		Subsystem: "outbound",
+		Name:      "circuit_breaker_trips_total",
+		Help:      "Number of times a circuit breaker opened after consecutive failures.",
+	}, []string{"target"})
+
+	outboundCircuitBreakerOpenDesc = prometheus.NewDesc(
+		"tumblebug_outbound_circuit_breakers_open",
+		"Number of currently open circuit breakers (request keys blocked) by target.",
+		[]string{"target"}, nil)
+)
+
+func init() {
+	prometheus.MustRegister(
+		outboundRequestDuration,
+		outboundRequestsShortCircuited,
+		outboundCircuitBreakerTrips,
+		circuitBreakerCollector{},
+	)
+}
+
+// outboundTarget classifies a URL (or a request key containing it) by backend service.
+func outboundTarget(url string) string {
+	switch {
+	case model.SpiderRestUrl != "" && strings.Contains(url, model.SpiderRestUrl):
+		return targetSpider
+	case model.TerrariumRestUrl != "" && strings.Contains(url, model.TerrariumRestUrl):
+		return targetTerrarium
+	case model.DragonflyRestUrl != "" && strings.Contains(url, model.DragonflyRestUrl):
+		return targetDragonfly
+	default:
+		return targetOther
+	}
+}
+
+// observeOutboundRequest records the latency of one outbound call.
+// code is the HTTP status, or "error" when no response was received.
+func observeOutboundRequest(method, url string, resp *resty.Response, err error, d time.Duration) {
+	code := "error"
+	if resp != nil && resp.StatusCode() != 0 {
+		code = strconv.Itoa(resp.StatusCode())
+	} else if err == nil {
+		code = "unknown"
+	}
+	outboundRequestDuration.WithLabelValues(outboundTarget(url), method, code).Observe(d.Seconds())
+}
+
+// circuitBreakerCollector reports open circuit breakers at scrape time, applying
+// the same expiry rule as checkCircuitBreaker so lapsed breakers are not counted.
+type circuitBreakerCollector struct{}
+
+func (circuitBreakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- outboundCircuitBreakerOpenDesc
+}
+
+func (circuitBreakerCollector) Collect(ch chan<- prometheus.Metric) {
+	open := map[string]int{targetSpider: 0, targetTerrarium: 0, targetDragonfly: 0, targetOther: 0}
+	clientCircuitBreakers.Range(func(k, v any) bool {
+		breaker, ok := v.(CircuitBreakerState)
+		if ok && breaker.IsOpen && time.Since(breaker.LastFailure) <= circuitBreakerOpenDuration {
+			open[outboundTarget(k.(string))]++
+		}
+		return true
+	})
+	for target, n := range open {
+		ch <- prometheus.MustNewConstMetric(outboundCircuitBreakerOpenDesc, prometheus.GaugeValue, float64(n), target)
+	}
+}
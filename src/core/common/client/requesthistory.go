@@ -0,0 +1,409 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+)
+
+// Request history is persisted in kvstore so that request records survive restarts
+// and can be filtered. The metadata and the bodies are stored under separate keys,
+// so listing and pruning never load request/response bodies.
+const (
+	requestHistoryPrefix     = "/requestHistory/"
+	requestHistoryMetaPrefix = requestHistoryPrefix + "meta/"
+	requestHistoryBodyPrefix = requestHistoryPrefix + "body/"
+	requestHistoryLinkPrefix = requestHistoryPrefix + "link/"
+
+	// requestHistoryMaxBodyBytes caps the persisted bodies of a request (etcd values are size-limited).
+	requestHistoryMaxBodyBytes = 32 * 1024
+)
+
+// requestHistoryRedacted replaces the bodies of requests to sensitiveBodyRoutes
+const requestHistoryRedacted = "[redacted]"
+
+// sensitiveBodyRoutes are route prefixes whose request and response bodies carry secrets
+// (CSP credentials, issued API tokens, login tokens); their bodies are never kept or returned.
+var sensitiveBodyRoutes = []string{
+	"/tumblebug/credential", "/tumblebug/ns/:nsId/apiToken", "/tumblebug/auth",
+}
+
+// redactSensitiveBodies drops the bodies of a request to a sensitive route.
+func redactSensitiveBodies(details *RequestDetails) {
+	for _, prefix := range sensitiveBodyRoutes {
+		if strings.HasPrefix(details.Route, prefix) {
+			if details.RequestInfo.Body != nil {
+				details.RequestInfo.Body = requestHistoryRedacted
+			}
+			if details.ResponseData != nil {
+				details.ResponseData = requestHistoryRedacted
+			}
+			return
+		}
+	}
+}
+
+// Kinds of records linked to the request that spawned them.
+const (
+	RequestLinkProvisioningEvent = "provisioningEvent"
+	RequestLinkCommand           = "command"
+)
+
+// requestHistoryEnabled gates request-history persistence (set TB_REQUEST_HISTORY_ENABLED=false to disable)
+var requestHistoryEnabled = os.Getenv("TB_REQUEST_HISTORY_ENABLED") != "false"
+
+// requestHistoryRetention is how long persisted requests are kept (TB_REQUEST_HISTORY_RETENTION, default 168h).
+var requestHistoryRetention = func() time.Duration {
+	if v := os.Getenv("TB_REQUEST_HISTORY_RETENTION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Warn().Str("value", v).Msg("invalid TB_REQUEST_HISTORY_RETENTION; using default 168h")
+	}
+	return 7 * 24 * time.Hour
+}()
+
+// requestHistoryMaxEntries caps the number of persisted requests (TB_REQUEST_HISTORY_MAX_ENTRIES, default 10000).
+var requestHistoryMaxEntries = func() int {
+	if v := os.Getenv("TB_REQUEST_HISTORY_MAX_ENTRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+		log.Warn().Str("value", v).Msg("invalid TB_REQUEST_HISTORY_MAX_ENTRIES; using default 10000")
+	}
+	return 10000
+}()
+
+// RequestLink refers to a record (e.g., a provisioning event or a remote command) spawned by a request.
+type RequestLink struct {
+	Kind    string    `json:"kind" example:"command"`
+	Id      string    `json:"id" example:"req-12345678:g1-1:1"`
+	NsId    string    `json:"nsId,omitempty" example:"default"`
+	InfraId string    `json:"infraId,omitempty" example:"infra01"`
+	NodeId  string    `json:"nodeId,omitempty" example:"g1-1"`
+	Summary string    `json:"summary,omitempty" example:"apt update"`
+	Time    time.Time `json:"time"`
+}
+
+// RequestHistoryFilter is the filter of QueryRequests. Empty fields match everything.
+type RequestHistoryFilter struct {
+	NsId   string
+	User   string
+	Route  string
+	Status string
+	Method string
+	URL    string
+	Source string
+	From   time.Time
+	To     time.Time
+	Offset int
+	Limit  int
+	// Brief omits request and response bodies
+	Brief bool
+	// Visible, if set, hides the requests it rejects (e.g., outside the caller's namespaces)
+	Visible func(RequestDetails) bool
+}
+
+// RequestDetailsList is a page of request records, newest first.
+type RequestDetailsList struct {
+	Requests []RequestDetails `json:"requests"`
+	// Total is the number of matching requests before paging
+	Total int `json:"total"`
+}
+
+// requestHistoryBody is the persisted request and response body of a request.
+type requestHistoryBody struct {
+	RequestBody  any  `json:"requestBody,omitempty"`
+	ResponseData any  `json:"responseData,omitempty"`
+	Truncated    bool `json:"truncated,omitempty"`
+}
+
+func requestHistoryKey(prefix, reqID string) string {
+	return prefix + url.PathEscape(reqID)
+}
+
+// PersistRequest stores a finished request in the request history.
+func PersistRequest(reqID string, details RequestDetails) {
+	if !requestHistoryEnabled || reqID == "" {
+		return
+	}
+	details.RequestId = reqID
+	redactSensitiveBodies(&details)
+
+	body := requestHistoryBody{RequestBody: details.RequestInfo.Body, ResponseData: details.ResponseData}
+	bodyVal, err := json.Marshal(body)
+	if err == nil && len(bodyVal) > requestHistoryMaxBodyBytes {
+		body.ResponseData, body.Truncated = nil, true
+		bodyVal, err = json.Marshal(body)
+		if err == nil && len(bodyVal) > requestHistoryMaxBodyBytes {
+			body.RequestBody = nil
+			bodyVal, err = json.Marshal(body)
+		}
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("reqID", reqID).Msg("Failed to marshal request body for request history")
+		bodyVal = nil
+	}
+
+	details.RequestInfo.Body = nil
+	details.ResponseData = nil
+	metaVal, err := json.Marshal(details)
+	if err != nil {
+		log.Warn().Err(err).Str("reqID", reqID).Msg("Failed to marshal request for request history")
+		return
+	}
+	if err := kvstore.Put(requestHistoryKey(requestHistoryMetaPrefix, reqID), string(metaVal)); err != nil {
+		log.Warn().Err(err).Str("reqID", reqID).Msg("Failed to persist request history")
+		return
+	}
+	if bodyVal != nil {
+		if err := kvstore.Put(requestHistoryKey(requestHistoryBodyPrefix, reqID), string(bodyVal)); err != nil {
+			log.Warn().Err(err).Str("reqID", reqID).Msg("Failed to persist request history body")
+		}
+	}
+}
+
+// loadPersistedBody fills the request and response body of a persisted request.
+func loadPersistedBody(details *RequestDetails) {
+	val, exists, err := kvstore.Get(requestHistoryKey(requestHistoryBodyPrefix, details.RequestId))
+	if err != nil || !exists {
+		return
+	}
+	body := requestHistoryBody{}
+	if err := json.Unmarshal([]byte(val), &body); err != nil {
+		return
+	}
+	details.RequestInfo.Body = body.RequestBody
+	details.ResponseData = body.ResponseData
+	details.BodyTruncated = body.Truncated
+}
+
+// GetRequest returns a request (in progress or from the history) with the records it spawned.
+func GetRequest(reqID string) (RequestDetails, bool, error) {
+	var details RequestDetails
+	if v, ok := RequestMap.Load(reqID); ok {
+		details, _ = v.(RequestDetails)
+		details.RequestId = reqID
+		redactSensitiveBodies(&details)
+	} else {
+		val, exists, err := kvstore.Get(requestHistoryKey(requestHistoryMetaPrefix, reqID))
+		if err != nil || !exists {
+			return RequestDetails{}, false, err
+		}
+		if err := json.Unmarshal([]byte(val), &details); err != nil {
+			return RequestDetails{}, false, err
+		}
+		loadPersistedBody(&details)
+	}
+
+	links, err := ListRequestLinks(reqID)
+	if err != nil {
+		log.Warn().Err(err).Str("reqID", reqID).Msg("Failed to list records linked to the request")
+	}
+	details.Links = links
+	return details, true, nil
+}
+
+func (f RequestHistoryFilter) match(d RequestDetails) bool {
+	return (f.Status == "" || strings.EqualFold(d.Status, f.Status)) &&
+		(f.Method == "" || strings.EqualFold(d.RequestInfo.Method, f.Method)) &&
+		(f.URL == "" || strings.Contains(strings.ToLower(d.RequestInfo.URL), strings.ToLower(f.URL))) &&
+		(f.Route == "" || strings.EqualFold(d.Route, f.Route)) &&
+		(f.NsId == "" || d.NsId == f.NsId) &&
+		(f.User == "" || d.User == f.User) &&
+		(f.Source == "" || strings.EqualFold(d.RequestInfo.Header["X-Request-Source"], f.Source)) &&
+		(f.From.IsZero() || !d.StartTime.Before(f.From)) &&
+		(f.To.IsZero() || !d.StartTime.After(f.To)) &&
+		(f.Visible == nil || f.Visible(d))
+}
+
+// QueryRequests returns the requests in progress and in the history matching the filter, newest first.
+func QueryRequests(filter RequestHistoryFilter) (RequestDetailsList, error) {
+	matched := []RequestDetails{}
+	inMemory := map[string]bool{}
+
+	RequestMap.Range(func(key, value any) bool {
+		details, ok := value.(RequestDetails)
+		if !ok {
+			return true
+		}
+		details.RequestId, _ = key.(string)
+		inMemory[details.RequestId] = true
+		if filter.match(details) {
+			matched = append(matched, details)
+		}
+		return true
+	})
+
+	if requestHistoryEnabled {
+		vals, err := kvstore.GetList(requestHistoryMetaPrefix)
+		if err != nil {
+			return RequestDetailsList{}, err
+		}
+		for _, val := range vals {
+			details := RequestDetails{}
+			if err := json.Unmarshal([]byte(val), &details); err != nil {
+				continue
+			}
+			if !inMemory[details.RequestId] && filter.match(details) {
+				matched = append(matched, details)
+			}
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].StartTime.After(matched[j].StartTime) })
+	list := RequestDetailsList{Total: len(matched)}
+
+	start := min(max(filter.Offset, 0), len(matched))
+	end := len(matched)
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, len(matched))
+	}
+	list.Requests = matched[start:end]
+
+	for i := range list.Requests {
+		d := &list.Requests[i]
+		if filter.Brief {
+			d.RequestInfo.Body = nil
+			d.ResponseData = nil
+		} else if !inMemory[d.RequestId] {
+			loadPersistedBody(d)
+		} else {
+			redactSensitiveBodies(d)
+		}
+	}
+	return list, nil
+}
+
+// deletePersistedRequest removes a request and its links from the history.
+func deletePersistedRequest(reqID string) error {
+	if err := kvstore.Delete(requestHistoryKey(requestHistoryMetaPrefix, reqID)); err != nil {
+		return err
+	}
+	if err := kvstore.Delete(requestHistoryKey(requestHistoryBodyPrefix, reqID)); err != nil {
+		return err
+	}
+	return kvstore.DeleteWithPrefix(requestHistoryKey(requestHistoryLinkPrefix, reqID) + "/")
+}
+
+// DeleteRequest removes a request from memory and from the history.
+// It reports whether the request existed.
+func DeleteRequest(reqID string) (bool, error) {
+	_, found := RequestMap.LoadAndDelete(reqID)
+	if found {
+		DecrementRequestMapCount()
+	}
+	_, persisted, err := kvstore.Get(requestHistoryKey(requestHistoryMetaPrefix, reqID))
+	if err != nil {
+		return found, err
+	}
+	if !found && !persisted {
+		return false, nil
+	}
+	return true, deletePersistedRequest(reqID)
+}
+
+// DeleteAllRequests removes all requests from memory and from the history.
+func DeleteAllRequests() error {
+	RequestMap.Range(func(key, value any) bool {
+		if _, loaded := RequestMap.LoadAndDelete(key); loaded {
+			DecrementRequestMapCount()
+		}
+		return true
+	})
+	return kvstore.DeleteWithPrefix(requestHistoryPrefix)
+}
+
+// LinkRequest records that a request spawned a record (e.g., a provisioning event or a remote command).
+// Linking the same kind and id again replaces the link.
+func LinkRequest(reqID string, link RequestLink) {
+	if !requestHistoryEnabled || reqID == "" {
+		return
+	}
+	if link.Time.IsZero() {
+		link.Time = time.Now()
+	}
+	val, err := json.Marshal(link)
+	if err != nil {
+		return
+	}
+	key := requestHistoryKey(requestHistoryLinkPrefix, reqID) + "/" + link.Kind + "/" + url.PathEscape(link.Id)
+	if err := kvstore.Put(key, string(val)); err != nil {
+		log.Warn().Err(err).Str("reqID", reqID).Str("kind", link.Kind).Msg("Failed to link record to request")
+	}
+}
+
+// ListRequestLinks returns the records spawned by a request, oldest first.
+func ListRequestLinks(reqID string) ([]RequestLink, error) {
+	vals, err := kvstore.GetList(requestHistoryKey(requestHistoryLinkPrefix, reqID) + "/")
+	if err != nil {
+		return nil, err
+	}
+	links := []RequestLink{}
+	for _, val := range vals {
+		link := RequestLink{}
+		if err := json.Unmarshal([]byte(val), &link); err == nil {
+			links = append(links, link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].Time.Before(links[j].Time) })
+	return links, nil
+}
+
+// pruneRequestHistory removes persisted requests older than the retention period
+// and the oldest ones beyond the maximum number of entries.
+func pruneRequestHistory() {
+	if !requestHistoryEnabled {
+		return
+	}
+	vals, err := kvstore.GetList(requestHistoryMetaPrefix)
+	if err != nil {
+		log.Debug().Err(err).Msg("Request history pruning skipped")
+		return
+	}
+	entries := make([]RequestDetails, 0, len(vals))
+	for _, val := range vals {
+		details := RequestDetails{}
+		if err := json.Unmarshal([]byte(val), &details); err == nil {
+			entries = append(entries, details)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].StartTime.After(entries[j].StartTime) })
+
+	cutoff := time.Now().Add(-requestHistoryRetention)
+	pruned := 0
+	for i, details := range entries {
+		if i < requestHistoryMaxEntries && details.StartTime.After(cutoff) {
+			continue
+		}
+		if err := deletePersistedRequest(details.RequestId); err != nil {
+			log.Warn().Err(err).Str("reqID", details.RequestId).Msg("Failed to prune request history")
+			continue
+		}
+		pruned++
+	}
+	if pruned > 0 {
+		log.Info().Int("prunedCount", pruned).Msg("Request history pruned")
+	}
+}
@@ -45,6 +45,9 @@ var RuntimeExtractPatternsInfo = model.ExtractPatternsInfo{}
 // RuntimeRDBMSInfo is global variable for model.RDBMSInfoConfig
 var RuntimeRDBMSInfo = model.RDBMSInfoConfig{}
 
+// RuntimeCostInfo is global variable for model.CostAssetInfo
+var RuntimeCostInfo = model.CostAssetInfo{}
+
 // RuntimeLatancyMap is global variable for LatancyMap
 var RuntimeLatancyMap = [][]string{}
 
@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to include common methods for managing multi-cloud infra
+package common
+
+import (
+	"strings"
+)
+
+// Reference prices of assets/costinfo.yaml. Every getter returns the price in the base
+// currency (model.CostBaseCurrency) and false when the provider or the item has no price.
+
+// GetDiskCostPerGiBMonth returns the monthly price per GiB of a disk type of a provider.
+// An empty or "default" disk type uses the provider's default disk price.
+func GetDiskCostPerGiBMonth(providerName, diskType string) (float64, bool) {
+	detail, ok := RuntimeCostInfo.CSPs[strings.ToLower(providerName)]
+	if !ok || len(detail.DiskPerGiBMonth) == 0 {
+		return 0, false
+	}
+	key := strings.ToLower(strings.TrimSpace(diskType))
+	if key == "" {
+		key = "default"
+	}
+	price, ok := detail.DiskPerGiBMonth[key]
+	if !ok {
+		return 0, false
+	}
+	return float64(ConvertToBaseCurrency(float32(price), detail.Currency)), true
+}
+
+// GetNLBCostPerHour returns the hourly price of a network load balancer of a provider.
+func GetNLBCostPerHour(providerName string) (float64, bool) {
+	detail, ok := RuntimeCostInfo.CSPs[strings.ToLower(providerName)]
+	if !ok || detail.NLBPerHour == nil {
+		return 0, false
+	}
+	return float64(ConvertToBaseCurrency(float32(*detail.NLBPerHour), detail.Currency)), true
+}
+
+// GetK8sControlPlaneCostPerHour returns the hourly price of a managed Kubernetes control plane of a provider.
+func GetK8sControlPlaneCostPerHour(providerName string) (float64, bool) {
+	detail, ok := RuntimeCostInfo.CSPs[strings.ToLower(providerName)]
+	if !ok || detail.K8sControlPlanePerHour == nil {
+		return 0, false
+	}
+	return float64(ConvertToBaseCurrency(float32(*detail.K8sControlPlanePerHour), detail.Currency)), true
+}
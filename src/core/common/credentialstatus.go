@@ -0,0 +1,408 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to include common methods for managing multi-cloud infra
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/csp"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	modelcsp "github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+)
+
+const (
+	credentialStatusKeyPrefix = "/credentialStatus/"
+
+	// credentialValidationHistoryLimit is the number of validation results kept per credential
+	credentialValidationHistoryLimit = 20
+	// credentialValidationMaxAttempts limits the region representative connections tried per validation
+	credentialValidationMaxAttempts = 3
+)
+
+// Validation triggers
+const (
+	credentialTriggerRegister = "register"
+	credentialTriggerRotate   = "rotate"
+	credentialTriggerPeriodic = "periodic"
+	credentialTriggerManual   = "manual"
+)
+
+// credentialValidationInterval controls how often StartCredentialValidationLoop
+// validates every credential. Configure via TB_CREDENTIAL_VALIDATION_INTERVAL
+// (Go duration, e.g., "12h"); 0 or negative disables the loop.
+var credentialValidationInterval = func() time.Duration {
+	if v := os.Getenv("TB_CREDENTIAL_VALIDATION_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		log.Warn().Str("value", v).Msg("invalid TB_CREDENTIAL_VALIDATION_INTERVAL; using default 24h")
+	}
+	return 24 * time.Hour
+}()
+
+// credentialExpiryWarningDays is how many days before ExpiresAt a credential is
+// reported as expiring. Configure via TB_CREDENTIAL_EXPIRY_WARNING_DAYS.
+var credentialExpiryWarningDays = func() int {
+	if v := os.Getenv("TB_CREDENTIAL_EXPIRY_WARNING_DAYS"); v != "" {
+		if d, err := strconv.Atoi(v); err == nil && d >= 0 {
+			return d
+		}
+		log.Warn().Str("value", v).Msg("invalid TB_CREDENTIAL_EXPIRY_WARNING_DAYS; using default 14")
+	}
+	return 14
+}()
+
+// credentialStatusMu serializes read-modify-write of credential status records
+var credentialStatusMu sync.Mutex
+
+func credentialStatusKey(holder, provider string) string {
+	return credentialStatusKeyPrefix + strings.ToLower(holder) + "/" + strings.ToLower(provider)
+}
+
+func getCredentialStatusRecord(holder, provider string) (model.CredentialStatus, error) {
+	status := model.CredentialStatus{
+		CredentialHolder:  strings.ToLower(holder),
+		ProviderName:      strings.ToLower(provider),
+		ValidationHistory: []model.CredentialValidation{},
+	}
+	val, exists, err := kvstore.Get(credentialStatusKey(holder, provider))
+	if err != nil || !exists {
+		return status, err
+	}
+	if err := json.Unmarshal([]byte(val), &status); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+func putCredentialStatusRecord(status model.CredentialStatus) error {
+	status.DaysToExpiry = nil
+	status.ExpiryWarning = false
+	val, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put(credentialStatusKey(status.CredentialHolder, status.ProviderName), string(val))
+}
+
+// updateCredentialStatus applies fn to the status record of a credential and stores it.
+func updateCredentialStatus(holder, provider string, fn func(*model.CredentialStatus)) (model.CredentialStatus, error) {
+	credentialStatusMu.Lock()
+	defer credentialStatusMu.Unlock()
+
+	status, err := getCredentialStatusRecord(holder, provider)
+	if err != nil {
+		return status, err
+	}
+	fn(&status)
+	if err := putCredentialStatusRecord(status); err != nil {
+		return status, err
+	}
+	return withExpiry(status), nil
+}
+
+// withExpiry fills the derived expiry fields of a status.
+func withExpiry(status model.CredentialStatus) model.CredentialStatus {
+	if status.ExpiresAt == "" {
+		return status
+	}
+	expiresAt, err := time.Parse(time.RFC3339, status.ExpiresAt)
+	if err != nil {
+		return status
+	}
+	days := int(math.Floor(time.Until(expiresAt).Hours() / 24))
+	status.DaysToExpiry = &days
+	status.ExpiryWarning = days < credentialExpiryWarningDays
+	return status
+}
+
+// appendValidation records a validation result, keeping the latest entries only.
+func appendValidation(status *model.CredentialStatus, v model.CredentialValidation) {
+	status.LastValidation = &v
+	status.ValidationHistory = append(status.ValidationHistory, v)
+	if n := len(status.ValidationHistory); n > credentialValidationHistoryLimit {
+		status.ValidationHistory = status.ValidationHistory[n-credentialValidationHistoryLimit:]
+	}
+}
+
+// validationFromConnections summarizes the verification results of connection configs.
+func validationFromConnections(conns []model.ConnConfig, trigger string) model.CredentialValidation {
+	v := model.CredentialValidation{Time: time.Now().UTC().Format(time.RFC3339), Trigger: trigger}
+	for _, conn := range conns {
+		if conn.Verified {
+			v.VerifiedConnections++
+			continue
+		}
+		v.FailedConnections++
+		if v.Message == "" {
+			v.Message = conn.VerifiedMessage
+		}
+	}
+	v.Status = "invalid"
+	if v.VerifiedConnections > 0 {
+		v.Status = "valid"
+	}
+	return v
+}
+
+// validateExpiresAt checks the optional expiry of a credential request.
+func validateExpiresAt(expiresAt string) error {
+	if expiresAt == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, expiresAt); err != nil {
+		return fmt.Errorf("invalid expiresAt '%s' (RFC3339 required, e.g., 2026-12-31T00:00:00Z)", expiresAt)
+	}
+	return nil
+}
+
+// providerConnections returns the connection configs of a holder for a provider.
+func providerConnections(conns []model.ConnConfig, provider string) []model.ConnConfig {
+	result := []model.ConnConfig{}
+	for _, conn := range conns {
+		if strings.EqualFold(conn.ProviderName, provider) {
+			result = append(result, conn)
+		}
+	}
+	return result
+}
+
+// recordCredentialRegistration records the registration (or rotation) of a credential
+// with the verification results of its connections.
+func recordCredentialRegistration(holder, provider, expiresAt string, conns []model.ConnConfig, rotated bool) (model.CredentialStatus, error) {
+	trigger := credentialTriggerRegister
+	if rotated {
+		trigger = credentialTriggerRotate
+	}
+	return updateCredentialStatus(holder, provider, func(status *model.CredentialStatus) {
+		now := time.Now().UTC().Format(time.RFC3339)
+		if status.RegisteredAt == "" {
+			status.RegisteredAt = now
+		}
+		if rotated {
+			status.RotatedAt = now
+			status.RotationCount++
+			// A new secret has its own lifetime; the old expiry does not carry over
+			status.ExpiresAt = ""
+		}
+		if expiresAt != "" {
+			status.ExpiresAt = expiresAt
+		}
+		appendValidation(status, validationFromConnections(conns, trigger))
+	})
+}
+
+// GetCredentialStatus returns the rotation, expiry and validation status of a credential.
+func GetCredentialStatus(holder, provider string) (model.CredentialStatus, error) {
+	status, err := getCredentialStatusRecord(holder, provider)
+	if err != nil {
+		return status, err
+	}
+	if status.RegisteredAt == "" && status.LastValidation == nil {
+		return status, &apierr.StatusError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("no credential status for holder '%s' and provider '%s'", holder, provider)}
+	}
+	return withExpiry(status), nil
+}
+
+// ListCredentialStatus returns the status of every registered credential.
+// Credentials registered before status tracking existed are listed from their connection configs.
+func ListCredentialStatus() ([]model.CredentialStatus, error) {
+	pairs, err := credentialPairs()
+	if err != nil {
+		return nil, err
+	}
+	list := []model.CredentialStatus{}
+	for _, p := range pairs {
+		status, err := getCredentialStatusRecord(p[0], p[1])
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, withExpiry(status))
+	}
+	return list, nil
+}
+
+// credentialPairs returns the distinct (holder, provider) pairs of registered connection configs.
+func credentialPairs() ([][2]string, error) {
+	allConnections, err := GetConnConfigList("", false, false)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[[2]string]bool{}
+	pairs := [][2]string{}
+	for _, conn := range allConnections.Connectionconfig {
+		holder := strings.ToLower(conn.CredentialHolder)
+		if holder == "" {
+			holder = model.DefaultCredentialHolder
+		}
+		p := [2]string{holder, strings.ToLower(conn.ProviderName)}
+		if !seen[p] {
+			seen[p] = true
+			pairs = append(pairs, p)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	return pairs, nil
+}
+
+// ValidateCredential checks a credential against a few of its region representative
+// connections. One working connection makes it valid; failures are classified with
+// csp.ExplainCredentialError (e.g., expired secret vs. missing permission).
+func ValidateCredential(holder, provider string) (model.CredentialStatus, error) {
+	return validateCredential(holder, provider, credentialTriggerManual)
+}
+
+func validateCredential(holder, provider, trigger string) (model.CredentialStatus, error) {
+	holder = strings.ToLower(holder)
+	provider = strings.ToLower(provider)
+
+	representatives, err := GetConnConfigList(holder, false, true)
+	if err != nil {
+		return model.CredentialStatus{}, err
+	}
+	conns := providerConnections(representatives.Connectionconfig, provider)
+	if len(conns) == 0 {
+		return model.CredentialStatus{}, &apierr.StatusError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("no connection of holder '%s' for provider '%s'", holder, provider)}
+	}
+
+	v := model.CredentialValidation{Time: time.Now().UTC().Format(time.RFC3339), Status: "invalid", Trigger: trigger}
+	for i, conn := range conns {
+		if i >= credentialValidationMaxAttempts {
+			break
+		}
+		verified, err := CheckConnConfigAvailable(conn.ConfigName)
+		if verified {
+			v.VerifiedConnections++
+			v.Status = "valid"
+			v.Message = ""
+			break
+		}
+		v.FailedConnections++
+		v.Message = csp.ExplainCredentialError(provider, err)
+	}
+
+	status, err := updateCredentialStatus(holder, provider, func(status *model.CredentialStatus) {
+		appendValidation(status, v)
+	})
+	if err != nil {
+		return status, err
+	}
+	if v.Status != "valid" {
+		log.Warn().Msgf("Credential of holder '%s' for '%s' failed validation: %s", holder, provider, v.Message)
+	}
+	return status, nil
+}
+
+// ValidateAllCredentials validates every registered credential and warns about
+// credentials close to their expiry.
+func ValidateAllCredentials() {
+	pairs, err := credentialPairs()
+	if err != nil {
+		log.Error().Err(err).Msg("credential validation: cannot list credentials")
+		return
+	}
+	valid := 0
+	for _, p := range pairs {
+		status, err := validateCredential(p[0], p[1], credentialTriggerPeriodic)
+		if err != nil {
+			log.Warn().Err(err).Msgf("credential validation: skipped holder '%s' provider '%s'", p[0], p[1])
+			continue
+		}
+		if status.LastValidation != nil && status.LastValidation.Status == "valid" {
+			valid++
+		}
+		if status.ExpiryWarning && status.DaysToExpiry != nil {
+			if *status.DaysToExpiry < 0 {
+				log.Warn().Msgf("Credential of holder '%s' for '%s' expired at %s; rotate it", p[0], p[1], status.ExpiresAt)
+			} else {
+				log.Warn().Msgf("Credential of holder '%s' for '%s' expires in %d day(s) (%s); rotate it", p[0], p[1], *status.DaysToExpiry, status.ExpiresAt)
+			}
+		}
+	}
+	log.Info().Msgf("credential validation: %d/%d credentials valid", valid, len(pairs))
+}
+
+// StartCredentialValidationLoop validates every credential on a fixed interval for the
+// lifetime of the process (first run after one interval, since registration at startup
+// already verifies connections). Call once, after the kvstore has been initialized.
+func StartCredentialValidationLoop() {
+	if credentialValidationInterval <= 0 {
+		log.Info().Msg("credential validation: disabled (TB_CREDENTIAL_VALIDATION_INTERVAL <= 0)")
+		return
+	}
+
+	log.Info().Dur("interval", credentialValidationInterval).Msg("credential validation: starting periodic loop")
+
+	go func() {
+		ticker := time.NewTicker(credentialValidationInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ValidateAllCredentials()
+		}
+	}()
+}
+
+// RotateCredential replaces the credential of an already registered holder and provider.
+// The new secret is registered in CB-Spider and written to the secret store as a new secret version,
+// the connections are re-validated, cached CSP clients of the provider are dropped, and the
+// affected connection configs are reported.
+func RotateCredential(req model.CredentialReq) (model.CredentialRotationResult, error) {
+	holder := strings.ToLower(req.CredentialHolder)
+	provider := strings.ToLower(req.ProviderName)
+
+	existing, err := GetConnConfigList(holder, false, false)
+	if err != nil {
+		return model.CredentialRotationResult{}, err
+	}
+	if len(providerConnections(existing.Connectionconfig, provider)) == 0 {
+		return model.CredentialRotationResult{}, fmt.Errorf("no credential of holder '%s' for provider '%s' to rotate; register it first", holder, provider)
+	}
+
+	info, err := registerCredential(req, true)
+	if err != nil {
+		return model.CredentialRotationResult{}, err
+	}
+
+	result := model.CredentialRotationResult{
+		OpenBaoStatus:       info.OpenBaoStatus,
+		AffectedConnections: providerConnections(info.AllConnections.Connectionconfig, provider),
+		InvalidatedClients:  csp.InvalidateClientCache(modelcsp.ResolveCloudPlatform(provider)),
+	}
+	result.Status, err = GetCredentialStatus(holder, provider)
+	if err != nil {
+		return result, err
+	}
+	log.Info().Msgf("Credential of holder '%s' for '%s' rotated: %d connection(s) affected, %d cached client(s) invalidated",
+		holder, provider, len(result.AffectedConnections), result.InvalidatedClients)
+	return result, nil
+}
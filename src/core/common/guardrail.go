@@ -0,0 +1,379 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to include common methods for managing multi-cloud infra
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+)
+
+const guardrailKeyPrefix = "/guardrail/"
+
+var guardrailRuleTypes = []string{
+	model.GuardrailRequiredLabels,
+	model.GuardrailAllowedImages,
+	model.GuardrailAllowedSpecs,
+	model.GuardrailDenyPublicIp,
+	model.GuardrailDenyIngress,
+}
+
+// guardrailDefaultIngressCidrs are the sources denyIngress rejects when Cidrs is empty
+var guardrailDefaultIngressCidrs = []string{"0.0.0.0/0", "::/0"}
+
+// SetGuardrailPolicy validates and sets (replaces) the guardrail policy of a namespace.
+func SetGuardrailPolicy(nsId string, req *model.GuardrailPolicyReq) (model.GuardrailPolicy, error) {
+	if check, err := CheckNs(nsId); err != nil || !check {
+		return model.GuardrailPolicy{}, fmt.Errorf("namespace '%s' does not exist", nsId)
+	}
+
+	names := map[string]bool{}
+	for i := range req.Rules {
+		rule := &req.Rules[i]
+		rule.Name = strings.TrimSpace(rule.Name)
+		if rule.Name == "" {
+			return model.GuardrailPolicy{}, fmt.Errorf("rules[%d]: name is required", i)
+		}
+		if names[rule.Name] {
+			return model.GuardrailPolicy{}, fmt.Errorf("rules[%d]: duplicated rule name '%s'", i, rule.Name)
+		}
+		names[rule.Name] = true
+		if err := validateGuardrailRule(rule); err != nil {
+			return model.GuardrailPolicy{}, fmt.Errorf("rules[%d] (%s): %w", i, rule.Name, err)
+		}
+	}
+
+	policy := model.GuardrailPolicy{
+		NsId:      nsId,
+		Rules:     req.Rules,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if policy.Rules == nil {
+		policy.Rules = []model.GuardrailRule{}
+	}
+	val, err := json.Marshal(policy)
+	if err != nil {
+		return model.GuardrailPolicy{}, err
+	}
+	if err := kvstore.Put(guardrailKeyPrefix+nsId, string(val)); err != nil {
+		log.Error().Err(err).Msg("")
+		return model.GuardrailPolicy{}, err
+	}
+	log.Info().Msgf("Guardrail policy of namespace '%s' updated (%d rules)", nsId, len(policy.Rules))
+	return policy, nil
+}
+
+// validateGuardrailRule checks a rule and normalizes its enforcement mode.
+func validateGuardrailRule(rule *model.GuardrailRule) error {
+	if !slices.Contains(guardrailRuleTypes, rule.Type) {
+		return fmt.Errorf("unknown type '%s' (available: %s)", rule.Type, strings.Join(guardrailRuleTypes, ", "))
+	}
+	switch strings.ToLower(rule.Enforcement) {
+	case "", model.GuardrailEnforcementDeny:
+		rule.Enforcement = model.GuardrailEnforcementDeny
+	case model.GuardrailEnforcementWarn:
+		rule.Enforcement = model.GuardrailEnforcementWarn
+	default:
+		return fmt.Errorf("unknown enforcement '%s' (available: deny, warn)", rule.Enforcement)
+	}
+
+	switch rule.Type {
+	case model.GuardrailRequiredLabels, model.GuardrailAllowedImages, model.GuardrailAllowedSpecs:
+		if len(rule.Values) == 0 {
+			return fmt.Errorf("values are required for type '%s'", rule.Type)
+		}
+		for _, v := range rule.Values {
+			if strings.TrimSpace(v) == "" {
+				return fmt.Errorf("empty pattern in values of type '%s'", rule.Type)
+			}
+		}
+	case model.GuardrailDenyIngress:
+		for _, c := range rule.Cidrs {
+			if _, _, err := net.ParseCIDR(c); err != nil {
+				return fmt.Errorf("invalid CIDR '%s'", c)
+			}
+		}
+		for _, p := range rule.Ports {
+			if _, err := parseGuardrailPorts(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetGuardrailPolicy returns the guardrail policy of a namespace (no rules if not set).
+func GetGuardrailPolicy(nsId string) (model.GuardrailPolicy, error) {
+	val, exists, err := kvstore.Get(guardrailKeyPrefix + nsId)
+	if err != nil {
+		return model.GuardrailPolicy{}, err
+	}
+	if !exists {
+		return model.GuardrailPolicy{NsId: nsId, Rules: []model.GuardrailRule{}}, nil
+	}
+	policy := model.GuardrailPolicy{}
+	if err := json.Unmarshal([]byte(val), &policy); err != nil {
+		return model.GuardrailPolicy{}, err
+	}
+	return policy, nil
+}
+
+// DeleteGuardrailPolicy removes the guardrail policy of a namespace.
+func DeleteGuardrailPolicy(nsId string) error {
+	return kvstore.Delete(guardrailKeyPrefix + nsId)
+}
+
+// EvaluateGuardrails evaluates the guardrail policy of a namespace against
+// resources to be provisioned and returns every violation (deny and warn).
+func EvaluateGuardrails(nsId string, targets []model.GuardrailTarget) ([]model.GuardrailViolation, error) {
+	policy, err := GetGuardrailPolicy(nsId)
+	if err != nil {
+		return nil, err
+	}
+	violations := []model.GuardrailViolation{}
+	for _, rule := range policy.Rules {
+		for _, target := range targets {
+			violations = append(violations, evaluateGuardrailRule(rule, target)...)
+		}
+	}
+	return violations, nil
+}
+
+// EnforceGuardrails evaluates the guardrail policy of a namespace and rejects the
+// request with a validation error listing the violations if any deny rule is violated.
+// Violations of warn rules are only logged.
+func EnforceGuardrails(nsId string, targets ...model.GuardrailTarget) error {
+	violations, err := EvaluateGuardrails(nsId, targets)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate guardrails of namespace '%s': %w", nsId, err)
+	}
+	denied := 0
+	for _, v := range violations {
+		if v.Enforcement == model.GuardrailEnforcementDeny {
+			denied++
+			continue
+		}
+		log.Warn().Msgf("Guardrail '%s' (warn) violated by %s '%s': %s", v.Rule, v.Target, v.Resource, v.Message)
+	}
+	if denied == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("request violates %d guardrail rule(s) of namespace '%s'", denied, nsId)
+	log.Warn().Msg(msg)
+	return apierr.Invalid(msg, violations)
+}
+
+func evaluateGuardrailRule(rule model.GuardrailRule, target model.GuardrailTarget) []model.GuardrailViolation {
+	violation := func(field, format string, args ...any) model.GuardrailViolation {
+		return model.GuardrailViolation{
+			Rule:        rule.Name,
+			Type:        rule.Type,
+			Enforcement: rule.Enforcement,
+			Target:      target.Kind,
+			Resource:    target.Name,
+			Field:       field,
+			Message:     fmt.Sprintf(format, args...),
+		}
+	}
+	labelable := target.Kind == model.GuardrailTargetNode || target.Kind == model.GuardrailTargetK8sCluster
+
+	var violations []model.GuardrailViolation
+	switch rule.Type {
+	case model.GuardrailRequiredLabels:
+		if !labelable {
+			break
+		}
+		for _, required := range rule.Values {
+			key, value, hasValue := strings.Cut(required, "=")
+			got, ok := target.Labels[key]
+			switch {
+			case !ok || got == "":
+				violations = append(violations, violation("label", "label '%s' is required", key))
+			case hasValue && got != value:
+				violations = append(violations, violation("label", "label '%s' must be '%s' (got '%s')", key, value, got))
+			}
+		}
+
+	case model.GuardrailAllowedImages:
+		if !labelable {
+			break
+		}
+		for _, imageId := range target.ImageIds {
+			if imageId != "" && !matchGuardrailPattern(rule.Values, imageId) {
+				violations = append(violations, violation("imageId", "image '%s' is not in the approved list", imageId))
+			}
+		}
+
+	case model.GuardrailAllowedSpecs:
+		if !labelable {
+			break
+		}
+		for _, specId := range target.SpecIds {
+			if specId != "" && !matchGuardrailPattern(rule.Values, specId) {
+				violations = append(violations, violation("specId", "spec '%s' is not in the approved list", specId))
+			}
+		}
+
+	case model.GuardrailDenyPublicIp:
+		if target.Kind == model.GuardrailTargetNode && target.PublicIp {
+			violations = append(violations, violation("publicIp", "a public IP would be assigned, which is not allowed"))
+		}
+
+	case model.GuardrailDenyIngress:
+		for i, fw := range target.FirewallRules {
+			if msg, ok := matchGuardrailIngress(rule, fw); ok {
+				violations = append(violations, violation(fmt.Sprintf("firewallRules[%d]", i), "%s", msg))
+			}
+		}
+	}
+	return violations
+}
+
+// matchGuardrailPattern reports whether s matches one of the glob patterns (case-insensitive).
+// "*" matches any sequence including "/" so that URN-style image IDs (GCP, Azure)
+// are covered by a single pattern, and "?" matches any one character.
+func matchGuardrailPattern(patterns []string, s string) bool {
+	s = strings.ToLower(s)
+	for _, p := range patterns {
+		if matchGuardrailGlob(strings.ToLower(p), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGuardrailGlob matches s against pattern with "*" and "?" wildcards only.
+func matchGuardrailGlob(pattern, s string) bool {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	return err == nil && re.MatchString(s)
+}
+
+// matchGuardrailIngress reports whether an inbound firewall rule opens denied ports
+// to a denied source range (or a wider one), and describes it.
+func matchGuardrailIngress(rule model.GuardrailRule, fw model.GuardrailFirewallRule) (string, bool) {
+	direction := strings.ToLower(fw.Direction)
+	if direction != "inbound" && direction != "ingress" {
+		return "", false
+	}
+
+	protocol := strings.ToUpper(fw.Protocol)
+	anyProtocol := protocol == "" || protocol == "ALL" || protocol == "-1"
+	if len(rule.Protocols) > 0 && !anyProtocol &&
+		!slices.ContainsFunc(rule.Protocols, func(p string) bool { return strings.EqualFold(p, protocol) }) {
+		return "", false
+	}
+
+	source := fw.CIDR
+	if source == "" {
+		source = "0.0.0.0/0"
+	}
+	_, sourceNet, err := net.ParseCIDR(source)
+	if err != nil {
+		return "", false
+	}
+	cidrs := rule.Cidrs
+	if len(cidrs) == 0 {
+		cidrs = guardrailDefaultIngressCidrs
+	}
+	if !slices.ContainsFunc(cidrs, func(c string) bool { return cidrCovers(sourceNet, c) }) {
+		return "", false
+	}
+
+	opened, err := parseGuardrailPorts(fw.Ports)
+	if err != nil {
+		return "", false
+	}
+	denied := [][2]int{{1, 65535}}
+	if len(rule.Ports) > 0 {
+		denied = nil
+		for _, p := range rule.Ports {
+			ranges, _ := parseGuardrailPorts(p)
+			denied = append(denied, ranges...)
+		}
+	}
+	for _, o := range opened {
+		for _, d := range denied {
+			if o[0] <= d[1] && d[0] <= o[1] {
+				ports := fw.Ports
+				if ports == "" {
+					ports = "all ports"
+				}
+				if anyProtocol {
+					protocol = "ALL"
+				}
+				return fmt.Sprintf("inbound %s %s from %s is not allowed", protocol, ports, source), true
+			}
+		}
+	}
+	return "", false
+}
+
+// cidrCovers reports whether source is the same as or wider than cidr.
+func cidrCovers(source *net.IPNet, cidr string) bool {
+	_, target, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	sourceOnes, sourceBits := source.Mask.Size()
+	targetOnes, targetBits := target.Mask.Size()
+	return sourceBits == targetBits && sourceOnes <= targetOnes && source.Contains(target.IP)
+}
+
+// parseGuardrailPorts parses "22", "900-1000", "22,80" into port ranges.
+// An empty value, "-1", "*" or "ALL" means all ports.
+func parseGuardrailPorts(ports string) ([][2]int, error) {
+	ports = strings.TrimSpace(ports)
+	if ports == "" || ports == "-1" || ports == "*" || strings.EqualFold(ports, "ALL") {
+		return [][2]int{{1, 65535}}, nil
+	}
+	var ranges [][2]int
+	for _, part := range strings.Split(ports, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		if !isRange {
+			to = from
+		}
+		start, err1 := strconv.Atoi(strings.TrimSpace(from))
+		end, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || start < 0 || end > 65535 || start > end {
+			return nil, fmt.Errorf("invalid port or port range '%s'", part)
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
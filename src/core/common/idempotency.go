@@ -0,0 +1,270 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to include common methods for managing multi-cloud infra
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.etcd.io/etcd/client/v3/concurrency"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+)
+
+const (
+	idempotencyKeyPrefix = "/idempotency/"
+	// idempotencyLockPrefix is the prefix of the kvstore locks held by the server running
+	// a request (outside idempotencyKeyPrefix, which only holds records)
+	idempotencyLockPrefix = "/idempotencyLock/"
+
+	// idempotencyMaxResponseBytes caps stored responses (etcd values are size-limited)
+	idempotencyMaxResponseBytes = 512 * 1024
+)
+
+// idempotencyKeyTTL is how long idempotency keys are remembered.
+// Configure via TB_IDEMPOTENCY_KEY_TTL (Go duration, e.g., "12h").
+var idempotencyKeyTTL = func() time.Duration {
+	if v := os.Getenv("TB_IDEMPOTENCY_KEY_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Warn().Str("value", v).Msg("invalid TB_IDEMPOTENCY_KEY_TTL; using default 24h")
+	}
+	return 24 * time.Hour
+}()
+
+// idempotencyMu serializes the check-and-reserve of keys within this server.
+// Across servers, the server running a request owns it through a kvstore lock
+// bound to the lease of its session: the lock is released when the request ends,
+// or when the lease expires because the server stopped (crash, restart).
+var idempotencyMu sync.Mutex
+
+// idempotencyOwned holds the locks of the requests running on this server (key: store key).
+var idempotencyOwned = map[string]*concurrency.Mutex{}
+
+// idempotencySession is the kvstore session (lease) of this server's locks.
+var idempotencySession *concurrency.Session
+
+// getIdempotencySession returns the session of this server's locks, renewing it if its lease was lost.
+func getIdempotencySession() (*concurrency.Session, error) {
+	if idempotencySession != nil {
+		select {
+		case <-idempotencySession.Done():
+			idempotencySession = nil
+		default:
+			return idempotencySession, nil
+		}
+	}
+	session, err := kvstore.NewSession(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	idempotencySession = session
+	return session, nil
+}
+
+// ownIdempotencyKey takes the lock of a key for this server. It returns false if
+// another server holds it, i.e., is running the request.
+func ownIdempotencyKey(storeKey string) (bool, error) {
+	session, err := getIdempotencySession()
+	if err != nil {
+		return false, err
+	}
+	lock := concurrency.NewMutex(session, idempotencyLockPrefix+strings.TrimPrefix(storeKey, idempotencyKeyPrefix))
+	if err := lock.TryLock(context.Background()); err != nil {
+		if errors.Is(err, concurrency.ErrLocked) {
+			return false, nil
+		}
+		return false, err
+	}
+	idempotencyOwned[storeKey] = lock
+	return true, nil
+}
+
+// releaseIdempotencyKey releases the lock of a key taken by ownIdempotencyKey.
+func releaseIdempotencyKey(storeKey string) {
+	lock, ok := idempotencyOwned[storeKey]
+	if !ok {
+		return
+	}
+	delete(idempotencyOwned, storeKey)
+	if err := lock.Unlock(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to release the idempotency lock; it is released when the lease expires")
+	}
+}
+
+func idempotencyStoreKey(scope, key string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + key))
+	return idempotencyKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+func getIdempotencyRecord(storeKey string) (*model.IdempotencyRecord, error) {
+	val, exists, err := kvstore.Get(storeKey)
+	if err != nil || !exists {
+		return nil, err
+	}
+	record := &model.IdempotencyRecord{}
+	if err := json.Unmarshal([]byte(val), record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func putIdempotencyRecord(storeKey string, record *model.IdempotencyRecord) error {
+	val, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put(storeKey, string(val))
+}
+
+func idempotencyRecordExpired(record *model.IdempotencyRecord, now time.Time) bool {
+	expiresAt, err := time.Parse(time.RFC3339, record.ExpiresAt)
+	return err != nil || now.After(expiresAt)
+}
+
+// BeginIdempotentRequest reserves an idempotency key for a request. If the key is already
+// in use (and not expired), the existing record is returned and the request must not run.
+// A key left in progress is only taken over when no server holds its lock any more
+// (the server running it stopped and its lease expired).
+func BeginIdempotentRequest(record model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	idempotencyMu.Lock()
+	defer idempotencyMu.Unlock()
+
+	storeKey := idempotencyStoreKey(record.Scope, record.Key)
+	now := time.Now().UTC()
+	existing, err := getIdempotencyRecord(storeKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && !idempotencyRecordExpired(existing, now) {
+		if existing.Status != model.IdempotencyInProgress {
+			return existing, nil
+		}
+		// The lock of this server's session would be granted again, so check running requests first
+		if _, running := idempotencyOwned[storeKey]; running {
+			return existing, nil
+		}
+	}
+
+	owned, err := ownIdempotencyKey(storeKey)
+	if err != nil {
+		return nil, err
+	}
+	if !owned {
+		// Another server is running the request (or is about to record it)
+		if existing == nil || idempotencyRecordExpired(existing, now) {
+			running := record
+			running.Status = model.IdempotencyInProgress
+			running.RequestId = ""
+			return &running, nil
+		}
+		return existing, nil
+	}
+	if existing != nil && existing.Status == model.IdempotencyInProgress && !idempotencyRecordExpired(existing, now) {
+		log.Warn().Str("requestId", existing.RequestId).Msg("Idempotent request was interrupted by a server stop; executing the retry")
+	}
+
+	record.Status = model.IdempotencyInProgress
+	record.CreatedAt = now.Format(time.RFC3339)
+	record.ExpiresAt = now.Add(idempotencyKeyTTL).Format(time.RFC3339)
+	if err := putIdempotencyRecord(storeKey, &record); err != nil {
+		releaseIdempotencyKey(storeKey)
+		return nil, err
+	}
+	return nil, nil
+}
+
+// CompleteIdempotentRequest stores the response of a request started with BeginIdempotentRequest.
+func CompleteIdempotentRequest(scope, key string, httpStatus int, contentType string, response []byte) error {
+	idempotencyMu.Lock()
+	defer idempotencyMu.Unlock()
+
+	storeKey := idempotencyStoreKey(scope, key)
+	defer releaseIdempotencyKey(storeKey)
+	record, err := getIdempotencyRecord(storeKey)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return fmt.Errorf("idempotency key '%s' is not reserved", key)
+	}
+	record.Status = model.IdempotencyCompleted
+	record.HttpStatus = httpStatus
+	record.ContentType = contentType
+	if len(response) > idempotencyMaxResponseBytes {
+		record.ResponseOmitted = true
+	} else {
+		record.Response = response
+	}
+	return putIdempotencyRecord(storeKey, record)
+}
+
+// AbandonIdempotentRequest releases an idempotency key whose request produced no response,
+// so that a retry runs again.
+func AbandonIdempotentRequest(scope, key string) error {
+	idempotencyMu.Lock()
+	defer idempotencyMu.Unlock()
+	storeKey := idempotencyStoreKey(scope, key)
+	defer releaseIdempotencyKey(storeKey)
+	return kvstore.Delete(storeKey)
+}
+
+// StartIdempotencyCleanupLoop periodically deletes expired idempotency keys.
+// Call once, after the kvstore has been initialized.
+func StartIdempotencyCleanupLoop() {
+	interval := min(idempotencyKeyTTL, time.Hour)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			cleanupIdempotencyKeys()
+		}
+	}()
+}
+
+func cleanupIdempotencyKeys() {
+	kvs, err := kvstore.GetKvList(idempotencyKeyPrefix)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list idempotency keys for cleanup")
+		return
+	}
+	now := time.Now().UTC()
+	deleted := 0
+	for _, kv := range kvs {
+		record := &model.IdempotencyRecord{}
+		if err := json.Unmarshal([]byte(kv.Value), record); err == nil && !idempotencyRecordExpired(record, now) {
+			continue
+		}
+		if err := kvstore.Delete(kv.Key); err != nil {
+			log.Warn().Err(err).Str("key", kv.Key).Msg("Failed to delete expired idempotency key")
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		log.Debug().Int("deletedCount", deleted).Msg("Expired idempotency keys deleted")
+	}
+}
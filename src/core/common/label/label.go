@@ -504,7 +504,8 @@ func UpdateCSPResourceLabel(ctx context.Context, labelType, uid string, labels m
 			Value: value,
 		}
 
-		_, err := clientManager.ExecuteHttpRequest(
+		_, err := clientManager.ExecuteHttpRequestWithContext(
+			ctx,
 			client,
 			method,
 			url,
@@ -536,7 +537,8 @@ func RemoveCSPResourceLabel(ctx context.Context, labelType, uid string, key stri
 	requestBody.ReqInfo.ResourceName = uid
 	requestBody.ReqInfo.ResourceType = convertTermToSpider(labelType)
 
-	clientManager.ExecuteHttpRequest(
+	clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -627,7 +629,8 @@ func ListCSPResourceLabel(ctx context.Context, labelType, uid string, connection
 	var callResult jsonResult
 	requestBody := clientManager.NoBody
 
-	_, err := clientManager.ExecuteHttpRequest(
+	_, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
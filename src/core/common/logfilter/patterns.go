@@ -47,6 +47,8 @@ var RequestSkipPatterns = []SkipRule{
 	{Patterns: []string{"/tumblebug/livez"}},
 	{Patterns: []string{"/tumblebug/httpVersion"}},
 	{Patterns: []string{"/tumblebug/testStreamResponse"}},
+	{Patterns: []string{"/tumblebug/metrics"}},
+	{Patterns: []string{"/tumblebug/statusAgent"}},
 
 	// Request tracking endpoints (avoid recursive logging)
 	{Patterns: []string{"/tumblebug/request"}},
@@ -54,6 +56,7 @@ var RequestSkipPatterns = []SkipRule{
 
 	// SSE streaming endpoints — BodyDump and TracingMiddleware interfere with streaming responses
 	{Method: "GET", Patterns: []string{"/stream/cmd/"}},
+	{Method: "GET", Patterns: []string{"/stream/status"}},
 
 	// High-frequency polling endpoints from UI (cb-mapui)
 	// These are called every 5-10 seconds and storing their large response bodies
@@ -91,6 +94,10 @@ var APISkipPatterns = []SkipRule{
 	{Patterns: []string{"/tumblebug/livez"}},
 	{Patterns: []string{"/tumblebug/httpVersion"}},
 
+	// Metrics scraping and introspection (polled by Prometheus)
+	{Patterns: []string{"/tumblebug/metrics"}},
+	{Patterns: []string{"/tumblebug/statusAgent"}},
+
 	// Infra status polling (very frequent) - GET only
 	{Method: "GET", Patterns: []string{"/infra", "option=status"}},
 	{Method: "GET", Patterns: []string{"/infra"}},
@@ -22,6 +22,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
@@ -31,6 +32,23 @@ import (
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 )
 
+// NsCleanupFunc deletes the data a package keeps for a namespace outside "/ns/{nsId}".
+type NsCleanupFunc func(nsId string) error
+
+// nsCleanupFuncs are registered by packages that common cannot import
+// (e.g., infra keeps budgets under "/budget/{nsId}/"); DelNs runs them.
+var (
+	nsCleanupMu    sync.Mutex
+	nsCleanupFuncs = map[string]NsCleanupFunc{}
+)
+
+// RegisterNsCleanupFunc registers a function run by DelNs to delete the named data of a namespace.
+func RegisterNsCleanupFunc(name string, fn NsCleanupFunc) {
+	nsCleanupMu.Lock()
+	defer nsCleanupMu.Unlock()
+	nsCleanupFuncs[name] = fn
+}
+
 func NsValidation() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -376,6 +394,26 @@ func DelNs(id string) error {
 		log.Error().Err(err).Msg("")
 	}
 
+	if err := DeleteAllRoleBindings(id); err != nil {
+		log.Error().Err(err).Msgf("Failed to delete role bindings of namespace '%s'", id)
+	}
+	if err := DeleteAllApiTokens(id); err != nil {
+		log.Error().Err(err).Msgf("Failed to delete API tokens of namespace '%s'", id)
+	}
+	if err := DeleteGuardrailPolicy(id); err != nil {
+		log.Error().Err(err).Msgf("Failed to delete guardrail policy of namespace '%s'", id)
+	}
+	if err := DeleteNsQuotaReservations(id); err != nil {
+		log.Error().Err(err).Msgf("Failed to delete quota reservations of namespace '%s'", id)
+	}
+	nsCleanupMu.Lock()
+	for name, cleanup := range nsCleanupFuncs {
+		if err := cleanup(id); err != nil {
+			log.Error().Err(err).Msgf("Failed to delete %s of namespace '%s'", name, id)
+		}
+	}
+	nsCleanupMu.Unlock()
+
 	return nil
 }
 
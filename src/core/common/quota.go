@@ -0,0 +1,338 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to include common methods for managing multi-cloud infra
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+)
+
+const (
+	// nsQuotaLockPrefix is the kvstore lock serializing quota reservations per namespace
+	nsQuotaLockPrefix = "/quota/lock/"
+	// nsQuotaReservationPrefix is the kvstore prefix of reservations (/quota/reservation/{nsId}/{id})
+	nsQuotaReservationPrefix = "/quota/reservation/"
+	// nsQuotaReservationTTL bounds how long a reservation counts if it is never released
+	// (e.g., the server stopped in the middle of provisioning)
+	nsQuotaReservationTTL = time.Hour
+)
+
+// nsQuotaReservation is capacity admitted by the quota for a request in flight.
+type nsQuotaReservation struct {
+	Usage     model.NsResourceUsage `json:"usage"`
+	ExpiresAt string                `json:"expiresAt"`
+}
+
+// nsQuotaReservationCtxKey marks a context whose request holds a reservation in a namespace.
+type nsQuotaReservationCtxKey struct{ nsId string }
+
+// NsUsageFunc computes the current resource usage of a namespace.
+type NsUsageFunc func(nsId string) (model.NsResourceUsage, error)
+
+// nsUsageFunc is registered by the infra package, which knows how to count
+// Nodes, K8s clusters and object storages (common cannot import it).
+var (
+	nsUsageMu   sync.RWMutex
+	nsUsageFunc NsUsageFunc
+)
+
+// RegisterNsUsageFunc registers the function used to compute namespace usage.
+func RegisterNsUsageFunc(fn NsUsageFunc) {
+	nsUsageMu.Lock()
+	defer nsUsageMu.Unlock()
+	nsUsageFunc = fn
+}
+
+func getNsUsage(nsId string) (model.NsResourceUsage, error) {
+	nsUsageMu.RLock()
+	fn := nsUsageFunc
+	nsUsageMu.RUnlock()
+	if fn == nil {
+		return model.NsResourceUsage{}, fmt.Errorf("namespace usage collector is not registered")
+	}
+	return fn(nsId)
+}
+
+// putNsInfo stores the namespace object as is.
+func putNsInfo(ns model.NsInfo) error {
+	val, err := json.Marshal(ns)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put("/ns/"+ns.Id, string(val))
+}
+
+// SetNsQuota sets (replaces) the quota of a namespace.
+func SetNsQuota(nsId string, quota model.NsQuota) (model.NsInfo, error) {
+	if quota.MaxNodes < 0 || quota.MaxVCpu < 0 || quota.MaxMemoryGiB < 0 || quota.MaxGpus < 0 ||
+		quota.MaxCostPerHour < 0 || quota.MaxK8sClusters < 0 || quota.MaxObjectStorages < 0 {
+		return model.NsInfo{}, fmt.Errorf("quota limits must not be negative")
+	}
+	for i, p := range quota.AllowedProviders {
+		quota.AllowedProviders[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+	for i, r := range quota.AllowedRegions {
+		quota.AllowedRegions[i] = strings.ToLower(strings.TrimSpace(r))
+	}
+
+	ns, err := GetNs(nsId)
+	if err != nil {
+		return model.NsInfo{}, err
+	}
+	ns.Quota = &quota
+	if err := putNsInfo(ns); err != nil {
+		log.Error().Err(err).Msg("")
+		return model.NsInfo{}, err
+	}
+	log.Info().Msgf("Quota of namespace '%s' updated: %+v", nsId, quota)
+	return ns, nil
+}
+
+// GetNsQuota returns the quota of a namespace (nil if unlimited).
+func GetNsQuota(nsId string) (*model.NsQuota, error) {
+	ns, err := GetNs(nsId)
+	if err != nil {
+		return nil, err
+	}
+	return ns.Quota, nil
+}
+
+// DeleteNsQuota removes the quota of a namespace, making it unlimited.
+func DeleteNsQuota(nsId string) error {
+	ns, err := GetNs(nsId)
+	if err != nil {
+		return err
+	}
+	if ns.Quota == nil {
+		return nil
+	}
+	ns.Quota = nil
+	return putNsInfo(ns)
+}
+
+// GetNsQuotaUsage returns the current consumption of a namespace against its quota.
+func GetNsQuotaUsage(nsId string) (model.NsQuotaUsage, error) {
+	quota, err := GetNsQuota(nsId)
+	if err != nil {
+		return model.NsQuotaUsage{}, err
+	}
+	usage, err := getNsUsage(nsId)
+	if err != nil {
+		return model.NsQuotaUsage{}, err
+	}
+	return model.NsQuotaUsage{NsId: nsId, Quota: quota, Usage: usage}, nil
+}
+
+// locationAllowed reports whether provider/region passes the quota's location filters.
+// An unknown provider or region fails a filter that is set.
+func locationAllowed(quota *model.NsQuota, loc model.NsQuotaLocation) bool {
+	provider := strings.ToLower(loc.Provider)
+	region := strings.ToLower(loc.Region)
+	if len(quota.AllowedProviders) > 0 && !slices.Contains(quota.AllowedProviders, provider) {
+		return false
+	}
+	if len(quota.AllowedRegions) > 0 {
+		return slices.Contains(quota.AllowedRegions, region) || slices.Contains(quota.AllowedRegions, provider+"+"+region)
+	}
+	return true
+}
+
+// nsQuotaNeedsUsage reports whether the quota has limits checked against usage
+// (rather than only location filters).
+func nsQuotaNeedsUsage(quota *model.NsQuota) bool {
+	return quota.MaxNodes > 0 || quota.MaxVCpu > 0 || quota.MaxMemoryGiB > 0 || quota.MaxGpus > 0 ||
+		quota.MaxCostPerHour > 0 || quota.MaxK8sClusters > 0 || quota.MaxObjectStorages > 0
+}
+
+// getNsQuotaReserved sums the unexpired reservations of a namespace and deletes expired ones.
+func getNsQuotaReserved(nsId string) (model.NsResourceUsage, error) {
+	reserved := model.NsResourceUsage{}
+	kvs, err := kvstore.GetKvList(nsQuotaReservationPrefix + nsId + "/")
+	if err != nil {
+		return reserved, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, kv := range kvs {
+		r := nsQuotaReservation{}
+		if err := json.Unmarshal([]byte(kv.Value), &r); err != nil || r.ExpiresAt < now {
+			if err := kvstore.Delete(kv.Key); err != nil {
+				log.Warn().Err(err).Msgf("Failed to delete expired quota reservation %s", kv.Key)
+			}
+			continue
+		}
+		reserved.Nodes += r.Usage.Nodes
+		reserved.VCpu += r.Usage.VCpu
+		reserved.MemoryGiB += r.Usage.MemoryGiB
+		reserved.Gpus += r.Usage.Gpus
+		reserved.CostPerHour += r.Usage.CostPerHour
+		reserved.K8sClusters += r.Usage.K8sClusters
+		reserved.ObjectStorages += r.Usage.ObjectStorages
+	}
+	return reserved, nil
+}
+
+// CheckNsQuota checks whether demand fits into the namespace quota on top of the
+// current usage and the capacity reserved by requests in flight, without reserving it.
+// Provisioning requests use ReserveNsQuota instead, so that concurrent requests cannot
+// together exceed the quota.
+func CheckNsQuota(nsId string, demand model.NsQuotaDemand) error {
+	quota, err := GetNsQuota(nsId)
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		return nil
+	}
+	return checkNsQuota(nsId, quota, demand)
+}
+
+// ReserveNsQuota checks demand against the namespace quota like CheckNsQuota and, if it fits,
+// reserves it until release is called: the check and the reservation are serialized per
+// namespace by a kvstore lock, and later checks count the reservation as usage.
+// Callers release the reservation once the resources are recorded (typically when the
+// provisioning call returns); until then the resources may be counted twice, which errs
+// on the side of the limit. The returned context marks the reservation, so nested
+// provisioning calls of the same request do not check and reserve the capacity again.
+func ReserveNsQuota(ctx context.Context, nsId string, demand model.NsQuotaDemand) (context.Context, func(), error) {
+	release := func() {}
+	if ctx.Value(nsQuotaReservationCtxKey{nsId}) != nil {
+		return ctx, release, nil
+	}
+	quota, err := GetNsQuota(nsId)
+	if err != nil {
+		return ctx, release, err
+	}
+	if quota == nil {
+		return ctx, release, nil
+	}
+	if !nsQuotaNeedsUsage(quota) {
+		return ctx, release, checkNsQuota(nsId, quota, demand)
+	}
+
+	session, err := kvstore.NewSession(ctx)
+	if err != nil {
+		return ctx, release, fmt.Errorf("failed to lock the quota of namespace '%s': %w", nsId, err)
+	}
+	defer session.Close()
+	lock, err := kvstore.NewLock(ctx, session, nsQuotaLockPrefix+nsId)
+	if err != nil {
+		return ctx, release, fmt.Errorf("failed to lock the quota of namespace '%s': %w", nsId, err)
+	}
+	defer func() {
+		if err := lock.Unlock(context.WithoutCancel(ctx)); err != nil {
+			log.Warn().Err(err).Msgf("Failed to unlock the quota of namespace '%s'", nsId)
+		}
+	}()
+
+	if err := checkNsQuota(nsId, quota, demand); err != nil {
+		return ctx, release, err
+	}
+
+	key := nsQuotaReservationPrefix + nsId + "/" + GenUid()
+	// Only growth is reserved (a downsizing resize has negative demand)
+	held := model.NsResourceUsage{
+		Nodes:          max(demand.Nodes, 0),
+		VCpu:           max(demand.VCpu, 0),
+		MemoryGiB:      max(demand.MemoryGiB, 0),
+		Gpus:           max(demand.Gpus, 0),
+		CostPerHour:    max(demand.CostPerHour, 0),
+		K8sClusters:    max(demand.K8sClusters, 0),
+		ObjectStorages: max(demand.ObjectStorages, 0),
+	}
+	val, err := json.Marshal(nsQuotaReservation{
+		Usage:     held,
+		ExpiresAt: time.Now().UTC().Add(nsQuotaReservationTTL).Format(time.RFC3339),
+	})
+	if err != nil {
+		return ctx, release, err
+	}
+	if err := kvstore.Put(key, string(val)); err != nil {
+		return ctx, release, fmt.Errorf("failed to reserve the quota of namespace '%s': %w", nsId, err)
+	}
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			if err := kvstore.Delete(key); err != nil {
+				log.Warn().Err(err).Msgf("Failed to release quota reservation %s (it expires in %s)", key, nsQuotaReservationTTL)
+			}
+		})
+	}
+	return context.WithValue(ctx, nsQuotaReservationCtxKey{nsId}, key), release, nil
+}
+
+// DeleteNsQuotaReservations deletes the quota reservations of a namespace.
+func DeleteNsQuotaReservations(nsId string) error {
+	return kvstore.DeleteWithPrefix(nsQuotaReservationPrefix + nsId + "/")
+}
+
+// checkNsQuota checks demand against quota on top of the usage and reservations of the
+// namespace and returns an apierr.Forbidden error describing every exceeded limit.
+func checkNsQuota(nsId string, quota *model.NsQuota, demand model.NsQuotaDemand) error {
+	var violations []string
+	for _, loc := range demand.Locations {
+		if locationAllowed(quota, loc) {
+			continue
+		}
+		if loc.Provider == "" || loc.Region == "" {
+			violations = append(violations, "location could not be resolved from the spec or connection, so allowed providers/regions cannot be verified")
+		} else {
+			violations = append(violations, fmt.Sprintf("location %s/%s is not allowed", loc.Provider, loc.Region))
+		}
+	}
+
+	if nsQuotaNeedsUsage(quota) {
+		usage, err := getNsUsage(nsId)
+		if err != nil {
+			return fmt.Errorf("failed to compute usage of namespace '%s' for quota check: %w", nsId, err)
+		}
+		reserved, err := getNsQuotaReserved(nsId)
+		if err != nil {
+			return fmt.Errorf("failed to read quota reservations of namespace '%s': %w", nsId, err)
+		}
+		checkInt := func(name string, limit, used, held, add int) {
+			if limit > 0 && add > 0 && used+held+add > limit {
+				violations = append(violations, fmt.Sprintf("%s: %d in use + %d reserved + %d requested > %d", name, used, held, add, limit))
+			}
+		}
+		checkFloat := func(name string, limit, used, held, add float64) {
+			if limit > 0 && add > 0 && used+held+add > limit {
+				violations = append(violations, fmt.Sprintf("%s: %.2f in use + %.2f reserved + %.2f requested > %.2f", name, used, held, add, limit))
+			}
+		}
+		checkInt("maxNodes", quota.MaxNodes, usage.Nodes, reserved.Nodes, demand.Nodes)
+		checkInt("maxVCpu", quota.MaxVCpu, usage.VCpu, reserved.VCpu, demand.VCpu)
+		checkFloat("maxMemoryGiB", quota.MaxMemoryGiB, usage.MemoryGiB, reserved.MemoryGiB, demand.MemoryGiB)
+		checkInt("maxGpus", quota.MaxGpus, usage.Gpus, reserved.Gpus, demand.Gpus)
+		checkFloat("maxCostPerHour", quota.MaxCostPerHour, usage.CostPerHour, reserved.CostPerHour, demand.CostPerHour)
+		checkInt("maxK8sClusters", quota.MaxK8sClusters, usage.K8sClusters, reserved.K8sClusters, demand.K8sClusters)
+		checkInt("maxObjectStorages", quota.MaxObjectStorages, usage.ObjectStorages, reserved.ObjectStorages, demand.ObjectStorages)
+	}
+
+	if len(violations) > 0 {
+		return apierr.Forbidden(fmt.Sprintf("namespace '%s' quota exceeded: %s", nsId, strings.Join(violations, "; ")))
+	}
+	return nil
+}
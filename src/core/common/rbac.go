@@ -0,0 +1,491 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to include common methods for managing multi-cloud infra
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+)
+
+// kvstore key prefixes for RBAC objects. Bindings are kept outside "/ns/{nsId}"
+// so namespace listing and resource checks are not affected.
+const (
+	rbacRoleKeyPrefix    = "/rbac/role/"
+	rbacBindingKeyPrefix = "/rbac/binding/"
+)
+
+// rbacAuthActive is set by the REST server when JWT auth and RBAC are enabled,
+// the only auth mode that tells individual users apart.
+var rbacAuthActive atomic.Bool
+
+// SetRbacAuthActive records whether requests are authenticated per user (JWT auth with RBAC).
+func SetRbacAuthActive(active bool) {
+	rbacAuthActive.Store(active)
+}
+
+// RbacAuthActive reports whether requests are authenticated per user (JWT auth with RBAC).
+func RbacAuthActive() bool {
+	return rbacAuthActive.Load()
+}
+
+var rbacAllGroups = []string{
+	model.RbacGroupSystem,
+	model.RbacGroupGlobal,
+	model.RbacGroupCredential,
+	model.RbacGroupNamespace,
+	model.RbacGroupRbac,
+	model.RbacGroupCatalog,
+	model.RbacGroupInfra,
+	model.RbacGroupResource,
+	model.RbacGroupK8s,
+}
+
+var rbacAllVerbs = []string{model.RbacVerbRead, model.RbacVerbWrite, model.RbacVerbDelete}
+
+// builtInRoles are the roles derived from the realm roles of the access token.
+var builtInRoles = map[string]model.RbacRole{
+	model.RoleMaintainer: {
+		Name:        model.RoleMaintainer,
+		Description: "Full access, including TB system configuration",
+		Rules: []model.RbacRule{
+			{Groups: []string{model.RbacWildcard}, Verbs: []string{model.RbacWildcard}},
+		},
+	},
+	model.RoleAdmin: {
+		Name:        model.RoleAdmin,
+		Description: "Full access to namespaces, credentials, RBAC and data shared by namespaces; read-only TB system configuration",
+		Rules: []model.RbacRule{
+			{
+				Groups: []string{model.RbacGroupCredential, model.RbacGroupNamespace, model.RbacGroupRbac, model.RbacGroupGlobal,
+					model.RbacGroupCatalog, model.RbacGroupInfra, model.RbacGroupResource, model.RbacGroupK8s},
+				Verbs: []string{model.RbacWildcard},
+			},
+			{Groups: []string{model.RbacGroupSystem}, Verbs: []string{model.RbacVerbRead}},
+		},
+	},
+	model.RoleUser: {
+		Name:        model.RoleUser,
+		Description: "Manage workloads in namespaces; no credential, namespace or RBAC management",
+		Rules: []model.RbacRule{
+			{
+				Groups: []string{model.RbacGroupCatalog, model.RbacGroupInfra, model.RbacGroupResource, model.RbacGroupK8s},
+				Verbs:  []string{model.RbacWildcard},
+			},
+			{Groups: []string{model.RbacGroupNamespace, model.RbacGroupSystem}, Verbs: []string{model.RbacVerbRead}},
+		},
+	},
+	model.RoleGuest: {
+		Name:        model.RoleGuest,
+		Description: "Read-only access to workloads; catalog queries allowed",
+		Rules: []model.RbacRule{
+			{Groups: []string{model.RbacGroupCatalog}, Verbs: []string{model.RbacWildcard}},
+			{
+				Groups: []string{model.RbacGroupNamespace, model.RbacGroupInfra, model.RbacGroupResource, model.RbacGroupK8s},
+				Verbs:  []string{model.RbacVerbRead},
+			},
+		},
+	},
+}
+
+// rbacGlobalRoutes are non-namespaced routes whose data spans all namespaces
+// (raw kvstore objects, request history) or that reload shared assets
+var rbacGlobalRoutes = []string{
+	"/object", "/objects", "/request/:reqId", "/requests", "/loadAssets",
+}
+
+// rbacGlobalWritePrefixes are non-namespaced routes that anyone with resource access may read,
+// but whose changes affect every namespace
+var rbacGlobalWritePrefixes = []string{
+	"/label/", "/mergeCSPLabel/", "/resources/globalDns/",
+}
+
+// rbacSystemPrefixes are non-namespaced routes in the system group
+var rbacSystemPrefixes = []string{
+	"/config", "/forward", "/readyz/init",
+	"/registerCspResources", "/systemInfra", "/fetchSpecs", "/fetchPrice", "/fetchImages",
+	"/updateImagesFromAsset", "/updateExistingSpecList", "/provisioning", "/metrics", "/statusAgent",
+	"/costUsage",
+}
+
+// rbacCatalogRoutes are the first path segments of non-namespaced routes that only
+// query CSP catalogs, TB assets or TB health. Routes not listed anywhere are unclassified
+// and denied, so a newly added route cannot silently become guest-writable.
+var rbacCatalogRoutes = []string{
+	"api", "auth", "livez", "readyz", "httpVersion", "testStreamResponse",
+	"cloudInfo", "connConfig", "provider", "regionFromCsp", "assetsSummary",
+	"lookupSpec", "lookupSpecs", "lookupImage", "lookupImages",
+	"recommendSpec", "recommendSpecOptions", "recommendAlternativeNodeConfig",
+	"specImagePairReview", "infraDynamicCheckRequest",
+	"availableZonesForSpec", "availableRegionZonesForSpec", "availableRegionZonesForSpecList",
+	"availableK8sVersion", "availableK8sNodeImage", "requiredK8sSubnetCount",
+	"checkK8sNodeGroupsOnK8sCreation", "checkK8sNodeImageDesignation",
+	"k8sClusterInfo", "k8sClusterRecommendNode", "k8sClusterDynamicCheckRequest",
+	"nlb", "objectStorage", "rdbms", "util",
+}
+
+// RbacGroupOf classifies a route template (echo's c.Path(), e.g.,
+// /tumblebug/ns/:nsId/infra/:infraId) and the request method into an RBAC route group.
+// It returns "" for routes that belong to no group.
+func RbacGroupOf(route, method string) string {
+	p := strings.TrimPrefix(route, "/tumblebug")
+
+	if slices.Contains(rbacGlobalRoutes, p) {
+		return model.RbacGroupGlobal
+	}
+	for _, prefix := range rbacGlobalWritePrefixes {
+		if strings.HasPrefix(p, prefix) && RbacVerbOf(route, method, "") != model.RbacVerbRead {
+			return model.RbacGroupGlobal
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(p, "/ns/:nsId/"); ok {
+		seg, _, _ := strings.Cut(rest, "/")
+		switch {
+		case seg == "rbac" || seg == "apiToken":
+			return model.RbacGroupRbac
+		case seg == "quota" || seg == "guardrail" || seg == "budget":
+			return model.RbacGroupNamespace
+		case strings.HasPrefix(seg, "k8s"):
+			return model.RbacGroupK8s
+		case seg == "resources" || strings.HasSuffix(seg, "Resource") || strings.HasSuffix(seg, "Resources"):
+			// resources/*, sharedResource(s), registerCspResource, deregisterResource, checkResource
+			return model.RbacGroupResource
+		default:
+			return model.RbacGroupInfra
+		}
+	}
+
+	switch {
+	case p == "/ns" || p == "/ns/:nsId" || strings.HasPrefix(p, "/checkNs"):
+		return model.RbacGroupNamespace
+	case strings.HasPrefix(p, "/credential"):
+		return model.RbacGroupCredential
+	case strings.HasPrefix(p, "/rbac") || strings.HasPrefix(p, "/approval"):
+		return model.RbacGroupRbac
+	case strings.HasPrefix(p, "/label") || strings.HasPrefix(p, "/mergeCSPLabel") || strings.HasPrefix(p, "/resources/") ||
+		strings.HasPrefix(p, "/inspectResources"):
+		return model.RbacGroupResource
+	case strings.HasPrefix(p, "/cloudInfo/"):
+		// Registering or unregistering a CSP definition changes TB itself
+		return model.RbacGroupSystem
+	}
+	for _, prefix := range rbacSystemPrefixes {
+		if strings.HasPrefix(p, prefix) {
+			return model.RbacGroupSystem
+		}
+	}
+	seg, _, _ := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+	if slices.Contains(rbacCatalogRoutes, seg) {
+		return model.RbacGroupCatalog
+	}
+	return ""
+}
+
+// rbacDeleteActions are control actions that remove CSP resources
+var rbacDeleteActions = []string{"terminate", "withdraw", "abort"}
+
+// rbacStatefulGetRoutes are routes served by GET that change state
+var rbacStatefulGetRoutes = []string{"/tumblebug/loadAssets"}
+
+// RbacVerbOf maps a request to an RBAC verb. Control routes (/control/...) are
+// served by GET but change state, so their verb comes from the action parameter.
+func RbacVerbOf(route, method, action string) string {
+	if slices.Contains(rbacStatefulGetRoutes, route) {
+		return model.RbacVerbWrite
+	}
+	if strings.Contains(route, "/control/") {
+		if slices.Contains(rbacDeleteActions, action) && !strings.HasSuffix(route, "/rollout") {
+			return model.RbacVerbDelete
+		}
+		return model.RbacVerbWrite
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return model.RbacVerbRead
+	case http.MethodDelete:
+		return model.RbacVerbDelete
+	default:
+		return model.RbacVerbWrite
+	}
+}
+
+// roleAllows reports whether any rule of role grants verb on group.
+func roleAllows(role model.RbacRole, group, verb string) bool {
+	for _, rule := range role.Rules {
+		if (slices.Contains(rule.Groups, model.RbacWildcard) || slices.Contains(rule.Groups, group)) &&
+			(slices.Contains(rule.Verbs, model.RbacWildcard) || slices.Contains(rule.Verbs, verb)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize checks whether subject may perform verb on group.
+// The realm role from the access token applies everywhere; when nsId is given,
+// the subject's role binding in that namespace adds its permissions.
+// It returns an apierr.Forbidden error when access is denied.
+func Authorize(subject, realmRole, nsId, group, verb string) error {
+	if group == "" {
+		return apierr.Forbidden(fmt.Sprintf("permission denied: '%s' cannot %s a route outside the RBAC route groups", subject, verb))
+	}
+	if role, ok := builtInRoles[realmRole]; ok && roleAllows(role, group, verb) {
+		return nil
+	}
+
+	if nsId != "" && subject != "" {
+		binding, exists, err := getRoleBinding(nsId, subject)
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed to read role binding of '%s' in ns '%s'", subject, nsId)
+		} else if exists {
+			role, err := GetRbacRole(binding.Role)
+			if err != nil {
+				log.Warn().Err(err).Msgf("Role binding of '%s' in ns '%s' refers to an unknown role", subject, nsId)
+			} else if roleAllows(role, group, verb) {
+				return nil
+			}
+		}
+	}
+
+	scope := "globally"
+	if nsId != "" {
+		scope = "in namespace '" + nsId + "'"
+	}
+	return apierr.Forbidden(fmt.Sprintf("permission denied: '%s' (role: %s) cannot %s %s %s", subject, realmRole, verb, group, scope))
+}
+
+// validateRbacRules checks that rules only use known groups and verbs.
+func validateRbacRules(rules []model.RbacRule) error {
+	if len(rules) == 0 {
+		return fmt.Errorf("at least one rule is required")
+	}
+	for i, rule := range rules {
+		if len(rule.Groups) == 0 || len(rule.Verbs) == 0 {
+			return fmt.Errorf("rule %d: groups and verbs must not be empty", i)
+		}
+		for _, g := range rule.Groups {
+			if g != model.RbacWildcard && !slices.Contains(rbacAllGroups, g) {
+				return fmt.Errorf("rule %d: unknown group '%s' (available: %s)", i, g, strings.Join(rbacAllGroups, ", "))
+			}
+		}
+		for _, v := range rule.Verbs {
+			if v != model.RbacWildcard && !slices.Contains(rbacAllVerbs, v) {
+				return fmt.Errorf("rule %d: unknown verb '%s' (available: %s)", i, v, strings.Join(rbacAllVerbs, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// CreateOrUpdateRbacRole creates a custom role or replaces an existing one.
+func CreateOrUpdateRbacRole(req *model.RbacRoleReq) (model.RbacRole, error) {
+	if err := CheckString(req.Name); err != nil {
+		return model.RbacRole{}, err
+	}
+	if _, ok := builtInRoles[req.Name]; ok {
+		return model.RbacRole{}, &apierr.StatusError{StatusCode: http.StatusConflict, Message: "built-in role '" + req.Name + "' cannot be modified"}
+	}
+	if err := validateRbacRules(req.Rules); err != nil {
+		return model.RbacRole{}, err
+	}
+
+	role := model.RbacRole{Name: req.Name, Description: req.Description, Rules: req.Rules}
+	val, err := json.Marshal(role)
+	if err != nil {
+		return model.RbacRole{}, err
+	}
+	if err := kvstore.Put(rbacRoleKeyPrefix+role.Name, string(val)); err != nil {
+		log.Error().Err(err).Msg("")
+		return model.RbacRole{}, err
+	}
+	return role, nil
+}
+
+// GetRbacRole returns a built-in or custom role.
+func GetRbacRole(name string) (model.RbacRole, error) {
+	if role, ok := builtInRoles[name]; ok {
+		role.BuiltIn = true
+		return role, nil
+	}
+	val, exists, err := kvstore.Get(rbacRoleKeyPrefix + name)
+	if err != nil {
+		return model.RbacRole{}, err
+	}
+	if !exists {
+		return model.RbacRole{}, fmt.Errorf("role '%s' does not exist", name)
+	}
+	role := model.RbacRole{}
+	if err := json.Unmarshal([]byte(val), &role); err != nil {
+		return model.RbacRole{}, err
+	}
+	return role, nil
+}
+
+// ListRbacRoles returns built-in roles followed by custom roles, sorted by name.
+func ListRbacRoles() ([]model.RbacRole, error) {
+	roles := make([]model.RbacRole, 0, len(builtInRoles))
+	for _, name := range []string{model.RoleMaintainer, model.RoleAdmin, model.RoleUser, model.RoleGuest} {
+		role, _ := GetRbacRole(name)
+		roles = append(roles, role)
+	}
+
+	vals, err := kvstore.GetList(rbacRoleKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	custom := make([]model.RbacRole, 0, len(vals))
+	for _, val := range vals {
+		role := model.RbacRole{}
+		if err := json.Unmarshal([]byte(val), &role); err != nil {
+			log.Warn().Err(err).Msg("Skipping malformed role")
+			continue
+		}
+		custom = append(custom, role)
+	}
+	sort.Slice(custom, func(i, j int) bool { return custom[i].Name < custom[j].Name })
+	return append(roles, custom...), nil
+}
+
+// DeleteRbacRole deletes a custom role that is not referenced by any binding.
+func DeleteRbacRole(name string) error {
+	if _, ok := builtInRoles[name]; ok {
+		return &apierr.StatusError{StatusCode: http.StatusConflict, Message: "built-in role '" + name + "' cannot be deleted"}
+	}
+	if _, err := GetRbacRole(name); err != nil {
+		return err
+	}
+
+	bindings, err := listRoleBindingsWithPrefix(rbacBindingKeyPrefix)
+	if err != nil {
+		return err
+	}
+	for _, b := range bindings {
+		if b.Role == name {
+			return &apierr.StatusError{StatusCode: http.StatusConflict,
+				Message: fmt.Sprintf("role '%s' is still bound to '%s' in namespace '%s'", name, b.Subject, b.NsId)}
+		}
+	}
+	return kvstore.Delete(rbacRoleKeyPrefix + name)
+}
+
+// rbacBindingKey escapes the subject, which comes from the token and may contain any character.
+func rbacBindingKey(nsId, subject string) string {
+	return rbacBindingKeyPrefix + nsId + "/" + url.PathEscape(subject)
+}
+
+func getRoleBinding(nsId, subject string) (model.RoleBinding, bool, error) {
+	val, exists, err := kvstore.Get(rbacBindingKey(nsId, subject))
+	if err != nil || !exists {
+		return model.RoleBinding{}, exists, err
+	}
+	binding := model.RoleBinding{}
+	if err := json.Unmarshal([]byte(val), &binding); err != nil {
+		return model.RoleBinding{}, false, err
+	}
+	return binding, true, nil
+}
+
+// CreateOrUpdateRoleBinding binds a role to a subject in a namespace,
+// replacing the subject's previous binding in that namespace.
+func CreateOrUpdateRoleBinding(nsId string, req *model.RoleBindingReq) (model.RoleBinding, error) {
+	if strings.TrimSpace(req.Subject) == "" {
+		return model.RoleBinding{}, fmt.Errorf("subject is required")
+	}
+	if _, err := GetRbacRole(req.Role); err != nil {
+		return model.RoleBinding{}, err
+	}
+
+	binding := model.RoleBinding{
+		NsId:      nsId,
+		Subject:   req.Subject,
+		Role:      req.Role,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	val, err := json.Marshal(binding)
+	if err != nil {
+		return model.RoleBinding{}, err
+	}
+	if err := kvstore.Put(rbacBindingKey(nsId, req.Subject), string(val)); err != nil {
+		log.Error().Err(err).Msg("")
+		return model.RoleBinding{}, err
+	}
+	log.Info().Msgf("Role '%s' bound to '%s' in namespace '%s'", req.Role, req.Subject, nsId)
+	return binding, nil
+}
+
+// GetRoleBinding returns the role binding of a subject in a namespace.
+func GetRoleBinding(nsId, subject string) (model.RoleBinding, error) {
+	binding, exists, err := getRoleBinding(nsId, subject)
+	if err != nil {
+		return model.RoleBinding{}, err
+	}
+	if !exists {
+		return model.RoleBinding{}, fmt.Errorf("role binding for '%s' does not exist in namespace '%s'", subject, nsId)
+	}
+	return binding, nil
+}
+
+// ListRoleBindings returns the role bindings of a namespace sorted by subject.
+func ListRoleBindings(nsId string) ([]model.RoleBinding, error) {
+	return listRoleBindingsWithPrefix(rbacBindingKeyPrefix + nsId + "/")
+}
+
+func listRoleBindingsWithPrefix(prefix string) ([]model.RoleBinding, error) {
+	vals, err := kvstore.GetList(prefix)
+	if err != nil {
+		return nil, err
+	}
+	bindings := make([]model.RoleBinding, 0, len(vals))
+	for _, val := range vals {
+		binding := model.RoleBinding{}
+		if err := json.Unmarshal([]byte(val), &binding); err != nil {
+			log.Warn().Err(err).Msg("Skipping malformed role binding")
+			continue
+		}
+		bindings = append(bindings, binding)
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		if bindings[i].NsId != bindings[j].NsId {
+			return bindings[i].NsId < bindings[j].NsId
+		}
+		return bindings[i].Subject < bindings[j].Subject
+	})
+	return bindings, nil
+}
+
+// DeleteRoleBinding removes the role binding of a subject in a namespace.
+func DeleteRoleBinding(nsId, subject string) error {
+	if _, err := GetRoleBinding(nsId, subject); err != nil {
+		return err
+	}
+	return kvstore.Delete(rbacBindingKey(nsId, subject))
+}
+
+// DeleteAllRoleBindings removes every role binding of a namespace.
+func DeleteAllRoleBindings(nsId string) error {
+	return kvstore.DeleteWithPrefix(rbacBindingKeyPrefix + nsId + "/")
+}
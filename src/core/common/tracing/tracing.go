@@ -0,0 +1,152 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing sets up OpenTelemetry distributed tracing for CB-Tumblebug.
+//
+// Spans are exported via OTLP/HTTP when TB_OTEL_ENABLED=true. Otherwise the
+// global no-op tracer is kept, so Start/End are cheap and safe to call anywhere,
+// and incoming W3C trace context is still propagated to outbound calls.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/cloud-barista/cb-tumblebug"
+	serviceName         = "cb-tumblebug"
+)
+
+func init() {
+	// Propagate W3C traceparent/tracestate and baggage regardless of whether export is enabled
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Init configures the global tracer provider from environment variables:
+//   - TB_OTEL_ENABLED: "true" to export spans (default: false)
+//   - TB_OTEL_EXPORTER_OTLP_ENDPOINT: OTLP/HTTP collector host:port (default: localhost:4318)
+//   - TB_OTEL_EXPORTER_OTLP_INSECURE: "false" to use TLS (default: true)
+//   - TB_OTEL_SAMPLING_RATIO: fraction of new traces to sample, 0.0-1.0 (default: 1.0)
+//
+// The returned function flushes and stops the exporter; it is a no-op when tracing is disabled.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	if strings.ToLower(os.Getenv("TB_OTEL_ENABLED")) != "true" {
+		log.Info().Msg("OpenTelemetry tracing is disabled (TB_OTEL_ENABLED != true)")
+		return noop, nil
+	}
+
+	endpoint := os.Getenv("TB_OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "localhost:4318"
+	}
+	// Accept a URL form (http://collector:4318) as well as host:port
+	insecure := strings.ToLower(os.Getenv("TB_OTEL_EXPORTER_OTLP_INSECURE")) != "false"
+	if rest, ok := strings.CutPrefix(endpoint, "https://"); ok {
+		endpoint, insecure = rest, false
+	} else if rest, ok := strings.CutPrefix(endpoint, "http://"); ok {
+		endpoint, insecure = rest, true
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	ratio := 1.0
+	if s := os.Getenv("TB_OTEL_SAMPLING_RATIO"); s != "" {
+		r, err := strconv.ParseFloat(s, 64)
+		if err != nil || r < 0 || r > 1 {
+			return noop, fmt.Errorf("invalid TB_OTEL_SAMPLING_RATIO '%s' (must be between 0.0 and 1.0)", s)
+		}
+		ratio = r
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return noop, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Follow the caller's sampling decision; sample new traces by ratio
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	log.Info().Msgf("OpenTelemetry tracing is enabled (OTLP/HTTP endpoint: %s, sampling ratio: %.2f)", endpoint, ratio)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the CB-Tumblebug tracer from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts an internal span as a child of the span in ctx (if any).
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartDetached is Start on a context that keeps the values and trace of ctx but not
+// its cancellation. Use it for work that must complete even when the client of the
+// originating request goes away, e.g., kvstore writes that follow a CSP call.
+func StartDetached(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Start(context.WithoutCancel(ctx), name, attrs...)
+}
+
+// End records err (if any) on the span and ends it.
+// Use as: defer func() { tracing.End(span, err) }()
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject writes the trace context of ctx into outbound HTTP headers (W3C traceparent).
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract returns ctx with the trace context carried by inbound HTTP headers.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
@@ -901,6 +901,15 @@ func unpad(data []byte, blockSize int) ([]byte, error) {
 
 // RegisterCredential is func to register credential and all related connection configs
 func RegisterCredential(req model.CredentialReq) (model.CredentialInfo, error) {
+	return registerCredential(req, false)
+}
+
+// registerCredential registers (or, if rotated, replaces) a credential and its connection configs
+func registerCredential(req model.CredentialReq, rotated bool) (model.CredentialInfo, error) {
+
+	if err := validateExpiresAt(req.ExpiresAt); err != nil {
+		return model.CredentialInfo{}, err
+	}
 
 	mu.Lock()
 	privateKey, exists := privateKeyStore[req.PublicKeyTokenId]
@@ -1035,32 +1044,32 @@ func RegisterCredential(req model.CredentialReq) (model.CredentialInfo, error) {
 	}
 	//PrintJsonPretty(callResult)
 
-	// Register credentials in OpenBao for runtime CSP access (non-fatal: warn and
-	// continue if unavailable). The outcome is reported in the response via
-	// OpenBaoStatus so init tooling can surface silent failures to the user —
-	// without OpenBao, direct CSP API features cannot access this credential.
-	if model.VaultToken == "" {
-		callResult.OpenBaoStatus = "skipped: VAULT_TOKEN is not set in the cb-tumblebug environment; credential NOT stored in OpenBao"
-		log.Warn().Msgf("OpenBao registration skipped (VAULT_TOKEN not set): provider=%s holder=%s", req.ProviderName, req.CredentialHolder)
+	// Register credentials in the secret store (OpenBao by default) for runtime CSP
+	// access (non-fatal: warn and continue if unavailable). The outcome is reported
+	// in the response via OpenBaoStatus so init tooling can surface silent failures
+	// to the user — without it, direct CSP API features cannot access this credential.
+	if reason := csp.SecretStoreUnconfiguredReason(); reason != "" {
+		callResult.OpenBaoStatus = fmt.Sprintf("skipped: %s; credential NOT stored in %s", reason, csp.SecretStoreBackend())
+		log.Warn().Msgf("Secret store registration skipped (%s): provider=%s holder=%s", reason, req.ProviderName, req.CredentialHolder)
 	} else {
-		// Bound the OpenBao calls so a slow/unreachable OpenBao cannot stall
+		// Bound the secret store calls so a slow/unreachable backend cannot stall
 		// credential registration for long (write + placeholder sweep).
-		openBaoCtx, openBaoCancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer openBaoCancel()
+		secretCtx, secretCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer secretCancel()
 
 		secretPath := csp.BuildSecretPathForHolder(req.CredentialHolder, req.ProviderName)
 		secretData := csp.ApplyCredentialKeyMap(req.ProviderName, decryptedKeyValueList)
-		if err := csp.WriteOpenBaoSecret(openBaoCtx, secretPath, secretData); err != nil {
-			callResult.OpenBaoStatus = fmt.Sprintf("failed: %v; credential NOT stored in OpenBao", err)
-			log.Warn().Err(err).Msgf("Failed to register credential in OpenBao (non-fatal): provider=%s holder=%s", req.ProviderName, req.CredentialHolder)
+		if err := csp.WriteSecret(secretCtx, secretPath, secretData); err != nil {
+			callResult.OpenBaoStatus = fmt.Sprintf("failed: %v; credential NOT stored in %s", err, csp.SecretStoreBackend())
+			log.Warn().Err(err).Msgf("Failed to register credential in %s (non-fatal): provider=%s holder=%s", csp.SecretStoreBackend(), req.ProviderName, req.CredentialHolder)
 		} else {
 			callResult.OpenBaoStatus = "registered at " + secretPath
-			log.Info().Msgf("Registered credential in OpenBao: path=%s", secretPath)
+			log.Info().Msgf("Registered credential in %s: path=%s", csp.SecretStoreBackend(), secretPath)
 		}
 		// Ensure every known CSP has at least a placeholder secret so consumers
 		// that read all CSP paths (e.g. mc-terrarium's tofu plan) don't hard-fail
-		// on providers without credentials. CAS-protected: never overwrites.
-		csp.EnsurePlaceholderCredentialSecrets(openBaoCtx)
+		// on providers without credentials. Write-if-absent: never overwrites.
+		csp.EnsurePlaceholderCredentialSecrets(secretCtx)
 	}
 
 	callResult.CredentialHolder = req.CredentialHolder
@@ -1276,6 +1285,11 @@ func RegisterCredential(req model.CredentialReq) (model.CredentialInfo, error) {
 		return callResult, err
 	}
 
+	conns := providerConnections(callResult.AllConnections.Connectionconfig, req.ProviderName)
+	if _, err := recordCredentialRegistration(req.CredentialHolder, req.ProviderName, req.ExpiresAt, conns, rotated); err != nil {
+		log.Warn().Err(err).Msgf("Failed to record credential status: provider=%s holder=%s", req.ProviderName, req.CredentialHolder)
+	}
+
 	return callResult, nil
 }
 
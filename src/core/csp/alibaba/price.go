@@ -327,7 +327,7 @@ func listInstanceTypes(client *ecs.Client, region string) ([]string, error) {
 
 func getAlibabaCreds(ctx context.Context) (accessKeyID, accessKeySecret string, err error) {
 	path := csp.BuildSecretPath(ctx, "alibaba")
-	data, err := csp.ReadOpenBaoSecret(ctx, path)
+	data, err := csp.ReadSecret(ctx, path)
 	if err != nil {
 		return "", "", err
 	}
@@ -0,0 +1,53 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/cloud-barista/cb-tumblebug/src/core/csp"
+	csptypes "github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	csp.RegisterVMResizeHandler(csptypes.AWS, csp.VMResizeHandler{
+		Resize:       ResizeInstance,
+		RequiresStop: true,
+	})
+}
+
+// ResizeInstance changes the instance type of a stopped EC2 instance.
+// EC2 rejects ModifyInstanceAttribute(InstanceType) on a running instance,
+// so the caller must stop the instance first (RequiresStop).
+func ResizeInstance(ctx context.Context, region, zone, instanceId, instanceType string) error {
+	client, err := newEC2Client(ctx, region)
+	if err != nil {
+		return err
+	}
+	_, err = client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+		InstanceId:   awssdk.String(instanceId),
+		InstanceType: &ec2types.AttributeValue{Value: awssdk.String(instanceType)},
+	})
+	if err != nil {
+		return fmt.Errorf("ModifyInstanceAttribute failed (region=%s, instance=%s, type=%s): %w", region, instanceId, instanceType, err)
+	}
+	log.Debug().Str("region", region).Str("instance", instanceId).Str("type", instanceType).
+		Msg("[AWS] ResizeInstance completed")
+	return nil
+}
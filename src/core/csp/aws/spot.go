@@ -0,0 +1,198 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/cloud-barista/cb-tumblebug/src/core/csp"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	csptypes "github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	csp.RegisterSpotLauncher(csptypes.AWS, LaunchSpotInstance)
+}
+
+// spotProductDescription limits spot price history to Linux instances, which is
+// what TB-provisioned images overwhelmingly are and what on-demand prices assume.
+const spotProductDescription = "Linux/UNIX"
+
+// LaunchSpotInstance launches a single one-time spot instance via RunInstances with
+// InstanceMarketOptions. CB-Spider has no spot purchase option, so TB launches the
+// instance directly and then registers it through Spider /regvm.
+func LaunchSpotInstance(ctx context.Context, req csp.SpotLaunchReq) (string, error) {
+	client, err := newEC2Client(ctx, req.Region)
+	if err != nil {
+		return "", err
+	}
+
+	spotOpts := &ec2types.SpotMarketOptions{
+		SpotInstanceType:             ec2types.SpotInstanceTypeOneTime,
+		InstanceInterruptionBehavior: ec2types.InstanceInterruptionBehaviorTerminate,
+	}
+	if req.MaxPrice > 0 {
+		spotOpts.MaxPrice = aws.String(strconv.FormatFloat(req.MaxPrice, 'f', -1, 64))
+	}
+
+	input := &ec2.RunInstancesInput{
+		ImageId:      aws.String(req.ImageId),
+		InstanceType: ec2types.InstanceType(req.InstanceType),
+		MinCount:     aws.Int32(1),
+		MaxCount:     aws.Int32(1),
+		SubnetId:     aws.String(req.SubnetId),
+		InstanceMarketOptions: &ec2types.InstanceMarketOptionsRequest{
+			MarketType:  ec2types.MarketTypeSpot,
+			SpotOptions: spotOpts,
+		},
+		TagSpecifications: []ec2types.TagSpecification{{
+			ResourceType: ec2types.ResourceTypeInstance,
+			Tags:         []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String(req.Name)}},
+		}},
+	}
+	if len(req.SecurityGroupIds) > 0 {
+		input.SecurityGroupIds = req.SecurityGroupIds
+	}
+	if req.KeyPairName != "" {
+		input.KeyName = aws.String(req.KeyPairName)
+	}
+	if req.Zone != "" {
+		input.Placement = &ec2types.Placement{AvailabilityZone: aws.String(req.Zone)}
+	}
+
+	if req.RootDiskSize > 0 || isEBSVolumeType(req.RootDiskType) {
+		// A block device mapping needs the image's root device name.
+		rootDevice, err := imageRootDeviceName(ctx, client, req.ImageId)
+		if err != nil {
+			return "", err
+		}
+		ebs := &ec2types.EbsBlockDevice{DeleteOnTermination: aws.Bool(true)}
+		if req.RootDiskSize > 0 {
+			ebs.VolumeSize = aws.Int32(int32(req.RootDiskSize))
+		}
+		if isEBSVolumeType(req.RootDiskType) {
+			ebs.VolumeType = ec2types.VolumeType(strings.ToLower(req.RootDiskType))
+		}
+		input.BlockDeviceMappings = []ec2types.BlockDeviceMapping{{DeviceName: aws.String(rootDevice), Ebs: ebs}}
+	}
+
+	out, err := client.RunInstances(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("RunInstances (spot) failed (region=%s, type=%s): %w", req.Region, req.InstanceType, err)
+	}
+	if len(out.Instances) == 0 || out.Instances[0].InstanceId == nil {
+		return "", fmt.Errorf("RunInstances (spot) returned no instance (region=%s, type=%s)", req.Region, req.InstanceType)
+	}
+
+	instanceId := *out.Instances[0].InstanceId
+	log.Info().
+		Str("region", req.Region).
+		Str("instanceType", req.InstanceType).
+		Str("instanceId", instanceId).
+		Float64("maxPrice", req.MaxPrice).
+		Msg("[AWS] Spot instance launched")
+	return instanceId, nil
+}
+
+// isEBSVolumeType reports whether t is an EBS volume type accepted by RunInstances.
+// "default" and empty values leave the image's own root volume type in place.
+func isEBSVolumeType(t string) bool {
+	switch strings.ToLower(t) {
+	case "gp2", "gp3", "io1", "io2", "st1", "sc1", "standard":
+		return true
+	}
+	return false
+}
+
+// imageRootDeviceName looks up the root device name (e.g., /dev/xvda) of an AMI.
+func imageRootDeviceName(ctx context.Context, client *ec2.Client, imageId string) (string, error) {
+	out, err := client.DescribeImages(ctx, &ec2.DescribeImagesInput{ImageIds: []string{imageId}})
+	if err != nil {
+		return "", fmt.Errorf("DescribeImages failed (image=%s): %w", imageId, err)
+	}
+	if len(out.Images) == 0 || out.Images[0].RootDeviceName == nil {
+		return "", fmt.Errorf("image %s not found or has no root device", imageId)
+	}
+	return *out.Images[0].RootDeviceName, nil
+}
+
+// FetchSpotPricesByRegion fetches current EC2 spot prices for a region.
+//
+// DescribeSpotPriceHistory with StartTime=now returns only the latest price per
+// instance type and availability zone; the lowest price across zones is kept per
+// instance type, mirroring how on-demand prices are stored per region.
+func FetchSpotPricesByRegion(ctx context.Context, region string) (model.SpiderCloudPrice, error) {
+	client, err := newEC2Client(ctx, region)
+	if err != nil {
+		return model.SpiderCloudPrice{}, err
+	}
+
+	best := map[string]float64{}
+	paginator := ec2.NewDescribeSpotPriceHistoryPaginator(client, &ec2.DescribeSpotPriceHistoryInput{
+		StartTime:           aws.Time(time.Now()),
+		ProductDescriptions: []string{spotProductDescription},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return model.SpiderCloudPrice{}, fmt.Errorf("DescribeSpotPriceHistory failed (region=%s): %w", region, err)
+		}
+		for _, sp := range page.SpotPriceHistory {
+			if sp.SpotPrice == nil || sp.InstanceType == "" {
+				continue
+			}
+			price, err := strconv.ParseFloat(*sp.SpotPrice, 64)
+			if err != nil || price <= 0 {
+				continue
+			}
+			name := string(sp.InstanceType)
+			if prev, ok := best[name]; !ok || price < prev {
+				best[name] = price
+			}
+		}
+	}
+
+	specNames := make([]string, 0, len(best))
+	for name := range best {
+		specNames = append(specNames, name)
+	}
+	sort.Strings(specNames)
+
+	priceList := make([]model.SpiderPrice, 0, len(specNames))
+	for _, name := range specNames {
+		priceList = append(priceList, model.SpiderPrice{
+			ProductInfo: model.SpiderProductInfo{VMSpecName: name},
+			PriceInfo: model.SpiderPriceInfo{
+				OnDemand: model.SpiderOnDemand{
+					PricingId: "spot",
+					Unit:      "Hour",
+					Currency:  "USD",
+					Price:     strconv.FormatFloat(best[name], 'f', -1, 64),
+				},
+			},
+		})
+	}
+
+	log.Debug().Msgf("AWS spot pricing: region=%s priced=%d", region, len(priceList))
+	return model.SpiderCloudPrice{PriceList: priceList}, nil
+}
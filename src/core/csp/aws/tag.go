@@ -111,10 +111,10 @@ func resolveKeyPairId(ctx context.Context, client *ec2.Client, keyName string) (
 	return *out.KeyPairs[0].KeyPairId, nil
 }
 
-// getAWSCreds retrieves AWS credentials from OpenBao.
+// getAWSCreds retrieves AWS credentials from the secret store.
 func getAWSCreds(ctx context.Context) (accessKey, secretKey string, err error) {
 	path := csp.BuildSecretPath(ctx, "aws")
-	data, err := csp.ReadOpenBaoSecret(ctx, path)
+	data, err := csp.ReadSecret(ctx, path)
 	if err != nil {
 		return "", "", err
 	}
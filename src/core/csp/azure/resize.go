@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	armcompute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/cloud-barista/cb-tumblebug/src/core/csp"
+	csptypes "github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	csp.RegisterVMResizeHandler(csptypes.Azure, csp.VMResizeHandler{
+		Resize: ResizeInstance,
+		// Azure restarts a running VM by itself when its size changes. Stopping it
+		// first would only add a power cycle, and deallocating it would release a
+		// dynamic public IP.
+		RequiresStop: false,
+	})
+}
+
+// ResizeInstance changes the VM size of an Azure VM and waits until the update
+// (including the restart Azure performs on a running VM) is finished.
+// instanceId is the full ARM resource ID of the VM.
+func ResizeInstance(ctx context.Context, region, zone, instanceId, instanceType string) error {
+	parts, err := parseAzureArmID(instanceId)
+	if err != nil {
+		return err
+	}
+	creds, err := getCreds(ctx)
+	if err != nil {
+		return fmt.Errorf("Azure resize: cannot get credentials: %w", err)
+	}
+	vmClient, err := newVMClient(creds)
+	if err != nil {
+		return fmt.Errorf("Azure resize: failed to get VM client: %w", err)
+	}
+
+	vmSize := armcompute.VirtualMachineSizeTypes(instanceType)
+	poller, err := vmClient.BeginUpdate(ctx, parts.resourceGroup, parts.vmName, armcompute.VirtualMachineUpdate{
+		Properties: &armcompute.VirtualMachineProperties{
+			HardwareProfile: &armcompute.HardwareProfile{VMSize: &vmSize},
+		},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("Azure VM update failed (vm=%s, size=%s): %w", parts.vmName, instanceType, err)
+	}
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return fmt.Errorf("Azure VM update did not complete (vm=%s, size=%s): %w", parts.vmName, instanceType, err)
+	}
+	log.Debug().Str("region", region).Str("vm", parts.vmName).Str("size", instanceType).
+		Msg("[Azure] ResizeInstance completed")
+	return nil
+}
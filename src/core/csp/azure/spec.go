@@ -29,7 +29,7 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-// azureCreds holds Azure service principal credentials fetched from OpenBao.
+// azureCreds holds Azure service principal credentials fetched from the secret store.
 type azureCreds struct {
 	ClientID       string
 	ClientSecret   string
@@ -340,10 +340,10 @@ func fetchQuotas(ctx context.Context, creds *azureCreds, cred *azidentity.Client
 	return usages, nil
 }
 
-// getCreds fetches Azure credentials from OpenBao based on the credential holder in context.
+// getCreds fetches Azure credentials from the secret store based on the credential holder in context.
 func getCreds(ctx context.Context) (*azureCreds, error) {
 	path := csp.BuildSecretPath(ctx, "azure")
-	data, err := csp.ReadOpenBaoSecret(ctx, path)
+	data, err := csp.ReadSecret(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -354,7 +354,7 @@ func getCreds(ctx context.Context) (*azureCreds, error) {
 	subscriptionID := csp.GetString(data, "ARM_SUBSCRIPTION_ID")
 
 	if clientID == "" || clientSecret == "" || tenantID == "" || subscriptionID == "" {
-		return nil, fmt.Errorf("Azure credentials incomplete in OpenBao (need ARM_CLIENT_ID, ARM_CLIENT_SECRET, ARM_TENANT_ID, ARM_SUBSCRIPTION_ID)")
+		return nil, fmt.Errorf("Azure credentials incomplete in the secret store (need ARM_CLIENT_ID, ARM_CLIENT_SECRET, ARM_TENANT_ID, ARM_SUBSCRIPTION_ID)")
 	}
 
 	return &azureCreds{
@@ -25,6 +25,7 @@ import (
 
 func init() {
 	csp.RegisterBatchTagHandler(csptypes.Azure, BatchUpsertTags)
+	csp.RegisterClientCache(csptypes.Azure, &credentialCache)
 }
 
 // BatchUpsertTags merges multiple tags onto an Azure ARM resource in a single PATCH call.
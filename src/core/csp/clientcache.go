@@ -53,3 +53,36 @@ func StoreClient(cache *sync.Map, account, credKey string, client any) any {
 	})
 	return actual
 }
+
+// clientCaches are the client caches of each CSP package, so that a rotated
+// credential can drop the clients built from the old one right away.
+var (
+	clientCachesMu sync.Mutex
+	clientCaches   = map[string][]*sync.Map{}
+)
+
+// RegisterClientCache registers a client cache of a CSP package for invalidation.
+// Each CSP package calls this from its init() function.
+func RegisterClientCache(provider string, cache *sync.Map) {
+	clientCachesMu.Lock()
+	defer clientCachesMu.Unlock()
+	provider = strings.ToLower(provider)
+	clientCaches[provider] = append(clientCaches[provider], cache)
+}
+
+// InvalidateClientCache drops every cached client of a provider and returns how many were dropped.
+func InvalidateClientCache(provider string) int {
+	clientCachesMu.Lock()
+	caches := clientCaches[strings.ToLower(provider)]
+	clientCachesMu.Unlock()
+
+	dropped := 0
+	for _, cache := range caches {
+		cache.Range(func(k, _ any) bool {
+			cache.Delete(k)
+			dropped++
+			return true
+		})
+	}
+	return dropped
+}
@@ -119,6 +119,58 @@ func GetBatchVMControlHandler(provider, action string) (BatchVMControlFunc, bool
 	}
 }
 
+// VMResizeFunc changes the instance type of a single VM through the CSP SDK.
+// ctx must carry model.CtxKeyCredentialHolder for credential lookup.
+// region is the CSP-native region identifier and zone is the VM's zone
+// (required by zone-scoped CSPs such as GCP). instanceId is the CspResourceId
+// of the VM and instanceType is the CSP-native spec name (e.g., "t3.large").
+// It returns when the CSP has applied the new instance type.
+type VMResizeFunc func(ctx context.Context, region, zone, instanceId, instanceType string) error
+
+// VMResizeHandler is the vertical-resize capability of a CSP.
+type VMResizeHandler struct {
+	Resize VMResizeFunc
+	// RequiresStop is true when the VM must be stopped before Resize is called
+	// (e.g., AWS ModifyInstanceAttribute, GCP setMachineType). When false, the
+	// CSP restarts the VM by itself as part of the resize (e.g., Azure).
+	RequiresStop bool
+}
+
+var (
+	vmResizeMu       sync.RWMutex
+	vmResizeHandlers = make(map[string]VMResizeHandler)
+)
+
+// RegisterVMResizeHandler registers the vertical-resize function for a CSP.
+// Each CSP package calls this from its init() function.
+func RegisterVMResizeHandler(provider string, h VMResizeHandler) {
+	vmResizeMu.Lock()
+	defer vmResizeMu.Unlock()
+	vmResizeHandlers[strings.ToLower(provider)] = h
+}
+
+// GetVMResizeHandler returns the registered VMResizeHandler for the given provider.
+func GetVMResizeHandler(provider string) (VMResizeHandler, bool) {
+	vmResizeMu.RLock()
+	defer vmResizeMu.RUnlock()
+	h, ok := vmResizeHandlers[strings.ToLower(provider)]
+	return h, ok && h.Resize != nil
+}
+
+// ListVMResizeProviders returns the providers that support vertical resize, sorted by name.
+func ListVMResizeProviders() []string {
+	vmResizeMu.RLock()
+	defer vmResizeMu.RUnlock()
+	providers := make([]string, 0, len(vmResizeHandlers))
+	for p, h := range vmResizeHandlers {
+		if h.Resize != nil {
+			providers = append(providers, p)
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
+
 // credentialKeyMap maps each CSP's YAML credential keys to the environment variable
 // names expected by OpenTofu providers and cb-tumblebug's runtime credential lookup.
 // Must stay in sync with init/openbao/openbao-register-creds.py KEY_MAP.
@@ -278,7 +330,8 @@ func ValidateCredentialKeys(provider string, keys []string) error {
 	return nil
 }
 
-// BuildSecretPathForHolder builds the OpenBao secret path using holder and provider directly.
+// BuildSecretPathForHolder builds the secret path using holder and provider directly.
+// The path follows the OpenBao KV v2 layout and is used as the key by every secret store.
 // Both holder and provider are lowercased to stay consistent with BuildSecretPath.
 func BuildSecretPathForHolder(holder, provider string) string {
 	holder = strings.ToLower(holder)
@@ -371,14 +424,14 @@ var (
 	placeholderSweepBusy atomic.Bool
 )
 
-// EnsurePlaceholderCredentialSecrets writes an all-empty placeholder secret for every
-// known CSP that has no secret yet under the default credential holder path.
+// EnsurePlaceholderCredentialSecrets writes an all-empty placeholder secret to the active
+// secret store for every known CSP that has no secret yet under the default credential holder path.
 // This keeps consumers that read all CSP secret paths (e.g. mc-terrarium's
 // vault_kv_secret_v2 data sources during `tofu plan`) from hard-failing on CSPs whose
 // credentials were never provided — they fail gracefully at auth time instead.
-// Existing secrets are never touched (CAS-protected), so real credentials always win.
+// Existing secrets are never touched (write-if-absent), so real credentials always win.
 func EnsurePlaceholderCredentialSecrets(ctx context.Context) {
-	if placeholderSweepDone.Load() || model.VaultToken == "" || model.VaultAddr == "" {
+	if placeholderSweepDone.Load() || SecretStoreUnconfiguredReason() != "" {
 		return
 	}
 	if !placeholderSweepBusy.CompareAndSwap(false, true) {
@@ -392,14 +445,14 @@ func EnsurePlaceholderCredentialSecrets(ctx context.Context) {
 			placeholder[terrariumKey] = ""
 		}
 		path := BuildSecretPathForHolder(model.DefaultCredentialHolder, provider)
-		created, err := WriteOpenBaoSecretIfAbsent(ctx, path, placeholder)
+		created, err := WriteSecretIfAbsent(ctx, path, placeholder)
 		if err != nil {
 			allOK = false
-			log.Warn().Err(err).Str("provider", provider).Msg("[CSP] failed to ensure placeholder secret in OpenBao")
+			log.Warn().Err(err).Str("provider", provider).Msg("[CSP] failed to ensure placeholder secret in secret store")
 			continue
 		}
 		if created {
-			log.Info().Msgf("[CSP] placeholder secret registered in OpenBao: %s (%d empty keys)", path, len(placeholder))
+			log.Info().Msgf("[CSP] placeholder secret registered in %s: %s (%d empty keys)", SecretStoreBackend(), path, len(placeholder))
 		}
 	}
 	if allOK {
@@ -496,7 +549,7 @@ func CheckOpenBaoStatus(ctx context.Context) model.OpenBaoStatusInfo {
 	return status
 }
 
-// BuildSecretPath builds the OpenBao secret path for a given CSP provider
+// BuildSecretPath builds the secret path for a given CSP provider
 // based on the credential holder from context.
 // For "admin" holder: "secret/data/csp/{provider}"
 // For other holders:  "secret/data/users/{holder}/csp/{provider}"
@@ -0,0 +1,74 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/csp"
+	csptypes "github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/compute/v1"
+)
+
+func init() {
+	csp.RegisterVMResizeHandler(csptypes.GCP, csp.VMResizeHandler{
+		Resize:       ResizeInstance,
+		RequiresStop: true,
+	})
+}
+
+// gcpResizeWaitRounds bounds ZoneOperations.Wait calls; each call blocks for up to
+// about two minutes, so the operation gets well over the time setMachineType needs.
+const gcpResizeWaitRounds = 5
+
+// ResizeInstance changes the machine type of a stopped (TERMINATED) GCP instance
+// and waits for the zone operation to finish. instanceId is the instance name.
+func ResizeInstance(ctx context.Context, region, zone, instanceId, instanceType string) error {
+	if zone == "" {
+		return fmt.Errorf("GCP resize: zone of instance %s is unknown", instanceId)
+	}
+	creds, err := getGCPCreds(ctx)
+	if err != nil {
+		return fmt.Errorf("GCP resize: cannot get credentials: %w", err)
+	}
+	svc, err := newComputeService(ctx, creds)
+	if err != nil {
+		return fmt.Errorf("GCP resize: cannot create compute service: %w", err)
+	}
+
+	req := &compute.InstancesSetMachineTypeRequest{
+		MachineType: fmt.Sprintf("zones/%s/machineTypes/%s", zone, instanceType),
+	}
+	op, err := svc.Instances.SetMachineType(creds.ProjectID, zone, instanceId, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("GCP Instances.SetMachineType failed for %s: %w", instanceId, err)
+	}
+	for i := 0; i < gcpResizeWaitRounds && op.Status != "DONE"; i++ {
+		op, err = svc.ZoneOperations.Wait(creds.ProjectID, zone, op.Name).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("GCP Instances.SetMachineType wait failed for %s: %w", instanceId, err)
+		}
+	}
+	if op.Status != "DONE" {
+		return fmt.Errorf("GCP Instances.SetMachineType for %s did not finish (operation %s is %s)", instanceId, op.Name, op.Status)
+	}
+	if op.Error != nil && len(op.Error.Errors) > 0 {
+		return fmt.Errorf("GCP Instances.SetMachineType operation error for %s: %s", instanceId, op.Error.Errors[0].Message)
+	}
+	log.Debug().Str("zone", zone).Str("instance", instanceId).Str("type", instanceType).
+		Msg("[GCP] ResizeInstance completed")
+	return nil
+}
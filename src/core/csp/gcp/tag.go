@@ -53,6 +53,7 @@ var gcpInvalidChars = regexp.MustCompile(`[^a-z0-9_-]`)
 
 func init() {
 	csp.RegisterBatchTagHandler(csptypes.GCP, BatchUpsertTags)
+	csp.RegisterClientCache(csptypes.GCP, &computeServiceCache)
 }
 
 // sanitizeGCPKey converts a label key to GCP-compatible format.
@@ -280,17 +281,17 @@ func upsertDiskLabels(svc *compute.Service, projectID, zone, diskName string, la
 	return nil
 }
 
-// gcpCreds holds GCP service account credentials from OpenBao.
+// gcpCreds holds GCP service account credentials from the secret store.
 type gcpCreds struct {
 	ClientEmail string
 	PrivateKey  string
 	ProjectID   string
 }
 
-// getGCPCreds retrieves GCP credentials from OpenBao.
+// getGCPCreds retrieves GCP credentials from the secret store.
 func getGCPCreds(ctx context.Context) (*gcpCreds, error) {
 	path := csp.BuildSecretPath(ctx, "gcp")
-	data, err := csp.ReadOpenBaoSecret(ctx, path)
+	data, err := csp.ReadSecret(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -324,7 +325,7 @@ func newComputeService(ctx context.Context, creds *gcpCreds) (*compute.Service,
 		return v.(*compute.Service), nil
 	}
 
-	// OpenBao stores the private key with literal "\n" (two chars: backslash + n).
+	// The secret store may hold the private key with literal "\n" (two chars: backslash + n).
 	// Convert to actual newlines for PEM parsing.
 	privateKey := strings.ReplaceAll(creds.PrivateKey, `\n`, "\n")
 
@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+)
+
+// SecretStoreOpenBao and SecretStoreFile are the built-in secret store backends
+// selectable by TB_SECRET_STORE.
+const (
+	SecretStoreOpenBao = "openbao"
+	SecretStoreFile    = "file"
+)
+
+// SecretStore keeps CSP credentials for direct CSP API calls. Secrets are maps of
+// credential keys (see ApplyCredentialKeyMap) addressed by the paths built by
+// BuildSecretPath / BuildSecretPathForHolder, whatever the backend.
+type SecretStore interface {
+	// Configured returns an empty reason if the backend has the settings it needs.
+	Configured() (reason string)
+	// Read returns the latest version of the secret at path.
+	Read(ctx context.Context, path string) (map[string]any, error)
+	// Write stores data as a new version of the secret at path (upsert).
+	Write(ctx context.Context, path string, data map[string]any) error
+	// WriteIfAbsent stores data only if no secret exists at path yet.
+	// It returns created=false with a nil error when the secret already exists.
+	WriteIfAbsent(ctx context.Context, path string, data map[string]any) (created bool, err error)
+	// Status checks that secrets can actually be stored and read.
+	Status(ctx context.Context) model.SecretStoreStatusInfo
+}
+
+var (
+	secretStoreMu sync.RWMutex
+	secretStores  = make(map[string]SecretStore)
+)
+
+// RegisterSecretStore registers a secret store backend under the name used in TB_SECRET_STORE.
+func RegisterSecretStore(name string, store SecretStore) {
+	secretStoreMu.Lock()
+	defer secretStoreMu.Unlock()
+	secretStores[strings.ToLower(name)] = store
+}
+
+// SecretStoreBackend returns the configured secret store backend name (OpenBao by default).
+func SecretStoreBackend() string {
+	if model.SecretStoreBackend == "" {
+		return SecretStoreOpenBao
+	}
+	return strings.ToLower(model.SecretStoreBackend)
+}
+
+// ActiveSecretStore returns the secret store selected by TB_SECRET_STORE.
+func ActiveSecretStore() (SecretStore, error) {
+	secretStoreMu.RLock()
+	defer secretStoreMu.RUnlock()
+	store, ok := secretStores[SecretStoreBackend()]
+	if !ok {
+		names := make([]string, 0, len(secretStores))
+		for name := range secretStores {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown secret store '%s' (TB_SECRET_STORE); available: %v", SecretStoreBackend(), names)
+	}
+	return store, nil
+}
+
+// SecretStoreUnconfiguredReason returns why the active secret store cannot be used,
+// or an empty string if it is configured.
+func SecretStoreUnconfiguredReason() string {
+	store, err := ActiveSecretStore()
+	if err != nil {
+		return err.Error()
+	}
+	return store.Configured()
+}
+
+// ReadSecret reads a secret from the active secret store.
+func ReadSecret(ctx context.Context, path string) (map[string]any, error) {
+	store, err := ActiveSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Read(ctx, path)
+}
+
+// WriteSecret writes a secret to the active secret store as a new version (upsert).
+func WriteSecret(ctx context.Context, path string, data map[string]any) error {
+	store, err := ActiveSecretStore()
+	if err != nil {
+		return err
+	}
+	return store.Write(ctx, path, data)
+}
+
+// WriteSecretIfAbsent writes a secret to the active secret store only if none exists at path.
+func WriteSecretIfAbsent(ctx context.Context, path string, data map[string]any) (bool, error) {
+	store, err := ActiveSecretStore()
+	if err != nil {
+		return false, err
+	}
+	return store.WriteIfAbsent(ctx, path, data)
+}
+
+// CheckSecretStoreStatus reports whether the active secret store is usable.
+func CheckSecretStoreStatus(ctx context.Context) model.SecretStoreStatusInfo {
+	store, err := ActiveSecretStore()
+	if err != nil {
+		return model.SecretStoreStatusInfo{Backend: SecretStoreBackend(), Message: err.Error()}
+	}
+	return store.Status(ctx)
+}
+
+// openBaoSecretStore is the OpenBao KV v2 backend (the default).
+type openBaoSecretStore struct{}
+
+func init() {
+	RegisterSecretStore(SecretStoreOpenBao, openBaoSecretStore{})
+}
+
+func (openBaoSecretStore) Configured() string {
+	if model.VaultAddr == "" {
+		return "VAULT_ADDR is not set in the cb-tumblebug environment"
+	}
+	if model.VaultToken == "" {
+		return "VAULT_TOKEN is not set in the cb-tumblebug environment"
+	}
+	return ""
+}
+
+func (openBaoSecretStore) Read(ctx context.Context, path string) (map[string]any, error) {
+	return ReadOpenBaoSecret(ctx, path)
+}
+
+func (openBaoSecretStore) Write(ctx context.Context, path string, data map[string]any) error {
+	return WriteOpenBaoSecret(ctx, path, data)
+}
+
+func (openBaoSecretStore) WriteIfAbsent(ctx context.Context, path string, data map[string]any) (bool, error) {
+	return WriteOpenBaoSecretIfAbsent(ctx, path, data)
+}
+
+func (openBaoSecretStore) Status(ctx context.Context) model.SecretStoreStatusInfo {
+	status := CheckOpenBaoStatus(ctx)
+	return model.SecretStoreStatusInfo{
+		Backend:   SecretStoreOpenBao,
+		Available: status.Available,
+		Message:   status.Message,
+		OpenBao:   &status,
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+)
+
+// fileSecretStore keeps secrets in a local file encrypted with AES-256-GCM.
+// The key is derived from TB_SECRET_STORE_FILE_KEY with scrypt and a random
+// salt stored in the file; every write re-encrypts the whole file with a new nonce.
+type fileSecretStore struct {
+	mu sync.Mutex
+	// derived key cache, valid for (passphrase, salt)
+	cachedPassphrase string
+	cachedSalt       []byte
+	cachedKey        []byte
+}
+
+// encryptedSecretFile is the on-disk format of the file secret store.
+type encryptedSecretFile struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// fileSecret is one secret in the decrypted file content.
+type fileSecret struct {
+	Version   int            `json:"version"`
+	UpdatedAt string         `json:"updatedAt"`
+	Data      map[string]any `json:"data"`
+}
+
+func init() {
+	RegisterSecretStore(SecretStoreFile, &fileSecretStore{})
+}
+
+func (s *fileSecretStore) Configured() string {
+	if model.SecretStoreFilePath == "" {
+		return "TB_SECRET_STORE_FILE_PATH is not set in the cb-tumblebug environment"
+	}
+	if model.SecretStoreFileKey == "" {
+		return "TB_SECRET_STORE_FILE_KEY is not set in the cb-tumblebug environment"
+	}
+	return ""
+}
+
+func (s *fileSecretStore) key(salt []byte) ([]byte, error) {
+	if s.cachedKey != nil && s.cachedPassphrase == model.SecretStoreFileKey && string(s.cachedSalt) == string(salt) {
+		return s.cachedKey, nil
+	}
+	key, err := scrypt.Key([]byte(model.SecretStoreFileKey), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	s.cachedPassphrase, s.cachedSalt, s.cachedKey = model.SecretStoreFileKey, salt, key
+	return key, nil
+}
+
+// load decrypts the secret file. A missing file is an empty store.
+func (s *fileSecretStore) load() (map[string]fileSecret, []byte, error) {
+	if reason := s.Configured(); reason != "" {
+		return nil, nil, errors.New(reason)
+	}
+	raw, err := os.ReadFile(model.SecretStoreFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]fileSecret{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read secret file %s: %w", model.SecretStoreFilePath, err)
+	}
+	file := encryptedSecretFile{}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, nil, fmt.Errorf("invalid secret file %s: %w", model.SecretStoreFilePath, err)
+	}
+	key, err := s.key(file.Salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt secret file %s (wrong TB_SECRET_STORE_FILE_KEY?)", model.SecretStoreFilePath)
+	}
+	secrets := map[string]fileSecret{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, nil, fmt.Errorf("invalid secret file content %s: %w", model.SecretStoreFilePath, err)
+	}
+	return secrets, file.Salt, nil
+}
+
+// save encrypts and atomically replaces the secret file.
+func (s *fileSecretStore) save(secrets map[string]fileSecret, salt []byte) error {
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+	}
+	key, err := s.key(salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(encryptedSecretFile{Salt: salt, Nonce: nonce, Ciphertext: gcm.Seal(nil, nonce, plain, nil)})
+	if err != nil {
+		return err
+	}
+
+	path := model.SecretStoreFilePath
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create secret file directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write secret file %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secret file %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write secret file %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write secret file %s: %w", path, err)
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *fileSecretStore) Read(ctx context.Context, path string) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	secret, ok := secrets[path]
+	if !ok || secret.Data == nil {
+		return nil, fmt.Errorf("secret not found at %s", path)
+	}
+	return secret.Data, nil
+}
+
+func (s *fileSecretStore) write(path string, data map[string]any, ifAbsent bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, salt, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	current, exists := secrets[path]
+	if exists && ifAbsent {
+		return false, nil
+	}
+	secrets[path] = fileSecret{Version: current.Version + 1, UpdatedAt: time.Now().UTC().Format(time.RFC3339), Data: data}
+	if err := s.save(secrets, salt); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *fileSecretStore) Write(ctx context.Context, path string, data map[string]any) error {
+	_, err := s.write(path, data, false)
+	return err
+}
+
+func (s *fileSecretStore) WriteIfAbsent(ctx context.Context, path string, data map[string]any) (bool, error) {
+	return s.write(path, data, true)
+}
+
+func (s *fileSecretStore) Status(ctx context.Context) model.SecretStoreStatusInfo {
+	status := model.SecretStoreStatusInfo{Backend: SecretStoreFile, FilePath: model.SecretStoreFilePath}
+	if reason := s.Configured(); reason != "" {
+		status.Message = reason
+		return status
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, _, err := s.load()
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+	if err := os.MkdirAll(filepath.Dir(model.SecretStoreFilePath), 0700); err != nil {
+		status.Message = fmt.Sprintf("secret file directory is not writable: %v", err)
+		return status
+	}
+	status.Available = true
+	status.Message = fmt.Sprintf("Encrypted secret file is available for credential storage (%d secrets)", len(secrets))
+	return status
+}
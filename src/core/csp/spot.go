@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csp
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	csptypes "github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
+)
+
+// SpotLaunchReq is the CSP-neutral input for launching one spot/preemptible instance.
+// All resource references are CSP-native identifiers (already resolved from TB ids),
+// because the launch bypasses CB-Spider, which has no spot purchase option.
+type SpotLaunchReq struct {
+	Region           string
+	Zone             string
+	Name             string // CSP-side instance name (TB Node Uid, same as the Spider path)
+	InstanceType     string
+	ImageId          string
+	SubnetId         string
+	SecurityGroupIds []string
+	KeyPairName      string
+	RootDiskType     string
+	RootDiskSize     int     // GB; 0 = CSP default
+	MaxPrice         float64 // USD per hour; 0 = up to the on-demand price
+}
+
+// SpotLaunchFunc launches a spot instance and returns its CSP instance ID.
+// ctx must carry model.CtxKeyCredentialHolder for credential lookup.
+// The instance is then imported into CB-Spider via /regvm like any registered VM.
+type SpotLaunchFunc func(ctx context.Context, req SpotLaunchReq) (instanceId string, err error)
+
+var (
+	spotLauncherMu sync.RWMutex
+	spotLaunchers  = make(map[string]SpotLaunchFunc)
+)
+
+// RegisterSpotLauncher registers a direct-SDK spot launch function for a CSP.
+// Each CSP package that supports spot capacity calls this from its init() function.
+func RegisterSpotLauncher(provider string, fn SpotLaunchFunc) {
+	spotLauncherMu.Lock()
+	defer spotLauncherMu.Unlock()
+	spotLaunchers[strings.ToLower(provider)] = fn
+}
+
+// GetSpotLauncher returns the registered SpotLaunchFunc for the given provider.
+// The provider may be a derived CSP name; it is normalized via ResolveCloudPlatform.
+func GetSpotLauncher(provider string) (SpotLaunchFunc, bool) {
+	spotLauncherMu.RLock()
+	defer spotLauncherMu.RUnlock()
+	fn, ok := spotLaunchers[csptypes.ResolveCloudPlatform(provider)]
+	return fn, ok
+}
+
+// SpotSupportedProviders returns the providers with a registered spot launcher.
+func SpotSupportedProviders() []string {
+	spotLauncherMu.RLock()
+	defer spotLauncherMu.RUnlock()
+	out := make([]string, 0, len(spotLaunchers))
+	for p := range spotLaunchers {
+		out = append(out, p)
+	}
+	return out
+}
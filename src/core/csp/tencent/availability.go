@@ -199,7 +199,7 @@ func (c *availabilityChecker) CheckInstance(ctx context.Context, q model.Availab
 
 func getTencentCreds(ctx context.Context) (secretID, secretKey string, err error) {
 	path := csp.BuildSecretPath(ctx, cspconst.Tencent)
-	data, err := csp.ReadOpenBaoSecret(ctx, path)
+	data, err := csp.ReadSecret(ctx, path)
 	if err != nil {
 		return "", "", err
 	}
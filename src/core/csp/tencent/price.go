@@ -0,0 +1,157 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tencent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/csp"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/rs/zerolog/log"
+
+	tccommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	// chargeTypeOnDemand is Tencent's pay-as-you-go (hourly) instance charge type.
+	chargeTypeOnDemand = "POSTPAID_BY_HOUR"
+
+	// chargeTypeSpot is Tencent's spot (preemptible) instance charge type.
+	chargeTypeSpot = "SPOTPAID"
+
+	// defaultTencentPriceCurrency is the currency of ItemPrice values returned
+	// by the international site. Accounts on the China site are billed in CNY;
+	// set TB_TENCENT_PRICE_CURRENCY=CNY for those.
+	defaultTencentPriceCurrency = "USD"
+)
+
+// FetchNodePricesByRegion fetches Tencent CVM on-demand instance prices for a region.
+//
+// DescribeZoneInstanceConfigInfos already returns the per-zone ItemPrice for every
+// sellable instance type, so a single call (filtered by instance-charge-type)
+// covers the whole region without a per-spec InquiryPriceRunInstances sweep.
+// The lowest hourly price across zones is kept per instance type.
+func FetchNodePricesByRegion(ctx context.Context, region string) (model.SpiderCloudPrice, error) {
+	return fetchNodePricesByChargeType(ctx, region, chargeTypeOnDemand)
+}
+
+func fetchNodePricesByChargeType(ctx context.Context, region, chargeType string) (model.SpiderCloudPrice, error) {
+	region = strings.TrimSpace(region)
+	if region == "" {
+		return model.SpiderCloudPrice{}, fmt.Errorf("region is empty")
+	}
+
+	secretID, secretKey, err := getTencentCreds(ctx)
+	if err != nil {
+		return model.SpiderCloudPrice{}, fmt.Errorf("failed to get Tencent credentials: %w", err)
+	}
+
+	client, err := newCVMClient(region, secretID, secretKey)
+	if err != nil {
+		return model.SpiderCloudPrice{}, fmt.Errorf("failed to create Tencent CVM client for region %s: %w", region, err)
+	}
+
+	req := cvm.NewDescribeZoneInstanceConfigInfosRequest()
+	req.Filters = []*cvm.Filter{
+		{
+			Name:   new("instance-charge-type"),
+			Values: tccommon.StringPtrs([]string{chargeType}),
+		},
+	}
+
+	resp, err := client.DescribeZoneInstanceConfigInfosWithContext(ctx, req)
+	if err != nil {
+		return model.SpiderCloudPrice{}, fmt.Errorf("failed to query Tencent instance prices for region %s: %s", region, csp.RedactErr(err))
+	}
+	if resp == nil || resp.Response == nil {
+		return model.SpiderCloudPrice{}, nil
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(os.Getenv("TB_TENCENT_PRICE_CURRENCY")))
+	if currency == "" {
+		currency = defaultTencentPriceCurrency
+	}
+
+	bestBySpec := map[string]float64{}
+	skipped := 0
+	for _, item := range resp.Response.InstanceTypeQuotaSet {
+		if item == nil || item.InstanceType == nil || item.Price == nil {
+			skipped++
+			continue
+		}
+		instanceType := strings.TrimSpace(*item.InstanceType)
+		price := hourlyPrice(item.Price)
+		if instanceType == "" || price <= 0 {
+			skipped++
+			continue
+		}
+		if prev, ok := bestBySpec[instanceType]; !ok || price < prev {
+			bestBySpec[instanceType] = price
+		}
+	}
+
+	specNames := make([]string, 0, len(bestBySpec))
+	for name := range bestBySpec {
+		specNames = append(specNames, name)
+	}
+	sort.Strings(specNames)
+
+	priceList := make([]model.SpiderPrice, 0, len(specNames))
+	for _, name := range specNames {
+		priceList = append(priceList, model.SpiderPrice{
+			ProductInfo: model.SpiderProductInfo{VMSpecName: name},
+			PriceInfo: model.SpiderPriceInfo{
+				OnDemand: model.SpiderOnDemand{
+					PricingId: chargeType,
+					Unit:      "Hour",
+					Currency:  currency,
+					Price:     strconv.FormatFloat(bestBySpec[name], 'f', -1, 64),
+				},
+			},
+		})
+	}
+
+	log.Info().Msgf("Tencent direct pricing: region=%s chargeType=%s quotaItems=%d priced=%d skipped=%d",
+		region, chargeType, len(resp.Response.InstanceTypeQuotaSet), len(priceList), skipped)
+
+	return model.SpiderCloudPrice{PriceList: priceList}, nil
+}
+
+// hourlyPrice returns the effective hourly price from a Tencent ItemPrice.
+// The discounted unit price is preferred since it is what the account is
+// actually charged; the list unit price is used when no discount applies.
+func hourlyPrice(p *cvm.ItemPrice) float64 {
+	if p.ChargeUnit != nil && !strings.EqualFold(*p.ChargeUnit, "HOUR") {
+		return 0
+	}
+	if p.UnitPriceDiscount != nil && *p.UnitPriceDiscount > 0 {
+		return *p.UnitPriceDiscount
+	}
+	if p.UnitPrice != nil && *p.UnitPrice > 0 {
+		return *p.UnitPrice
+	}
+	return 0
+}
+
+// FetchSpotPricesByRegion fetches Tencent CVM spot (SPOTPAID) instance prices for a region.
+// The result shares the on-demand layout; callers store it as the spec's spot price.
+func FetchSpotPricesByRegion(ctx context.Context, region string) (model.SpiderCloudPrice, error) {
+	return fetchNodePricesByChargeType(ctx, region, chargeTypeSpot)
+}
@@ -0,0 +1,392 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
+)
+
+func init() {
+	common.RegisterApprovalExecutor(model.ApprovalOpInfraTerminate, func(ctx context.Context, cr model.ChangeRequest) (any, error) {
+		return DelInfra(cr.NsId, cr.TargetId, cr.Option)
+	})
+	common.RegisterApprovalExecutor(model.ApprovalOpInfraCreate, func(ctx context.Context, cr model.ChangeRequest) (any, error) {
+		req := &model.InfraDynamicReq{}
+		if err := json.Unmarshal(cr.Payload, req); err != nil {
+			return nil, fmt.Errorf("invalid Infra request in change request '%s': %w", cr.Id, err)
+		}
+		infraInfo, err := CreateInfraDynamic(ctx, cr.NsId, req, cr.Option)
+		if err != nil {
+			return nil, err
+		}
+		return model.SimpleMsg{Message: fmt.Sprintf("Infra '%s' has been created (status: %s)", infraInfo.Id, infraInfo.Status)}, nil
+	})
+	common.RegisterApprovalExecutor(model.ApprovalOpNodeResize, func(ctx context.Context, cr model.ChangeRequest) (any, error) {
+		infraId, nodeId, _ := strings.Cut(cr.TargetId, "/")
+		result, err := HandleInfraNodeAction(ctx, cr.NsId, infraId, nodeId, model.ActionResize, false, cr.Option)
+		if err != nil {
+			return nil, err
+		}
+		return model.SimpleMsg{Message: result}, nil
+	})
+	common.RegisterApprovalExecutor(model.ApprovalOpAllInfraTerminate, func(ctx context.Context, cr model.ChangeRequest) (any, error) {
+		message, err := DelAllInfra(cr.NsId, cr.Option)
+		if err != nil {
+			return nil, err
+		}
+		return model.SimpleMsg{Message: message}, nil
+	})
+	common.RegisterApprovalExecutor(model.ApprovalOpInfraCreateStatic, func(ctx context.Context, cr model.ChangeRequest) (any, error) {
+		req := &model.InfraReq{}
+		if err := json.Unmarshal(cr.Payload, req); err != nil {
+			return nil, fmt.Errorf("invalid Infra request in change request '%s': %w", cr.Id, err)
+		}
+		infraInfo, err := CreateInfra(ctx, cr.NsId, req, cr.Option, false)
+		if err != nil {
+			return nil, err
+		}
+		return model.SimpleMsg{Message: fmt.Sprintf("Infra '%s' has been created (status: %s)", infraInfo.Id, infraInfo.Status)}, nil
+	})
+	common.RegisterApprovalExecutor(model.ApprovalOpNodeGroupAdd, func(ctx context.Context, cr model.ChangeRequest) (any, error) {
+		req := &model.AddNodeGroupDynamicReq{}
+		if err := json.Unmarshal(cr.Payload, req); err != nil {
+			return nil, fmt.Errorf("invalid NodeGroup request in change request '%s': %w", cr.Id, err)
+		}
+		infraInfo, err := CreateInfraNodeGroupDynamic(ctx, cr.NsId, cr.TargetId, req)
+		if err != nil {
+			return nil, err
+		}
+		return model.SimpleMsg{Message: fmt.Sprintf("NodeGroup '%s' has been added to Infra '%s' (status: %s)", req.Name, infraInfo.Id, infraInfo.Status)}, nil
+	})
+	common.RegisterApprovalExecutor(model.ApprovalOpNodeGroupScaleOut, func(ctx context.Context, cr model.ChangeRequest) (any, error) {
+		req := &model.ScaleOutNodeGroupReq{}
+		if err := json.Unmarshal(cr.Payload, req); err != nil {
+			return nil, fmt.Errorf("invalid scale-out request in change request '%s': %w", cr.Id, err)
+		}
+		infraId, nodeGroupId, _ := strings.Cut(cr.TargetId, "/")
+		infraInfo, err := ScaleOutInfraNodeGroup(ctx, cr.NsId, infraId, nodeGroupId, req.NumNodesToAdd)
+		if err != nil {
+			return nil, err
+		}
+		return model.SimpleMsg{Message: fmt.Sprintf("NodeGroup '%s' of Infra '%s' has been scaled out by %d Nodes (status: %s)", nodeGroupId, infraId, req.NumNodesToAdd, infraInfo.Status)}, nil
+	})
+	common.RegisterApprovalExecutor(model.ApprovalOpInfraDesiredState, func(ctx context.Context, cr model.ChangeRequest) (any, error) {
+		req := &model.InfraDynamicReq{}
+		if err := json.Unmarshal(cr.Payload, req); err != nil {
+			return nil, fmt.Errorf("invalid desired state in change request '%s': %w", cr.Id, err)
+		}
+		// The plan is recomputed, so changes made since the request was parked are taken into account
+		return ApplyInfraDesiredState(ctx, cr.NsId, cr.TargetId, req)
+	})
+}
+
+// RequestInfraTerminateApproval parks the termination of an Infra as a change request
+// if the approval policy requires it. It returns nil if the deletion can proceed.
+func RequestInfraTerminateApproval(ctx context.Context, nsId, infraId, option, requester string) (*model.ChangeRequest, error) {
+	if common.ToLower(option) != "terminate" || !common.ApprovalRequired(model.ApprovalOpInfraTerminate, 0) {
+		return nil, nil
+	}
+	infraInfo, err := GetInfraInfo(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+
+	cr, err := common.SubmitChangeRequest(model.ChangeRequest{
+		Operation:        model.ApprovalOpInfraTerminate,
+		NsId:             nsId,
+		TargetId:         infraId,
+		Option:           option,
+		CredentialHolder: common.CredentialHolderFromContext(ctx),
+		Requester:        requester,
+		Impact: model.ChangeRequestImpact{
+			Summary:   fmt.Sprintf("terminate %d Nodes of Infra '%s' and delete it", len(infraInfo.Node), infraId),
+			NodeCount: len(infraInfo.Node),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+// RequestInfraCreateApproval parks an Infra dynamic creation as a change request if its
+// estimated cost exceeds the approval threshold. It returns nil if the creation can proceed.
+// The estimate comes from ReviewInfraDynamicReq, which is attached to the change request.
+func RequestInfraCreateApproval(ctx context.Context, nsId string, req *model.InfraDynamicReq, option, requester string) (*model.ChangeRequest, error) {
+	if common.ApprovalCostThreshold() <= 0 {
+		return nil, nil
+	}
+	review, err := ReviewInfraDynamicReq(ctx, nsId, req, option)
+	if err != nil {
+		return nil, err
+	}
+	if !common.ApprovalRequired(model.ApprovalOpInfraCreate, review.EstimatedCostPerHour) {
+		return nil, nil
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeCount := 0
+	for _, ng := range req.NodeGroups {
+		nodeCount += max(ng.NodeGroupSize, 1)
+	}
+	cr, err := common.SubmitChangeRequest(model.ChangeRequest{
+		Operation:        model.ApprovalOpInfraCreate,
+		NsId:             nsId,
+		TargetId:         req.Name,
+		Option:           option,
+		CredentialHolder: common.CredentialHolderFromContext(ctx),
+		Payload:          payload,
+		Requester:        requester,
+		Impact: model.ChangeRequestImpact{
+			Summary: fmt.Sprintf("create Infra '%s' with %d Nodes (estimated $%.4f/hour, threshold $%.4f/hour)",
+				req.Name, nodeCount, review.EstimatedCostPerHour, common.ApprovalCostThreshold()),
+			NodeCount:            nodeCount,
+			EstimatedCostPerHour: review.EstimatedCostPerHour,
+			Review:               review,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+// RequestAllInfraTerminateApproval parks the termination of all Infras of a namespace
+// as a change request if the approval policy requires it. It returns nil if the deletion can proceed.
+func RequestAllInfraTerminateApproval(ctx context.Context, nsId, option, requester string) (*model.ChangeRequest, error) {
+	if common.ToLower(option) != "terminate" || !common.ApprovalRequired(model.ApprovalOpAllInfraTerminate, 0) {
+		return nil, nil
+	}
+	infraList, err := ListInfraId(nsId)
+	if err != nil {
+		return nil, err
+	}
+	if len(infraList) == 0 {
+		return nil, nil
+	}
+	nodeCount := 0
+	for _, infraId := range infraList {
+		infraInfo, err := GetInfraInfo(nsId, infraId)
+		if err != nil {
+			return nil, err
+		}
+		nodeCount += len(infraInfo.Node)
+	}
+
+	cr, err := common.SubmitChangeRequest(model.ChangeRequest{
+		Operation:        model.ApprovalOpAllInfraTerminate,
+		NsId:             nsId,
+		Option:           option,
+		CredentialHolder: common.CredentialHolderFromContext(ctx),
+		Requester:        requester,
+		Impact: model.ChangeRequestImpact{
+			Summary:   fmt.Sprintf("terminate %d Nodes of %d Infras (%s) and delete them", nodeCount, len(infraList), strings.Join(infraList, ", ")),
+			NodeCount: nodeCount,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+// submitCostApproval parks an operation adding capacity as a change request if the estimated
+// cost of the added capacity (cr.Impact.EstimatedCostPerHour) exceeds the approval threshold.
+// It returns nil if the operation can proceed.
+func submitCostApproval(ctx context.Context, cr model.ChangeRequest, payload any) (*model.ChangeRequest, error) {
+	if !common.ApprovalRequired(cr.Operation, cr.Impact.EstimatedCostPerHour) {
+		return nil, nil
+	}
+	val, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	cr.Payload = val
+	cr.CredentialHolder = common.CredentialHolderFromContext(ctx)
+	cr.Impact.Summary += fmt.Sprintf(" (estimated $%.4f/hour, threshold $%.4f/hour)", cr.Impact.EstimatedCostPerHour, common.ApprovalCostThreshold())
+
+	submitted, err := common.SubmitChangeRequest(cr)
+	if err != nil {
+		return nil, err
+	}
+	return &submitted, nil
+}
+
+// nodeGroupDynamicCostPerHour returns the estimated hourly cost of all Nodes of a dynamic NodeGroup request.
+func nodeGroupDynamicCostPerHour(ctx context.Context, nsId string, req model.CreateNodeGroupDynamicReq) (float64, error) {
+	review, err := ReviewSingleNodeGroupDynamicReq(ctx, nsId, &req)
+	if err != nil {
+		return 0, err
+	}
+	return review.EstimatedCostPerHour, nil
+}
+
+// RequestInfraCreateStaticApproval parks an Infra creation (or registration) from static
+// NodeGroup requests as a change request if its estimated cost exceeds the approval threshold.
+// It returns nil if the creation can proceed. Specs without a price count as free.
+func RequestInfraCreateStaticApproval(ctx context.Context, nsId string, req *model.InfraReq, option, requester string) (*model.ChangeRequest, error) {
+	if common.ApprovalCostThreshold() <= 0 {
+		return nil, nil
+	}
+	cost := 0.0
+	nodeCount := 0
+	for _, ng := range req.NodeGroups {
+		size := max(ng.NodeGroupSize, 1)
+		nodeCount += size
+		specInfo, err := resource.GetSpec(model.SystemCommonNs, ng.SpecId)
+		if err != nil {
+			continue
+		}
+		cost += specCostPerHour(specInfo, ng.CapacityType) * float64(size)
+	}
+
+	return submitCostApproval(ctx, model.ChangeRequest{
+		Operation: model.ApprovalOpInfraCreateStatic,
+		NsId:      nsId,
+		TargetId:  req.Name,
+		Option:    option,
+		Requester: requester,
+		Impact: model.ChangeRequestImpact{
+			Summary:              fmt.Sprintf("%s Infra '%s' with %d Nodes", option, req.Name, nodeCount),
+			NodeCount:            nodeCount,
+			EstimatedCostPerHour: cost,
+		},
+	}, req)
+}
+
+// RequestNodeGroupAddApproval parks the addition of a dynamic NodeGroup to an Infra as a
+// change request if its estimated cost exceeds the approval threshold. It returns nil if
+// the addition can proceed.
+func RequestNodeGroupAddApproval(ctx context.Context, nsId, infraId string, req *model.AddNodeGroupDynamicReq, requester string) (*model.ChangeRequest, error) {
+	if common.ApprovalCostThreshold() <= 0 {
+		return nil, nil
+	}
+	cost, err := nodeGroupDynamicCostPerHour(ctx, nsId, req.CreateNodeGroupDynamicReq)
+	if err != nil {
+		return nil, err
+	}
+	nodeCount := max(req.NodeGroupSize, 1)
+
+	return submitCostApproval(ctx, model.ChangeRequest{
+		Operation: model.ApprovalOpNodeGroupAdd,
+		NsId:      nsId,
+		TargetId:  infraId,
+		Requester: requester,
+		Impact: model.ChangeRequestImpact{
+			Summary:              fmt.Sprintf("add NodeGroup '%s' with %d Nodes to Infra '%s'", req.Name, nodeCount, infraId),
+			NodeCount:            nodeCount,
+			EstimatedCostPerHour: cost,
+		},
+	}, req)
+}
+
+// RequestNodeGroupScaleOutApproval parks the scale-out of a NodeGroup as a change request
+// if the estimated cost of the added Nodes (priced like the existing ones) exceeds the
+// approval threshold. It returns nil if the scale-out can proceed.
+func RequestNodeGroupScaleOutApproval(ctx context.Context, nsId, infraId, nodeGroupId string, req *model.ScaleOutNodeGroupReq, requester string) (*model.ChangeRequest, error) {
+	if common.ApprovalCostThreshold() <= 0 || req.NumNodesToAdd <= 0 {
+		return nil, nil
+	}
+	infraInfo, err := GetInfraInfo(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(infraInfo.Node, func(node model.NodeInfo) bool { return node.NodeGroupId == nodeGroupId })
+	if idx < 0 {
+		// Nothing to price; ScaleOutInfraNodeGroup reports the missing NodeGroup
+		return nil, nil
+	}
+	cost := nodeCostPerHour(infraInfo.Node[idx]) * float64(req.NumNodesToAdd)
+
+	return submitCostApproval(ctx, model.ChangeRequest{
+		Operation: model.ApprovalOpNodeGroupScaleOut,
+		NsId:      nsId,
+		TargetId:  infraId + "/" + nodeGroupId,
+		Requester: requester,
+		Impact: model.ChangeRequestImpact{
+			Summary:              fmt.Sprintf("scale out NodeGroup '%s' of Infra '%s' by %d Nodes", nodeGroupId, infraId, req.NumNodesToAdd),
+			NodeCount:            req.NumNodesToAdd,
+			EstimatedCostPerHour: cost,
+		},
+	}, req)
+}
+
+// RequestInfraDesiredStateApproval parks the apply of a desired state as a change request
+// if the estimated cost of the capacity it adds exceeds the approval threshold: the whole
+// Infra if it does not exist yet, the added NodeGroups and scale-outs otherwise.
+// It returns nil if the apply can proceed.
+func RequestInfraDesiredStateApproval(ctx context.Context, nsId, infraId string, req *model.InfraDynamicReq, plan *model.InfraDesiredStatePlan, requester string) (*model.ChangeRequest, error) {
+	if !plan.InfraExists {
+		return RequestInfraCreateApproval(ctx, nsId, req, "", requester)
+	}
+	if common.ApprovalCostThreshold() <= 0 {
+		return nil, nil
+	}
+	infraInfo, err := GetInfraInfo(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := 0.0
+	nodeCount := 0
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case model.DesiredActionAddNodeGroup:
+			idx := slices.IndexFunc(req.NodeGroups, func(ng model.CreateNodeGroupDynamicReq) bool { return ng.Name == change.NodeGroupId })
+			if idx < 0 {
+				continue
+			}
+			ngCost, err := nodeGroupDynamicCostPerHour(ctx, nsId, req.NodeGroups[idx])
+			if err != nil {
+				return nil, err
+			}
+			cost += ngCost
+			nodeCount += change.DesiredSize
+		case model.DesiredActionScaleOut:
+			added := change.DesiredSize - change.CurrentSize
+			idx := slices.IndexFunc(infraInfo.Node, func(node model.NodeInfo) bool { return node.NodeGroupId == change.NodeGroupId })
+			if idx >= 0 {
+				cost += nodeCostPerHour(infraInfo.Node[idx]) * float64(added)
+			}
+			nodeCount += added
+		}
+	}
+	if nodeCount == 0 {
+		return nil, nil
+	}
+
+	return submitCostApproval(ctx, model.ChangeRequest{
+		Operation: model.ApprovalOpInfraDesiredState,
+		NsId:      nsId,
+		TargetId:  infraId,
+		Requester: requester,
+		Impact: model.ChangeRequestImpact{
+			Summary:              fmt.Sprintf("apply the desired state of Infra '%s', adding %d Nodes", infraId, nodeCount),
+			NodeCount:            nodeCount,
+			EstimatedCostPerHour: cost,
+		},
+	}, req)
+}
@@ -22,6 +22,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
 	"github.com/rs/zerolog/log"
@@ -148,6 +149,8 @@ func ReviewInfraAutopilot(ctx context.Context, nsId string, req *model.InfraAuto
 	if len(summary.UnreachableSpecs) == len(req.NodeSpecs) {
 		summary.Feasibility = "Infeasible"
 	}
+	summary.CostPerMonthMin = roundCost(summary.CostPerHourMin * model.CostHoursPerMonth)
+	summary.CostPerMonthMax = roundCost(summary.CostPerHourMax * model.CostHoursPerMonth)
 
 	result.Summary = summary
 
@@ -470,6 +473,16 @@ func CreateInfraAutopilot(ctx context.Context, nsId string, req *model.InfraAuto
 	startTime := time.Now()
 	log.Info().Msgf("CreateInfraAutopilot: ns=%s, name=%s", nsId, req.Name)
 
+	// Specs are only chosen per attempt (each attempt is checked again by
+	// CreateInfraDynamic / CreateInfraNodeGroupDynamic); reject early on Node count alone.
+	quotaDemand := model.NsQuotaDemand{}
+	for _, nodeSpec := range req.NodeSpecs {
+		quotaDemand.Nodes += nodeSpec.DesiredCount
+	}
+	if err := common.CheckNsQuota(nsId, quotaDemand); err != nil {
+		return nil, err
+	}
+
 	runKey := autopilotRunKey(nsId, req.Name)
 	run := &activeAutopilotRun{
 		req:            req,
@@ -563,7 +576,7 @@ func CreateInfraAutopilot(ctx context.Context, nsId string, req *model.InfraAuto
 			if !ok {
 				return
 			}
-			if _, refErr := HandleInfraAction(nsId, infraId, model.ActionRefine, true); refErr != nil {
+			if _, refErr := HandleInfraAction(ctx, nsId, infraId, model.ActionRefine, true); refErr != nil {
 				log.Warn().Err(refErr).Msgf("refine after provision error failed for nodeGroup '%s'", ngReq.Name)
 			}
 		}
@@ -1423,7 +1436,7 @@ func provisionNodeSpec(
 				}(ngName)
 			}
 			termWg.Wait()
-			if _, refErr := HandleInfraAction(nsId, infraId, model.ActionRefine, true); refErr != nil {
+			if _, refErr := HandleInfraAction(ctx, nsId, infraId, model.ActionRefine, true); refErr != nil {
 				log.Warn().Err(refErr).Msg("refine after excess NodeGroup cleanup failed")
 			}
 		}
@@ -1809,7 +1822,7 @@ func terminateNodeGroup(nsId, infraId, nodeGroupId string) error {
 	}
 	var errs []string
 	for _, nodeId := range nodeIds {
-		if _, err := HandleInfraNodeAction(nsId, infraId, nodeId, model.ActionTerminate, true); err != nil {
+		if _, err := HandleInfraNodeAction(context.Background(), nsId, infraId, nodeId, model.ActionTerminate, true, ""); err != nil {
 			errs = append(errs, nodeId+": "+err.Error())
 		}
 	}
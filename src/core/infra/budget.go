@@ -0,0 +1,704 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+	"github.com/rs/zerolog/log"
+)
+
+// Budgets.
+//
+// A budget caps the actual spend (usage.go) of a namespace, or of the Infras with a label,
+// per daily or monthly period in UTC. Budgets are evaluated periodically by
+// StartBudgetEvaluator and whenever they are changed: each threshold reached in a period
+// emits one event, delivered to the budget's webhook. An exhausted budget can block new
+// provisioning in its scope (checked from the stored state, so provisioning requests do
+// not recompute spend) or also suspend the running Infras of the scope. A suspending budget
+// is enforced on every evaluation while it stays exhausted, so an Infra resumed meanwhile is
+// suspended again, and resuming Infras in its scope is rejected.
+// An override lifts the enforcement until it expires; nothing is resumed automatically.
+
+const (
+	// budgetKeyPrefix is the kvstore prefix of budgets (/budget/{nsId}/{budgetId})
+	budgetKeyPrefix = "/budget/"
+	// budgetEvaluationInterval is the interval of the periodic budget evaluation
+	budgetEvaluationInterval = 5 * time.Minute
+	// budgetWebhookTimeout bounds the delivery of one event to a webhook
+	budgetWebhookTimeout = 10 * time.Second
+	// budgetEventHistory is the number of events kept in a budget
+	budgetEventHistory = 50
+)
+
+// budgetMu serializes read-modify-write of budgets
+var budgetMu sync.Mutex
+
+var defaultBudgetThresholds = []int{50, 80, 100}
+
+func init() {
+	common.RegisterBudgetBlockFunc(func(nsId string, labels ...map[string]string) error {
+		return checkBudgetBlock(nsId, "", labels...)
+	})
+	common.RegisterNsCleanupFunc("budgets", DeleteAllBudgets)
+}
+
+// budgetWebhookClient delivers budget events. Webhook URLs are user input, so the client only
+// connects to public addresses; the check runs on the resolved address at dial time, so host
+// names (and redirects) cannot lead it into the internal network.
+var budgetWebhookClient = &http.Client{
+	Timeout: budgetWebhookTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: budgetWebhookTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				return checkWebhookIp(net.ParseIP(host))
+			},
+		}).DialContext,
+		TLSHandshakeTimeout:   budgetWebhookTimeout,
+		ResponseHeaderTimeout: budgetWebhookTimeout,
+	},
+}
+
+// checkWebhookIp rejects addresses a budget webhook must not reach.
+func checkWebhookIp(ip net.IP) error {
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook address %v is not a public address", ip)
+	}
+	return nil
+}
+
+// validateBudgetWebhookUrl checks the scheme of a webhook URL and the addresses of its host.
+func validateBudgetWebhookUrl(webhookUrl string) error {
+	u, err := url.Parse(webhookUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return apierr.Invalid("webhookUrl must be an http(s) URL with a host", err)
+	}
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil {
+		return apierr.Invalid(fmt.Sprintf("cannot resolve the host of webhookUrl: %v", err), err)
+	}
+	for _, ip := range ips {
+		if err := checkWebhookIp(ip); err != nil {
+			return apierr.Invalid(err.Error(), err)
+		}
+	}
+	return nil
+}
+
+func budgetKey(nsId, budgetId string) string {
+	return budgetKeyPrefix + nsId + "/" + budgetId
+}
+
+func getBudgetObject(nsId, budgetId string) (model.BudgetInfo, bool, error) {
+	budget := model.BudgetInfo{}
+	val, exists, err := kvstore.Get(budgetKey(nsId, budgetId))
+	if err != nil || !exists {
+		return budget, false, err
+	}
+	if err := json.Unmarshal([]byte(val), &budget); err != nil {
+		return budget, false, err
+	}
+	return budget, true, nil
+}
+
+func putBudgetObject(budget model.BudgetInfo) error {
+	val, err := json.Marshal(budget)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put(budgetKey(budget.NsId, budget.Id), string(val))
+}
+
+func listBudgetObjects(prefix string) ([]model.BudgetInfo, error) {
+	vals, err := kvstore.GetList(prefix)
+	if err != nil {
+		return nil, err
+	}
+	budgets := make([]model.BudgetInfo, 0, len(vals))
+	for _, val := range vals {
+		budget := model.BudgetInfo{}
+		if err := json.Unmarshal([]byte(val), &budget); err != nil {
+			log.Warn().Err(err).Msg("skipping an unreadable budget")
+			continue
+		}
+		budgets = append(budgets, budget)
+	}
+	return budgets, nil
+}
+
+// normalizeBudgetReq validates a budget request and fills its defaults.
+func normalizeBudgetReq(req *model.BudgetReq) error {
+	if err := common.CheckString(req.Name); err != nil {
+		return apierr.Invalid(err.Error(), err)
+	}
+	if req.Amount <= 0 {
+		return apierr.Invalid("amount must be positive", nil)
+	}
+	if (req.LabelKey == "") != (req.LabelValue == "") {
+		return apierr.Invalid("labelKey and labelValue must be given together", nil)
+	}
+	req.Period = strings.ToLower(req.Period)
+	if req.Period == "" {
+		req.Period = model.BudgetPeriodMonthly
+	}
+	if req.Period != model.BudgetPeriodDaily && req.Period != model.BudgetPeriodMonthly {
+		return apierr.Invalid(fmt.Sprintf("unknown period '%s' (daily or monthly)", req.Period), nil)
+	}
+	req.Enforcement = strings.ToLower(req.Enforcement)
+	if req.Enforcement == "" {
+		req.Enforcement = model.BudgetEnforceNone
+	}
+	switch req.Enforcement {
+	case model.BudgetEnforceNone, model.BudgetEnforceBlock, model.BudgetEnforceSuspend:
+	default:
+		return apierr.Invalid(fmt.Sprintf("unknown enforcement '%s' (none, block or suspend)", req.Enforcement), nil)
+	}
+	if len(req.Thresholds) == 0 {
+		req.Thresholds = slices.Clone(defaultBudgetThresholds)
+	}
+	for _, t := range req.Thresholds {
+		if t <= 0 || t > 1000 {
+			return apierr.Invalid(fmt.Sprintf("threshold %d%% is out of range (1-1000)", t), nil)
+		}
+	}
+	slices.Sort(req.Thresholds)
+	req.Thresholds = slices.Compact(req.Thresholds)
+	if req.WebhookUrl != "" {
+		return validateBudgetWebhookUrl(req.WebhookUrl)
+	}
+	return nil
+}
+
+// CreateBudget creates a budget in a namespace and evaluates it right away.
+func CreateBudget(nsId string, req model.BudgetReq) (model.BudgetInfo, error) {
+	if err := normalizeBudgetReq(&req); err != nil {
+		return model.BudgetInfo{}, err
+	}
+	if _, err := common.GetNs(nsId); err != nil {
+		return model.BudgetInfo{}, err
+	}
+
+	budgetMu.Lock()
+	_, exists, err := getBudgetObject(nsId, req.Name)
+	if err != nil {
+		budgetMu.Unlock()
+		return model.BudgetInfo{}, err
+	}
+	if exists {
+		budgetMu.Unlock()
+		return model.BudgetInfo{}, &apierr.StatusError{StatusCode: http.StatusConflict,
+			Message: fmt.Sprintf("budget '%s' already exists in namespace '%s'", req.Name, nsId)}
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	budget := model.BudgetInfo{Id: req.Name, NsId: nsId, BudgetReq: req, CreatedAt: now, UpdatedAt: now, Events: []model.BudgetEvent{}}
+	err = putBudgetObject(budget)
+	budgetMu.Unlock()
+	if err != nil {
+		return model.BudgetInfo{}, err
+	}
+	return EvaluateBudget(nsId, budget.Id)
+}
+
+// UpdateBudget replaces the settings of a budget and evaluates it again.
+// The state is kept unless the period or the scope changes.
+func UpdateBudget(nsId, budgetId string, req model.BudgetReq) (model.BudgetInfo, error) {
+	req.Name = budgetId
+	if err := normalizeBudgetReq(&req); err != nil {
+		return model.BudgetInfo{}, err
+	}
+
+	budgetMu.Lock()
+	budget, exists, err := getBudgetObject(nsId, budgetId)
+	if err == nil && !exists {
+		err = budgetNotFound(nsId, budgetId)
+	}
+	if err != nil {
+		budgetMu.Unlock()
+		return model.BudgetInfo{}, err
+	}
+	if budget.Period != req.Period || budget.LabelKey != req.LabelKey || budget.LabelValue != req.LabelValue {
+		// A new period or scope starts the budget over
+		budget.PeriodStart = ""
+	}
+	budget.BudgetReq = req
+	budget.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	err = putBudgetObject(budget)
+	budgetMu.Unlock()
+	if err != nil {
+		return model.BudgetInfo{}, err
+	}
+	return EvaluateBudget(nsId, budgetId)
+}
+
+// GetBudget returns a budget with its last evaluated state.
+func GetBudget(nsId, budgetId string) (model.BudgetInfo, error) {
+	budget, exists, err := getBudgetObject(nsId, budgetId)
+	if err == nil && !exists {
+		err = budgetNotFound(nsId, budgetId)
+	}
+	return budget, err
+}
+
+// ListBudget returns the budgets of a namespace.
+func ListBudget(nsId string) (model.BudgetList, error) {
+	budgets, err := listBudgetObjects(budgetKeyPrefix + nsId + "/")
+	return model.BudgetList{Budgets: budgets}, err
+}
+
+// DeleteBudget deletes a budget; its enforcement stops immediately.
+func DeleteBudget(nsId, budgetId string) error {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	_, exists, err := getBudgetObject(nsId, budgetId)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return budgetNotFound(nsId, budgetId)
+	}
+	return kvstore.Delete(budgetKey(nsId, budgetId))
+}
+
+// DeleteAllBudgets deletes all budgets of a namespace (called when the namespace is deleted).
+func DeleteAllBudgets(nsId string) error {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	return kvstore.DeleteWithPrefix(budgetKeyPrefix + nsId + "/")
+}
+
+func budgetNotFound(nsId, budgetId string) error {
+	return &apierr.StatusError{StatusCode: http.StatusNotFound,
+		Message: fmt.Sprintf("budget '%s' does not exist in namespace '%s'", budgetId, nsId)}
+}
+
+// OverrideBudget lifts the enforcement of a budget until the override expires
+// (by default at the end of the current period).
+func OverrideBudget(nsId, budgetId string, req model.BudgetOverrideReq, actor string) (model.BudgetInfo, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return model.BudgetInfo{}, apierr.Invalid("reason is required for a budget override", nil)
+	}
+	if req.DurationMinutes < 0 {
+		return model.BudgetInfo{}, apierr.Invalid("durationMinutes must not be negative", nil)
+	}
+
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	budget, exists, err := getBudgetObject(nsId, budgetId)
+	if err == nil && !exists {
+		err = budgetNotFound(nsId, budgetId)
+	}
+	if err != nil {
+		return model.BudgetInfo{}, err
+	}
+
+	now := time.Now().UTC()
+	until := budgetPeriodEnd(budget.Period, budgetPeriodStart(budget.Period, now))
+	if req.DurationMinutes > 0 {
+		until = now.Add(time.Duration(req.DurationMinutes) * time.Minute)
+	}
+	budget.Override = &model.BudgetOverride{Until: until.Format(time.RFC3339), Actor: actor, Reason: req.Reason}
+	evt := newBudgetEvent(budget, model.BudgetEventOverridden, 0,
+		fmt.Sprintf("enforcement of budget %s overridden by %s until %s: %s", budget.Id, actor, budget.Override.Until, req.Reason))
+	appendBudgetEvent(&budget, evt)
+	budget.UpdatedAt = now.Format(time.RFC3339)
+	if err := putBudgetObject(budget); err != nil {
+		return model.BudgetInfo{}, err
+	}
+	go deliverBudgetEvent(budget.WebhookUrl, evt)
+	return budget, nil
+}
+
+// DeleteBudgetOverride ends the override of a budget; the enforcement applies again at the next evaluation.
+func DeleteBudgetOverride(nsId, budgetId string) (model.BudgetInfo, error) {
+	budgetMu.Lock()
+	budget, exists, err := getBudgetObject(nsId, budgetId)
+	if err == nil && !exists {
+		err = budgetNotFound(nsId, budgetId)
+	}
+	if err != nil {
+		budgetMu.Unlock()
+		return model.BudgetInfo{}, err
+	}
+	budget.Override = nil
+	budget.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	err = putBudgetObject(budget)
+	budgetMu.Unlock()
+	if err != nil {
+		return model.BudgetInfo{}, err
+	}
+	return EvaluateBudget(nsId, budgetId)
+}
+
+// budgetPeriodStart returns the start of the period containing t.
+func budgetPeriodStart(period string, t time.Time) time.Time {
+	t = t.UTC()
+	if period == model.BudgetPeriodDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// budgetPeriodEnd returns the end of the period starting at start.
+func budgetPeriodEnd(period string, start time.Time) time.Time {
+	if period == model.BudgetPeriodDaily {
+		return start.AddDate(0, 0, 1)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// overrideActive reports whether the enforcement of a budget is overridden at now.
+func overrideActive(budget model.BudgetInfo, now time.Time) bool {
+	if budget.Override == nil {
+		return false
+	}
+	until, err := time.Parse(time.RFC3339, budget.Override.Until)
+	return err == nil && now.Before(until)
+}
+
+func newBudgetEvent(budget model.BudgetInfo, eventType string, threshold int, msg string) model.BudgetEvent {
+	return model.BudgetEvent{
+		Time:      time.Now().UTC().Format(time.RFC3339),
+		Type:      eventType,
+		NsId:      budget.NsId,
+		BudgetId:  budget.Id,
+		Threshold: threshold,
+		Spend:     budget.Spend,
+		Amount:    budget.Amount,
+		Message:   msg,
+	}
+}
+
+func appendBudgetEvent(budget *model.BudgetInfo, evt model.BudgetEvent) {
+	budget.Events = append(budget.Events, evt)
+	if n := len(budget.Events); n > budgetEventHistory {
+		budget.Events = budget.Events[n-budgetEventHistory:]
+	}
+}
+
+// budgetSpend returns the actual spend of the budget scope since start.
+func budgetSpend(budget model.BudgetInfo, start, now time.Time) (float64, error) {
+	req := model.CostUsageReportReq{
+		From:    start.Format(time.RFC3339),
+		To:      now.Format(time.RFC3339),
+		GroupBy: model.CostUsageGroupByNamespace,
+	}
+	if budget.LabelKey != "" {
+		req.GroupBy = model.CostUsageGroupByLabel
+		req.LabelKey = budget.LabelKey
+	}
+	report, err := GetCostUsageReport(budget.NsId, req)
+	if err != nil {
+		return 0, err
+	}
+	if budget.LabelKey == "" {
+		return report.TotalCost, nil
+	}
+	for _, row := range report.Rows {
+		if row.Key == budget.LabelValue {
+			return row.Cost, nil
+		}
+	}
+	return 0, nil
+}
+
+// budgetScopeInfraIds returns the Infras of a namespace in the scope of a budget.
+func budgetScopeInfraIds(budget model.BudgetInfo) ([]string, error) {
+	infraIds, err := ListInfraId(budget.NsId)
+	if err != nil || budget.LabelKey == "" {
+		return infraIds, err
+	}
+	var scoped []string
+	for _, infraId := range infraIds {
+		infraInfo, exists, err := GetInfraObject(budget.NsId, infraId)
+		if err == nil && exists && infraInfo.Label[budget.LabelKey] == budget.LabelValue {
+			scoped = append(scoped, infraId)
+		}
+	}
+	return scoped, nil
+}
+
+// EvaluateBudget recomputes the spend of a budget, emits the events of newly reached thresholds
+// and applies its enforcement.
+func EvaluateBudget(nsId, budgetId string) (model.BudgetInfo, error) {
+	budgetMu.Lock()
+	budget, exists, err := getBudgetObject(nsId, budgetId)
+	if err == nil && !exists {
+		err = budgetNotFound(nsId, budgetId)
+	}
+	if err != nil {
+		budgetMu.Unlock()
+		return budget, err
+	}
+	events, suspendInfraIds, err := evaluateBudgetState(&budget, time.Now().UTC())
+	if err == nil {
+		err = putBudgetObject(budget)
+	}
+	budgetMu.Unlock()
+	if err != nil {
+		return budget, err
+	}
+
+	for _, evt := range events {
+		log.Info().Msgf("[Budget] %s", evt.Message)
+		go deliverBudgetEvent(budget.WebhookUrl, evt)
+	}
+	for _, infraId := range suspendInfraIds {
+		go func(infraId string) {
+			if err := ControlInfraAsync(context.Background(), nsId, infraId, model.ActionSuspend, false); err != nil {
+				log.Warn().Err(err).Msgf("[Budget] cannot suspend Infra %s for budget %s", infraId, budgetId)
+			}
+		}(infraId)
+	}
+	return budget, nil
+}
+
+// evaluateBudgetState updates the state of a budget at now and returns the new events
+// and the Infras to suspend.
+func evaluateBudgetState(budget *model.BudgetInfo, now time.Time) ([]model.BudgetEvent, []string, error) {
+	var events []model.BudgetEvent
+	emit := func(evt model.BudgetEvent) {
+		appendBudgetEvent(budget, evt)
+		events = append(events, evt)
+	}
+
+	start := budgetPeriodStart(budget.Period, now)
+	if budget.PeriodStart != start.Format(time.RFC3339) {
+		if budget.PeriodStart != "" {
+			emit(newBudgetEvent(*budget, model.BudgetEventReset, 0, fmt.Sprintf("budget %s starts a new %s period", budget.Id, budget.Period)))
+		}
+		budget.PeriodStart = start.Format(time.RFC3339)
+		budget.NotifiedThresholds = nil
+		budget.Exhausted = false
+		budget.EnforcedAt = ""
+	}
+	if budget.Override != nil && !overrideActive(*budget, now) {
+		budget.Override = nil
+	}
+
+	spend, err := budgetSpend(*budget, start, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	budget.Spend = roundCost(spend)
+	budget.PercentUsed = roundCost(spend / budget.Amount * 100)
+	budget.LastEvaluatedAt = now.Format(time.RFC3339)
+
+	for _, t := range budget.Thresholds {
+		if budget.PercentUsed >= float64(t) && !slices.Contains(budget.NotifiedThresholds, t) {
+			budget.NotifiedThresholds = append(budget.NotifiedThresholds, t)
+			emit(newBudgetEvent(*budget, model.BudgetEventThreshold, t, fmt.Sprintf("%d%% of budget %s used (%.2f of %.2f %s)",
+				t, budget.Id, budget.Spend, budget.Amount, model.CostBaseCurrency)))
+		}
+	}
+	if !budget.Exhausted && budget.Spend >= budget.Amount {
+		budget.Exhausted = true
+		emit(newBudgetEvent(*budget, model.BudgetEventExhausted, 100, fmt.Sprintf("budget %s is exhausted (%.2f of %.2f %s); enforcement: %s",
+			budget.Id, budget.Spend, budget.Amount, model.CostBaseCurrency, budget.Enforcement)))
+	}
+
+	// Enforced on every evaluation, so Infras resumed (or created) after the first enforcement
+	// are suspended as well; an event is emitted when the enforcement starts or suspends more Infras.
+	var suspendInfraIds []string
+	if budget.Exhausted && budget.Enforcement == model.BudgetEnforceSuspend && !overrideActive(*budget, now) {
+		infraIds, err := budgetScopeInfraIds(*budget)
+		if err != nil {
+			return events, nil, err
+		}
+		for _, infraId := range infraIds {
+			if infraHasRunningNode(budget.NsId, infraId) {
+				suspendInfraIds = append(suspendInfraIds, infraId)
+			}
+		}
+		if budget.EnforcedAt == "" || len(suspendInfraIds) > 0 {
+			budget.EnforcedAt = now.Format(time.RFC3339)
+			evt := newBudgetEvent(*budget, model.BudgetEventEnforced, 0, fmt.Sprintf("budget %s enforced: suspending %d Infras", budget.Id, len(suspendInfraIds)))
+			evt.InfraIds = suspendInfraIds
+			emit(evt)
+		}
+	}
+	return events, suspendInfraIds, nil
+}
+
+// infraHasRunningNode reports whether any Node of an Infra is running.
+func infraHasRunningNode(nsId, infraId string) bool {
+	nodeIds, err := ListNodeId(nsId, infraId)
+	if err != nil {
+		return false
+	}
+	for _, nodeId := range nodeIds {
+		if e, ok := globalStatusStore.Get(nsId, infraId, nodeId); ok && strings.EqualFold(e.Status, model.StatusRunning) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverBudgetEvent posts a budget event to a webhook; failures are only logged.
+func deliverBudgetEvent(webhookUrl string, evt model.BudgetEvent) {
+	if webhookUrl == "" {
+		return
+	}
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), budgetWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		log.Warn().Err(err).Msgf("[Budget] invalid webhook for budget %s", evt.BudgetId)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := budgetWebhookClient.Do(req)
+	if err != nil {
+		log.Warn().Err(err).Msgf("[Budget] cannot deliver %s event of budget %s", evt.Type, evt.BudgetId)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Warn().Msgf("[Budget] webhook of budget %s answered %d to %s event", evt.BudgetId, resp.StatusCode, evt.Type)
+	}
+}
+
+// StartBudgetEvaluator evaluates every budget periodically until ctx is done.
+func StartBudgetEvaluator(ctx context.Context) {
+	ticker := time.NewTicker(budgetEvaluationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			budgets, err := listBudgetObjects(budgetKeyPrefix)
+			if err != nil {
+				log.Warn().Err(err).Msg("[Budget] cannot list budgets")
+				continue
+			}
+			nsExists := map[string]bool{}
+			for _, b := range budgets {
+				exists, checked := nsExists[b.NsId]
+				if !checked {
+					exists, err = common.CheckNs(b.NsId)
+					if err != nil {
+						log.Warn().Err(err).Msgf("[Budget] cannot check namespace %s", b.NsId)
+						continue
+					}
+					nsExists[b.NsId] = exists
+					if !exists {
+						// Left behind by a namespace deleted before DelNs cleaned up budgets
+						if err := DeleteAllBudgets(b.NsId); err != nil {
+							log.Warn().Err(err).Msgf("[Budget] cannot delete budgets of deleted namespace %s", b.NsId)
+						}
+					}
+				}
+				if !exists {
+					continue
+				}
+				if _, err := EvaluateBudget(b.NsId, b.Id); err != nil {
+					log.Warn().Err(err).Msgf("[Budget] cannot evaluate budget %s of namespace %s", b.Id, b.NsId)
+				}
+			}
+		}
+	}
+}
+
+// checkBudgetBlock rejects provisioning in the scope of an exhausted budget that blocks it.
+// The labels of the request (and of the Infra, when infraId is given) select label budgets.
+func checkBudgetBlock(nsId, infraId string, labels ...map[string]string) error {
+	blocking, err := exhaustedBudgetsInScope(nsId, infraId, false, labels...)
+	if err != nil {
+		return err
+	}
+	if len(blocking) > 0 {
+		return apierr.Forbidden(fmt.Sprintf("provisioning in namespace '%s' is blocked by exhausted budgets: %s (ask for a budget override)",
+			nsId, strings.Join(blocking, "; ")))
+	}
+	return nil
+}
+
+// checkBudgetResume rejects resuming an Infra (or its Nodes) in the scope of an exhausted
+// budget that suspends it; the evaluator would suspend it again.
+func checkBudgetResume(nsId, infraId string, labels ...map[string]string) error {
+	suspending, err := exhaustedBudgetsInScope(nsId, infraId, true, labels...)
+	if err != nil {
+		return err
+	}
+	if len(suspending) > 0 {
+		return apierr.Forbidden(fmt.Sprintf("resuming Infra '%s' is blocked by exhausted budgets: %s (ask for a budget override)",
+			infraId, strings.Join(suspending, "; ")))
+	}
+	return nil
+}
+
+// exhaustedBudgetsInScope describes the exhausted, enforced budgets (only suspending ones
+// if suspendOnly) whose scope covers the labels (and the Infra, when infraId is given).
+func exhaustedBudgetsInScope(nsId, infraId string, suspendOnly bool, labels ...map[string]string) ([]string, error) {
+	budgets, err := listBudgetObjects(budgetKeyPrefix + nsId + "/")
+	if err != nil {
+		return nil, err
+	}
+	if infraId != "" {
+		if infraInfo, exists, err := GetInfraObject(nsId, infraId); err == nil && exists {
+			labels = append(labels, infraInfo.Label)
+		}
+	}
+
+	now := time.Now().UTC()
+	var blocking []string
+	for _, b := range budgets {
+		if !b.Exhausted || b.Enforcement == model.BudgetEnforceNone || overrideActive(b, now) {
+			continue
+		}
+		if suspendOnly && b.Enforcement != model.BudgetEnforceSuspend {
+			continue
+		}
+		if b.PeriodStart != budgetPeriodStart(b.Period, now).Format(time.RFC3339) {
+			// Exhausted in a past period; the next evaluation resets it
+			continue
+		}
+		inScope := b.LabelKey == ""
+		for _, l := range labels {
+			if b.LabelKey != "" && l[b.LabelKey] == b.LabelValue {
+				inScope = true
+			}
+		}
+		if inScope {
+			blocking = append(blocking, fmt.Sprintf("%s (%.2f of %.2f %s)", b.Id, b.Spend, b.Amount, model.CostBaseCurrency))
+		}
+	}
+	return blocking, nil
+}
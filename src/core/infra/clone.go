@@ -0,0 +1,420 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
+	"github.com/rs/zerolog/log"
+)
+
+// Infra clone NodeGroup mapping sources
+const (
+	cloneSourceMatched  = "matched"
+	cloneSourceOverride = "override"
+	cloneSourceSame     = "same"
+)
+
+// PlanInfraClone previews a clone of an Infra in the target region/CSP without creating anything.
+// Each NodeGroup is mapped to an equivalent spec and image by RecommendAlternativeNodeConfig
+// (unless pinned by an override or already in the target location), and the vNet and
+// SecurityGroup of the source are turned into templates that the clone is created with.
+func PlanInfraClone(ctx context.Context, nsId string, infraId string, req *model.InfraCloneReq) (*model.InfraClonePlan, error) {
+	if err := common.CheckString(nsId); err != nil {
+		return nil, err
+	}
+	if err := common.CheckString(infraId); err != nil {
+		return nil, err
+	}
+	if err := common.CheckString(req.Name); err != nil {
+		return nil, apierr.Invalid(fmt.Sprintf("invalid clone name '%s': %v", req.Name, err), nil)
+	}
+	if req.TargetProviderName == "" || req.TargetRegionName == "" {
+		return nil, apierr.Invalid("targetProviderName and targetRegionName are required", nil)
+	}
+	if exists, _ := CheckInfra(nsId, req.Name); exists {
+		return nil, &apierr.StatusError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("Infra '%s' already exists in namespace '%s'", req.Name, nsId)}
+	}
+
+	source, err := ExtractInfraDynamicReqFromInfraInfo(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]model.InfraCloneNodeGroupOverride, len(req.NodeGroups))
+	for _, o := range req.NodeGroups {
+		overrides[o.NodeGroupId] = o
+	}
+
+	plan := &model.InfraClonePlan{
+		SourceNsId:         nsId,
+		SourceInfraId:      infraId,
+		TargetProviderName: req.TargetProviderName,
+		TargetRegionName:   req.TargetRegionName,
+		Ready:              true,
+		NodeGroups:         []model.InfraCloneNodeGroupPlan{},
+	}
+	plan.InfraDynamicReq = model.InfraDynamicReq{
+		Name:            req.Name,
+		InstallMonAgent: source.InstallMonAgent,
+		Label:           source.Label,
+		Description:     fmt.Sprintf("Clone of Infra '%s' (ns: %s) in %s/%s", infraId, nsId, req.TargetProviderName, req.TargetRegionName),
+		PostCommands:    source.PostCommands,
+	}
+	if req.SkipPostCommands {
+		plan.InfraDynamicReq.PostCommands = nil
+	}
+
+	network := newCloneNetworkMapper(nsId, req.Name)
+
+	for _, ng := range source.NodeGroups {
+		ngPlan, target := planCloneNodeGroup(ctx, nsId, req, ng, overrides[ng.Name])
+		if ngPlan.Error != "" {
+			plan.Ready = false
+		}
+		plan.SourceCostPerHour += ngPlan.SourceCostPerHour * float32(ngPlan.NodeGroupSize)
+		plan.TargetCostPerHour += ngPlan.TargetCostPerHour * float32(ngPlan.NodeGroupSize)
+
+		if target.CapacityType == model.CapacityTypeSpot && ngPlan.Source != cloneSourceSame {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("NodeGroup '%s' uses spot capacity; check the spot availability and price of '%s'", ng.Name, ngPlan.TargetSpecId))
+		}
+
+		if !req.SkipNetwork {
+			vNetTemplateId, sgTemplateId, warn := network.mapNodeGroup(infraId, ng.Name)
+			if warn != "" {
+				plan.Warnings = append(plan.Warnings, warn)
+			}
+			ngPlan.VNetTemplateId = vNetTemplateId
+			ngPlan.SgTemplateId = sgTemplateId
+			target.VNetTemplateId = vNetTemplateId
+			target.SgTemplateId = sgTemplateId
+		}
+
+		plan.NodeGroups = append(plan.NodeGroups, ngPlan)
+		plan.InfraDynamicReq.NodeGroups = append(plan.InfraDynamicReq.NodeGroups, target)
+	}
+	plan.VNetTemplates = network.vNetTemplates
+	plan.SgTemplates = network.sgTemplates
+
+	return plan, nil
+}
+
+// planCloneNodeGroup maps a source NodeGroup to the target location and returns its plan and request.
+func planCloneNodeGroup(ctx context.Context, nsId string, req *model.InfraCloneReq, ng model.CreateNodeGroupDynamicReq, override model.InfraCloneNodeGroupOverride) (model.InfraCloneNodeGroupPlan, model.CreateNodeGroupDynamicReq) {
+	ngPlan := model.InfraCloneNodeGroupPlan{
+		NodeGroupId:   ng.Name,
+		NodeGroupSize: ng.NodeGroupSize,
+		SourceSpecId:  ng.SpecId,
+		SourceImageId: ng.ImageId,
+	}
+	target := ng
+	target.ConnectionName = ""
+
+	sourceSpec, err := resource.GetSpec(model.SystemCommonNs, ng.SpecId)
+	if err != nil {
+		ngPlan.Error = fmt.Sprintf("source spec '%s' is not found: %v", ng.SpecId, err)
+		return ngPlan, target
+	}
+	ngPlan.SourceCostPerHour = sourceSpec.CostPerHour
+	sameLocation := strings.EqualFold(sourceSpec.ProviderName, req.TargetProviderName) &&
+		strings.EqualFold(sourceSpec.RegionName, req.TargetRegionName)
+
+	// The source image may be a custom image of the namespace or a common image.
+	osType := req.OSType
+	if osType == "" {
+		osType = resolveOSTypeFromImage(nsId, ng.ImageId)
+	}
+	if osType == "" {
+		osType = resolveOSTypeFromImage(model.SystemCommonNs, ng.ImageId)
+	}
+
+	switch {
+	case override.SpecId != "":
+		targetSpec, err := resource.GetSpec(model.SystemCommonNs, override.SpecId)
+		if err != nil {
+			ngPlan.Error = fmt.Sprintf("override spec '%s' is not found: %v", override.SpecId, err)
+			return ngPlan, target
+		}
+		if !strings.EqualFold(targetSpec.ProviderName, req.TargetProviderName) || !strings.EqualFold(targetSpec.RegionName, req.TargetRegionName) {
+			ngPlan.Error = fmt.Sprintf("override spec '%s' is not in %s/%s", override.SpecId, req.TargetProviderName, req.TargetRegionName)
+			return ngPlan, target
+		}
+		ngPlan.Source = cloneSourceOverride
+		ngPlan.TargetSpecId = targetSpec.Id
+		ngPlan.TargetCostPerHour = targetSpec.CostPerHour
+		diff := buildSpecDiff(sourceSpec, targetSpec)
+		ngPlan.SpecDiff = &diff
+		ngPlan.TargetImageId = override.ImageId
+		if ngPlan.TargetImageId == "" {
+			primary, _ := selectAlternativeImages(model.SystemCommonNs, targetSpec.Id, targetSpec.ProviderName, targetSpec.RegionName,
+				strings.EqualFold(targetSpec.AcceleratorType, "gpu"), osType, 0)
+			if primary != nil {
+				ngPlan.TargetImageId = primary.Id
+			}
+		}
+
+	case sameLocation:
+		ngPlan.Source = cloneSourceSame
+		ngPlan.TargetSpecId = ng.SpecId
+		ngPlan.TargetImageId = ng.ImageId
+		ngPlan.TargetCostPerHour = sourceSpec.CostPerHour
+		if override.ImageId != "" {
+			ngPlan.Source = cloneSourceOverride
+			ngPlan.TargetImageId = override.ImageId
+		}
+
+	default:
+		recommendation, err := RecommendAlternativeNodeConfig(ctx, model.SystemCommonNs, model.RecommendAlternativeNodeConfigReq{
+			SourceSpecId:          ng.SpecId,
+			SourceImageId:         ng.ImageId,
+			TargetProviderName:    req.TargetProviderName,
+			TargetRegionName:      req.TargetRegionName,
+			TolerancePercent:      req.TolerancePercent,
+			MatchCriteria:         req.MatchCriteria,
+			SpecCandidateLimit:    5,
+			ImageAlternativeLimit: 1,
+			OSType:                osType,
+		})
+		if err != nil {
+			ngPlan.Error = fmt.Sprintf("no equivalent spec for '%s' in %s/%s: %v", ng.SpecId, req.TargetProviderName, req.TargetRegionName, err)
+			return ngPlan, target
+		}
+		for _, c := range recommendation.Candidates {
+			if c.PrimaryImage == nil && override.ImageId == "" {
+				continue
+			}
+			ngPlan.Source = cloneSourceMatched
+			ngPlan.TargetSpecId = c.Spec.Id
+			ngPlan.TargetCostPerHour = c.Spec.CostPerHour
+			ngPlan.SimilarityScore = c.SimilarityScore
+			diff := c.SpecDiff
+			ngPlan.SpecDiff = &diff
+			if override.ImageId != "" {
+				ngPlan.TargetImageId = override.ImageId
+			} else {
+				ngPlan.TargetImageId = c.PrimaryImage.Id
+			}
+			break
+		}
+	}
+
+	if ngPlan.TargetSpecId == "" {
+		ngPlan.Error = fmt.Sprintf("no equivalent spec with an image for '%s' in %s/%s; relax matchCriteria or pin a spec", ng.SpecId, req.TargetProviderName, req.TargetRegionName)
+		return ngPlan, target
+	}
+	if ngPlan.TargetImageId == "" {
+		ngPlan.Error = fmt.Sprintf("no image for spec '%s'; pin an imageId for NodeGroup '%s'", ngPlan.TargetSpecId, ng.Name)
+		return ngPlan, target
+	}
+
+	target.SpecId = ngPlan.TargetSpecId
+	target.ImageId = ngPlan.TargetImageId
+	target.Zone = override.Zone
+	if sameLocation && override.Zone == "" {
+		target.Zone = ng.Zone
+	}
+	if !strings.EqualFold(sourceSpec.ProviderName, req.TargetProviderName) {
+		// Disk categories are CSP specific; the CSP default is the equivalent.
+		target.RootDiskType = ""
+	}
+	return ngPlan, target
+}
+
+// cloneNetworkMapper turns the vNets and SecurityGroups of the source Nodes into templates,
+// one template per distinct source vNet and per distinct set of SecurityGroups.
+type cloneNetworkMapper struct {
+	nsId      string
+	cloneName string
+
+	vNetTemplateIds map[string]string
+	sgTemplateIds   map[string]string
+	vNetTemplates   []model.VNetTemplateReq
+	sgTemplates     []model.SecurityGroupTemplateReq
+}
+
+func newCloneNetworkMapper(nsId, cloneName string) *cloneNetworkMapper {
+	return &cloneNetworkMapper{
+		nsId:            nsId,
+		cloneName:       cloneName,
+		vNetTemplateIds: make(map[string]string),
+		sgTemplateIds:   make(map[string]string),
+	}
+}
+
+// mapNodeGroup returns the vNet and SecurityGroup templates of a source NodeGroup.
+// A NodeGroup whose network cannot be read gets the default network and a warning.
+func (m *cloneNetworkMapper) mapNodeGroup(infraId, nodeGroupId string) (string, string, string) {
+	nodeIds, err := ListNodeByNodeGroup(m.nsId, infraId, nodeGroupId)
+	if err != nil || len(nodeIds) == 0 {
+		return "", "", fmt.Sprintf("NodeGroup '%s': cannot read its Nodes, the clone uses the default network", nodeGroupId)
+	}
+	node, err := GetNodeObject(m.nsId, infraId, nodeIds[0])
+	if err != nil {
+		return "", "", fmt.Sprintf("NodeGroup '%s': cannot read Node '%s', the clone uses the default network", nodeGroupId, nodeIds[0])
+	}
+
+	vNet, err := resource.GetVNet(m.nsId, node.VNetId)
+	if err != nil {
+		return "", "", fmt.Sprintf("NodeGroup '%s': cannot read vNet '%s', the clone uses the default network", nodeGroupId, node.VNetId)
+	}
+	vNetTemplateId, ok := m.vNetTemplateIds[node.VNetId]
+	if !ok {
+		vNetTemplateId = fmt.Sprintf("%s-vnet%d", m.cloneName, len(m.vNetTemplates)+1)
+		m.vNetTemplateIds[node.VNetId] = vNetTemplateId
+		m.vNetTemplates = append(m.vNetTemplates, cloneVNetTemplate(vNetTemplateId, vNet))
+	}
+
+	if len(node.SecurityGroupIds) == 0 {
+		return vNetTemplateId, "", ""
+	}
+	sgIds := append([]string(nil), node.SecurityGroupIds...)
+	sort.Strings(sgIds)
+	sgKey := strings.Join(sgIds, ",")
+	sgTemplateId, ok := m.sgTemplateIds[sgKey]
+	if !ok {
+		rules := []model.FirewallRuleReq{}
+		for _, sgId := range sgIds {
+			sg, err := resource.GetSecurityGroup(m.nsId, sgId)
+			if err != nil {
+				return vNetTemplateId, "", fmt.Sprintf("NodeGroup '%s': cannot read SecurityGroup '%s', the clone uses the default firewall rules", nodeGroupId, sgId)
+			}
+			rules = append(rules, cloneFirewallRules(sg.FirewallRules, vNet.CidrBlock)...)
+		}
+		sgTemplateId = fmt.Sprintf("%s-sg%d", m.cloneName, len(m.sgTemplates)+1)
+		m.sgTemplateIds[sgKey] = sgTemplateId
+		m.sgTemplates = append(m.sgTemplates, model.SecurityGroupTemplateReq{
+			Name:        sgTemplateId,
+			Description: fmt.Sprintf("Firewall rules of SecurityGroup %s, cloned for Infra '%s'", sgKey, m.cloneName),
+			SecurityGroupReq: model.SecurityGroupReq{
+				Name:          sgTemplateId,
+				FirewallRules: &rules,
+			},
+		})
+	}
+	return vNetTemplateId, sgTemplateId, ""
+}
+
+// cloneVNetTemplate returns a policy-mode vNet template equivalent to a source vNet:
+// same CIDR block and subnet count, dedicated to the clone.
+func cloneVNetTemplate(templateId string, vNet model.VNetInfo) model.VNetTemplateReq {
+	cidr := "auto"
+	if _, _, err := net.ParseCIDR(vNet.CidrBlock); err == nil {
+		cidr = vNet.CidrBlock
+	}
+	zones := make(map[string]bool)
+	for _, s := range vNet.SubnetInfoList {
+		if s.Zone != "" {
+			zones[s.Zone] = true
+		}
+	}
+	return model.VNetTemplateReq{
+		Name:        templateId,
+		Description: fmt.Sprintf("Equivalent of vNet %s (%s)", vNet.Id, vNet.CidrBlock),
+		VNetPolicy: &model.VNetPolicy{
+			CidrBlock:   cidr,
+			SubnetCount: max(1, min(len(vNet.SubnetInfoList), 2)),
+			MultiZone:   len(zones) > 1,
+			Dedicated:   true,
+		},
+	}
+}
+
+// cloneFirewallRules converts the rules of a source SecurityGroup to rule requests.
+// Rules that allow the source vNet CIDR use the "internal" keyword so that they
+// allow the clone's own vNet instead.
+func cloneFirewallRules(rules []model.FirewallRuleInfo, vNetCidr string) []model.FirewallRuleReq {
+	out := make([]model.FirewallRuleReq, 0, len(rules))
+	for _, r := range rules {
+		cidr := r.CIDR
+		if vNetCidr != "" && cidr == vNetCidr {
+			cidr = model.FirewallCidrKeywordInternal
+		}
+		out = append(out, model.FirewallRuleReq{
+			Ports:     r.Port,
+			Protocol:  r.Protocol,
+			Direction: r.Direction,
+			CIDR:      cidr,
+		})
+	}
+	return out
+}
+
+// ApplyInfraClone creates the network templates of a clone plan and then the cloned Infra.
+// Post commands of the source run on the clone as part of the dynamic creation.
+func ApplyInfraClone(ctx context.Context, nsId string, plan *model.InfraClonePlan) (*model.InfraCloneResult, error) {
+	if !plan.Ready {
+		var reasons []string
+		for _, ng := range plan.NodeGroups {
+			if ng.Error != "" {
+				reasons = append(reasons, fmt.Sprintf("%s: %s", ng.NodeGroupId, ng.Error))
+			}
+		}
+		return nil, apierr.Invalid("the clone plan is not ready: "+strings.Join(reasons, "; "), nil)
+	}
+
+	if err := CreateInfraCloneTemplates(nsId, plan); err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("[Clone] Infra %s/%s -> %s in %s/%s (%d NodeGroups)", plan.SourceNsId, plan.SourceInfraId,
+		plan.InfraDynamicReq.Name, plan.TargetProviderName, plan.TargetRegionName, len(plan.NodeGroups))
+
+	result := &model.InfraCloneResult{Plan: *plan}
+	infraInfo, err := CreateInfraDynamic(ctx, nsId, &plan.InfraDynamicReq, "")
+	if err != nil {
+		return result, err
+	}
+	result.Infra = infraInfo
+	return result, nil
+}
+
+// CreateInfraCloneTemplates stores the vNet and SecurityGroup templates of a clone plan in the namespace.
+// It is also used before a clone is parked for approval, since the approved request refers to the templates.
+func CreateInfraCloneTemplates(nsId string, plan *model.InfraClonePlan) error {
+	// Template names derive from the (new) clone name, so an existing one is a
+	// leftover of an earlier attempt and is overwritten.
+	for i := range plan.VNetTemplates {
+		t := plan.VNetTemplates[i]
+		if _, err := common.GetVNetTemplate(nsId, t.Name); err == nil {
+			_, err = common.UpdateVNetTemplate(nsId, t.Name, &t)
+			if err != nil {
+				return fmt.Errorf("failed to update vNet template '%s': %w", t.Name, err)
+			}
+		} else if _, err := common.CreateVNetTemplate(nsId, &t); err != nil {
+			return fmt.Errorf("failed to create vNet template '%s': %w", t.Name, err)
+		}
+	}
+	for i := range plan.SgTemplates {
+		t := plan.SgTemplates[i]
+		if _, err := common.GetSecurityGroupTemplate(nsId, t.Name); err == nil {
+			_, err = common.UpdateSecurityGroupTemplate(nsId, t.Name, &t)
+			if err != nil {
+				return fmt.Errorf("failed to update SecurityGroup template '%s': %w", t.Name, err)
+			}
+		} else if _, err := common.CreateSecurityGroupTemplate(nsId, &t); err != nil {
+			return fmt.Errorf("failed to create SecurityGroup template '%s': %w", t.Name, err)
+		}
+	}
+	return nil
+}
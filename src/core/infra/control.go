@@ -27,12 +27,14 @@ import (
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	cspdirect "github.com/cloud-barista/cb-tumblebug/src/core/csp"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
 	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
 	"github.com/go-resty/resty/v2"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // globalControlSem limits concurrent Spider control calls (DELETE/action) process-wide.
@@ -43,9 +45,16 @@ var globalControlSem = make(chan struct{}, 50)
 // Infra Control
 
 // HandleInfraAction is func to handle actions to Infra
-func HandleInfraAction(nsId string, infraId string, action string, force bool) (string, error) {
+func HandleInfraAction(ctx context.Context, nsId string, infraId string, action string, force bool) (_ string, err error) {
 	action = common.ToLower(action)
 
+	ctx, span := tracing.Start(ctx, "infra.control",
+		attribute.String("ns.id", nsId),
+		attribute.String("infra.id", infraId),
+		attribute.String("action", action),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	// err := common.CheckString(nsId)
 	// if err != nil {
 	// 	log.Error().Err(err).Msg("")
@@ -68,7 +77,7 @@ func HandleInfraAction(nsId string, infraId string, action string, force bool) (
 
 	if action == "suspend" {
 
-		err := ControlInfraAsync(nsId, infraId, model.ActionSuspend, force)
+		err := ControlInfraAsync(ctx, nsId, infraId, model.ActionSuspend, force)
 		if err != nil {
 			return "", err
 		}
@@ -77,7 +86,10 @@ func HandleInfraAction(nsId string, infraId string, action string, force bool) (
 
 	} else if action == "resume" {
 
-		err := ControlInfraAsync(nsId, infraId, model.ActionResume, force)
+		if err := checkBudgetResume(nsId, infraId); err != nil {
+			return "", err
+		}
+		err := ControlInfraAsync(ctx, nsId, infraId, model.ActionResume, force)
 		if err != nil {
 			return "", err
 		}
@@ -86,7 +98,7 @@ func HandleInfraAction(nsId string, infraId string, action string, force bool) (
 
 	} else if action == "reboot" {
 
-		err := ControlInfraAsync(nsId, infraId, model.ActionReboot, force)
+		err := ControlInfraAsync(ctx, nsId, infraId, model.ActionReboot, force)
 		if err != nil {
 			return "", err
 		}
@@ -105,7 +117,7 @@ func HandleInfraAction(nsId string, infraId string, action string, force bool) (
 			return "No Node to terminate in the Infra", nil
 		}
 
-		err = ControlInfraAsync(nsId, infraId, model.ActionTerminate, force)
+		err = ControlInfraAsync(ctx, nsId, infraId, model.ActionTerminate, force)
 		if err != nil {
 			return "", err
 		}
@@ -256,9 +268,17 @@ func HandleInfraAction(nsId string, infraId string, action string, force bool) (
 }
 
 // HandleInfraNodeAction is func to Get InfraNode Action
-func HandleInfraNodeAction(nsId string, infraId string, nodeId string, action string, force bool) (string, error) {
+func HandleInfraNodeAction(ctx context.Context, nsId string, infraId string, nodeId string, action string, force bool, specId string) (_ string, err error) {
 
-	err := common.CheckString(nsId)
+	ctx, span := tracing.Start(ctx, "node.control",
+		attribute.String("ns.id", nsId),
+		attribute.String("infra.id", infraId),
+		attribute.String("node.id", nodeId),
+		attribute.String("action", action),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	err = common.CheckString(nsId)
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return "", err
@@ -299,6 +319,22 @@ func HandleInfraNodeAction(nsId string, infraId string, nodeId string, action st
 		}
 	}
 
+	// Resize changes the spec of the Node in place (stop → resize → start) and
+	// validates the Node status by itself.
+	if strings.EqualFold(action, model.ActionResize) {
+		return resizeInfraNode(nsId, infraId, nodeId, specId)
+	}
+
+	if strings.EqualFold(action, model.ActionResume) {
+		node, err := GetNodeObject(nsId, infraId, nodeId)
+		if err != nil {
+			return "", err
+		}
+		if err := checkBudgetResume(nsId, infraId, node.Label); err != nil {
+			return "", err
+		}
+	}
+
 	err = CheckAllowedTransition(nsId, infraId, model.OptionalParameter{Set: true, Value: nodeId}, action)
 	if err != nil {
 		if !force {
@@ -324,13 +360,13 @@ func HandleInfraNodeAction(nsId string, infraId string, nodeId string, action st
 	results := make(chan model.ControlNodeResult, 1)
 	wg.Add(1)
 	if strings.EqualFold(action, model.ActionSuspend) {
-		go ControlNodeAsync(&wg, nsId, infraId, nodeId, model.ActionSuspend, results)
+		go ControlNodeAsync(ctx, &wg, nsId, infraId, nodeId, model.ActionSuspend, results)
 	} else if strings.EqualFold(action, model.ActionResume) {
-		go ControlNodeAsync(&wg, nsId, infraId, nodeId, model.ActionResume, results)
+		go ControlNodeAsync(ctx, &wg, nsId, infraId, nodeId, model.ActionResume, results)
 	} else if strings.EqualFold(action, model.ActionReboot) {
-		go ControlNodeAsync(&wg, nsId, infraId, nodeId, model.ActionReboot, results)
+		go ControlNodeAsync(ctx, &wg, nsId, infraId, nodeId, model.ActionReboot, results)
 	} else if strings.EqualFold(action, model.ActionTerminate) {
-		go ControlNodeAsync(&wg, nsId, infraId, nodeId, model.ActionTerminate, results)
+		go ControlNodeAsync(ctx, &wg, nsId, infraId, nodeId, model.ActionTerminate, results)
 	} else {
 		close(results)
 		wg.Done()
@@ -345,7 +381,7 @@ func HandleInfraNodeAction(nsId string, infraId string, nodeId string, action st
 }
 
 // ControlInfraAsync is func to control Infra async
-func ControlInfraAsync(nsId string, infraId string, action string, force bool) error {
+func ControlInfraAsync(ctx context.Context, nsId string, infraId string, action string, force bool) error {
 
 	infra, _, err := GetInfraObject(nsId, infraId)
 	if err != nil {
@@ -409,7 +445,7 @@ func ControlInfraAsync(nsId string, infraId string, action string, force bool) e
 	UpdateInfraInfo(nsId, infra)
 
 	// Apply CSP-aware rate limiting for Node control operations
-	err = ControlNodesInParallel(nsId, infraId, nodeList, action, force)
+	err = ControlNodesInParallel(ctx, nsId, infraId, nodeList, action, force)
 	if err != nil {
 		log.Error().Err(err).Msgf("Failed to control Nodes in parallel for action %s", action)
 		// Re-fetch and clear TargetAction so future operations are not permanently blocked.
@@ -477,7 +513,7 @@ func getNodeControlRateLimitsForCSP(cspName string) (maxRegions, maxNodesPerRegi
 // Level 1: CSPs are processed in parallel
 // Level 2: Within each CSP, regions are processed with semaphore (maxConcurrentRegionsPerCSP)
 // Level 3: Within each region, VMs are processed with semaphore (maxConcurrentNodesPerRegion)
-func ControlNodesInParallel(nsId, infraId string, nodeList []string, action string, force bool) error {
+func ControlNodesInParallel(ctx context.Context, nsId, infraId string, nodeList []string, action string, force bool) error {
 	if len(nodeList) == 0 {
 		return nil
 	}
@@ -641,7 +677,7 @@ func ControlNodesInParallel(nsId, infraId string, nodeList []string, action stri
 							// Add delay to avoid overwhelming CSP APIs
 							common.RandomSleep(0, 1000)
 
-							go ControlNodeAsync(&controlWg, nsId, infraId, nodeId, action, results)
+							go ControlNodeAsync(ctx, &controlWg, nsId, infraId, nodeId, action, results)
 
 							result := <-results
 							close(results)
@@ -972,7 +1008,7 @@ func asyncSpiderForceDeleteVM(connectionName, cspResourceName string) {
 }
 
 // ControlNodeAsync is func to control VM async
-func ControlNodeAsync(wg *sync.WaitGroup, nsId string, infraId string, nodeId string, action string, results chan<- model.ControlNodeResult) {
+func ControlNodeAsync(ctx context.Context, wg *sync.WaitGroup, nsId string, infraId string, nodeId string, action string, results chan<- model.ControlNodeResult) {
 	defer wg.Done() //goroutine sync done
 
 	var err error
@@ -981,6 +1017,14 @@ func ControlNodeAsync(wg *sync.WaitGroup, nsId string, infraId string, nodeId st
 	callResult.NodeId = nodeId
 	callResult.Status = ""
 
+	ctx, span := tracing.Start(ctx, "node.controlAsync",
+		attribute.String("ns.id", nsId),
+		attribute.String("infra.id", infraId),
+		attribute.String("node.id", nodeId),
+		attribute.String("action", action),
+	)
+	defer func() { tracing.End(span, callResult.Error) }()
+
 	// Use GetNodeObject to get VM information
 	temp, err := GetNodeObject(nsId, infraId, nodeId)
 	if err != nil {
@@ -1186,7 +1230,8 @@ func ControlNodeAsync(wg *sync.WaitGroup, nsId string, infraId string, nodeId st
 				}
 			}
 		} else {
-			_, err = clientManager.ExecuteHttpRequest(
+			_, err = clientManager.ExecuteHttpRequestWithContext(
+				ctx,
 				client,
 				method,
 				url,
@@ -1973,7 +2018,7 @@ func reconcileInfraBackward(nsId, infraId string) (string, error) {
 	if len(readyIds) > 0 {
 		// force=true bypasses both per-Node transient guard and the Infra
 		// TargetAction guard inside the parallel control path.
-		if cerr := ControlNodesInParallel(nsId, infraId, readyIds, model.ActionTerminate, true); cerr != nil {
+		if cerr := ControlNodesInParallel(context.Background(), nsId, infraId, readyIds, model.ActionTerminate, true); cerr != nil {
 			log.Warn().Err(cerr).Msgf("reconcileInfraBackward: parallel terminate reported errors")
 		}
 	}
@@ -1985,7 +2030,7 @@ func reconcileInfraBackward(nsId, infraId string) (string, error) {
 	// Nodes (still Terminating) are never touched.
 	cleanedCount := 0
 	if markedFailed > 0 {
-		if _, rerr := HandleInfraAction(nsId, infraId, model.ActionRefine, true); rerr != nil {
+		if _, rerr := HandleInfraAction(context.Background(), nsId, infraId, model.ActionRefine, true); rerr != nil {
 			log.Warn().Err(rerr).Msgf("reconcileInfraBackward: refine cleanup during abort failed")
 		} else {
 			cleanedCount = markedFailed
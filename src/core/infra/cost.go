@@ -0,0 +1,508 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
+	"github.com/rs/zerolog/log"
+)
+
+// Cost estimation.
+//
+// Node prices come from the spec of each Node (SpecSummary for running Nodes, SpecInfo for
+// requests), which is already in the base currency. Disks, NLBs and K8s control planes have
+// no spec; they are priced from assets/costinfo.yaml when the provider has an entry there and
+// are otherwise reported as unpriced items, which makes the estimate partial.
+
+const (
+	costNoteUsageExcluded = "Usage-based charges (network traffic, requests, IOPS, ...) are not included"
+	costNoteDefaultDisk   = "Root disks of the CSP default size are not included"
+)
+
+// specCostPerHour returns the hourly price of a Node of a spec: the spot price for spot
+// capacity when known, the on-demand price otherwise.
+func specCostPerHour(specInfo model.SpecInfo, capacityType string) float64 {
+	if capacityType == model.CapacityTypeSpot && specInfo.SpotCostPerHour > 0 {
+		return float64(specInfo.SpotCostPerHour)
+	}
+	return float64(specInfo.CostPerHour)
+}
+
+// newCostEstimate returns an empty estimate in the base currency.
+func newCostEstimate(scope, nsId, targetId string) model.CostEstimate {
+	return model.CostEstimate{
+		Scope:    scope,
+		NsId:     nsId,
+		TargetId: targetId,
+		Currency: model.CostBaseCurrency,
+		Items:    []model.CostItem{},
+	}
+}
+
+// addCostItem appends an item to the estimate and adds it to the totals when it is priced.
+func addCostItem(est *model.CostEstimate, item model.CostItem) {
+	if item.Count == 0 {
+		item.Count = 1
+	}
+	if item.Priced {
+		item.CostPerHour = roundCost(item.CostPerHour)
+		item.CostPerMonth = roundCost(item.CostPerHour * model.CostHoursPerMonth)
+		est.CostPerHour += item.CostPerHour
+	} else {
+		item.CostPerHour, item.CostPerMonth = 0, 0
+		est.Partial = true
+		est.UnpricedItems++
+	}
+	est.Items = append(est.Items, item)
+}
+
+// addCostNote adds a note to the estimate once.
+func addCostNote(est *model.CostEstimate, note string) {
+	for _, n := range est.Notes {
+		if n == note {
+			return
+		}
+	}
+	est.Notes = append(est.Notes, note)
+}
+
+// finalizeCostEstimate rounds the totals and, unless groupKind is empty, builds one group of
+// groupKind per item GroupId.
+func finalizeCostEstimate(est *model.CostEstimate, groupKind string) {
+	est.CostPerHour = roundCost(est.CostPerHour)
+	est.CostPerMonth = roundCost(est.CostPerHour * model.CostHoursPerMonth)
+	addCostNote(est, costNoteUsageExcluded)
+	if groupKind == "" {
+		return
+	}
+
+	index := map[string]int{}
+	for _, item := range est.Items {
+		if item.GroupId == "" {
+			continue
+		}
+		i, ok := index[item.GroupId]
+		if !ok {
+			i = len(est.Groups)
+			index[item.GroupId] = i
+			est.Groups = append(est.Groups, model.CostGroup{Kind: groupKind, Id: item.GroupId})
+		}
+		est.Groups[i].CostPerHour += item.CostPerHour
+		if !item.Priced {
+			est.Groups[i].Partial = true
+		}
+	}
+	for i := range est.Groups {
+		est.Groups[i].CostPerHour = roundCost(est.Groups[i].CostPerHour)
+		est.Groups[i].CostPerMonth = roundCost(est.Groups[i].CostPerHour * model.CostHoursPerMonth)
+	}
+}
+
+// roundCost rounds a cost to 4 decimal places.
+func roundCost(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
+
+// diskCostItem prices a disk of sizeGiB from the reference disk prices of the provider.
+func diskCostItem(kind, id, groupId, providerName, regionName, diskType string, sizeGiB, count int) model.CostItem {
+	if diskType == "" {
+		diskType = "default"
+	}
+	item := model.CostItem{
+		Kind:         kind,
+		Id:           id,
+		GroupId:      groupId,
+		ProviderName: providerName,
+		RegionName:   regionName,
+		Detail:       fmt.Sprintf("%s %dGiB", diskType, sizeGiB),
+		Count:        count,
+	}
+	pricePerGiBMonth, ok := common.GetDiskCostPerGiBMonth(providerName, diskType)
+	if !ok {
+		item.Note = fmt.Sprintf("no price data for disk type '%s' of %s", diskType, providerName)
+		return item
+	}
+	item.Priced = true
+	item.CostPerHour = pricePerGiBMonth * float64(sizeGiB) * float64(max(count, 1)) / model.CostHoursPerMonth
+	return item
+}
+
+// specCostItem prices count Nodes of a spec; spec lookup tries nsId first, then the system namespace.
+func specCostItem(kind, id, groupId, nsId, specId, capacityType string, count int) (model.CostItem, model.SpecInfo, bool) {
+	item := model.CostItem{Kind: kind, Id: id, GroupId: groupId, Detail: specId, Count: count}
+	specInfo, err := resource.GetSpec(nsId, specId)
+	if err != nil && nsId != model.SystemCommonNs {
+		specInfo, err = resource.GetSpec(model.SystemCommonNs, specId)
+	}
+	if err != nil {
+		item.Note = fmt.Sprintf("spec '%s' is not found", specId)
+		return item, specInfo, false
+	}
+	item.ProviderName = specInfo.ProviderName
+	item.RegionName = specInfo.RegionName
+	item.Detail = specInfo.CspSpecName
+
+	price := specCostPerHour(specInfo, capacityType)
+	switch {
+	case price <= 0:
+		item.Note = fmt.Sprintf("no price data for spec '%s'", specId)
+		return item, specInfo, true
+	case capacityType == model.CapacityTypeSpot && specInfo.SpotCostPerHour > 0:
+		item.Note = "spot price"
+	case capacityType == model.CapacityTypeSpot:
+		item.Note = "spot price unknown; on-demand price used"
+	}
+	item.Priced = true
+	item.CostPerHour = price * float64(max(count, 1))
+	return item, specInfo, true
+}
+
+// loadNsDataDisks returns the data disks of a namespace by ID.
+func loadNsDataDisks(nsId string) (map[string]model.DataDiskInfo, error) {
+	disks := map[string]model.DataDiskInfo{}
+	list, err := resource.ListResource(nsId, model.StrDataDisk, "", "")
+	if err != nil {
+		return disks, err
+	}
+	if dataDisks, ok := list.([]model.DataDiskInfo); ok {
+		for _, d := range dataDisks {
+			disks[d.Id] = d
+		}
+	}
+	return disks, nil
+}
+
+// GetInfraCost returns the running cost of an Infra: Nodes (suspended Nodes only keep their disks),
+// root disks of a known size, attached data disks and NLBs, with a subtotal per NodeGroup.
+func GetInfraCost(nsId, infraId string) (model.CostEstimate, error) {
+	est := newCostEstimate(model.CostScopeInfra, nsId, infraId)
+	dataDisks, err := loadNsDataDisks(nsId)
+	if err != nil {
+		return est, err
+	}
+	if err := addInfraCostItems(&est, nsId, infraId, dataDisks, map[string]bool{}); err != nil {
+		return est, err
+	}
+	finalizeCostEstimate(&est, "nodeGroup")
+	return est, nil
+}
+
+// addInfraCostItems adds the cost items of an Infra to est. countedDisks collects the data disks
+// already priced, so that a namespace estimate can price the unattached ones separately.
+func addInfraCostItems(est *model.CostEstimate, nsId, infraId string, dataDisks map[string]model.DataDiskInfo, countedDisks map[string]bool) error {
+	nodes, err := ListInfraNodeInfo(nsId, infraId)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if node.Status == model.StatusTerminated {
+			continue
+		}
+		providerName := node.ConnectionConfig.ProviderName
+		regionName := node.ConnectionConfig.RegionDetail.RegionName
+
+		item := model.CostItem{
+			Kind:         model.CostItemNode,
+			Id:           node.Id,
+			GroupId:      node.NodeGroupId,
+			ProviderName: providerName,
+			RegionName:   regionName,
+			Detail:       node.CspSpecName,
+		}
+		switch price := nodeCostPerHour(node); {
+		case node.Status == model.StatusSuspended:
+			item.Priced = true
+			item.Note = "suspended; compute is not billed"
+		case price > 0:
+			item.Priced = true
+			item.CostPerHour = price
+			if node.CapacityType == model.CapacityTypeSpot {
+				item.Note = "spot"
+			}
+		default:
+			item.Note = fmt.Sprintf("no price data for spec '%s'", node.SpecId)
+		}
+		addCostItem(est, item)
+
+		if node.RootDiskSize > 0 {
+			addCostItem(est, diskCostItem(model.CostItemRootDisk, node.Id, node.NodeGroupId, providerName, regionName, node.RootDiskType, node.RootDiskSize, 1))
+		} else {
+			addCostNote(est, costNoteDefaultDisk)
+		}
+
+		for _, diskId := range node.DataDiskIds {
+			if countedDisks[diskId] {
+				continue
+			}
+			countedDisks[diskId] = true
+			disk, ok := dataDisks[diskId]
+			if !ok {
+				addCostItem(est, model.CostItem{Kind: model.CostItemDataDisk, Id: diskId, GroupId: node.NodeGroupId,
+					ProviderName: providerName, RegionName: regionName, Note: "data disk object is not found"})
+				continue
+			}
+			addCostItem(est, diskCostItem(model.CostItemDataDisk, diskId, node.NodeGroupId, providerName, regionName, disk.DiskType, disk.DiskSize, 1))
+		}
+	}
+
+	nlbIds, err := ListNLBId(nsId, infraId)
+	if err != nil {
+		return err
+	}
+	for _, nlbId := range nlbIds {
+		nlb, err := GetNLB(nsId, infraId, nlbId)
+		if err != nil {
+			log.Warn().Err(err).Msgf("cannot get NLB %s of Infra %s for cost estimation", nlbId, infraId)
+			continue
+		}
+		addCostItem(est, nlbCostItem(nlb))
+	}
+	return nil
+}
+
+// nlbCostItem prices an NLB from the reference NLB price of its provider.
+func nlbCostItem(nlb model.NLBInfo) model.CostItem {
+	providerName := nlb.ConnectionConfig.ProviderName
+	item := model.CostItem{
+		Kind:         model.CostItemNLB,
+		Id:           nlb.Id,
+		ProviderName: providerName,
+		RegionName:   nlb.ConnectionConfig.RegionDetail.RegionName,
+		Detail:       strings.TrimSpace(nlb.Type + " " + nlb.Scope),
+	}
+	price, ok := common.GetNLBCostPerHour(providerName)
+	if !ok {
+		item.Note = fmt.Sprintf("no price data for NLBs of %s", providerName)
+		return item
+	}
+	item.Priced = true
+	item.CostPerHour = price
+	return item
+}
+
+// GetK8sClusterCost returns the running cost of a K8sCluster: the control plane and the Nodes
+// of each K8sNodeGroup (current Nodes, or the desired size when the Nodes are not listed yet).
+func GetK8sClusterCost(nsId, k8sClusterId string) (model.CostEstimate, error) {
+	est := newCostEstimate(model.CostScopeK8sCluster, nsId, k8sClusterId)
+	k8sCluster, err := resource.GetK8sCluster(nsId, k8sClusterId)
+	if err != nil {
+		return est, err
+	}
+	addK8sClusterCostItems(&est, nsId, k8sCluster)
+	finalizeCostEstimate(&est, "k8sNodeGroup")
+	return est, nil
+}
+
+// addK8sClusterCostItems adds the cost items of a K8sCluster to est.
+func addK8sClusterCostItems(est *model.CostEstimate, nsId string, k8sCluster *model.K8sClusterInfo) {
+	providerName := k8sCluster.ConnectionConfig.ProviderName
+	regionName := k8sCluster.ConnectionConfig.RegionDetail.RegionName
+	addCostItem(est, k8sControlPlaneCostItem(k8sCluster.Id, providerName, regionName))
+
+	for _, ng := range k8sCluster.K8sNodeGroupList {
+		count := len(ng.K8sNodes)
+		if count == 0 {
+			count = ng.DesiredNodeSize
+		}
+		if count == 0 {
+			continue
+		}
+		item, _, _ := specCostItem(model.CostItemK8sNode, ng.Id, ng.Id, nsId, ng.SpecId, "", count)
+		addCostItem(est, item)
+		if ng.RootDiskSize > 0 {
+			addCostItem(est, diskCostItem(model.CostItemRootDisk, ng.Id, ng.Id, providerName, regionName, ng.RootDiskType, ng.RootDiskSize, count))
+		} else {
+			addCostNote(est, costNoteDefaultDisk)
+		}
+	}
+}
+
+// k8sControlPlaneCostItem prices a managed Kubernetes control plane of a provider.
+func k8sControlPlaneCostItem(id, providerName, regionName string) model.CostItem {
+	item := model.CostItem{
+		Kind:         model.CostItemK8sControlPlane,
+		Id:           id,
+		ProviderName: providerName,
+		RegionName:   regionName,
+	}
+	price, ok := common.GetK8sControlPlaneCostPerHour(providerName)
+	if !ok {
+		item.Note = fmt.Sprintf("no price data for the K8s control plane of %s", providerName)
+		return item
+	}
+	item.Priced = true
+	item.CostPerHour = price
+	if price == 0 {
+		item.Note = "free tier"
+	}
+	return item
+}
+
+// GetNsCost returns the running cost of a namespace: every Infra and K8sCluster, plus the data
+// disks not attached to any Node, with a subtotal per Infra and K8sCluster.
+func GetNsCost(nsId string) (model.CostEstimate, error) {
+	est := newCostEstimate(model.CostScopeNamespace, nsId, "")
+	if err := common.CheckString(nsId); err != nil {
+		return est, apierr.Invalid(err.Error(), err)
+	}
+	dataDisks, err := loadNsDataDisks(nsId)
+	if err != nil {
+		return est, err
+	}
+	countedDisks := map[string]bool{}
+
+	infraIds, err := ListInfraId(nsId)
+	if err != nil {
+		return est, err
+	}
+	for _, infraId := range infraIds {
+		sub := newCostEstimate(model.CostScopeInfra, nsId, infraId)
+		if err := addInfraCostItems(&sub, nsId, infraId, dataDisks, countedDisks); err != nil {
+			return est, err
+		}
+		mergeCostItems(&est, sub, "infra", infraId)
+	}
+
+	k8sClusterIds, err := resource.ListK8sClusterId(nsId)
+	if err != nil {
+		return est, err
+	}
+	for _, k8sClusterId := range k8sClusterIds {
+		k8sCluster, err := resource.GetK8sCluster(nsId, k8sClusterId)
+		if err != nil {
+			log.Warn().Err(err).Msgf("cannot get K8sCluster %s for cost estimation", k8sClusterId)
+			continue
+		}
+		sub := newCostEstimate(model.CostScopeK8sCluster, nsId, k8sClusterId)
+		addK8sClusterCostItems(&sub, nsId, k8sCluster)
+		mergeCostItems(&est, sub, "k8sCluster", k8sClusterId)
+	}
+
+	for diskId, disk := range dataDisks {
+		if countedDisks[diskId] {
+			continue
+		}
+		addCostItem(&est, diskCostItem(model.CostItemDataDisk, diskId, "", disk.ConnectionConfig.ProviderName,
+			disk.ConnectionConfig.RegionDetail.RegionName, disk.DiskType, disk.DiskSize, 1))
+	}
+
+	finalizeCostEstimate(&est, "")
+	return est, nil
+}
+
+// mergeCostItems moves the items and notes of sub into est and adds sub as a group of est.
+func mergeCostItems(est *model.CostEstimate, sub model.CostEstimate, groupKind, groupId string) {
+	for _, item := range sub.Items {
+		item.GroupId = groupId
+		addCostItem(est, item)
+	}
+	for _, note := range sub.Notes {
+		addCostNote(est, note)
+	}
+	costPerHour := roundCost(sub.CostPerHour)
+	est.Groups = append(est.Groups, model.CostGroup{
+		Kind:         groupKind,
+		Id:           groupId,
+		CostPerHour:  costPerHour,
+		CostPerMonth: roundCost(costPerHour * model.CostHoursPerMonth),
+		Partial:      sub.Partial,
+	})
+}
+
+// EstimateInfraDynamicReqCost estimates the cost of an Infra dynamic request with a subtotal per NodeGroup.
+func EstimateInfraDynamicReqCost(nsId string, req *model.InfraDynamicReq) (model.CostEstimate, error) {
+	est := newCostEstimate(model.CostScopeInfraDynamicReq, nsId, req.Name)
+	if len(req.NodeGroups) == 0 {
+		return est, apierr.Invalid("nodeGroups is required for cost estimation", nil)
+	}
+	for i := range req.NodeGroups {
+		addNodeGroupReqCostItems(&est, &req.NodeGroups[i], i)
+	}
+	finalizeCostEstimate(&est, "nodeGroup")
+	return est, nil
+}
+
+// EstimateNodeGroupDynamicReqCost estimates the cost added to an Infra by a NodeGroup dynamic request.
+func EstimateNodeGroupDynamicReqCost(nsId, infraId string, req *model.CreateNodeGroupDynamicReq) (model.CostEstimate, error) {
+	est := newCostEstimate(model.CostScopeNodeGroupReq, nsId, infraId)
+	exists, err := CheckInfra(nsId, infraId)
+	if err != nil {
+		return est, err
+	}
+	if !exists {
+		return est, &apierr.StatusError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("Infra '%s' does not exist", infraId)}
+	}
+	addNodeGroupReqCostItems(&est, req, 0)
+	finalizeCostEstimate(&est, "nodeGroup")
+	return est, nil
+}
+
+// addNodeGroupReqCostItems adds the Nodes and root disks of a NodeGroup dynamic request to est.
+func addNodeGroupReqCostItems(est *model.CostEstimate, ng *model.CreateNodeGroupDynamicReq, index int) {
+	groupId := ng.Name
+	if groupId == "" {
+		groupId = fmt.Sprintf("nodeGroup-%d", index+1)
+	}
+	size := max(ng.NodeGroupSize, 1)
+	item, specInfo, found := specCostItem(model.CostItemNode, groupId, groupId, model.SystemCommonNs, ng.SpecId, ng.CapacityType, size)
+	addCostItem(est, item)
+	if !found {
+		return
+	}
+	if ng.RootDiskSize > 0 {
+		addCostItem(est, diskCostItem(model.CostItemRootDisk, groupId, groupId, specInfo.ProviderName, specInfo.RegionName, ng.RootDiskType, ng.RootDiskSize, size))
+	} else {
+		addCostNote(est, costNoteDefaultDisk)
+	}
+}
+
+// EstimateK8sClusterDynamicReqCost estimates the cost of a K8sCluster dynamic request:
+// the control plane and the desired Nodes of the initial K8sNodeGroup.
+func EstimateK8sClusterDynamicReqCost(nsId string, req *model.K8sClusterDynamicReq) (model.CostEstimate, error) {
+	est := newCostEstimate(model.CostScopeK8sClusterReq, nsId, req.Name)
+	if req.SpecId == "" {
+		return est, apierr.Invalid("specId is required for cost estimation", nil)
+	}
+	groupId := req.NodeGroupName
+	if groupId == "" {
+		groupId = "k8sNodeGroup-1"
+	}
+	count := req.DesiredNodeSize
+	if count == 0 {
+		count = max(req.MinNodeSize, 1)
+	}
+
+	item, specInfo, found := specCostItem(model.CostItemK8sNode, groupId, groupId, nsId, req.SpecId, "", count)
+	if !found {
+		return est, apierr.Invalid(fmt.Sprintf("spec '%s' is not found", req.SpecId), nil)
+	}
+	addCostItem(&est, k8sControlPlaneCostItem(req.Name, specInfo.ProviderName, specInfo.RegionName))
+	addCostItem(&est, item)
+	if req.RootDiskSize > 0 {
+		addCostItem(&est, diskCostItem(model.CostItemRootDisk, groupId, groupId, specInfo.ProviderName, specInfo.RegionName, req.RootDiskType, req.RootDiskSize, count))
+	} else {
+		addCostNote(&est, costNoteDefaultDisk)
+	}
+	finalizeCostEstimate(&est, "k8sNodeGroup")
+	return est, nil
+}
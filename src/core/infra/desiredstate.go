@@ -0,0 +1,480 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/rs/zerolog/log"
+)
+
+// Declarative Infra management: a desired state (InfraDynamicReq) is compared with
+// the current Infra and the difference is applied with the imperative operations
+// (CreateInfraDynamic, CreateInfraNodeGroupDynamic, ScaleOutInfraNodeGroup, DelInfraNode).
+// Applying the same desired state twice is a no-op.
+//
+// Labels in the desired state are set; labels that are not in it are left as they are,
+// since labels are also written by other means (label API, CSP tag sync).
+// Spec, image, disk and placement changes of an existing NodeGroup are reported as
+// warnings and not applied (they need the Nodes to be replaced).
+
+// infraDesiredStateLock serializes apply per Infra
+var infraDesiredStateLock sync.Map
+
+// PlanInfraDesiredState computes the changes needed to bring an Infra to the desired state
+func PlanInfraDesiredState(nsId string, infraId string, req *model.InfraDynamicReq) (*model.InfraDesiredStatePlan, error) {
+	if err := common.CheckString(nsId); err != nil {
+		return nil, err
+	}
+	if err := common.CheckString(infraId); err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		req.Name = infraId
+	}
+	if req.Name != infraId {
+		return nil, apierr.Invalid(fmt.Sprintf("name '%s' in the desired state does not match the Infra ID '%s'", req.Name, infraId), nil)
+	}
+	if len(req.NodeGroups) == 0 {
+		return nil, apierr.Invalid("the desired state has no NodeGroup", nil)
+	}
+	seen := map[string]bool{}
+	for _, ng := range req.NodeGroups {
+		if ng.Name == "" {
+			return nil, apierr.Invalid("every NodeGroup in the desired state needs a name (it identifies the NodeGroup across applies)", nil)
+		}
+		if seen[ng.Name] {
+			return nil, apierr.Invalid(fmt.Sprintf("NodeGroup '%s' appears more than once in the desired state", ng.Name), nil)
+		}
+		seen[ng.Name] = true
+	}
+	if err := ValidatePostCommandRequest(req.PostCommands); err != nil {
+		return nil, apierr.Invalid(err.Error(), nil)
+	}
+
+	plan := &model.InfraDesiredStatePlan{NsId: nsId, InfraId: infraId, Changes: []model.InfraDesiredStateChange{}}
+
+	exists, err := CheckInfra(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		nodeCount := 0
+		for _, ng := range req.NodeGroups {
+			nodeCount += max(ng.NodeGroupSize, 1)
+		}
+		plan.Changes = append(plan.Changes, model.InfraDesiredStateChange{
+			Action:       model.DesiredActionCreateInfra,
+			DesiredSize:  nodeCount,
+			PostCommands: len(req.PostCommands) > 0,
+			Description:  fmt.Sprintf("create Infra '%s' with %d NodeGroups (%d Nodes)", infraId, len(req.NodeGroups), nodeCount),
+		})
+		return plan, nil
+	}
+	plan.InfraExists = true
+
+	infraInfo, err := GetInfraInfo(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Current Nodes by NodeGroup (same grouping as ExtractInfraDynamicReqFromInfraInfo)
+	currentNodes := map[string][]model.NodeInfo{}
+	var currentOrder []string
+	for _, node := range infraInfo.Node {
+		ngId := node.NodeGroupId
+		if ngId == "" {
+			ngId = node.Id
+		}
+		if _, ok := currentNodes[ngId]; !ok {
+			currentOrder = append(currentOrder, ngId)
+		}
+		currentNodes[ngId] = append(currentNodes[ngId], node)
+	}
+
+	var scaleOuts, labelUpdates, scaleIns, removals []model.InfraDesiredStateChange
+
+	for _, ng := range req.NodeGroups {
+		desiredSize := max(ng.NodeGroupSize, 1)
+		nodes, ok := currentNodes[ng.Name]
+		if !ok {
+			plan.Changes = append(plan.Changes, model.InfraDesiredStateChange{
+				Action:       model.DesiredActionAddNodeGroup,
+				NodeGroupId:  ng.Name,
+				DesiredSize:  desiredSize,
+				PostCommands: len(postCommandsForNodeGroup(req.PostCommands, ng.Name)) > 0,
+				Description:  fmt.Sprintf("add NodeGroup '%s' with %d Nodes", ng.Name, desiredSize),
+			})
+			continue
+		}
+
+		plan.Warnings = append(plan.Warnings, nodeGroupDriftWarnings(ng, nodes[0])...)
+
+		var removed []string
+		switch {
+		case desiredSize > len(nodes):
+			scaleOuts = append(scaleOuts, model.InfraDesiredStateChange{
+				Action:       model.DesiredActionScaleOut,
+				NodeGroupId:  ng.Name,
+				CurrentSize:  len(nodes),
+				DesiredSize:  desiredSize,
+				PostCommands: len(postCommandsForNodeGroup(req.PostCommands, ng.Name)) > 0,
+				Description:  fmt.Sprintf("scale out NodeGroup '%s' from %d to %d Nodes", ng.Name, len(nodes), desiredSize),
+			})
+		case desiredSize < len(nodes):
+			removed = nodesToScaleIn(nodes, len(nodes)-desiredSize)
+			scaleIns = append(scaleIns, model.InfraDesiredStateChange{
+				Action:      model.DesiredActionScaleIn,
+				NodeGroupId: ng.Name,
+				CurrentSize: len(nodes),
+				DesiredSize: desiredSize,
+				NodeIds:     removed,
+				Description: fmt.Sprintf("scale in NodeGroup '%s' from %d to %d Nodes (remove %s)", ng.Name, len(nodes), desiredSize, strings.Join(removed, ", ")),
+			})
+		}
+
+		// Nodes that are kept and miss some of the desired labels
+		var relabel []string
+		for _, node := range nodes {
+			if !slices.Contains(removed, node.Id) && len(missingLabels(node.Label, ng.Label)) > 0 {
+				relabel = append(relabel, node.Id)
+			}
+		}
+		if len(relabel) > 0 {
+			labelUpdates = append(labelUpdates, model.InfraDesiredStateChange{
+				Action:      model.DesiredActionUpdateNodeLabel,
+				NodeGroupId: ng.Name,
+				NodeIds:     relabel,
+				SetLabel:    ng.Label,
+				Description: fmt.Sprintf("update labels of %d Nodes in NodeGroup '%s'", len(relabel), ng.Name),
+			})
+		}
+	}
+
+	if changed := missingLabels(infraInfo.Label, req.Label); len(changed) > 0 {
+		labelUpdates = append([]model.InfraDesiredStateChange{{
+			Action:      model.DesiredActionUpdateInfraLabel,
+			SetLabel:    changed,
+			Description: fmt.Sprintf("update %d labels of Infra '%s'", len(changed), infraId),
+		}}, labelUpdates...)
+	}
+
+	for _, ngId := range currentOrder {
+		if seen[ngId] {
+			continue
+		}
+		nodeIds := make([]string, 0, len(currentNodes[ngId]))
+		for _, node := range currentNodes[ngId] {
+			nodeIds = append(nodeIds, node.Id)
+		}
+		removals = append(removals, model.InfraDesiredStateChange{
+			Action:      model.DesiredActionRemoveNodeGroup,
+			NodeGroupId: ngId,
+			CurrentSize: len(nodeIds),
+			NodeIds:     nodeIds,
+			Description: fmt.Sprintf("remove NodeGroup '%s' and its %d Nodes", ngId, len(nodeIds)),
+		})
+	}
+
+	// Grow before shrinking, so that capacity does not dip while the Infra is reshaped
+	plan.Changes = append(plan.Changes, scaleOuts...)
+	plan.Changes = append(plan.Changes, labelUpdates...)
+	plan.Changes = append(plan.Changes, scaleIns...)
+	plan.Changes = append(plan.Changes, removals...)
+	plan.UpToDate = len(plan.Changes) == 0
+	return plan, nil
+}
+
+// ApplyInfraDesiredState computes the plan for the desired state and applies it.
+// Changes are applied in plan order; a failed change is reported and the rest still run.
+func ApplyInfraDesiredState(ctx context.Context, nsId string, infraId string, req *model.InfraDynamicReq) (*model.InfraDesiredStateApplyResult, error) {
+	lockKey := common.GenInfraKey(nsId, infraId, "")
+	if _, busy := infraDesiredStateLock.LoadOrStore(lockKey, true); busy {
+		return nil, &apierr.StatusError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("a desired state is already being applied to Infra '%s'", infraId)}
+	}
+	defer infraDesiredStateLock.Delete(lockKey)
+
+	plan, err := PlanInfraDesiredState(nsId, infraId, req)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.InfraDesiredStateApplyResult{Plan: *plan, Results: []model.InfraDesiredStateChangeResult{}}
+
+	if plan.InfraExists && !plan.UpToDate {
+		infraObj, _, err := GetInfraObject(nsId, infraId)
+		if err != nil {
+			return nil, err
+		}
+		if infraObj.TargetAction != "" && infraObj.TargetAction != model.ActionComplete {
+			return nil, &apierr.StatusError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("Infra '%s' is under %s; apply the desired state after it completes", infraId, infraObj.TargetAction)}
+		}
+	}
+
+	for _, change := range plan.Changes {
+		log.Info().Str("infraId", infraId).Str("action", change.Action).Msg(change.Description)
+		err := applyInfraDesiredStateChange(ctx, nsId, infraId, req, change)
+		changeResult := model.InfraDesiredStateChangeResult{Change: change, Success: err == nil}
+		if err != nil {
+			log.Error().Err(err).Str("infraId", infraId).Str("action", change.Action).Msg("Failed to apply desired-state change")
+			changeResult.Error = err.Error()
+		}
+		result.Results = append(result.Results, changeResult)
+	}
+
+	if infraInfo, err := GetInfraInfo(nsId, infraId); err == nil {
+		result.Infra = infraInfo
+	}
+	return result, nil
+}
+
+func applyInfraDesiredStateChange(ctx context.Context, nsId string, infraId string, req *model.InfraDynamicReq, change model.InfraDesiredStateChange) error {
+	switch change.Action {
+	case model.DesiredActionCreateInfra:
+		_, err := CreateInfraDynamic(ctx, nsId, req, "")
+		return err
+
+	case model.DesiredActionAddNodeGroup:
+		ng := desiredNodeGroup(req, change.NodeGroupId)
+		addReq := &model.AddNodeGroupDynamicReq{
+			CreateNodeGroupDynamicReq: ng,
+			PostCommands:              postCommandsForNodeGroup(req.PostCommands, ng.Name),
+			PostCommandAsync:          req.PostCommandAsync,
+		}
+		_, err := CreateInfraNodeGroupDynamic(ctx, nsId, infraId, addReq)
+		return err
+
+	case model.DesiredActionScaleOut:
+		before, err := ListNodeByNodeGroup(nsId, infraId, change.NodeGroupId)
+		if err != nil {
+			return err
+		}
+		if _, err := ScaleOutInfraNodeGroup(ctx, nsId, infraId, change.NodeGroupId, change.DesiredSize-change.CurrentSize); err != nil {
+			return err
+		}
+		// The scaled-out Nodes copy the configuration of the NodeGroup, but not the desired labels
+		after, err := ListNodeByNodeGroup(nsId, infraId, change.NodeGroupId)
+		if err != nil {
+			return err
+		}
+		added := slices.DeleteFunc(after, func(id string) bool { return slices.Contains(before, id) })
+		ng := desiredNodeGroup(req, change.NodeGroupId)
+		if len(ng.Label) > 0 {
+			if err := setNodeLabels(ctx, nsId, infraId, added, ng.Label); err != nil {
+				return err
+			}
+		}
+		if change.PostCommands {
+			// As for a new NodeGroup, failed post-commands do not undo the scale-out
+			if err := runPostCommandsOnNewNodes(nsId, infraId, change.NodeGroupId, added, postCommandsForNodeGroup(req.PostCommands, change.NodeGroupId), req.PostCommandAsync); err != nil {
+				log.Error().Err(err).Msg("Post-deployment commands failed for the scaled-out Nodes, but continuing")
+			}
+		}
+		return nil
+
+	case model.DesiredActionUpdateInfraLabel:
+		infraObj, _, err := GetInfraObject(nsId, infraId)
+		if err != nil {
+			return err
+		}
+		return label.CreateOrUpdateLabel(ctx, model.StrInfra, infraObj.Uid, common.GenInfraKey(nsId, infraId, ""), maps.Clone(change.SetLabel))
+
+	case model.DesiredActionUpdateNodeLabel:
+		return setNodeLabels(ctx, nsId, infraId, change.NodeIds, change.SetLabel)
+
+	case model.DesiredActionScaleIn, model.DesiredActionRemoveNodeGroup:
+		victims := make([]model.ScaleInNodeResult, 0, len(change.NodeIds))
+		for _, nodeId := range change.NodeIds {
+			victims = append(victims, model.ScaleInNodeResult{NodeId: nodeId})
+		}
+		var errs []string
+		for _, victim := range removeInfraNodes(nsId, infraId, victims, nil, true) {
+			if !victim.Removed {
+				errs = append(errs, fmt.Sprintf("%s: %s", victim.NodeId, victim.Error))
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to remove %d of %d Nodes (%s)", len(errs), len(change.NodeIds), strings.Join(errs, "; "))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown desired-state action '%s'", change.Action)
+}
+
+// desiredNodeGroup returns a NodeGroup of the desired state, with the Infra-level
+// network templates filled in as CreateInfraDynamic would do.
+func desiredNodeGroup(req *model.InfraDynamicReq, nodeGroupId string) model.CreateNodeGroupDynamicReq {
+	for _, ng := range req.NodeGroups {
+		if ng.Name != nodeGroupId {
+			continue
+		}
+		if ng.VNetTemplateId == "" {
+			ng.VNetTemplateId = req.VNetTemplateId
+		}
+		if ng.SgTemplateId == "" {
+			ng.SgTemplateId = req.SgTemplateId
+		}
+		return ng
+	}
+	return model.CreateNodeGroupDynamicReq{Name: nodeGroupId}
+}
+
+// postCommandsForNodeGroup returns the post-command phases that concern a NodeGroup:
+// phases without a target (which are scoped to the NodeGroup when it is added) and
+// phases targeting it. Phases for other NodeGroups, Nodes or label selectors already ran.
+func postCommandsForNodeGroup(phases []model.PostCommandReq, nodeGroupId string) []model.PostCommandReq {
+	var result []model.PostCommandReq
+	for _, phase := range phases {
+		if phase.NodeId != "" || phase.LabelSelector != "" {
+			continue
+		}
+		if phase.NodeGroupId == "" || phase.NodeGroupId == nodeGroupId {
+			result = append(result, phase)
+		}
+	}
+	return result
+}
+
+// runPostCommandsOnNewNodes runs post-command phases only on the given (new) Nodes of a NodeGroup.
+// The error names the Nodes on which the commands did not complete (always nil when async).
+func runPostCommandsOnNewNodes(nsId, infraId, nodeGroupId string, nodeIds []string, phases []model.PostCommandReq, async bool) error {
+	if len(nodeIds) == 0 || len(phases) == 0 {
+		return nil
+	}
+	run := func() error {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var failed []string
+		for _, nodeId := range nodeIds {
+			nodePhases := make([]model.PostCommandReq, len(phases))
+			for i, phase := range phases {
+				phase.NodeGroupId = ""
+				phase.LabelSelector = ""
+				phase.NodeId = nodeId
+				nodePhases[i] = phase
+			}
+			wg.Add(1)
+			go func(nodeId string) {
+				defer wg.Done()
+				xRequestId := newPostCommandRequestId(infraId)
+				status, err := executePostCommands(nsId, infraId, nodeGroupId, nodePhases, xRequestId)
+				if err == nil && status == model.PostCommandStatusCompleted {
+					return
+				}
+				if err == nil {
+					err = fmt.Errorf("post-deployment commands finished with status %s", status)
+				}
+				log.Error().Err(err).Str("nodeId", nodeId).Msg("Post-deployment commands failed for the new Node")
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s: %v", nodeId, err))
+				mu.Unlock()
+			}(nodeId)
+		}
+		wg.Wait()
+		if len(failed) > 0 {
+			return fmt.Errorf("post-deployment commands failed on %d Nodes (%s)", len(failed), strings.Join(failed, "; "))
+		}
+		return nil
+	}
+	if async {
+		go run()
+		return nil
+	}
+	return run()
+}
+
+func setNodeLabels(ctx context.Context, nsId, infraId string, nodeIds []string, labels map[string]string) error {
+	for _, nodeId := range nodeIds {
+		nodeObj, err := GetNodeObject(nsId, infraId, nodeId)
+		if err != nil {
+			return err
+		}
+		if err := label.CreateOrUpdateLabel(ctx, model.StrNode, nodeObj.Uid, common.GenInfraKey(nsId, infraId, nodeId), maps.Clone(labels)); err != nil {
+			return fmt.Errorf("failed to update labels of Node '%s': %w", nodeId, err)
+		}
+	}
+	return nil
+}
+
+// missingLabels returns the desired labels that are absent or different in the current labels
+func missingLabels(current, desired map[string]string) map[string]string {
+	missing := map[string]string{}
+	for k, v := range desired {
+		if cur, ok := current[k]; !ok || cur != v {
+			missing[k] = v
+		}
+	}
+	return missing
+}
+
+// nodesToScaleIn picks the Nodes to remove from a NodeGroup with the newest strategy
+func nodesToScaleIn(nodes []model.NodeInfo, count int) []string {
+	ids := make([]string, 0, count)
+	for _, victim := range selectScaleInVictims(nodes, count, model.ScaleInNewest, nil) {
+		ids = append(ids, victim.NodeId)
+	}
+	return ids
+}
+
+// nodeIndex returns the numeric suffix of a Node ID (g1-3 -> 3)
+func nodeIndex(nodeId string) int {
+	i := strings.LastIndex(nodeId, "-")
+	if i < 0 {
+		return 0
+	}
+	n, err := strconv.Atoi(nodeId[i+1:])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// nodeGroupDriftWarnings reports differences that cannot be applied to an existing NodeGroup in place
+func nodeGroupDriftWarnings(desired model.CreateNodeGroupDynamicReq, current model.NodeInfo) []string {
+	var warnings []string
+	drift := func(field, want, have string) {
+		warnings = append(warnings, fmt.Sprintf("NodeGroup '%s': %s differs (desired %s, current %s); existing Nodes are not replaced", desired.Name, field, want, have))
+	}
+	if desired.SpecId != "" && desired.SpecId != current.SpecId && desired.SpecId != current.CspSpecName {
+		drift("specId", desired.SpecId, current.SpecId)
+	}
+	if desired.ImageId != "" && desired.ImageId != current.ImageId && desired.ImageId != current.CspImageName {
+		drift("imageId", desired.ImageId, current.ImageId)
+	}
+	if desired.ConnectionName != "" && desired.ConnectionName != current.ConnectionName {
+		drift("connectionName", desired.ConnectionName, current.ConnectionName)
+	}
+	if desired.Zone != "" && desired.Zone != current.Region.Zone {
+		drift("zone", desired.Zone, current.Region.Zone)
+	}
+	if desired.RootDiskSize != 0 && desired.RootDiskSize != current.RootDiskSize {
+		drift("rootDiskSize", strconv.Itoa(desired.RootDiskSize), strconv.Itoa(current.RootDiskSize))
+	}
+	if desired.CapacityType != "" && !strings.EqualFold(desired.CapacityType, current.CapacityType) &&
+		!(strings.EqualFold(desired.CapacityType, "on-demand") && current.CapacityType == "") {
+		drift("capacityType", desired.CapacityType, current.CapacityType)
+	}
+	return warnings
+}
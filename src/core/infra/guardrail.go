@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"maps"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+)
+
+// nodeGuardrailTarget builds the guardrail target of a NodeGroup.
+// Labels of the Infra are inherited by its Nodes; every Node gets a public IP.
+func nodeGuardrailTarget(name string, infraLabel, nodeLabel map[string]string, imageId, specId string) model.GuardrailTarget {
+	labels := map[string]string{}
+	maps.Copy(labels, infraLabel)
+	maps.Copy(labels, nodeLabel)
+	return model.GuardrailTarget{
+		Kind:     model.GuardrailTargetNode,
+		Name:     name,
+		Labels:   labels,
+		ImageIds: []string{imageId},
+		SpecIds:  []string{specId},
+		PublicIp: true,
+	}
+}
+
+// nodeGroupGuardrailTargets builds guardrail targets of static NodeGroup requests.
+// NodeGroups registering existing CSP VMs (CspResourceId set) are not evaluated.
+func nodeGroupGuardrailTargets(infraLabel map[string]string, nodeGroups []model.CreateNodeGroupReq) []model.GuardrailTarget {
+	targets := []model.GuardrailTarget{}
+	for _, ng := range nodeGroups {
+		if ng.CspResourceId != "" {
+			continue
+		}
+		targets = append(targets, nodeGuardrailTarget(ng.Name, infraLabel, ng.Label, ng.ImageId, ng.SpecId))
+	}
+	return targets
+}
+
+// dynamicNodeGroupGuardrailTargets builds guardrail targets of dynamic NodeGroup requests.
+func dynamicNodeGroupGuardrailTargets(infraLabel map[string]string, nodeGroups []model.CreateNodeGroupDynamicReq) []model.GuardrailTarget {
+	targets := []model.GuardrailTarget{}
+	for _, ng := range nodeGroups {
+		targets = append(targets, nodeGuardrailTarget(ng.Name, infraLabel, ng.Label, ng.ImageId, ng.SpecId))
+	}
+	return targets
+}
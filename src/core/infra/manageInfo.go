@@ -651,6 +651,8 @@ func ExtractInfraDynamicReqFromInfraInfo(nsId string, infraId string) (*model.In
 			RootDiskType:   rep.RootDiskType,
 			RootDiskSize:   rep.RootDiskSize,
 			Zone:           rep.Region.Zone,
+			CapacityType:   rep.CapacityType,
+			Spot:           rep.Spot,
 		}
 		nodeGroups = append(nodeGroups, sg)
 	}
@@ -2233,7 +2235,7 @@ func FetchNodeStatus(nsId string, infraId string, nodeId string) (model.NodeStat
 						if _, alreadySent := terminatingReTerminateSent.LoadOrStore(streakKey, true); !alreadySent {
 							log.Info().Msgf("[FetchNodeStatus] Node %s: streak=%d — re-issuing terminate to ensure CSP delivery (vmstatus unreachable)", nodeId, streak)
 							go func() {
-								if _, rtErr := HandleInfraNodeAction(nsId, infraId, nodeId, model.ActionTerminate, true); rtErr != nil {
+								if _, rtErr := HandleInfraNodeAction(context.Background(), nsId, infraId, nodeId, model.ActionTerminate, true, ""); rtErr != nil {
 									log.Warn().Err(rtErr).Msgf("[FetchNodeStatus] Node %s: background re-terminate failed", nodeId)
 								} else {
 									log.Info().Msgf("[FetchNodeStatus] Node %s: background re-terminate sent successfully", nodeId)
@@ -2499,6 +2501,10 @@ applyStatus:
 	// Prevent overwriting Terminated status with empty or other states
 	originalNodeInfo, _ := GetNodeObject(nsId, infraId, nodeId)
 	if originalNodeInfo.Status != model.StatusTerminated {
+		spotInterrupted := cspResourceName != "" && isSpotInterruption(originalNodeInfo, nodeStatusTmp.Status)
+		if spotInterrupted {
+			nodeStatusTmp.SystemMessage = spotInterruptionMessage
+		}
 		nodeInfo.Status = nodeStatusTmp.Status
 		nodeInfo.TargetAction = nodeStatusTmp.TargetAction
 		nodeInfo.TargetStatus = nodeStatusTmp.TargetStatus
@@ -2517,6 +2523,9 @@ applyStatus:
 			originalNodeInfo.SSHPort = nodeInfo.SSHPort
 			UpdateNodeInfo(nsId, infraId, originalNodeInfo)
 		}
+		if spotInterrupted {
+			handleSpotInterruption(nsId, infraId, originalNodeInfo)
+		}
 	}
 	// else: Node is already terminated, skip status update
 
@@ -3133,14 +3142,14 @@ func DelInfra(nsId string, infraId string, option string) (model.IdList, error)
 		if strings.EqualFold(option, model.ActionTerminate) {
 
 			// ActionRefine
-			_, err := HandleInfraAction(nsId, infraId, model.ActionRefine, true)
+			_, err := HandleInfraAction(context.Background(), nsId, infraId, model.ActionRefine, true)
 			if err != nil {
 				log.Error().Err(err).Msg("")
 				return deletedResources, err
 			}
 
 			// model.ActionTerminate
-			_, err = HandleInfraAction(nsId, infraId, model.ActionTerminate, true)
+			_, err = HandleInfraAction(context.Background(), nsId, infraId, model.ActionTerminate, true)
 			if err != nil {
 				log.Error().Err(err).Msg("")
 				return deletedResources, err
@@ -3272,6 +3281,8 @@ func DelInfra(nsId string, infraId string, option string) (model.IdList, error)
 		go func(i int, e nodeEntry) {
 			defer deleteWg.Done()
 			globalStatusStore.Delete(nsId, infraId, e.id)
+			closeNodeUsage(nsId, infraId, e.id)
+			deleteNodeUtilization(nsId, infraId, e.id)
 			deleteErrs[i] = kvstore.Delete(e.key)
 		}(i, e)
 	}
@@ -3435,7 +3446,7 @@ func DelInfraNode(nsId string, infraId string, nodeId string, option string) err
 	// skip termination if option is force
 	if option != "force" {
 		// ControlNode first
-		_, err := HandleInfraNodeAction(nsId, infraId, nodeId, model.ActionTerminate, false)
+		_, err := HandleInfraNodeAction(context.Background(), nsId, infraId, nodeId, model.ActionTerminate, false, "")
 		if err != nil {
 			log.Info().Msg(err.Error())
 			return err
@@ -3481,6 +3492,8 @@ func DelInfraNode(nsId string, infraId string, nodeId string, option string) err
 		return err
 	}
 	globalStatusStore.Delete(nsId, infraId, nodeId)
+	closeNodeUsage(nsId, infraId, nodeId)
+	deleteNodeUtilization(nsId, infraId, nodeId)
 
 	// remove empty NodeGroups
 	nodeGroup, err := ListNodeGroupId(nsId, infraId)
@@ -3601,6 +3614,8 @@ func DeregisterInfraNode(nsId string, infraId string, nodeId string) error {
 		return err
 	}
 	globalStatusStore.Delete(nsId, infraId, nodeId)
+	closeNodeUsage(nsId, infraId, nodeId)
+	deleteNodeUtilization(nsId, infraId, nodeId)
 
 	// remove empty NodeGroups
 	nodeListInNodeGroup, err := ListNodeByNodeGroup(nsId, infraId, nodeInfo.NodeGroupId)
@@ -0,0 +1,106 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infra
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcome labels shared by provisioning and scheduler metrics
+const (
+	metricResultSuccess = "success"
+	metricResultFailure = "failure"
+	metricResultPanic   = "panic"
+)
+
+var (
+	provisioningNodesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tumblebug",
+		Subsystem: "provisioning",
+		Name:      "nodes_total",
+		Help:      "Nodes provisioned through Infra creation, by provider and result (success/failure).",
+	}, []string{"provider", "result"})
+
+	schedulerJobExecutions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tumblebug",
+		Subsystem: "scheduler",
+		Name:      "job_executions_total",
+		Help:      "Scheduled job executions, by job type and result (success/failure/panic).",
+	}, []string{"job_type", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		provisioningNodesTotal,
+		schedulerJobExecutions,
+		commandSessionCollector{},
+	)
+}
+
+// recordProvisioningResult counts one Node provisioning outcome.
+func recordProvisioningResult(provider string, success bool) {
+	provider = strings.ToLower(provider)
+	if provider == "" {
+		provider = "unknown"
+	}
+	result := metricResultSuccess
+	if !success {
+		result = metricResultFailure
+	}
+	provisioningNodesTotal.WithLabelValues(provider, result).Inc()
+}
+
+// recordJobExecution counts one scheduled job execution outcome.
+func recordJobExecution(jobType JobType, result string) {
+	schedulerJobExecutions.WithLabelValues(string(jobType), result).Inc()
+}
+
+// commandSessionCollector exports remote command streaming gauges from
+// commandLogBroker at scrape time.
+type commandSessionCollector struct{}
+
+var (
+	commandActiveSessionsDesc = prometheus.NewDesc(
+		"tumblebug_remote_command_active_sessions",
+		"Number of remote command sessions still running (CommandDone not yet published).",
+		nil, nil)
+	commandSubscribersDesc = prometheus.NewDesc(
+		"tumblebug_remote_command_stream_subscribers",
+		"Number of SSE clients subscribed to remote command output.",
+		nil, nil)
+)
+
+func (commandSessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- commandActiveSessionsDesc
+	ch <- commandSubscribersDesc
+}
+
+func (commandSessionCollector) Collect(ch chan<- prometheus.Metric) {
+	active, subscribers := 0, 0
+	commandLogBroker.mu.RLock()
+	for _, session := range commandLogBroker.sessions {
+		session.mu.RLock()
+		if !session.done {
+			active++
+		}
+		subscribers += len(session.subscribers)
+		session.mu.RUnlock()
+	}
+	commandLogBroker.mu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(commandActiveSessionsDesc, prometheus.GaugeValue, float64(active))
+	ch <- prometheus.MustNewConstMetric(commandSubscribersDesc, prometheus.GaugeValue, float64(subscribers))
+}
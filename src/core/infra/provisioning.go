@@ -32,6 +32,7 @@ import (
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/netutil"
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	cspcheck "github.com/cloud-barista/cb-tumblebug/src/core/csp"
 	_ "github.com/cloud-barista/cb-tumblebug/src/core/csp/alibaba" // register Alibaba handlers (availability, vmstatus)
 	_ "github.com/cloud-barista/cb-tumblebug/src/core/csp/aws"     // register AWS handlers (vmstatus)
@@ -44,6 +45,7 @@ import (
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 	validator "github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // isQuotaOrCapacityError reports whether err is a definitive CSP rejection meaning
@@ -208,6 +210,8 @@ func reserveNodeNames(nsId, infraId, nodeGroupId string, nodeRequest *model.Crea
 	} else {
 		record.RootDiskType = nodeRequest.RootDiskType
 		record.RootDiskSize = nodeRequest.RootDiskSize
+		record.CapacityType = nodeRequest.CapacityType
+		record.Spot = nodeRequest.Spot
 	}
 
 	// Names in use: the Nodes that exist plus the ones the record already reserved
@@ -315,6 +319,8 @@ func createNodeGroup(ctx context.Context, nsId, infraId string, nodeRequest *mod
 		NodeGroupSize: nodeGroupSize,
 		RootDiskType:  nodeRequest.RootDiskType,
 		RootDiskSize:  nodeRequest.RootDiskSize,
+		CapacityType:  nodeRequest.CapacityType,
+		Spot:          nodeRequest.Spot,
 	}
 
 	// Build Node ID list
@@ -842,7 +848,7 @@ func rollbackCreatedResources(nsId string, createdResources []CreatedResource) e
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }() // Release semaphore
 
-			if err := resource.DelResource(nsId, model.StrSSHKey, resourceId, "false"); err != nil {
+			if err := resource.DelResource(context.Background(), nsId, model.StrSSHKey, resourceId, "false"); err != nil {
 				errorMsg := fmt.Sprintf("Failed to delete SSHKey '%s' in namespace '%s': %v", resourceId, nsId, err)
 				mutex.Lock()
 				errors = append(errors, errorMsg)
@@ -872,7 +878,7 @@ func rollbackCreatedResources(nsId string, createdResources []CreatedResource) e
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }() // Release semaphore
 
-			if err := resource.DelResource(nsId, model.StrSecurityGroup, resourceId, "false"); err != nil {
+			if err := resource.DelResource(context.Background(), nsId, model.StrSecurityGroup, resourceId, "false"); err != nil {
 				errorMsg := fmt.Sprintf("Failed to delete SecurityGroup '%s' in namespace '%s': %v", resourceId, nsId, err)
 				mutex.Lock()
 				errors = append(errors, errorMsg)
@@ -905,7 +911,7 @@ func rollbackCreatedResources(nsId string, createdResources []CreatedResource) e
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }() // Release semaphore
 
-			if err := resource.DelResource(nsId, model.StrVNet, resourceId, "false"); err != nil {
+			if err := resource.DelResource(context.Background(), nsId, model.StrVNet, resourceId, "false"); err != nil {
 				errorMsg := fmt.Sprintf("Failed to delete VNet '%s' in namespace '%s': %v", resourceId, nsId, err)
 				mutex.Lock()
 				errors = append(errors, errorMsg)
@@ -945,18 +951,34 @@ func ScaleOutInfraNodeGroup(ctx context.Context, nsId string, infraId string, no
 		return &model.InfraInfo{}, fmt.Errorf("numNodesToAdd must be 1 or more (got %d)", numNodesToAdd)
 	}
 
-	nodeIdList, err := ListNodeByNodeGroup(nsId, infraId, nodeGroupId)
+	nodeGroupReqTemplate, err := nodeGroupReqFromExistingNode(nsId, infraId, nodeGroupId)
+	if err != nil {
+		return &model.InfraInfo{}, err
+	}
+	nodeGroupReqTemplate.NodeGroupSize = numNodesToAdd
+
+	result, err := CreateInfraGroupNode(ctx, nsId, infraId, nodeGroupReqTemplate, true)
 	if err != nil {
 		temp := &model.InfraInfo{}
 		return temp, err
 	}
+	return result, nil
+
+}
+
+// nodeGroupReqFromExistingNode builds a request to create more Nodes in a NodeGroup,
+// copying the configuration of an existing Node (same network, security groups and key)
+func nodeGroupReqFromExistingNode(nsId string, infraId string, nodeGroupId string) (*model.CreateNodeGroupReq, error) {
+	nodeIdList, err := ListNodeByNodeGroup(nsId, infraId, nodeGroupId)
+	if err != nil {
+		return nil, err
+	}
 	if len(nodeIdList) == 0 {
-		return &model.InfraInfo{}, fmt.Errorf("NodeGroup '%s' has no Node in Infra '%s'; scale-out needs an existing Node to copy the configuration from", nodeGroupId, infraId)
+		return nil, fmt.Errorf("NodeGroup '%s' has no Node in Infra '%s' to copy the configuration from", nodeGroupId, infraId)
 	}
 	nodeObj, err := GetNodeObject(nsId, infraId, nodeIdList[0])
 	if err != nil {
-		temp := &model.InfraInfo{}
-		return temp, err
+		return nil, err
 	}
 
 	nodeGroupReqTemplate := &model.CreateNodeGroupReq{}
@@ -981,21 +1003,19 @@ func ScaleOutInfraNodeGroup(ctx context.Context, nsId string, infraId string, no
 	if nodeGroupInfo, err := GetNodeGroup(nsId, infraId, nodeGroupId); err == nil {
 		nodeGroupReqTemplate.RootDiskType = nodeGroupInfo.RootDiskType
 		nodeGroupReqTemplate.RootDiskSize = nodeGroupInfo.RootDiskSize
+		nodeGroupReqTemplate.CapacityType = nodeGroupInfo.CapacityType
+		nodeGroupReqTemplate.Spot = nodeGroupInfo.Spot
+	}
+	if nodeGroupReqTemplate.CapacityType == "" {
+		nodeGroupReqTemplate.CapacityType = nodeObj.CapacityType
+		nodeGroupReqTemplate.Spot = nodeObj.Spot
 	}
 	if nodeGroupReqTemplate.RootDiskSize == 0 {
 		nodeGroupReqTemplate.RootDiskSize = nodeObj.RootDiskSize
 	}
 	nodeGroupReqTemplate.Description = nodeObj.Description
 
-	nodeGroupReqTemplate.NodeGroupSize = numNodesToAdd
-
-	result, err := CreateInfraGroupNode(ctx, nsId, infraId, nodeGroupReqTemplate, true)
-	if err != nil {
-		temp := &model.InfraInfo{}
-		return temp, err
-	}
-	return result, nil
-
+	return nodeGroupReqTemplate, nil
 }
 
 // CreateInfraGroupNode is func to create Infra groupNode
@@ -1045,6 +1065,16 @@ func CreateInfraGroupNode(ctx context.Context, nsId string, infraId string, node
 		return nil, err
 	}
 
+	// Covers scale-out and NodeGroup addition; registration of CSP VMs is not counted
+	ctx, releaseQuota, err := checkNodeGroupQuota(ctx, nsId, []model.CreateNodeGroupReq{*nodeRequest})
+	if err != nil {
+		return nil, err
+	}
+	defer releaseQuota()
+	if err := checkBudgetBlock(nsId, infraId, nodeRequest.Label); err != nil {
+		return nil, err
+	}
+
 	infraTmp, _, err := GetInfraObject(nsId, infraId)
 
 	if err != nil {
@@ -1052,6 +1082,10 @@ func CreateInfraGroupNode(ctx context.Context, nsId string, infraId string, node
 		return temp, err
 	}
 
+	if err := common.EnforceGuardrails(nsId, nodeGroupGuardrailTargets(infraTmp.Label, []model.CreateNodeGroupReq{*nodeRequest})...); err != nil {
+		return nil, err
+	}
+
 	//nodeRequest := req
 
 	targetAction := model.ActionCreate
@@ -1144,6 +1178,8 @@ func CreateInfraGroupNode(ctx context.Context, nsId string, infraId string, node
 		nodeInfoData.NodeUserPassword = nodeRequest.NodeUserPassword
 		nodeInfoData.RootDiskType = nodeRequest.RootDiskType
 		nodeInfoData.RootDiskSize = nodeRequest.RootDiskSize
+		nodeInfoData.CapacityType = nodeRequest.CapacityType
+		nodeInfoData.Spot = nodeRequest.Spot
 
 		nodeInfoData.Label = nodeRequest.Label
 
@@ -1297,7 +1333,14 @@ func CreateInfraGroupNode(ctx context.Context, nsId string, infraId string, node
 }
 
 // CreateInfra is func to create Infra object and deploy requested VMs (register CSP native VM with option=register)
-func CreateInfra(ctx context.Context, nsId string, req *model.InfraReq, option string, isReqFromDynamic bool) (*model.InfraInfo, error) {
+func CreateInfra(ctx context.Context, nsId string, req *model.InfraReq, option string, isReqFromDynamic bool) (_ *model.InfraInfo, err error) {
+	ctx, span := tracing.Start(ctx, "infra.create",
+		attribute.String("ns.id", nsId),
+		attribute.String("infra.name", req.Name),
+		attribute.String("option", option),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	// Input validation
 	if err := common.CheckString(nsId); err != nil {
 		log.Error().Err(err).Msg("Invalid namespace ID")
@@ -1356,6 +1399,22 @@ func CreateInfra(ctx context.Context, nsId string, req *model.InfraReq, option s
 		}
 	}
 
+	// Namespace quota and guardrails (dynamic requests were already checked by CreateInfraDynamic)
+	if !isReqFromDynamic && option != "register" {
+		quotaCtx, releaseQuota, err := checkNodeGroupQuota(ctx, nsId, req.NodeGroups)
+		if err != nil {
+			return nil, err
+		}
+		defer releaseQuota()
+		ctx = quotaCtx
+		if err := checkBudgetBlock(nsId, "", req.Label); err != nil {
+			return nil, err
+		}
+		if err := common.EnforceGuardrails(nsId, nodeGroupGuardrailTargets(req.Label, req.NodeGroups)...); err != nil {
+			return nil, err
+		}
+	}
+
 	// Initialize Infra
 	uid := common.GenUid()
 	infraId := req.Name
@@ -1481,6 +1540,8 @@ func CreateInfra(ctx context.Context, nsId string, req *model.InfraReq, option s
 				NodeUserPassword: nodeGroupReq.NodeUserPassword,
 				RootDiskType:     nodeGroupReq.RootDiskType,
 				RootDiskSize:     nodeGroupReq.RootDiskSize,
+				CapacityType:     nodeGroupReq.CapacityType,
+				Spot:             nodeGroupReq.Spot,
 				Label:            nodeGroupReq.Label,
 				CspResourceId:    nodeGroupReq.CspResourceId,
 			}
@@ -1636,7 +1697,7 @@ func CreateInfra(ctx context.Context, nsId string, req *model.InfraReq, option s
 		log.Error().Msgf("Infra %s marked as Failed - all VM and Infra status updates completed", infraId)
 
 		// Record provisioning failure events even when all VMs failed
-		if err := RecordProvisioningEventsFromInfra(nsId, infraResult); err != nil {
+		if err := RecordProvisioningEventsFromInfra(ctx, nsId, infraResult); err != nil {
 			log.Error().Err(err).Msgf("Failed to record provisioning events for failed Infra '%s'", infraId)
 		}
 
@@ -1683,7 +1744,7 @@ func CreateInfra(ctx context.Context, nsId string, req *model.InfraReq, option s
 			log.Error().Msgf("VM creation failed for %d VMs, rolling back entire Infra due to policy=rollback", len(createErrors))
 			// Record provisioning failure events before rollback
 			if infraInfo, infraErr := GetInfraInfo(nsId, infraId); infraErr == nil {
-				if err := RecordProvisioningEventsFromInfra(nsId, infraInfo); err != nil {
+				if err := RecordProvisioningEventsFromInfra(ctx, nsId, infraInfo); err != nil {
 					log.Error().Err(err).Msgf("Failed to record provisioning events before rollback for Infra '%s'", infraId)
 				}
 			}
@@ -1764,7 +1825,7 @@ func CreateInfra(ctx context.Context, nsId string, req *model.InfraReq, option s
 	var shouldRefine bool
 	if req.PolicyOnPartialFailure == model.PolicyRefine && (len(nodeObjectErrors) > 0 || len(nodeCreateErrors) > 0) {
 		log.Info().Msgf("Executing refine action to cleanup failed VMs in Infra '%s'", infraId)
-		if refineResult, err := HandleInfraAction(nsId, infraId, model.ActionRefine, true); err != nil {
+		if refineResult, err := HandleInfraAction(ctx, nsId, infraId, model.ActionRefine, true); err != nil {
 			log.Error().Err(err).Msg("Failed to execute refine action, but continuing")
 		} else {
 			log.Info().Msgf("Refine action completed: %s", refineResult)
@@ -1812,7 +1873,7 @@ func CreateInfra(ctx context.Context, nsId string, req *model.InfraReq, option s
 	}
 
 	// Record provisioning events to history if there were any failures or if specs have previous failure history
-	if err := RecordProvisioningEventsFromInfra(nsId, infraResult); err != nil {
+	if err := RecordProvisioningEventsFromInfra(ctx, nsId, infraResult); err != nil {
 		log.Error().Err(err).Msgf("Failed to record provisioning events for Infra '%s', but continuing", infraId)
 	}
 
@@ -1880,7 +1941,15 @@ func CheckInfraDynamicReq(ctx context.Context, req *model.InfraConnectionConfigC
 }
 
 // CreateInfraDynamic is func to create Infra obeject and deploy requested VMs in a dynamic way
-func CreateInfraDynamic(ctx context.Context, nsId string, req *model.InfraDynamicReq, deployOption string) (*model.InfraInfo, error) {
+func CreateInfraDynamic(ctx context.Context, nsId string, req *model.InfraDynamicReq, deployOption string) (_ *model.InfraInfo, err error) {
+
+	ctx, span := tracing.Start(ctx, "infra.createDynamic",
+		attribute.String("ns.id", nsId),
+		attribute.String("infra.name", req.Name),
+		attribute.Int("infra.nodegroup_count", len(req.NodeGroups)),
+		attribute.String("deploy.option", deployOption),
+	)
+	defer func() { tracing.End(span, err) }()
 
 	reqID := common.RequestIDFromContext(ctx)
 	credentialHolder := common.CredentialHolderFromContext(ctx)
@@ -1911,7 +1980,7 @@ func CreateInfraDynamic(ctx context.Context, nsId string, req *model.InfraDynami
 	}
 
 	emptyInfra := &model.InfraInfo{}
-	err := common.CheckString(nsId)
+	err = common.CheckString(nsId)
 	if err != nil {
 		err := fmt.Errorf("invalid namespace. %w", err)
 		log.Error().Err(err).Msg("")
@@ -1931,6 +2000,25 @@ func CreateInfraDynamic(ctx context.Context, nsId string, req *model.InfraDynami
 		return emptyInfra, err
 	}
 
+	ctx, releaseQuota, err := checkDynamicNodeGroupQuota(ctx, nsId, req.NodeGroups)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		addErrorToHistory("Namespace Quota Check", err.Error())
+		return emptyInfra, err
+	}
+	defer releaseQuota()
+
+	if err := checkBudgetBlock(nsId, "", req.Label); err != nil {
+		log.Error().Err(err).Msg("")
+		addErrorToHistory("Budget Check", err.Error())
+		return emptyInfra, err
+	}
+
+	if err := common.EnforceGuardrails(nsId, dynamicNodeGroupGuardrailTargets(req.Label, req.NodeGroups)...); err != nil {
+		addErrorToHistory("Guardrail Check", err.Error())
+		return emptyInfra, err
+	}
+
 	// Initialize Infra
 	uid := common.GenUid()
 	infraId := req.Name
@@ -2485,6 +2573,8 @@ func reviewSingleNodeGroupDynamicReq(ctx context.Context, nsId string, infraSgTe
 				nodeGroupSizeInt := max(nodeGroupDynamicReq.NodeGroupSize, 1)
 				nodeReview.EstimatedCost = fmt.Sprintf("$%.4f/hour", float64(specInfo.CostPerHour)*float64(nodeGroupSizeInt))
 				nodeCost = float64(specInfo.CostPerHour) * float64(nodeGroupSizeInt)
+				nodeReview.EstimatedCostPerHour = roundCost(nodeCost)
+				nodeReview.EstimatedCostPerMonth = roundCost(nodeCost * model.CostHoursPerMonth)
 			} else {
 				nodeReview.EstimatedCost = "Cost estimation unavailable"
 			}
@@ -2567,6 +2657,33 @@ func reviewSingleNodeGroupDynamicReq(ctx context.Context, nsId string, infraSgTe
 		nodeReview.Info = append(nodeReview.Info, fmt.Sprintf("Root disk size configured: %d GB, be sure it meets minimum requirements", nodeGroupDynamicReq.RootDiskSize))
 	}
 
+	// Validate spot capacity settings
+	if nodeGroupDynamicReq.CapacityType == model.CapacityTypeSpot {
+		if specInfoPtr != nil {
+			if _, ok := cspcheck.GetSpotLauncher(specInfoPtr.ProviderName); !ok {
+				nodeReview.Errors = append(nodeReview.Errors, fmt.Sprintf(
+					"Spot capacity is not supported for provider '%s' (supported: %s)",
+					specInfoPtr.ProviderName, strings.Join(cspcheck.SpotSupportedProviders(), ", ")))
+				nodeReview.CanCreate = false
+				viable = false
+			} else if nodeGroupDynamicReq.Spot != nil && nodeGroupDynamicReq.Spot.MaxPrice > 0 &&
+				specInfoPtr.SpotCostPerHour > 0 && float64(specInfoPtr.SpotCostPerHour) > nodeGroupDynamicReq.Spot.MaxPrice {
+				nodeReview.Warnings = append(nodeReview.Warnings, fmt.Sprintf(
+					"Spot max price %.4f is below the current spot price %.4f of spec '%s'; the request may not be fulfilled",
+					nodeGroupDynamicReq.Spot.MaxPrice, specInfoPtr.SpotCostPerHour, nodeGroupDynamicReq.SpecId))
+				hasNodeWarning = true
+			} else {
+				nodeReview.Info = append(nodeReview.Info, "Spot capacity requested: nodes may be interrupted by the provider")
+			}
+		}
+	} else if nodeGroupDynamicReq.CapacityType != "" && nodeGroupDynamicReq.CapacityType != model.CapacityTypeOnDemand {
+		nodeReview.Errors = append(nodeReview.Errors, fmt.Sprintf(
+			"Invalid capacityType '%s' (allowed: %s, %s)",
+			nodeGroupDynamicReq.CapacityType, model.CapacityTypeOnDemand, model.CapacityTypeSpot))
+		nodeReview.CanCreate = false
+		viable = false
+	}
+
 	// Check provisioning history and risk analysis
 	if specInfoPtr != nil {
 		riskAnalysis, err := AnalyzeProvisioningRiskDetailed(nodeGroupDynamicReq.SpecId, nodeGroupDynamicReq.ImageId)
@@ -3309,6 +3426,8 @@ func ReviewInfraDynamicReq(ctx context.Context, nsId string, req *model.InfraDyn
 	}
 
 	// Set overall status and cost estimation
+	reviewResult.EstimatedCostPerHour = totalEstimatedCost
+	reviewResult.EstimatedCostPerMonth = roundCost(totalEstimatedCost * model.CostHoursPerMonth)
 	if totalEstimatedCost > 0 {
 		if nodeWithUnknownCost > 0 {
 			reviewResult.EstimatedCost = fmt.Sprintf("$%.4f/hour (partial - %d VMs have unknown costs)", totalEstimatedCost, nodeWithUnknownCost)
@@ -3319,6 +3438,24 @@ func ReviewInfraDynamicReq(ctx context.Context, nsId string, req *model.InfraDyn
 		reviewResult.EstimatedCost = fmt.Sprintf("Cost estimation unavailable for all %d VMs", nodeWithUnknownCost)
 	}
 
+	// Guardrail policy of the namespace: deny violations block creation, warn violations are reported
+	violations, err := common.EvaluateGuardrails(nsId, dynamicNodeGroupGuardrailTargets(req.Label, req.NodeGroups))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to evaluate guardrails")
+		reviewResult.Recommendations = append(reviewResult.Recommendations, "Guardrail policy could not be evaluated; it is enforced again at creation")
+	}
+	for _, v := range violations {
+		if v.Enforcement == model.GuardrailEnforcementDeny {
+			allViable = false
+		} else {
+			hasWarnings = true
+		}
+	}
+	if len(violations) > 0 {
+		reviewResult.GuardrailViolations = violations
+		reviewResult.Recommendations = append(reviewResult.Recommendations, "Adjust the request to comply with the namespace guardrail policy (see guardrailViolations)")
+	}
+
 	reviewResult.CreationViable = allViable
 
 	if !allViable {
@@ -3522,6 +3659,27 @@ func CreateInfraNodeGroupDynamic(ctx context.Context, nsId string, infraId strin
 		return emptyInfra, err
 	}
 
+	// Check the quota before shared resources are created on the CSP
+	ctx, releaseQuota, err := checkDynamicNodeGroupQuota(ctx, nsId, []model.CreateNodeGroupDynamicReq{req.CreateNodeGroupDynamicReq})
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return emptyInfra, err
+	}
+	defer releaseQuota()
+	if err := checkBudgetBlock(nsId, infraId, req.Label); err != nil {
+		log.Error().Err(err).Msg("")
+		return emptyInfra, err
+	}
+
+	infraObj, _, err := GetInfraObject(nsId, infraId)
+	if err != nil {
+		return emptyInfra, err
+	}
+	err = common.EnforceGuardrails(nsId, dynamicNodeGroupGuardrailTargets(infraObj.Label, []model.CreateNodeGroupDynamicReq{req.CreateNodeGroupDynamicReq})...)
+	if err != nil {
+		return emptyInfra, err
+	}
+
 	err = checkCommonResAvailableForNodeGroupDynamicReq(ctx, &req.CreateNodeGroupDynamicReq, nsId)
 	if err != nil {
 		log.Error().Err(err).Msg("")
@@ -4287,6 +4445,8 @@ func getNodeGroupReqFromDynamicReq(ctx context.Context, nsId string, infraId str
 	nodeGroupReq.Description = k.Description
 	nodeGroupReq.RootDiskType = k.RootDiskType
 	nodeGroupReq.RootDiskSize = k.RootDiskSize
+	nodeGroupReq.CapacityType = k.CapacityType
+	nodeGroupReq.Spot = k.Spot
 	// NodeUserPassword is not taken from the request; CreateNode generates a random
 	// password internally for the CSP-side requirement (Windows).
 
@@ -4532,13 +4692,86 @@ func CreateNodesInParallel(ctx context.Context, nsId, infraId string, nodeInfoLi
 	return nil
 }
 
+// launchSpotInstance launches a spot instance for nodeInfoData through the CSP's
+// registered spot launcher and returns the CSP instance ID. reqInfo carries the
+// already-resolved Spider request (image, spec and key pair names).
+func launchSpotInstance(ctx context.Context, nsId string, nodeInfoData *model.NodeInfo, reqInfo model.SpiderVMReqInfo, subnetInfo model.SubnetInfo) (string, error) {
+	provider := nodeInfoData.ConnectionConfig.ProviderName
+	launch, ok := cspcheck.GetSpotLauncher(provider)
+	if !ok {
+		return "", fmt.Errorf("spot capacity is not supported for provider '%s'", provider)
+	}
+	if len(reqInfo.DataDiskNames) > 0 {
+		return "", fmt.Errorf("attaching data disks at creation is not supported with spot capacity; attach them after the node is running")
+	}
+
+	var sgIds []string
+	for _, sgId := range nodeInfoData.SecurityGroupIds {
+		cspSgId, err := resource.GetCspResourceId(nsId, model.StrSecurityGroup, sgId)
+		if err != nil || cspSgId == "" {
+			return "", fmt.Errorf("cannot resolve CSP ID of security group %s: %v", sgId, err)
+		}
+		sgIds = append(sgIds, cspSgId)
+	}
+	keyPairId, err := resource.GetCspResourceId(nsId, model.StrSSHKey, nodeInfoData.SshKeyId)
+	if err != nil || keyPairId == "" {
+		return "", fmt.Errorf("cannot resolve CSP ID of SSH key %s: %v", nodeInfoData.SshKeyId, err)
+	}
+
+	spotReq := cspcheck.SpotLaunchReq{
+		Region:           nodeInfoData.ConnectionConfig.RegionDetail.RegionName,
+		Zone:             subnetInfo.Zone,
+		Name:             reqInfo.Name,
+		InstanceType:     reqInfo.VMSpecName,
+		ImageId:          reqInfo.ImageName,
+		SubnetId:         subnetInfo.CspResourceId,
+		SecurityGroupIds: sgIds,
+		KeyPairName:      keyPairId,
+		RootDiskType:     reqInfo.RootDiskType,
+		RootDiskSize:     nodeInfoData.RootDiskSize,
+	}
+	if nodeInfoData.Spot != nil {
+		spotReq.MaxPrice = nodeInfoData.Spot.MaxPrice
+	}
+
+	sdkCtx := context.WithValue(ctx, model.CtxKeyCredentialHolder, nodeInfoData.ConnectionConfig.CredentialHolder)
+	return launch(sdkCtx, spotReq)
+}
+
+// terminateSpotInstance terminates a spot instance launched by launchSpotInstance that
+// could not be imported into CB-Spider, so that it does not keep running (and billing)
+// unknown to TB. It runs even if ctx is canceled, since the instance exists regardless.
+func terminateSpotInstance(ctx context.Context, nodeInfoData *model.NodeInfo, cspId string) error {
+	provider := nodeInfoData.ConnectionConfig.ProviderName
+	terminate, ok := cspcheck.GetBatchVMControlHandler(provider, model.ActionTerminate)
+	if !ok {
+		return fmt.Errorf("no direct terminate handler for provider '%s'", provider)
+	}
+	sdkCtx := context.WithValue(context.WithoutCancel(ctx), model.CtxKeyCredentialHolder, nodeInfoData.ConnectionConfig.CredentialHolder)
+	accepted, err := terminate(sdkCtx, nodeInfoData.ConnectionConfig.RegionDetail.RegionName, []string{cspId})
+	if err != nil {
+		return err
+	}
+	if _, ok := accepted[cspId]; !ok {
+		return fmt.Errorf("termination of instance %s was not accepted", cspId)
+	}
+	return nil
+}
+
 // CreateNode is func to create VM (option = "register" for register existing VM)
-func CreateNode(ctx context.Context, wg *sync.WaitGroup, nsId string, infraId string, nodeInfoData *model.NodeInfo, option string) error {
+func CreateNode(ctx context.Context, wg *sync.WaitGroup, nsId string, infraId string, nodeInfoData *model.NodeInfo, option string) (err error) {
 	log.Info().Msgf("Start to create VM: %s", nodeInfoData.Name)
 	//goroutin
 	defer wg.Done()
 
-	var err error = nil
+	ctx, span := tracing.Start(ctx, "node.create",
+		attribute.String("ns.id", nsId),
+		attribute.String("infra.id", infraId),
+		attribute.String("node.id", nodeInfoData.Id),
+		attribute.String("connection.name", nodeInfoData.ConnectionName),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	switch {
 	case nodeInfoData.Name == "":
 		err = fmt.Errorf("nodeInfoData.Name is empty")
@@ -4787,6 +5020,28 @@ func CreateNode(ctx context.Context, wg *sync.WaitGroup, nsId string, infraId st
 			log.Error().Err(err).Msg("")
 			return err
 		}
+
+		// Spot capacity: CB-Spider has no spot purchase option, so launch the instance
+		// directly via the CSP SDK and import it through /regvm below. TB already holds
+		// every resource reference, so the register-mode ID reconstruction is not needed.
+		if nodeInfoData.CapacityType == model.CapacityTypeSpot {
+			cspId, err := launchSpotInstance(ctx, nsId, nodeInfoData, requestBody.ReqInfo, subnetInfo)
+			if err != nil {
+				nodeInfoData.Status = model.StatusFailed
+				nodeInfoData.TargetAction = model.ActionComplete
+				nodeInfoData.TargetStatus = ""
+				nodeInfoData.SystemMessage = err.Error()
+				UpdateNodeInfo(nsId, infraId, *nodeInfoData)
+				log.Error().Err(err).Msgf("[CreateNode] Spot launch failed for VM %s", nodeInfoData.Name)
+				return err
+			}
+			requestBody.ReqInfo = model.SpiderVMReqInfo{
+				Name:         requestBody.ReqInfo.Name,
+				CSPid:        cspId,
+				VMUserId:     requestBody.ReqInfo.VMUserId,
+				VMUserPasswd: requestBody.ReqInfo.VMUserPasswd,
+			}
+		}
 	}
 
 	common.RandomSleep(0, 5*1000)
@@ -4798,11 +5053,12 @@ func CreateNode(ctx context.Context, wg *sync.WaitGroup, nsId string, infraId st
 	client.SetTimeout(20 * time.Minute)
 
 	url := model.SpiderRestUrl + "/vm"
-	if option == "register" {
+	if option == "register" || requestBody.ReqInfo.CSPid != "" {
 		url = model.SpiderRestUrl + "/regvm"
 	}
 
-	_, err = clientManager.ExecuteHttpRequest(
+	_, err = clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -4816,6 +5072,19 @@ func CreateNode(ctx context.Context, wg *sync.WaitGroup, nsId string, infraId st
 	if err != nil {
 		err = fmt.Errorf("%v", err)
 
+		// A spot instance launched above exists on the CSP but is unknown to Spider and TB:
+		// terminate it instead of leaving it to run unmanaged.
+		if nodeInfoData.CapacityType == model.CapacityTypeSpot && requestBody.ReqInfo.CSPid != "" && option != "register" {
+			cspId := requestBody.ReqInfo.CSPid
+			if termErr := terminateSpotInstance(ctx, nodeInfoData, cspId); termErr != nil {
+				log.Error().Err(termErr).Msgf("[CreateNode] Failed to terminate spot instance %s of VM %s after the import failed; terminate it manually", cspId, nodeInfoData.Name)
+				err = fmt.Errorf("%w (spot instance %s could not be terminated and must be terminated manually: %v)", err, cspId, termErr)
+			} else {
+				log.Warn().Msgf("[CreateNode] Terminated spot instance %s of VM %s after the import failed", cspId, nodeInfoData.Name)
+				err = fmt.Errorf("%w (spot instance %s has been terminated)", err, cspId)
+			}
+		}
+
 		if isQuotaOrCapacityError(err) {
 			// Definitive pre-create rejection: the CSP refused the request before any
 			// resource was provisioned — no VM exists on the CSP side. Mark Failed
@@ -5047,16 +5316,7 @@ func CreateNode(ctx context.Context, wg *sync.WaitGroup, nsId string, infraId st
 		if err != nil {
 			log.Warn().Err(err).Msgf("Failed to get spec info for SpecSummary: %s", nodeInfoData.SpecId)
 		} else {
-			nodeInfoData.Spec = model.SpecSummary{
-				CspSpecName:         specInfo.CspSpecName,
-				VCPU:                specInfo.VCPU,
-				MemoryGiB:           specInfo.MemoryGiB,
-				AcceleratorModel:    specInfo.AcceleratorModel,
-				AcceleratorCount:    specInfo.AcceleratorCount,
-				AcceleratorMemoryGB: specInfo.AcceleratorMemoryGB,
-				AcceleratorType:     specInfo.AcceleratorType,
-				CostPerHour:         specInfo.CostPerHour,
-			}
+			nodeInfoData.Spec = specSummaryOf(specInfo)
 		}
 	}
 
@@ -5924,7 +6184,8 @@ func recordFailureMessage(messages []string, newMessage string, limit int) []str
 	return trimStringSliceHistory(append(messages, trimmed), limit)
 }
 
-// RecordProvisioningEvent records a provisioning event (success or failure) to the log
+// RecordProvisioningEvent records a provisioning event (success or failure) to the log.
+// Events from outside CB-Tumblebug are not counted in the failure analytics (see provisionstats.go).
 func RecordProvisioningEvent(event *model.ProvisioningEvent) error {
 	log.Debug().Msgf("Recording provisioning event for spec: %s, success: %t", event.SpecId, event.IsSuccess)
 
@@ -6018,8 +6279,9 @@ func RecordProvisioningEvent(event *model.ProvisioningEvent) error {
 	return nil
 }
 
-// RecordProvisioningEventsFromInfra analyzes Infra creation result and records provisioning events
-func RecordProvisioningEventsFromInfra(nsId string, infraInfo *model.InfraInfo) error {
+// RecordProvisioningEventsFromInfra analyzes Infra creation result and records provisioning events,
+// linking them to the request in ctx
+func RecordProvisioningEventsFromInfra(ctx context.Context, nsId string, infraInfo *model.InfraInfo) error {
 	log.Debug().Msgf("Recording provisioning events from Infra: %s", infraInfo.Id)
 
 	if infraInfo.CreationErrors == nil {
@@ -6034,6 +6296,7 @@ func RecordProvisioningEventsFromInfra(nsId string, infraInfo *model.InfraInfo)
 
 		// Determine if this VM failed or succeeded based on status
 		isSuccess := node.Status == model.StatusRunning
+		recordProvisioningResult(node.ConnectionConfig.ProviderName, isSuccess)
 		errorMessage := ""
 
 		if !isSuccess {
@@ -6072,14 +6335,30 @@ func RecordProvisioningEventsFromInfra(nsId string, infraInfo *model.InfraInfo)
 			Timestamp:    time.Now(),
 			NodeName:     node.Id,
 			InfraId:      infraInfo.Id,
+			Zone:         node.Region.Zone,
 		}
 
-		// Record the event
+		// Record the event; every attempt counts in the failure analytics,
+		// including successes without prior failures
+		recordProvisioningStats(event)
 		err := RecordProvisioningEvent(event)
 		if err != nil {
 			log.Error().Err(err).Msgf("Failed to record provisioning event for VM: %s", node.Id)
 			continue
 		}
+		summary := fmt.Sprintf("spec %s: succeeded", node.SpecId)
+		if !isSuccess {
+			summary = fmt.Sprintf("spec %s: failed: %s", node.SpecId, errorMessage)
+		}
+		clientManager.LinkRequest(common.RequestIDFromContext(ctx), clientManager.RequestLink{
+			Kind:    clientManager.RequestLinkProvisioningEvent,
+			Id:      infraInfo.Id + "/" + node.Id,
+			NsId:    nsId,
+			InfraId: infraInfo.Id,
+			NodeId:  node.Id,
+			Summary: summary,
+			Time:    event.Timestamp,
+		})
 
 		eventCount++
 		log.Debug().Msgf("Recorded provisioning event for VM: %s, spec: %s, success: %t",
@@ -0,0 +1,613 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+	"github.com/rs/zerolog/log"
+)
+
+// Provisioning failure analytics.
+//
+// ProvisioningLog keeps a short history per spec for risk analysis. In addition, every
+// provisioning attempt is counted in a daily bucket by provider, region, zone, spec and
+// image, with failures classified into normalized error categories. The buckets feed the
+// analytics API and the demotion of high-failure specs and regions in RecommendSpec.
+// Only attempts made by CB-Tumblebug itself are counted; events recorded through the API
+// go to the ProvisioningLog only, so they cannot demote specs or regions.
+
+const (
+	// provisioningStatsKeyPrefix is the kvstore prefix of daily buckets (/provisioning/stats/{yyyy-mm-dd})
+	provisioningStatsKeyPrefix = "/provisioning/stats/"
+	// provisioningDemotionPolicyKey is the kvstore key of the demotion policy
+	provisioningDemotionPolicyKey = "/provisioning/demotionPolicy"
+	// provisioningStatsRetentionDays is how long daily buckets are kept (and the maximum window)
+	provisioningStatsRetentionDays = 90
+	// provisioningDemotionCacheTTL bounds how often RecommendSpec re-reads the statistics
+	provisioningDemotionCacheTTL = time.Minute
+	// provisioningDemotionMaxGroups caps the specs (and the regions) demoted at once, worst first
+	provisioningDemotionMaxGroups = 100
+
+	defaultProvisioningAnalyticsWindowDays = 30
+	defaultDemotionWindowDays              = 14
+	defaultDemotionMinAttempts             = 3
+	defaultDemotionFailureRate             = 0.5
+)
+
+// provisioningStatsMu serializes read-modify-write of daily buckets
+var provisioningStatsMu sync.Mutex
+
+// provisioningStatsPrunedDate is the last day old buckets were pruned
+var provisioningStatsPrunedDate string
+
+// provisioningErrorKeywords classifies CSP error messages (lower-cased) in order.
+// Capacity shortages have no keywords here; they are matched by isAvailabilityFailure.
+var provisioningErrorKeywords = []struct {
+	category string
+	keywords []string
+}{
+	{model.ProvisioningErrorQuota, []string{
+		"quota", "limitexceeded", "limit exceeded", "exceeded the maximum", "maximum number of",
+		"operationnotallowed", "requestlimitexceeded",
+	}},
+	{model.ProvisioningErrorCapacity, nil},
+	{model.ProvisioningErrorImageIncompatible, []string{
+		"architecture", "incompatible", "not compatible", "unsupported image", "image is not supported",
+		"invalidamiid", "boot mode", "virtualization type", "hypervisor", "image not found", "imagenotfound",
+	}},
+	{model.ProvisioningErrorSpecUnavailable, []string{
+		"skunotavailable", "unsupported instance type", "invalid instance type", "instance type is not supported",
+		"invalidinstancetype", "is not supported in", "not available in", "machine type", "unsupportedoperation",
+	}},
+	{model.ProvisioningErrorPermission, []string{
+		"unauthorized", "forbidden", "permission", "access denied", "accessdenied", "authfailure",
+		"not authorized", "credential",
+	}},
+	{model.ProvisioningErrorNetwork, []string{
+		"subnet", "security group", "securitygroup", "vpc", "vnet", "ip address", "network",
+	}},
+	{model.ProvisioningErrorTimeout, []string{
+		"timeout", "timed out", "deadline exceeded",
+	}},
+}
+
+// classifyProvisioningError returns the normalized category of a CSP error message.
+func classifyProvisioningError(msg string) string {
+	lower := strings.ToLower(msg)
+	for _, rule := range provisioningErrorKeywords {
+		if rule.category == model.ProvisioningErrorCapacity {
+			if isAvailabilityFailure(msg) {
+				return rule.category
+			}
+			continue
+		}
+		for _, kw := range rule.keywords {
+			if strings.Contains(lower, kw) {
+				return rule.category
+			}
+		}
+	}
+	return model.ProvisioningErrorOther
+}
+
+// specFamilyOf returns the family of a CSP spec name
+// (t3.medium -> t3, ecs.g6.large -> g6, n2-standard-4 -> n2, Standard_D2s_v3 -> Ds_v3).
+func specFamilyOf(cspSpecName string) string {
+	name := cspSpecName
+	switch {
+	case strings.Contains(name, "."):
+		parts := strings.Split(name, ".")
+		if len(parts) > 2 && strings.EqualFold(parts[0], "ecs") {
+			return parts[1]
+		}
+		return parts[0]
+	case strings.Contains(name, "-"):
+		return strings.Split(name, "-")[0]
+	}
+	for _, prefix := range []string{"Standard_", "Basic_"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	parts := strings.SplitN(name, "_", 2)
+	family := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return -1
+		}
+		return r
+	}, parts[0])
+	if len(parts) == 2 {
+		family += "_" + parts[1]
+	}
+	if family == "" {
+		return cspSpecName
+	}
+	return family
+}
+
+// splitSpecId returns the provider, region and CSP spec name of a spec ID,
+// from its {provider}+{region}+{cspSpecName} form or from the spec object.
+func splitSpecId(specId string) (string, string, string) {
+	if parts := strings.SplitN(specId, "+", 3); len(parts) == 3 {
+		return parts[0], parts[1], parts[2]
+	}
+	if specInfo, err := resource.GetSpec(model.SystemCommonNs, specId); err == nil {
+		return specInfo.ProviderName, specInfo.RegionName, specInfo.CspSpecName
+	}
+	return "", "", specId
+}
+
+func provisioningStatsKey(date string) string {
+	return provisioningStatsKeyPrefix + date
+}
+
+// recordProvisioningStats counts a provisioning attempt in the bucket of its day.
+func recordProvisioningStats(event *model.ProvisioningEvent) {
+	if event.SpecId == "" {
+		return
+	}
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	at = at.UTC()
+	date := at.Format(time.DateOnly)
+	provider, region, cspSpecName := splitSpecId(event.SpecId)
+
+	provisioningStatsMu.Lock()
+	defer provisioningStatsMu.Unlock()
+
+	bucket := model.ProvisioningStatsBucket{Date: date, Counters: map[string]*model.ProvisioningStatsCounter{}}
+	val, exists, err := kvstore.Get(provisioningStatsKey(date))
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to read provisioning stats of %s", date)
+		return
+	}
+	if exists {
+		if err := json.Unmarshal([]byte(val), &bucket); err != nil || bucket.Counters == nil {
+			log.Warn().Err(err).Msgf("Resetting unreadable provisioning stats of %s", date)
+			bucket = model.ProvisioningStatsBucket{Date: date, Counters: map[string]*model.ProvisioningStatsCounter{}}
+		}
+	}
+
+	key := strings.Join([]string{provider, region, event.Zone, event.SpecId, event.CspImageName}, "|")
+	counter, ok := bucket.Counters[key]
+	if !ok {
+		counter = &model.ProvisioningStatsCounter{
+			ProviderName: provider,
+			RegionName:   region,
+			Zone:         event.Zone,
+			SpecId:       event.SpecId,
+			SpecFamily:   specFamilyOf(cspSpecName),
+			CspImageName: event.CspImageName,
+		}
+		bucket.Counters[key] = counter
+	}
+	counter.Attempts++
+	if !event.IsSuccess {
+		counter.Failures++
+		if counter.Categories == nil {
+			counter.Categories = map[string]int{}
+		}
+		counter.Categories[classifyProvisioningError(event.ErrorMessage)]++
+		counter.LastError = strings.TrimSpace(event.ErrorMessage)
+		counter.LastFailureAt = at.Format(time.RFC3339)
+	}
+
+	newVal, err := json.Marshal(bucket)
+	if err != nil {
+		return
+	}
+	if err := kvstore.Put(provisioningStatsKey(date), string(newVal)); err != nil {
+		log.Warn().Err(err).Msgf("Failed to save provisioning stats of %s", date)
+		return
+	}
+
+	if provisioningStatsPrunedDate != date {
+		provisioningStatsPrunedDate = date
+		pruneProvisioningStats(at)
+	}
+}
+
+// pruneProvisioningStats deletes the buckets older than the retention.
+func pruneProvisioningStats(now time.Time) {
+	keys, err := kvstore.GetList(provisioningStatsKeyPrefix)
+	if err != nil {
+		return
+	}
+	oldest := now.AddDate(0, 0, -provisioningStatsRetentionDays).Format(time.DateOnly)
+	for _, key := range keys {
+		if strings.TrimPrefix(key, provisioningStatsKeyPrefix) < oldest {
+			if err := kvstore.Delete(key); err != nil {
+				log.Warn().Err(err).Msgf("Failed to delete provisioning stats %s", key)
+			}
+		}
+	}
+}
+
+// loadProvisioningStats returns the buckets of the last windowDays days (today included).
+func loadProvisioningStats(windowDays int, now time.Time) ([]model.ProvisioningStatsBucket, error) {
+	from := now.AddDate(0, 0, -(windowDays - 1)).Format(time.DateOnly)
+	keyValues, err := kvstore.GetKvList(provisioningStatsKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var buckets []model.ProvisioningStatsBucket
+	for _, kv := range keyValues {
+		if strings.TrimPrefix(kv.Key, provisioningStatsKeyPrefix) < from {
+			continue
+		}
+		bucket := model.ProvisioningStatsBucket{}
+		if err := json.Unmarshal([]byte(kv.Value), &bucket); err != nil {
+			log.Warn().Err(err).Msgf("Skipping unreadable provisioning stats %s", kv.Key)
+			continue
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// provisioningGroupKey returns the group key of a counter and clears the fields below the grouping dimension.
+func provisioningGroupKey(c model.ProvisioningStatsCounter, groupBy string) (string, model.ProvisioningFailureStats) {
+	g := model.ProvisioningFailureStats{ProviderName: c.ProviderName}
+	switch groupBy {
+	case model.ProvisioningGroupByProvider:
+		return c.ProviderName, g
+	case model.ProvisioningGroupByZone:
+		g.RegionName, g.Zone = c.RegionName, c.Zone
+		return c.ProviderName + "+" + c.RegionName + "+" + c.Zone, g
+	case model.ProvisioningGroupBySpecFamily:
+		g.SpecFamily = c.SpecFamily
+		return c.ProviderName + "+" + c.SpecFamily, g
+	case model.ProvisioningGroupBySpec:
+		g.RegionName, g.SpecFamily, g.SpecId = c.RegionName, c.SpecFamily, c.SpecId
+		return c.SpecId, g
+	case model.ProvisioningGroupByImage:
+		g.RegionName, g.CspImageName = c.RegionName, c.CspImageName
+		return c.ProviderName + "+" + c.RegionName + "+" + c.CspImageName, g
+	default:
+		g.RegionName = c.RegionName
+		return c.ProviderName + "+" + c.RegionName, g
+	}
+}
+
+// aggregateProvisioningStats groups the counters of buckets by a dimension.
+func aggregateProvisioningStats(buckets []model.ProvisioningStatsBucket, groupBy, providerName, regionName string) map[string]*model.ProvisioningFailureStats {
+	groups := map[string]*model.ProvisioningFailureStats{}
+	for _, bucket := range buckets {
+		for _, c := range bucket.Counters {
+			if providerName != "" && !strings.EqualFold(c.ProviderName, providerName) {
+				continue
+			}
+			if regionName != "" && !strings.EqualFold(c.RegionName, regionName) {
+				continue
+			}
+			key, proto := provisioningGroupKey(*c, groupBy)
+			g, ok := groups[key]
+			if !ok {
+				proto.Key = key
+				g = &proto
+				groups[key] = g
+			}
+			g.Attempts += c.Attempts
+			g.Failures += c.Failures
+			for category, n := range c.Categories {
+				if g.Categories == nil {
+					g.Categories = map[string]int{}
+				}
+				g.Categories[category] += n
+			}
+			if c.LastFailureAt > g.LastFailureAt {
+				g.LastFailureAt, g.LastError = c.LastFailureAt, c.LastError
+			}
+		}
+	}
+	for _, g := range groups {
+		if g.Attempts > 0 {
+			g.FailureRate = math.Round(float64(g.Failures)/float64(g.Attempts)*1000) / 1000
+		}
+		top := 0
+		for category, n := range g.Categories {
+			if n > top || (n == top && category < g.TopCategory) {
+				top, g.TopCategory = n, category
+			}
+		}
+	}
+	return groups
+}
+
+// sortedFailureStats returns the groups with at least minAttempts attempts,
+// by failure rate and then by number of failures.
+func sortedFailureStats(groups map[string]*model.ProvisioningFailureStats, minAttempts int) []model.ProvisioningFailureStats {
+	result := []model.ProvisioningFailureStats{}
+	for _, g := range groups {
+		if g.Attempts >= minAttempts {
+			result = append(result, *g)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].FailureRate != result[j].FailureRate {
+			return result[i].FailureRate > result[j].FailureRate
+		}
+		if result[i].Failures != result[j].Failures {
+			return result[i].Failures > result[j].Failures
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// GetProvisioningAnalytics aggregates the provisioning attempts of the last windowDays days
+// by a dimension, with the top error categories and the daily timeline.
+func GetProvisioningAnalytics(windowDays int, groupBy, providerName, regionName string, minAttempts int) (model.ProvisioningAnalytics, error) {
+	if windowDays <= 0 {
+		windowDays = defaultProvisioningAnalyticsWindowDays
+	}
+	if windowDays > provisioningStatsRetentionDays {
+		return model.ProvisioningAnalytics{}, apierr.Invalid(fmt.Sprintf("windowDays must not exceed %d (retention of the statistics)", provisioningStatsRetentionDays), nil)
+	}
+	if groupBy == "" {
+		groupBy = model.ProvisioningGroupByRegion
+	}
+	switch groupBy {
+	case model.ProvisioningGroupByProvider, model.ProvisioningGroupByRegion, model.ProvisioningGroupByZone,
+		model.ProvisioningGroupBySpecFamily, model.ProvisioningGroupBySpec, model.ProvisioningGroupByImage:
+	default:
+		return model.ProvisioningAnalytics{}, apierr.Invalid(fmt.Sprintf("unsupported groupBy '%s' (provider, region, zone, specFamily, spec, image)", groupBy), nil)
+	}
+	if minAttempts <= 0 {
+		minAttempts = 1
+	}
+
+	now := time.Now().UTC()
+	buckets, err := loadProvisioningStats(windowDays, now)
+	if err != nil {
+		return model.ProvisioningAnalytics{}, err
+	}
+
+	result := model.ProvisioningAnalytics{
+		From:       now.AddDate(0, 0, -(windowDays - 1)).Format(time.DateOnly),
+		To:         now.Format(time.DateOnly),
+		WindowDays: windowDays,
+		GroupBy:    groupBy,
+	}
+
+	daily := map[string]*model.ProvisioningDailyStats{}
+	for d := 0; d < windowDays; d++ {
+		date := now.AddDate(0, 0, -(windowDays - 1 - d)).Format(time.DateOnly)
+		daily[date] = &model.ProvisioningDailyStats{Date: date}
+		result.Timeline = append(result.Timeline, model.ProvisioningDailyStats{Date: date})
+	}
+	categories := map[string]*model.ProvisioningErrorCategoryStats{}
+	categoryLatest := map[string]string{}
+	for _, bucket := range buckets {
+		for _, c := range bucket.Counters {
+			if providerName != "" && !strings.EqualFold(c.ProviderName, providerName) {
+				continue
+			}
+			if regionName != "" && !strings.EqualFold(c.RegionName, regionName) {
+				continue
+			}
+			result.TotalAttempts += c.Attempts
+			result.TotalFailures += c.Failures
+			if day, ok := daily[bucket.Date]; ok {
+				day.Attempts += c.Attempts
+				day.Failures += c.Failures
+			}
+			for category, n := range c.Categories {
+				stats, ok := categories[category]
+				if !ok {
+					stats = &model.ProvisioningErrorCategoryStats{Category: category}
+					categories[category] = stats
+				}
+				stats.Count += n
+				if c.LastFailureAt > categoryLatest[category] && classifyProvisioningError(c.LastError) == category {
+					categoryLatest[category] = c.LastFailureAt
+					stats.SampleError = c.LastError
+				}
+			}
+		}
+	}
+	for i := range result.Timeline {
+		result.Timeline[i] = *daily[result.Timeline[i].Date]
+	}
+	if result.TotalAttempts > 0 {
+		result.FailureRate = math.Round(float64(result.TotalFailures)/float64(result.TotalAttempts)*1000) / 1000
+	}
+
+	result.TopErrorCategories = []model.ProvisioningErrorCategoryStats{}
+	for _, stats := range categories {
+		if result.TotalFailures > 0 {
+			stats.Percent = math.Round(float64(stats.Count)/float64(result.TotalFailures)*1000) / 10
+		}
+		result.TopErrorCategories = append(result.TopErrorCategories, *stats)
+	}
+	sort.Slice(result.TopErrorCategories, func(i, j int) bool {
+		a, b := result.TopErrorCategories[i], result.TopErrorCategories[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Category < b.Category
+	})
+
+	result.Groups = sortedFailureStats(aggregateProvisioningStats(buckets, groupBy, providerName, regionName), minAttempts)
+	return result, nil
+}
+
+// provisioningDemotionCache holds the ORDER BY clause of the current demotion and its arguments
+var provisioningDemotionCache struct {
+	sync.Mutex
+	policy    model.ProvisioningDemotionPolicy
+	status    model.ProvisioningDemotionStatus
+	clause    string
+	args      []any
+	expiresAt time.Time
+}
+
+// normalizeDemotionPolicy fills the defaults of a demotion policy.
+func normalizeDemotionPolicy(policy *model.ProvisioningDemotionPolicy) error {
+	if policy.WindowDays <= 0 {
+		policy.WindowDays = defaultDemotionWindowDays
+	}
+	if policy.WindowDays > provisioningStatsRetentionDays {
+		return apierr.Invalid(fmt.Sprintf("windowDays must not exceed %d (retention of the statistics)", provisioningStatsRetentionDays), nil)
+	}
+	if policy.MinAttempts <= 0 {
+		policy.MinAttempts = defaultDemotionMinAttempts
+	}
+	if policy.FailureRateThreshold <= 0 {
+		policy.FailureRateThreshold = defaultDemotionFailureRate
+	}
+	if policy.FailureRateThreshold > 1 {
+		return apierr.Invalid("failureRateThreshold must be between 0 and 1", nil)
+	}
+	return nil
+}
+
+// GetProvisioningDemotionPolicy returns the demotion policy (enabled with the defaults unless set).
+func GetProvisioningDemotionPolicy() (model.ProvisioningDemotionPolicy, error) {
+	policy := model.ProvisioningDemotionPolicy{Enabled: true}
+	val, exists, err := kvstore.Get(provisioningDemotionPolicyKey)
+	if err != nil {
+		return policy, err
+	}
+	if exists {
+		if err := json.Unmarshal([]byte(val), &policy); err != nil {
+			return policy, err
+		}
+	}
+	err = normalizeDemotionPolicy(&policy)
+	return policy, err
+}
+
+// SetProvisioningDemotionPolicy stores the demotion policy and returns what it demotes.
+func SetProvisioningDemotionPolicy(policy model.ProvisioningDemotionPolicy) (model.ProvisioningDemotionStatus, error) {
+	if err := normalizeDemotionPolicy(&policy); err != nil {
+		return model.ProvisioningDemotionStatus{}, err
+	}
+	val, err := json.Marshal(policy)
+	if err != nil {
+		return model.ProvisioningDemotionStatus{}, err
+	}
+	if err := kvstore.Put(provisioningDemotionPolicyKey, string(val)); err != nil {
+		return model.ProvisioningDemotionStatus{}, err
+	}
+	provisioningDemotionCache.Lock()
+	provisioningDemotionCache.expiresAt = time.Time{}
+	provisioningDemotionCache.Unlock()
+	return GetProvisioningDemotionStatus()
+}
+
+// GetProvisioningDemotionStatus returns the demotion policy with the specs and regions it demotes.
+func GetProvisioningDemotionStatus() (model.ProvisioningDemotionStatus, error) {
+	status, _, _, err := currentProvisioningDemotion()
+	return status, err
+}
+
+// currentProvisioningDemotion returns the demotion status and its ORDER BY clause with the
+// arguments of its placeholders, cached for a minute.
+func currentProvisioningDemotion() (model.ProvisioningDemotionStatus, string, []any, error) {
+	provisioningDemotionCache.Lock()
+	defer provisioningDemotionCache.Unlock()
+	if time.Now().Before(provisioningDemotionCache.expiresAt) {
+		return provisioningDemotionCache.status, provisioningDemotionCache.clause, provisioningDemotionCache.args, nil
+	}
+
+	policy, err := GetProvisioningDemotionPolicy()
+	if err != nil {
+		return model.ProvisioningDemotionStatus{}, "", nil, err
+	}
+	now := time.Now().UTC()
+	buckets, err := loadProvisioningStats(policy.WindowDays, now)
+	if err != nil {
+		return model.ProvisioningDemotionStatus{}, "", nil, err
+	}
+
+	status := model.ProvisioningDemotionStatus{
+		Policy:          policy,
+		DemotedSpecs:    demotedFailureStats(aggregateProvisioningStats(buckets, model.ProvisioningGroupBySpec, "", ""), policy),
+		DemotedRegions:  demotedFailureStats(aggregateProvisioningStats(buckets, model.ProvisioningGroupByRegion, "", ""), policy),
+		LastEvaluatedAt: now.Format(time.RFC3339),
+	}
+
+	// Spec IDs, providers and regions come from recorded events, so they are bound, not inlined
+	var conditions []string
+	var args []any
+	if len(status.DemotedSpecs) > 0 {
+		placeholders := make([]string, 0, len(status.DemotedSpecs))
+		for _, s := range status.DemotedSpecs {
+			placeholders = append(placeholders, "?")
+			args = append(args, s.SpecId)
+		}
+		conditions = append(conditions, fmt.Sprintf("spec_infos.id IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	for _, r := range status.DemotedRegions {
+		conditions = append(conditions, "(spec_infos.provider_name = ? AND spec_infos.region_name = ?)")
+		args = append(args, r.ProviderName, r.RegionName)
+	}
+	clause := ""
+	if len(conditions) > 0 {
+		clause = fmt.Sprintf("CASE WHEN %s THEN 1 ELSE 0 END ASC", strings.Join(conditions, " OR "))
+	}
+
+	provisioningDemotionCache.policy = policy
+	provisioningDemotionCache.status = status
+	provisioningDemotionCache.clause = clause
+	provisioningDemotionCache.args = args
+	provisioningDemotionCache.expiresAt = time.Now().Add(provisioningDemotionCacheTTL)
+	return status, clause, args, nil
+}
+
+// demotedFailureStats returns the groups over the failure rate threshold of a policy,
+// at most provisioningDemotionMaxGroups of them.
+func demotedFailureStats(groups map[string]*model.ProvisioningFailureStats, policy model.ProvisioningDemotionPolicy) []model.ProvisioningFailureStats {
+	result := []model.ProvisioningFailureStats{}
+	for _, g := range sortedFailureStats(groups, policy.MinAttempts) {
+		if len(result) == provisioningDemotionMaxGroups {
+			break
+		}
+		if g.FailureRate >= policy.FailureRateThreshold {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// provisioningDemotionOrderBy returns the ORDER BY clause that puts demoted specs last with
+// the arguments of its placeholders, or "" when nothing is demoted. Unless force is set, it is
+// "" when the policy is disabled.
+func provisioningDemotionOrderBy(force bool) (string, []any) {
+	status, clause, args, err := currentProvisioningDemotion()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to evaluate provisioning demotion; specs are not demoted")
+		return "", nil
+	}
+	if !force && !status.Policy.Enabled {
+		return "", nil
+	}
+	// A copy, as callers append to the arguments of the cached clause
+	return clause, slices.Clone(args)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infra
+
+import (
+	"context"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
+)
+
+func init() {
+	common.RegisterNsUsageFunc(GetNsResourceUsage)
+}
+
+// GetNsResourceUsage counts the resources of a namespace that NsQuota limits.
+// Terminated Nodes are not counted; Node capacity and cost come from the Node's spec summary.
+func GetNsResourceUsage(nsId string) (model.NsResourceUsage, error) {
+	usage := model.NsResourceUsage{}
+
+	infraIds, err := ListInfraId(nsId)
+	if err != nil {
+		return usage, err
+	}
+	for _, infraId := range infraIds {
+		nodes, err := ListInfraNodeInfo(nsId, infraId)
+		if err != nil {
+			return usage, err
+		}
+		for _, node := range nodes {
+			if node.Status == model.StatusTerminated {
+				continue
+			}
+			usage.Nodes++
+			usage.VCpu += int(node.Spec.VCPU)
+			usage.MemoryGiB += float64(node.Spec.MemoryGiB)
+			usage.Gpus += int(node.Spec.AcceleratorCount)
+			if cost := nodeCostPerHour(node); cost > 0 {
+				usage.CostPerHour += cost
+			}
+		}
+	}
+
+	k8sClusterIds, err := resource.ListK8sClusterId(nsId)
+	if err != nil {
+		return usage, err
+	}
+	usage.K8sClusters = len(k8sClusterIds)
+
+	objectStorageIds, err := resource.ListResourceId(nsId, model.StrObjectStorage)
+	if err != nil {
+		return usage, err
+	}
+	usage.ObjectStorages = len(objectStorageIds)
+
+	return usage, nil
+}
+
+// checkNodeGroupQuota checks the namespace quota for the Nodes of static NodeGroup requests
+// and reserves them until release is called (see common.ReserveNsQuota).
+// NodeGroups registering existing CSP VMs (CspResourceId set) are not counted.
+func checkNodeGroupQuota(ctx context.Context, nsId string, nodeGroups []model.CreateNodeGroupReq) (context.Context, func(), error) {
+	demand := model.NsQuotaDemand{}
+	for _, ng := range nodeGroups {
+		if ng.CspResourceId != "" {
+			continue
+		}
+		resource.AddNodeQuotaDemand(&demand, nsId, ng.SpecId, ng.ConnectionName, ng.NodeGroupSize, ng.CapacityType == model.CapacityTypeSpot)
+	}
+	if demand.Nodes == 0 {
+		return ctx, func() {}, nil
+	}
+	return common.ReserveNsQuota(ctx, nsId, demand)
+}
+
+// checkDynamicNodeGroupQuota checks the namespace quota for the Nodes of dynamic NodeGroup
+// requests and reserves them until release is called (see common.ReserveNsQuota).
+func checkDynamicNodeGroupQuota(ctx context.Context, nsId string, nodeGroups []model.CreateNodeGroupDynamicReq) (context.Context, func(), error) {
+	demand := model.NsQuotaDemand{}
+	for _, ng := range nodeGroups {
+		resource.AddNodeQuotaDemand(&demand, nsId, ng.SpecId, ng.ConnectionName, ng.NodeGroupSize, ng.CapacityType == model.CapacityTypeSpot)
+	}
+	return common.ReserveNsQuota(ctx, nsId, demand)
+}
@@ -172,19 +172,25 @@ func RecommendSpec(ctx context.Context, nsId string, plan model.RecommendSpecReq
 	}
 
 	// Build ORDER BY clause based on priority policy
-	orderBy, err := buildOrderByClause(plan.Priority.Policy)
+	orderBy, orderByArgs, err := buildOrderByClause(plan.Priority.Policy)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to build ORDER BY clause")
 		return nil, err
 	}
 
+	// Demote specs and regions with a high provisioning failure rate (see provisionstats.go)
+	if demotion, demotionArgs := provisioningDemotionOrderBy(false); demotion != "" && !strings.Contains(orderBy, demotion) {
+		orderBy = demotion + ", " + orderBy
+		orderByArgs = append(demotionArgs, orderByArgs...)
+	}
+
 	// log.Debug().Msgf("Using ORDER BY: %s", orderBy)
 
 	// Filtering and sorting in one DB query
 	log.Debug().Msg("[Filtering and sorting specs with DB query]")
 
 	startTime := time.Now()
-	filteredSpecs, err := resource.FilterSpecsByRange(nsId, *u, orderBy)
+	filteredSpecs, err := resource.FilterSpecsByRange(nsId, *u, orderBy, orderByArgs...)
 
 	if err != nil {
 		log.Error().Err(err).Msg("")
@@ -204,26 +210,37 @@ func RecommendSpec(ctx context.Context, nsId string, plan model.RecommendSpecReq
 
 }
 
-// buildOrderByClause builds the ORDER BY clause based on priority policies
-func buildOrderByClause(policies []model.PriorityCondition) (string, error) {
+// buildOrderByClause builds the ORDER BY clause based on priority policies,
+// with the arguments of its ? placeholders
+func buildOrderByClause(policies []model.PriorityCondition) (string, []any, error) {
 	if len(policies) == 0 {
 		// Default to cost ordering (ascending - cheaper first), -1 means unknown cost (lowest priority)
-		return "CASE WHEN cost_per_hour > 0 THEN cost_per_hour ELSE 999999 END ASC", nil
+		return "CASE WHEN cost_per_hour > 0 THEN cost_per_hour ELSE 999999 END ASC", nil, nil
 	}
 
 	orderParts := []string{}
+	var orderArgs []any
 
 	for _, policy := range policies {
 		switch policy.Metric {
 		case "cost":
 			// Cost: ascending (cheaper first), -1 means unknown cost (lowest priority)
 			orderParts = append(orderParts, "CASE WHEN cost_per_hour > 0 THEN cost_per_hour ELSE 999999 END ASC")
+		case "spotCost":
+			// Spot cost: ascending (cheaper first); specs without a known spot price come last
+			orderParts = append(orderParts, "CASE WHEN spot_cost_per_hour > 0 THEN spot_cost_per_hour ELSE 999999 END ASC")
 		case "performance":
 			// Performance: descending (higher performance first), -1 means unknown performance (lowest priority)
 			orderParts = append(orderParts, "CASE WHEN evaluation_score01 > 0 THEN evaluation_score01 ELSE -999999 END DESC")
 		case "random":
 			// Random: use RANDOM() function
 			orderParts = append(orderParts, "RANDOM()")
+		case "reliability":
+			// Reliability: specs and regions with a high provisioning failure rate last
+			if demotion, demotionArgs := provisioningDemotionOrderBy(true); demotion != "" {
+				orderParts = append(orderParts, demotion)
+				orderArgs = append(orderArgs, demotionArgs...)
+			}
 		case "location":
 			// Location: build distance-based ORDER BY
 			locationOrderBy, err := BuildLocationOrderByClause(&policy.Parameter)
@@ -249,10 +266,10 @@ func buildOrderByClause(policies []model.PriorityCondition) (string, error) {
 	}
 
 	if len(orderParts) == 0 {
-		return "CASE WHEN cost_per_hour > 0 THEN cost_per_hour ELSE 999999 END ASC", nil
+		return "CASE WHEN cost_per_hour > 0 THEN cost_per_hour ELSE 999999 END ASC", nil, nil
 	}
 
-	return strings.Join(orderParts, ", "), nil
+	return strings.Join(orderParts, ", "), orderArgs, nil
 }
 
 // RecommendAlternativeNodeConfig finds the best-matching node configurations (spec + image)
@@ -1288,6 +1305,7 @@ func RecommendSpecOptions(nsId string) (*model.RecommendSpecRequestOptions, erro
 				"performance",
 				"location",
 				"latency",
+				"reliability",
 				"random",
 			},
 			ExamplePolicies: []model.PriorityConditionExample{
@@ -1325,6 +1343,11 @@ func RecommendSpecOptions(nsId string) (*model.RecommendSpecRequestOptions, erro
 						},
 					},
 				},
+				{
+					Metric:      "reliability",
+					Description: "Put specs and regions with a high provisioning failure rate last",
+					Weight:      "1.0",
+				},
 				{
 					Metric:      "random",
 					Description: "Random prioritization for testing",
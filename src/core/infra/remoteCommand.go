@@ -37,12 +37,15 @@ import (
 	"time"
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 	validator "github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -416,8 +419,21 @@ func TbInfraCmdReqStructLevelValidation(sl validator.StructLevel) {
 // It now supports user-configurable timeout via InfraCmdReq.TimeoutMinutes
 // Returns the task ID in x-task-id for tracking and cancellation
 func RemoteCommandToInfra(nsId string, infraId string, nodeGroupId string, nodeId string, labelSelector string, req *model.InfraCmdReq, xRequestId string) ([]model.SshCmdResult, error) {
+	return RemoteCommandToInfraWithContext(context.Background(), nsId, infraId, nodeGroupId, nodeId, labelSelector, req, xRequestId)
+}
 
-	err := common.CheckString(nsId)
+// RemoteCommandToInfraWithContext is RemoteCommandToInfra continuing the trace in ctx.
+// Cancellation of ctx is not inherited (async commands outlive the HTTP request);
+// the execution is bounded by the command timeout and the cancel API instead.
+func RemoteCommandToInfraWithContext(ctx context.Context, nsId string, infraId string, nodeGroupId string, nodeId string, labelSelector string, req *model.InfraCmdReq, xRequestId string) (results []model.SshCmdResult, err error) {
+	ctx, span := tracing.Start(context.WithoutCancel(ctx), "infra.remoteCommand",
+		attribute.String("ns.id", nsId),
+		attribute.String("infra.id", infraId),
+		attribute.String("request.id", xRequestId),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	err = common.CheckString(nsId)
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return nil, err
@@ -531,7 +547,7 @@ func RemoteCommandToInfra(nsId string, infraId string, nodeGroupId string, nodeI
 	// Create a parent context with timeout for overall execution
 	// Each Node will have its own child context for individual cancellation
 	timeout := time.Duration(timeoutMinutes) * time.Minute
-	parentCtx, parentCancel := context.WithTimeout(context.Background(), timeout)
+	parentCtx, parentCancel := context.WithTimeout(ctx, timeout)
 	defer parentCancel() // Ensure parent context is cancelled when function returns
 
 	log.Info().
@@ -1819,7 +1835,25 @@ func GetNodeSshHostKey(nsId string, infraId string, nodeId string) (model.SshHos
 	return *nodeInfo.SshHostKeyInfo, nil
 }
 
-// runSSHWithContext executes SSH commands with context-based timeout and cancellation support.
+// runSSHWithContext executes SSH commands with context-based timeout and cancellation support,
+// recording the SSH session as a trace span (see runSSHSession for the connection modes).
+func runSSHWithContext(ctx context.Context, bastionInfo model.SshInfo, targetInfo model.SshInfo, cmds []string, bastionCtx tofuContext, targetCtx tofuContext) (map[int]string, map[int]string, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("ssh.target", targetInfo.EndPoint),
+		attribute.String("ssh.user", targetInfo.UserName),
+		attribute.Bool("ssh.via_bastion", bastionCtx != targetCtx),
+		attribute.Int("ssh.command_count", len(cmds)),
+	}
+	if meta := getSSHLogMeta(ctx); meta != nil {
+		attrs = append(attrs, attribute.String("node.id", meta.NodeId), attribute.Int("command.index", meta.CommandIndex))
+	}
+	ctx, span := tracing.Start(ctx, "ssh.session", attrs...)
+	stdoutMap, stderrMap, err := runSSHSession(ctx, bastionInfo, targetInfo, cmds, bastionCtx, targetCtx)
+	tracing.End(span, err)
+	return stdoutMap, stderrMap, err
+}
+
+// runSSHSession executes SSH commands with context-based timeout and cancellation support.
 //
 // It transparently handles two connection modes based on the TOFU contexts:
 //   - bastion-tunneled: bastionCtx and targetCtx identify different VMs. We
@@ -1832,7 +1866,7 @@ func GetNodeSshHostKey(nsId string, infraId string, nodeId string) (model.SshHos
 //     endpoint directly. Caller is responsible for setting targetInfo.EndPoint
 //     to a publicly reachable address in this case (private IPs aren't
 //     routable from cb-tumblebug).
-func runSSHWithContext(ctx context.Context, bastionInfo model.SshInfo, targetInfo model.SshInfo, cmds []string, bastionCtx tofuContext, targetCtx tofuContext) (map[int]string, map[int]string, error) {
+func runSSHSession(ctx context.Context, bastionInfo model.SshInfo, targetInfo model.SshInfo, cmds []string, bastionCtx tofuContext, targetCtx tofuContext) (map[int]string, map[int]string, error) {
 	stdoutMap := make(map[int]string)
 	stderrMap := make(map[int]string)
 
@@ -2234,7 +2268,7 @@ func runSSHWithContext(ctx context.Context, bastionInfo model.SshInfo, targetInf
 // context carries SSH log metadata (see withSSHLogMeta) — publishes line-level
 // events to the SSE log broker for live streaming to UI clients.
 //
-// Both connection modes inside runSSHWithContext (bastion-tunneled and
+// Both connection modes inside runSSHSession (bastion-tunneled and
 // self-bastion direct) converge here once an *ssh.Client is established, so
 // the SSH session/IO/streaming logic lives in exactly one place.
 func executeCommandsOnSSHClient(ctx context.Context, client *ssh.Client, cmds []string) (map[int]string, map[int]string, error) {
@@ -4272,6 +4306,15 @@ func AddCommandStatusInfo(nsId, infraId, nodeId, xRequestId, commandRequested, c
 		return 0, err
 	}
 
+	clientManager.LinkRequest(xRequestId, clientManager.RequestLink{
+		Kind:    clientManager.RequestLinkCommand,
+		Id:      fmt.Sprintf("%s:%s:%d", xRequestId, nodeId, nextIndex),
+		NsId:    nsId,
+		InfraId: infraId,
+		NodeId:  nodeId,
+		Summary: commandRequested,
+	})
+
 	// Publish CommandStatus event for newly queued command
 	if xRequestId != "" {
 		PublishCommandEvent(xRequestId, model.CommandStreamEvent{
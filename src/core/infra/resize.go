@@ -0,0 +1,332 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	cspdirect "github.com/cloud-barista/cb-tumblebug/src/core/csp"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
+	"github.com/rs/zerolog/log"
+)
+
+// resizingNodes holds the Nodes with a resize in progress (key: nsId/infraId/nodeId).
+var resizingNodes sync.Map
+
+const (
+	// nodeResizeTimeout bounds the whole stop → resize → start sequence of a Node
+	nodeResizeTimeout = 30 * time.Minute
+	// nodeResizeStatusTimeout bounds waiting for a Node to reach Suspended or Running
+	nodeResizeStatusTimeout = 10 * time.Minute
+	// nodeResizePollInterval is the interval of Node status checks during a resize
+	nodeResizePollInterval = 10 * time.Second
+)
+
+// checkSpotResize rejects resizing a spot Node: resize stops and starts the instance,
+// and spot instances are launched one-time with terminate-on-interruption, so they cannot be stopped.
+func checkSpotResize(node model.NodeInfo) error {
+	if strings.EqualFold(node.CapacityType, model.CapacityTypeSpot) {
+		return apierr.Invalid(fmt.Sprintf("resize is not supported for spot Node '%s' (a spot instance cannot be stopped); replace it with a Node of the target spec instead", node.Id), nil)
+	}
+	return nil
+}
+
+// resizeInfraNode validates a vertical resize of a Node to specId and starts it in the background.
+// CB-Spider has no API to change the spec of a VM, so the resize goes through the CSP SDK
+// registered with cspdirect.RegisterVMResizeHandler; other providers are rejected.
+func resizeInfraNode(nsId, infraId, nodeId, specId string) (string, error) {
+	if specId == "" {
+		return "", apierr.Invalid("specId is required for the resize action", nil)
+	}
+
+	node, err := GetNodeObject(nsId, infraId, nodeId)
+	if err != nil {
+		return "", err
+	}
+	if node.SpecId == specId {
+		return "", apierr.Invalid(fmt.Sprintf("Node '%s' already has spec '%s'", nodeId, specId), nil)
+	}
+	if node.CspResourceId == "" {
+		return "", apierr.Invalid(fmt.Sprintf("Node '%s' has no CSP resource ID", nodeId), nil)
+	}
+	if err := checkSpotResize(node); err != nil {
+		return "", err
+	}
+
+	providerName := node.ConnectionConfig.ProviderName
+	handler, ok := cspdirect.GetVMResizeHandler(providerName)
+	if !ok {
+		return "", apierr.Invalid(fmt.Sprintf("resize is not supported for provider '%s' (supported: %s)",
+			providerName, strings.Join(cspdirect.ListVMResizeProviders(), ", ")), nil)
+	}
+
+	status, err := GetInfraNodeStatus(nsId, infraId, nodeId, false)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(status.Status, model.StatusRunning) && !strings.EqualFold(status.Status, model.StatusSuspended) {
+		return "", &apierr.StatusError{StatusCode: http.StatusConflict,
+			Message: fmt.Sprintf("resize is not allowed for Node '%s' under %s (Running or Suspended required)", nodeId, status.Status)}
+	}
+
+	specInfo, err := resource.GetSpec(model.SystemCommonNs, specId)
+	if err != nil {
+		return "", apierr.Invalid(fmt.Sprintf("spec '%s' is not found: %v", specId, err), nil)
+	}
+	if !strings.EqualFold(specInfo.ProviderName, providerName) ||
+		!strings.EqualFold(specInfo.RegionName, node.ConnectionConfig.RegionDetail.RegionName) {
+		return "", apierr.Invalid(fmt.Sprintf("spec '%s' is in %s/%s, but Node '%s' is in %s/%s; resize keeps the Node in place",
+			specId, specInfo.ProviderName, specInfo.RegionName, nodeId, providerName, node.ConnectionConfig.RegionDetail.RegionName), nil)
+	}
+
+	ctx := context.WithValue(context.Background(), model.CtxKeyCredentialHolder, node.ConnectionConfig.CredentialHolder)
+	review, err := ReviewSpecImagePair(ctx, specId, node.ImageId, node.RootDiskType, node.Region.Zone)
+	if err != nil {
+		return "", err
+	}
+	if !review.SpecValidation.IsAvailable {
+		return "", apierr.Invalid(fmt.Sprintf("spec '%s' is not available: %s", specId, review.SpecValidation.Message), nil)
+	}
+	// The image of the Node may be a custom image that the review cannot resolve;
+	// only a failed review of a resolvable pair (e.g., architecture mismatch) rejects the resize.
+	if !review.IsValid && review.ImageValidation.IsAvailable {
+		return "", apierr.Invalid(fmt.Sprintf("spec '%s' does not fit Node '%s': %s", specId, nodeId, strings.Join(review.Errors, "; ")), nil)
+	}
+	if review.Availability != nil && node.Region.Zone != "" {
+		for _, z := range review.Availability.Zones {
+			if strings.EqualFold(z.ZoneId, node.Region.Zone) && !z.Available {
+				return "", apierr.Invalid(fmt.Sprintf("spec '%s' is out of stock in zone %s of Node '%s'", specId, node.Region.Zone, nodeId), nil)
+			}
+		}
+	}
+
+	key := common.GenInfraKey(nsId, infraId, nodeId)
+	if _, busy := resizingNodes.LoadOrStore(key, specId); busy {
+		return "", &apierr.StatusError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("Node '%s' is already being resized", nodeId)}
+	}
+
+	// The growth stays reserved in the quota until the Node is recorded with the new spec
+	releaseQuota, err := checkNodeResizeLimits(nsId, infraId, node, specId, specInfo)
+	if err != nil {
+		resizingNodes.Delete(key)
+		return "", err
+	}
+
+	wasRunning := strings.EqualFold(status.Status, model.StatusRunning)
+	go func() {
+		defer resizingNodes.Delete(key)
+		defer releaseQuota()
+		runNodeResize(nsId, infraId, node, specInfo, handler, wasRunning)
+	}()
+
+	steps := "resize"
+	if handler.RequiresStop && wasRunning {
+		steps = "stop → resize → start"
+	}
+	return fmt.Sprintf("Working on resize of Node %s from %s to %s (%s)", nodeId, node.CspSpecName, specInfo.CspSpecName, steps), nil
+}
+
+// checkNodeResizeLimits applies the namespace quota (to the growth of the Node),
+// the guardrails and the budgets to the target spec of a resize. The growth is
+// reserved in the quota until release is called.
+func checkNodeResizeLimits(nsId, infraId string, node model.NodeInfo, specId string, specInfo model.SpecInfo) (func(), error) {
+	demand := model.NsQuotaDemand{NsResourceUsage: model.NsResourceUsage{
+		VCpu:        int(specInfo.VCPU) - int(node.Spec.VCPU),
+		MemoryGiB:   float64(specInfo.MemoryGiB) - float64(node.Spec.MemoryGiB),
+		Gpus:        int(specInfo.AcceleratorCount) - int(node.Spec.AcceleratorCount),
+		CostPerHour: specCostPerHour(specInfo, node.CapacityType) - nodeCostPerHour(node),
+	}}
+	_, release, err := common.ReserveNsQuota(context.Background(), nsId, demand)
+	if err != nil {
+		return release, err
+	}
+
+	infraInfo, _, err := GetInfraObject(nsId, infraId)
+	if err == nil {
+		err = common.EnforceGuardrails(nsId, nodeGuardrailTarget(node.Id, infraInfo.Label, node.Label, node.ImageId, specId))
+	}
+	if err == nil {
+		err = checkBudgetBlock(nsId, infraId, node.Label)
+	}
+	if err != nil {
+		release()
+		return func() {}, err
+	}
+	return release, nil
+}
+
+// RequestNodeResizeApproval parks an upsize of a Node as a change request if the cost of
+// the target spec exceeds the approval threshold. It returns nil if the resize can proceed.
+func RequestNodeResizeApproval(ctx context.Context, nsId, infraId, nodeId, specId, requester string) (*model.ChangeRequest, error) {
+	if common.ApprovalCostThreshold() <= 0 || specId == "" {
+		return nil, nil
+	}
+	node, err := GetNodeObject(nsId, infraId, nodeId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSpotResize(node); err != nil {
+		return nil, err
+	}
+	specInfo, err := resource.GetSpec(model.SystemCommonNs, specId)
+	if err != nil {
+		return nil, apierr.Invalid(fmt.Sprintf("spec '%s' is not found: %v", specId, err), nil)
+	}
+
+	currentCost := nodeCostPerHour(node)
+	targetCost := specCostPerHour(specInfo, node.CapacityType)
+	// Downsizing never requires approval
+	if targetCost <= currentCost || !common.ApprovalRequired(model.ApprovalOpNodeResize, targetCost) {
+		return nil, nil
+	}
+
+	cr, err := common.SubmitChangeRequest(model.ChangeRequest{
+		Operation:        model.ApprovalOpNodeResize,
+		NsId:             nsId,
+		TargetId:         infraId + "/" + nodeId,
+		Option:           specId,
+		CredentialHolder: common.CredentialHolderFromContext(ctx),
+		Requester:        requester,
+		Impact: model.ChangeRequestImpact{
+			Summary: fmt.Sprintf("resize Node '%s' of Infra '%s' from %s ($%.4f/hour) to %s ($%.4f/hour, threshold $%.4f/hour)",
+				nodeId, infraId, node.CspSpecName, currentCost, specInfo.CspSpecName, targetCost, common.ApprovalCostThreshold()),
+			NodeCount:            1,
+			EstimatedCostPerHour: targetCost,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+// runNodeResize changes the spec of a Node and records the outcome in the Node's system message.
+// A Node stopped for the resize is started again even if the resize fails.
+func runNodeResize(nsId, infraId string, node model.NodeInfo, specInfo model.SpecInfo, handler cspdirect.VMResizeHandler, wasRunning bool) {
+	nodeId := node.Id
+	log.Info().Msgf("[Resize] Node %s: %s -> %s", nodeId, node.CspSpecName, specInfo.CspSpecName)
+
+	stopped := false
+	if handler.RequiresStop && wasRunning {
+		if err := controlNodeAndWait(nsId, infraId, nodeId, model.ActionSuspend, model.StatusSuspended); err != nil {
+			recordNodeResizeMessage(nsId, infraId, nodeId, fmt.Sprintf("resize to %s failed: cannot stop Node: %v", specInfo.CspSpecName, err))
+			return
+		}
+		stopped = true
+	}
+
+	ctx, cancel := context.WithTimeout(
+		context.WithValue(context.Background(), model.CtxKeyCredentialHolder, node.ConnectionConfig.CredentialHolder),
+		nodeResizeTimeout)
+	defer cancel()
+	resizeErr := handler.Resize(ctx, node.ConnectionConfig.RegionDetail.RegionName, node.Region.Zone, node.CspResourceId, specInfo.CspSpecName)
+
+	if resizeErr == nil {
+		// Record the new spec (and its cost) before starting the Node again, so that a
+		// failed start does not leave the stored spec behind the CSP.
+		if latest, err := GetNodeObject(nsId, infraId, nodeId); err == nil {
+			latest.SpecId = specInfo.Id
+			latest.CspSpecName = specInfo.CspSpecName
+			latest.Spec = specSummaryOf(specInfo)
+			UpdateNodeInfo(nsId, infraId, latest)
+			updateNodeUsagePrice(nsId, infraId, latest)
+		}
+	}
+
+	var startErr error
+	if stopped {
+		startErr = controlNodeAndWait(nsId, infraId, nodeId, model.ActionResume, model.StatusRunning)
+	} else if wasRunning {
+		startErr = waitNodeStatus(nsId, infraId, nodeId, model.StatusRunning)
+	}
+
+	switch {
+	case resizeErr != nil && startErr != nil:
+		recordNodeResizeMessage(nsId, infraId, nodeId, fmt.Sprintf("resize to %s failed: %v; restarting the Node also failed: %v", specInfo.CspSpecName, resizeErr, startErr))
+	case resizeErr != nil:
+		recordNodeResizeMessage(nsId, infraId, nodeId, fmt.Sprintf("resize to %s failed (Node kept %s): %v", specInfo.CspSpecName, node.CspSpecName, resizeErr))
+	case startErr != nil:
+		recordNodeResizeMessage(nsId, infraId, nodeId, fmt.Sprintf("resized to %s, but the Node did not start again: %v", specInfo.CspSpecName, startErr))
+	default:
+		recordNodeResizeMessage(nsId, infraId, nodeId, fmt.Sprintf("resized from %s to %s", node.CspSpecName, specInfo.CspSpecName))
+	}
+}
+
+// controlNodeAndWait runs a lifecycle action on a Node and waits until it reaches targetStatus.
+func controlNodeAndWait(nsId, infraId, nodeId, action, targetStatus string) error {
+	var wg sync.WaitGroup
+	results := make(chan model.ControlNodeResult, 1)
+	wg.Add(1)
+	go ControlNodeAsync(context.Background(), &wg, nsId, infraId, nodeId, action, results)
+	result := <-results
+	if result.Error != nil {
+		return result.Error
+	}
+	return waitNodeStatus(nsId, infraId, nodeId, targetStatus)
+}
+
+// waitNodeStatus polls the CSP status of a Node until it reaches targetStatus.
+func waitNodeStatus(nsId, infraId, nodeId, targetStatus string) error {
+	deadline := time.Now().Add(nodeResizeStatusTimeout)
+	lastStatus := ""
+	for time.Now().Before(deadline) {
+		status, err := FetchNodeStatus(nsId, infraId, nodeId)
+		if err == nil {
+			lastStatus = status.Status
+			if strings.EqualFold(status.Status, targetStatus) {
+				return nil
+			}
+			if strings.EqualFold(status.Status, model.StatusFailed) || strings.EqualFold(status.Status, model.StatusTerminated) {
+				return fmt.Errorf("Node %s is %s", nodeId, status.Status)
+			}
+		}
+		time.Sleep(nodeResizePollInterval)
+	}
+	return fmt.Errorf("Node %s did not reach %s within %v (last status: %s)", nodeId, targetStatus, nodeResizeStatusTimeout, lastStatus)
+}
+
+// recordNodeResizeMessage logs the outcome of a resize and stores it as the Node's system message.
+func recordNodeResizeMessage(nsId, infraId, nodeId, msg string) {
+	log.Info().Msgf("[Resize] Node %s: %s", nodeId, msg)
+	node, err := GetNodeObject(nsId, infraId, nodeId)
+	if err != nil {
+		return
+	}
+	node.SystemMessage = msg
+	UpdateNodeInfo(nsId, infraId, node)
+}
+
+// specSummaryOf returns the SpecSummary of a Node with the given spec.
+func specSummaryOf(specInfo model.SpecInfo) model.SpecSummary {
+	return model.SpecSummary{
+		CspSpecName:         specInfo.CspSpecName,
+		VCPU:                specInfo.VCPU,
+		MemoryGiB:           specInfo.MemoryGiB,
+		AcceleratorModel:    specInfo.AcceleratorModel,
+		AcceleratorCount:    specInfo.AcceleratorCount,
+		AcceleratorMemoryGB: specInfo.AcceleratorMemoryGB,
+		AcceleratorType:     specInfo.AcceleratorType,
+		CostPerHour:         specInfo.CostPerHour,
+		SpotCostPerHour:     specInfo.SpotCostPerHour,
+	}
+}
@@ -0,0 +1,688 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/core/resource"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+	"github.com/rs/zerolog/log"
+)
+
+// Rightsizing.
+//
+// CB-Dragonfly only serves on-demand metrics, so utilization history is kept by
+// CB-Tumblebug: when enabled, the collector samples the CPU and memory utilization of
+// every running Node periodically, from the monitoring agent when it is installed and
+// over SSH (/proc/stat and /proc/meminfo) otherwise. SSH samples do not go through the
+// command history of the Node. Each sample is stored under its own key, so sampling
+// never rewrites the history of a Node. The analyzer classifies Nodes by the p95 utilization of a window
+// and looks for a cheaper spec of the same provider, region and architecture that runs
+// the p95 usage at the target utilization.
+
+// utilizationKeyPrefix is the kvstore prefix of utilization samples (/utilization/node/{nsId}/{infraId}/{nodeId}/{unixTime})
+const utilizationKeyPrefix = "/utilization/node/"
+
+const (
+	// utilizationRetention is how long utilization samples are kept
+	utilizationRetention = 31 * 24 * time.Hour
+	// utilizationPruneInterval is how often the collector deletes expired samples
+	utilizationPruneInterval = 24 * time.Hour
+	// utilizationSampleTimeout bounds the sampling of one Node
+	utilizationSampleTimeout = 2 * time.Minute
+	// utilizationSampleConcurrency bounds the Nodes of an Infra sampled at once
+	utilizationSampleConcurrency = 10
+
+	defaultRightsizingWindowHours = 168
+	defaultRightsizingMinSamples  = 3
+	defaultRightsizingIdleCpu     = 5.0
+	defaultRightsizingIdleMem     = 20.0
+	defaultRightsizingTarget      = 70.0
+	// rightsizingCandidateLimit is the number of cheaper specs examined per Node
+	rightsizingCandidateLimit = 5
+)
+
+// utilizationSampleInterval is the interval of the background utilization collector.
+// The collector opens an SSH session to every running Node without the monitoring agent,
+// so it is disabled unless TB_UTILIZATION_SAMPLE_INTERVAL_MINUTES is set (e.g., 15).
+var utilizationSampleInterval = func() time.Duration {
+	if v := os.Getenv("TB_UTILIZATION_SAMPLE_INTERVAL_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return time.Duration(n) * time.Minute
+		}
+	}
+	return 0
+}()
+
+// utilizationSshCommand prints two /proc/stat CPU lines 5 seconds apart and the memory totals
+const utilizationSshCommand = "head -n1 /proc/stat; sleep 5; head -n1 /proc/stat; grep -E '^(MemTotal|MemAvailable):' /proc/meminfo"
+
+func utilizationNodePrefix(nsId, infraId, nodeId string) string {
+	return utilizationKeyPrefix + nsId + "/" + infraId + "/" + nodeId + "/"
+}
+
+// parseUtilizationKey returns the Node ID and the sample time of a utilization sample key.
+func parseUtilizationKey(key string) (string, time.Time, bool) {
+	parts := strings.Split(strings.TrimPrefix(key, utilizationKeyPrefix), "/")
+	if len(parts) != 4 {
+		return "", time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return parts[2], time.Unix(sec, 0).UTC(), true
+}
+
+// getUtilizationRecord assembles the utilization record of a Node from its samples.
+func getUtilizationRecord(nsId, infraId, nodeId string) (model.NodeUtilizationRecord, error) {
+	records, err := listUtilizationRecords(nsId, infraId, utilizationNodePrefix(nsId, infraId, nodeId))
+	if err != nil {
+		return model.NodeUtilizationRecord{}, err
+	}
+	if len(records) == 0 {
+		return model.NodeUtilizationRecord{NsId: nsId, InfraId: infraId, NodeId: nodeId}, nil
+	}
+	return records[0], nil
+}
+
+// listUtilizationRecords groups the unexpired samples under a key prefix into records by Node,
+// sorted by Node ID with samples in time order.
+func listUtilizationRecords(nsId, infraId, keyPrefix string) ([]model.NodeUtilizationRecord, error) {
+	keyValues, err := kvstore.GetKvList(keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-utilizationRetention)
+	byNode := map[string]*model.NodeUtilizationRecord{}
+	for _, kv := range keyValues {
+		nodeId, t, ok := parseUtilizationKey(kv.Key)
+		if !ok || t.Before(cutoff) {
+			continue
+		}
+		sample := model.UtilizationSample{}
+		if err := json.Unmarshal([]byte(kv.Value), &sample); err != nil {
+			log.Warn().Err(err).Msgf("Failed to unmarshal utilization sample %s", kv.Key)
+			continue
+		}
+		record, ok := byNode[nodeId]
+		if !ok {
+			record = &model.NodeUtilizationRecord{NsId: nsId, InfraId: infraId, NodeId: nodeId}
+			byNode[nodeId] = record
+		}
+		record.Samples = append(record.Samples, sample)
+	}
+	records := make([]model.NodeUtilizationRecord, 0, len(byNode))
+	for _, record := range byNode {
+		sort.Slice(record.Samples, func(i, j int) bool { return record.Samples[i].Time < record.Samples[j].Time })
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].NodeId < records[j].NodeId })
+	return records, nil
+}
+
+// putUtilizationSample stores a sample of a Node under its own key.
+func putUtilizationSample(nsId, infraId, nodeId string, sample model.UtilizationSample, now time.Time) error {
+	val, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put(utilizationNodePrefix(nsId, infraId, nodeId)+strconv.FormatInt(now.Unix(), 10), string(val))
+}
+
+// pruneUtilizationSamples deletes the samples older than the retention.
+func pruneUtilizationSamples(now time.Time) {
+	keys, err := kvstore.GetKeyList(utilizationKeyPrefix)
+	if err != nil {
+		log.Warn().Err(err).Msg("Utilization collector: failed to list utilization samples")
+		return
+	}
+	cutoff := now.Add(-utilizationRetention)
+	pruned := 0
+	for _, key := range keys {
+		if _, t, ok := parseUtilizationKey(key); ok && !t.Before(cutoff) {
+			continue
+		}
+		if err := kvstore.Delete(key); err != nil {
+			log.Warn().Err(err).Msgf("Failed to delete utilization sample %s", key)
+			continue
+		}
+		pruned++
+	}
+	if pruned > 0 {
+		log.Debug().Msgf("Utilization collector: deleted %d expired samples", pruned)
+	}
+}
+
+// ListInfraUtilization returns the utilization records of the Nodes of an Infra.
+func ListInfraUtilization(nsId, infraId string) ([]model.NodeUtilizationRecord, error) {
+	check, err := CheckInfra(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+	if !check {
+		return nil, fmt.Errorf("Infra %s does not exist", infraId)
+	}
+	return listUtilizationRecords(nsId, infraId, utilizationKeyPrefix+nsId+"/"+infraId+"/")
+}
+
+// deleteNodeUtilization removes the utilization samples of a deleted Node.
+func deleteNodeUtilization(nsId, infraId, nodeId string) {
+	if err := kvstore.DeleteWithPrefix(utilizationNodePrefix(nsId, infraId, nodeId)); err != nil {
+		log.Warn().Err(err).Msgf("Failed to delete the utilization record of Node %s/%s/%s", nsId, infraId, nodeId)
+	}
+}
+
+// SampleInfraUtilization takes a utilization sample of every running Node of an Infra
+// and returns the samples by Node ID. Nodes that cannot be sampled are missing from the result.
+func SampleInfraUtilization(nsId, infraId string) (map[string]model.UtilizationSample, error) {
+	nodes, err := ListInfraNodeInfo(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+
+	var running []model.NodeInfo
+	agentNodes := false
+	for _, node := range nodes {
+		if !strings.EqualFold(node.Status, model.StatusRunning) {
+			continue
+		}
+		running = append(running, node)
+		if node.MonAgentStatus == "installed" {
+			agentNodes = true
+		}
+	}
+	samples := map[string]model.UtilizationSample{}
+	if len(running) == 0 {
+		return samples, nil
+	}
+
+	now := time.Now().UTC()
+	if agentNodes && model.DragonflyRestUrl != "" {
+		for nodeId, sample := range sampleAgentUtilization(nsId, infraId, now) {
+			samples[nodeId] = sample
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, utilizationSampleConcurrency)
+	for _, node := range running {
+		if _, ok := samples[node.Id]; ok {
+			continue
+		}
+		wg.Add(1)
+		go func(nodeId string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			sample, err := sampleSshUtilization(nsId, infraId, nodeId, now)
+			if err != nil {
+				log.Debug().Err(err).Msgf("Failed to sample the utilization of Node %s/%s/%s", nsId, infraId, nodeId)
+				return
+			}
+			mu.Lock()
+			samples[nodeId] = sample
+			mu.Unlock()
+		}(node.Id)
+	}
+	wg.Wait()
+
+	for nodeId, sample := range samples {
+		if err := putUtilizationSample(nsId, infraId, nodeId, sample, now); err != nil {
+			log.Warn().Err(err).Msgf("Failed to store the utilization sample of Node %s/%s/%s", nsId, infraId, nodeId)
+		}
+	}
+	return samples, nil
+}
+
+// sampleAgentUtilization reads the CPU and memory utilization of the Nodes of an Infra
+// from the monitoring agent. Only Nodes with both values are returned.
+func sampleAgentUtilization(nsId, infraId string, now time.Time) map[string]model.UtilizationSample {
+	values := map[string]map[string]float64{}
+	for _, metric := range []string{model.MonMetricCpu, model.MonMetricMem} {
+		// Partial failures are expected (Nodes without the agent); use what was returned
+		content, _ := GetMonitoringData(nsId, infraId, metric)
+		for _, r := range content.InfraMonitoring {
+			if r.Err != "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(r.Value), 64)
+			if err != nil {
+				continue
+			}
+			if values[r.NodeId] == nil {
+				values[r.NodeId] = map[string]float64{}
+			}
+			values[r.NodeId][metric] = v
+		}
+	}
+
+	samples := map[string]model.UtilizationSample{}
+	for nodeId, v := range values {
+		cpu, okCpu := v[model.MonMetricCpu]
+		mem, okMem := v[model.MonMetricMem]
+		if !okCpu || !okMem {
+			continue
+		}
+		samples[nodeId] = model.UtilizationSample{
+			Time:       now.Format(time.RFC3339),
+			CpuPercent: roundPercent(cpu),
+			MemPercent: roundPercent(mem),
+			Source:     model.UtilizationSourceAgent,
+		}
+	}
+	return samples
+}
+
+// sampleSshUtilization reads the CPU and memory utilization of a Node from /proc over SSH.
+func sampleSshUtilization(nsId, infraId, nodeId string, now time.Time) (model.UtilizationSample, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), utilizationSampleTimeout)
+	defer cancel()
+	stdout, stderr, err := RunRemoteCommandWithContext(ctx, nsId, infraId, nodeId, "", []string{utilizationSshCommand})
+	if err != nil {
+		return model.UtilizationSample{}, fmt.Errorf("%w (%s)", err, strings.TrimSpace(stderr[0]))
+	}
+	cpu, mem, err := parseProcUtilization(stdout[0])
+	if err != nil {
+		return model.UtilizationSample{}, err
+	}
+	return model.UtilizationSample{
+		Time:       now.Format(time.RFC3339),
+		CpuPercent: roundPercent(cpu),
+		MemPercent: roundPercent(mem),
+		Source:     model.UtilizationSourceSsh,
+	}, nil
+}
+
+// parseProcUtilization computes the CPU utilization between two /proc/stat "cpu" lines
+// and the memory utilization from MemTotal and MemAvailable of /proc/meminfo.
+func parseProcUtilization(out string) (float64, float64, error) {
+	var cpuLines [][]float64
+	var memTotal, memAvailable float64
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "cpu":
+			var values []float64
+			for _, f := range fields[1:] {
+				v, err := strconv.ParseFloat(f, 64)
+				if err != nil {
+					return 0, 0, fmt.Errorf("unexpected /proc/stat line: %q", line)
+				}
+				values = append(values, v)
+			}
+			if len(values) < 5 {
+				return 0, 0, fmt.Errorf("unexpected /proc/stat line: %q", line)
+			}
+			cpuLines = append(cpuLines, values)
+		case "MemTotal:", "MemAvailable:":
+			if len(fields) < 2 {
+				continue
+			}
+			v, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				continue
+			}
+			if fields[0] == "MemTotal:" {
+				memTotal = v
+			} else {
+				memAvailable = v
+			}
+		}
+	}
+	if len(cpuLines) != 2 || memTotal <= 0 {
+		return 0, 0, fmt.Errorf("unexpected utilization output: %q", out)
+	}
+
+	// user nice system idle iowait irq softirq steal ...; idle time is idle + iowait
+	sum := func(values []float64) (total, idle float64) {
+		for i, v := range values {
+			// guest and guest_nice are already counted in user and nice
+			if i < 8 {
+				total += v
+			}
+		}
+		return total, values[3] + values[4]
+	}
+	total0, idle0 := sum(cpuLines[0])
+	total1, idle1 := sum(cpuLines[1])
+	cpu := 0.0
+	if dt := total1 - total0; dt > 0 {
+		cpu = (1 - (idle1-idle0)/dt) * 100
+	}
+	mem := (1 - memAvailable/memTotal) * 100
+	return math.Max(0, math.Min(100, cpu)), math.Max(0, math.Min(100, mem)), nil
+}
+
+func roundPercent(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// StartUtilizationCollector samples the utilization of the running Nodes of every Infra
+// periodically until ctx is cancelled.
+func StartUtilizationCollector(ctx context.Context) {
+	if utilizationSampleInterval <= 0 {
+		log.Info().Msg("Utilization collector is disabled")
+		return
+	}
+	ticker := time.NewTicker(utilizationSampleInterval)
+	defer ticker.Stop()
+	var lastPrune time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			collectUtilization(ctx)
+			if now := time.Now(); now.Sub(lastPrune) >= utilizationPruneInterval {
+				pruneUtilizationSamples(now)
+				lastPrune = now
+			}
+		}
+	}
+}
+
+// collectUtilization takes a utilization sample of every running Node in every namespace.
+func collectUtilization(ctx context.Context) {
+	nsIds, err := common.ListNsId()
+	if err != nil {
+		log.Warn().Err(err).Msg("Utilization collector: failed to list namespaces")
+		return
+	}
+	for _, nsId := range nsIds {
+		infraIds, err := ListInfraId(nsId)
+		if err != nil {
+			continue
+		}
+		for _, infraId := range infraIds {
+			if ctx.Err() != nil {
+				return
+			}
+			if _, err := SampleInfraUtilization(nsId, infraId); err != nil {
+				log.Debug().Err(err).Msgf("Utilization collector: failed to sample Infra %s/%s", nsId, infraId)
+			}
+		}
+	}
+}
+
+// AnalyzeInfraRightsizing classifies the Nodes of an Infra by their utilization over a window
+// and recommends cheaper specs of the same provider, region and architecture.
+func AnalyzeInfraRightsizing(ctx context.Context, nsId, infraId string, req model.RightsizingReq) (model.RightsizingReport, error) {
+	if req.WindowHours <= 0 {
+		req.WindowHours = defaultRightsizingWindowHours
+	}
+	if req.MinSamples <= 0 {
+		req.MinSamples = defaultRightsizingMinSamples
+	}
+	if req.IdleCpuPercent <= 0 {
+		req.IdleCpuPercent = defaultRightsizingIdleCpu
+	}
+	if req.IdleMemPercent <= 0 {
+		req.IdleMemPercent = defaultRightsizingIdleMem
+	}
+	if req.TargetPercent <= 0 || req.TargetPercent > 100 {
+		req.TargetPercent = defaultRightsizingTarget
+	}
+
+	if req.SampleNow {
+		if _, err := SampleInfraUtilization(nsId, infraId); err != nil {
+			return model.RightsizingReport{}, err
+		}
+	}
+
+	now := time.Now().UTC()
+	from := now.Add(-time.Duration(req.WindowHours) * time.Hour)
+	report := model.RightsizingReport{
+		NsId:        nsId,
+		InfraId:     infraId,
+		From:        from.Format(time.RFC3339),
+		To:          now.Format(time.RFC3339),
+		WindowHours: req.WindowHours,
+		Currency:    model.CostBaseCurrency,
+		Nodes:       []model.NodeRightsizing{},
+	}
+
+	nodes, err := ListInfraNodeInfo(nsId, infraId)
+	if err != nil {
+		return model.RightsizingReport{}, err
+	}
+	for _, node := range nodes {
+		record, err := getUtilizationRecord(nsId, infraId, node.Id)
+		if err != nil {
+			return model.RightsizingReport{}, err
+		}
+		result := analyzeNodeRightsizing(ctx, node, windowSamples(record.Samples, from), req)
+		switch result.Classification {
+		case model.RightsizingIdle:
+			report.IdleCount++
+		case model.RightsizingUnderutilized:
+			report.UnderutilizedCount++
+		}
+		report.EstimatedSavingsPerMonth += result.EstimatedSavingsPerMonth
+		report.Nodes = append(report.Nodes, result)
+	}
+	report.EstimatedSavingsPerMonth = roundCost(report.EstimatedSavingsPerMonth)
+
+	if utilizationSampleInterval <= 0 {
+		report.Notes = append(report.Notes, "the utilization collector is disabled (set TB_UTILIZATION_SAMPLE_INTERVAL_MINUTES to enable it); only on-demand samples are analyzed")
+	}
+	report.Notes = append(report.Notes, fmt.Sprintf("Nodes are idle under %.0f%% CPU and %.0f%% memory (p95); recommended specs run the p95 usage at %.0f%%",
+		req.IdleCpuPercent, req.IdleMemPercent, req.TargetPercent))
+	return report, nil
+}
+
+// windowSamples returns the samples taken at or after from.
+func windowSamples(samples []model.UtilizationSample, from time.Time) []model.UtilizationSample {
+	var result []model.UtilizationSample
+	for _, s := range samples {
+		if t, err := time.Parse(time.RFC3339, s.Time); err == nil && !t.Before(from) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// analyzeNodeRightsizing classifies a Node by the samples of the window and looks for a cheaper spec.
+func analyzeNodeRightsizing(ctx context.Context, node model.NodeInfo, samples []model.UtilizationSample, req model.RightsizingReq) model.NodeRightsizing {
+	result := model.NodeRightsizing{
+		NodeId:      node.Id,
+		NodeGroupId: node.NodeGroupId,
+		Status:      node.Status,
+		SpecId:      node.SpecId,
+		CspSpecName: node.CspSpecName,
+		VCPU:        node.Spec.VCPU,
+		MemoryGiB:   node.Spec.MemoryGiB,
+		CostPerHour: roundCost(nodeCostPerHour(node)),
+		SampleCount: len(samples),
+	}
+
+	var cpus, mems []float64
+	sources := map[string]bool{}
+	for _, s := range samples {
+		cpus = append(cpus, s.CpuPercent)
+		mems = append(mems, s.MemPercent)
+		sources[s.Source] = true
+	}
+	for source := range sources {
+		result.Sources = append(result.Sources, source)
+	}
+	sort.Strings(result.Sources)
+
+	if len(samples) < req.MinSamples {
+		result.Classification = model.RightsizingInsufficientData
+		result.Message = fmt.Sprintf("%d samples in the window (%d required)", len(samples), req.MinSamples)
+		if !strings.EqualFold(node.Status, model.StatusRunning) {
+			result.Message += fmt.Sprintf("; only running Nodes are sampled (Node is %s)", node.Status)
+		}
+		return result
+	}
+	result.CpuAvgPercent, result.CpuP95Percent = roundPercent(mean(cpus)), roundPercent(percentile(cpus, 95))
+	result.MemAvgPercent, result.MemP95Percent = roundPercent(mean(mems)), roundPercent(percentile(mems, 95))
+
+	idle := result.CpuP95Percent < req.IdleCpuPercent && result.MemP95Percent < req.IdleMemPercent
+	if node.Spec.VCPU == 0 || node.Spec.MemoryGiB <= 0 {
+		result.Classification = model.RightsizingOptimal
+		if idle {
+			result.Classification = model.RightsizingIdle
+		}
+		result.Message = "the spec of the Node has no vCPU or memory size; no smaller spec can be searched"
+		return result
+	}
+
+	result.RequiredVCPU = math.Round(float64(node.Spec.VCPU)*result.CpuP95Percent/req.TargetPercent*100) / 100
+	result.RequiredMemoryGiB = math.Round(float64(node.Spec.MemoryGiB)*result.MemP95Percent/req.TargetPercent*100) / 100
+
+	if node.Spec.AcceleratorCount > 0 && !idle {
+		result.Classification = model.RightsizingOptimal
+		result.Message = "accelerator utilization is not sampled; Nodes with accelerators are only flagged when idle"
+		return result
+	}
+
+	candidate, err := findRightsizingSpec(ctx, node, result.RequiredVCPU, result.RequiredMemoryGiB)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to search a smaller spec for Node %s", node.Id)
+		result.Message = fmt.Sprintf("failed to search a smaller spec: %v", err)
+	}
+
+	switch {
+	case idle:
+		result.Classification = model.RightsizingIdle
+	case candidate != nil:
+		result.Classification = model.RightsizingUnderutilized
+	default:
+		result.Classification = model.RightsizingOptimal
+	}
+
+	if candidate != nil {
+		candidateCost := specCostPerHour(*candidate, node.CapacityType)
+		result.RecommendedSpecId = candidate.Id
+		result.RecommendedCspSpecName = candidate.CspSpecName
+		result.RecommendedVCPU = candidate.VCPU
+		result.RecommendedMemoryGiB = candidate.MemoryGiB
+		result.RecommendedCostPerHour = roundCost(candidateCost)
+		result.EstimatedSavingsPerHour = roundCost(nodeCostPerHour(node) - candidateCost)
+		result.EstimatedSavingsPerMonth = roundCost(result.EstimatedSavingsPerHour * model.CostHoursPerMonth)
+		if result.Message == "" {
+			result.Message = fmt.Sprintf("resize to %s (action=resize&specId=%s)", candidate.CspSpecName, candidate.Id)
+		}
+	}
+	if idle {
+		msg := fmt.Sprintf("idle: suspending or terminating the Node saves %.2f %s per month",
+			roundCost(nodeCostPerHour(node)*model.CostHoursPerMonth), model.CostBaseCurrency)
+		if result.Message != "" {
+			msg += "; " + result.Message
+		}
+		result.Message = msg
+	}
+	return result
+}
+
+// findRightsizingSpec returns the cheapest spec of the provider, region and architecture of a Node
+// that has at least the required vCPU and memory and costs less than the current spec, or nil.
+func findRightsizingSpec(ctx context.Context, node model.NodeInfo, requiredVCPU, requiredMemoryGiB float64) (*model.SpecInfo, error) {
+	currentCost := nodeCostPerHour(node)
+	if currentCost <= 0 {
+		return nil, fmt.Errorf("the price of spec '%s' is unknown", node.SpecId)
+	}
+	specInfo, err := resource.GetSpec(model.SystemCommonNs, node.SpecId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Candidates are priced like the Node: spot Nodes by the spot price of a spec
+	costMetric, costPriority := "costPerHour", "cost"
+	if node.CapacityType == model.CapacityTypeSpot {
+		costMetric, costPriority = "spotCostPerHour", "spotCost"
+	}
+
+	minVCPU := math.Max(1, math.Ceil(requiredVCPU))
+	minMemoryGiB := math.Max(0.5, requiredMemoryGiB)
+	filter := []model.FilterCondition{
+		{Metric: "providerName", Condition: []model.Operation{{Operand: specInfo.ProviderName}}},
+		{Metric: "regionName", Condition: []model.Operation{{Operand: specInfo.RegionName}}},
+		{Metric: "vCPU", Condition: []model.Operation{
+			{Operator: ">=", Operand: strconv.FormatFloat(minVCPU, 'f', -1, 64)},
+			{Operator: "<=", Operand: strconv.Itoa(int(specInfo.VCPU))},
+		}},
+		{Metric: "memoryGiB", Condition: []model.Operation{{Operator: ">=", Operand: strconv.FormatFloat(minMemoryGiB, 'f', 2, 64)}}},
+		{Metric: costMetric, Condition: []model.Operation{
+			{Operator: ">=", Operand: "0.0001"},
+			{Operator: "<=", Operand: strconv.FormatFloat(currentCost, 'f', -1, 64)},
+		}},
+	}
+	if specInfo.Architecture != "" {
+		filter = append(filter, model.FilterCondition{Metric: "architecture", Condition: []model.Operation{{Operand: specInfo.Architecture}}})
+	}
+	plan := model.RecommendSpecReq{
+		Filter:   model.FilterInfo{Policy: filter},
+		Priority: model.PriorityInfo{Policy: []model.PriorityCondition{{Metric: costPriority, Weight: 1.0}}},
+		Limit:    rightsizingCandidateLimit,
+	}
+	specs, err := RecommendSpec(ctx, model.SystemCommonNs, plan)
+	if err != nil {
+		return nil, err
+	}
+	for i := range specs {
+		if specs[i].Id == node.SpecId || (specInfo.AcceleratorCount == 0 && specs[i].AcceleratorCount > 0) {
+			continue
+		}
+		if specCostPerHour(specs[i], node.CapacityType) < currentCost {
+			return &specs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// mean returns the mean of values.
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// percentile returns the p-th percentile of values (nearest rank).
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,477 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/rs/zerolog/log"
+)
+
+// Rolling replacement (immutable upgrade) of a NodeGroup: batch by batch, replacement
+// Nodes are created with the new image/spec in the same network, bootstrapped and
+// health-checked, take over the NLB membership of the old Nodes, and then the old
+// Nodes are terminated. A batch whose replacement Nodes are not healthy is rolled back
+// (its replacement Nodes are removed, the old Nodes are kept) and the rollout stops;
+// batches completed before stay replaced.
+//
+// Rollouts run in the background and are tracked in memory (one per NodeGroup);
+// pause and abort take effect at the next batch boundary.
+
+const (
+	rolloutHealthCheckAttempts = 3
+	rolloutHealthCheckInterval = 20 * time.Second
+	rolloutPausePollInterval   = 3 * time.Second
+	rolloutMaxHistory          = 200
+)
+
+// nodeGroupRollouts tracks rollouts keyed by "{nsId}/{infraId}/{nodeGroupId}"
+var nodeGroupRollouts sync.Map
+
+type nodeGroupRollout struct {
+	mu     sync.Mutex
+	status model.NodeGroupRolloutStatus
+}
+
+func nodeGroupRolloutKey(nsId, infraId, nodeGroupId string) string {
+	return nsId + "/" + infraId + "/" + nodeGroupId
+}
+
+// snapshot returns a copy of the status that is safe to return to callers
+func (r *nodeGroupRollout) snapshot() *model.NodeGroupRolloutStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.status
+	status.CurrentBatch = slices.Clone(r.status.CurrentBatch)
+	status.PendingNodes = slices.Clone(r.status.PendingNodes)
+	status.Replaced = slices.Clone(r.status.Replaced)
+	status.History = slices.Clone(r.status.History)
+	return &status
+}
+
+// event records a history entry (and the latest message) of the rollout
+func (r *nodeGroupRollout) event(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	now := time.Now().UTC().Format(time.RFC3339)
+	r.mu.Lock()
+	r.status.Message = msg
+	r.status.UpdatedAt = now
+	r.status.History = append(r.status.History, model.NodeGroupRolloutEvent{Time: now, Message: msg})
+	if len(r.status.History) > rolloutMaxHistory {
+		r.status.History = r.status.History[len(r.status.History)-rolloutMaxHistory:]
+	}
+	r.mu.Unlock()
+	log.Info().Str("infraId", r.status.InfraId).Str("nodeGroupId", r.status.NodeGroupId).Msg("Rollout: " + msg)
+}
+
+func (r *nodeGroupRollout) setState(state string) {
+	r.mu.Lock()
+	r.status.State = state
+	r.status.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	r.mu.Unlock()
+}
+
+// StartNodeGroupRollout starts a rolling replacement of the Nodes of a NodeGroup with a new image and/or spec.
+// It returns as soon as the rollout is started; follow it with GetNodeGroupRolloutStatus.
+func StartNodeGroupRollout(ctx context.Context, nsId string, infraId string, nodeGroupId string, req *model.NodeGroupRolloutReq) (*model.NodeGroupRolloutStatus, error) {
+	if err := common.CheckString(nsId); err != nil {
+		return nil, err
+	}
+	if err := common.CheckString(infraId); err != nil {
+		return nil, err
+	}
+	if err := common.CheckString(nodeGroupId); err != nil {
+		return nil, err
+	}
+	if req.ImageId == "" && req.SpecId == "" {
+		return nil, apierr.Invalid("imageId or specId is required for a rollout", nil)
+	}
+	if req.BatchSize < 0 {
+		return nil, apierr.Invalid(fmt.Sprintf("batchSize must be 1 or more (got %d)", req.BatchSize), nil)
+	}
+	if err := ValidatePostCommandRequest(req.PostCommands); err != nil {
+		return nil, apierr.Invalid(err.Error(), nil)
+	}
+
+	infraInfo, err := GetInfraInfo(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+	if infraInfo.TargetAction != "" && infraInfo.TargetAction != model.ActionComplete {
+		return nil, &apierr.StatusError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("Infra '%s' is under %s; start the rollout after it completes", infraId, infraInfo.TargetAction)}
+	}
+	var oldNodes []string
+	for _, node := range infraInfo.Node {
+		if node.NodeGroupId == nodeGroupId {
+			oldNodes = append(oldNodes, node.Id)
+		}
+	}
+	if len(oldNodes) == 0 {
+		return nil, &apierr.StatusError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("NodeGroup '%s' has no Node in Infra '%s'", nodeGroupId, infraId)}
+	}
+	slices.SortFunc(oldNodes, func(a, b string) int { return nodeIndex(a) - nodeIndex(b) })
+
+	template, err := nodeGroupReqFromExistingNode(nsId, infraId, nodeGroupId)
+	if err != nil {
+		return nil, err
+	}
+	if req.ImageId != "" {
+		template.ImageId = req.ImageId
+		// resolved again from the new image when the Nodes are created
+		template.CspImageName = ""
+	}
+	if req.SpecId != "" {
+		template.SpecId = req.SpecId
+	}
+
+	batchSize := min(max(req.BatchSize, 1), len(oldNodes))
+	now := time.Now().UTC().Format(time.RFC3339)
+	rollout := &nodeGroupRollout{status: model.NodeGroupRolloutStatus{
+		NsId:         nsId,
+		InfraId:      infraId,
+		NodeGroupId:  nodeGroupId,
+		ImageId:      template.ImageId,
+		SpecId:       template.SpecId,
+		State:        model.RolloutRunning,
+		BatchSize:    batchSize,
+		TotalBatches: (len(oldNodes) + batchSize - 1) / batchSize,
+		PendingNodes: oldNodes,
+		Replaced:     []model.NodeReplacement{},
+		StartedAt:    now,
+		UpdatedAt:    now,
+		History:      []model.NodeGroupRolloutEvent{},
+	}}
+
+	key := nodeGroupRolloutKey(nsId, infraId, nodeGroupId)
+	if prev, ok := nodeGroupRollouts.Load(key); ok {
+		state := prev.(*nodeGroupRollout).snapshot().State
+		if state == model.RolloutRunning || state == model.RolloutPaused {
+			return nil, &apierr.StatusError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("a rollout of NodeGroup '%s' is already %s", nodeGroupId, strings.ToLower(state))}
+		}
+	}
+	nodeGroupRollouts.Store(key, rollout)
+
+	rollout.event("rollout started: %d Nodes in %d batches of %d (image %s, spec %s)", len(oldNodes), rollout.status.TotalBatches, batchSize, template.ImageId, template.SpecId)
+	// The rollout outlives the request; keep the request values (credential holder, request ID)
+	go runNodeGroupRollout(context.WithoutCancel(ctx), rollout, template, req)
+
+	return rollout.snapshot(), nil
+}
+
+// GetNodeGroupRolloutStatus returns the status of the latest rollout of a NodeGroup
+func GetNodeGroupRolloutStatus(nsId string, infraId string, nodeGroupId string) (*model.NodeGroupRolloutStatus, error) {
+	v, ok := nodeGroupRollouts.Load(nodeGroupRolloutKey(nsId, infraId, nodeGroupId))
+	if !ok {
+		return nil, &apierr.StatusError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("no rollout of NodeGroup '%s' in Infra '%s'", nodeGroupId, infraId)}
+	}
+	return v.(*nodeGroupRollout).snapshot(), nil
+}
+
+// ControlNodeGroupRollout pauses, resumes or aborts a rollout. Pause and abort take
+// effect when the current batch is finished.
+func ControlNodeGroupRollout(nsId string, infraId string, nodeGroupId string, action string) (*model.NodeGroupRolloutStatus, error) {
+	v, ok := nodeGroupRollouts.Load(nodeGroupRolloutKey(nsId, infraId, nodeGroupId))
+	if !ok {
+		return nil, &apierr.StatusError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("no rollout of NodeGroup '%s' in Infra '%s'", nodeGroupId, infraId)}
+	}
+	rollout := v.(*nodeGroupRollout)
+
+	rollout.mu.Lock()
+	state := rollout.status.State
+	if state != model.RolloutRunning && state != model.RolloutPaused {
+		rollout.mu.Unlock()
+		return nil, &apierr.StatusError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("the rollout of NodeGroup '%s' is already %s", nodeGroupId, state)}
+	}
+	var msg string
+	switch action {
+	case model.RolloutActionPause:
+		rollout.status.PauseRequested = true
+		msg = "pause requested"
+	case model.RolloutActionResume:
+		rollout.status.PauseRequested = false
+		msg = "resume requested"
+	case model.RolloutActionAbort:
+		rollout.status.AbortRequested = true
+		msg = "abort requested"
+	default:
+		rollout.mu.Unlock()
+		return nil, apierr.Invalid(fmt.Sprintf("unknown rollout action '%s' (pause, resume, abort)", action), nil)
+	}
+	rollout.mu.Unlock()
+
+	rollout.event("%s", msg)
+	return rollout.snapshot(), nil
+}
+
+// waitRolloutBatchBoundary handles pause and abort requests between batches.
+// It returns false if the rollout is aborted.
+func waitRolloutBatchBoundary(rollout *nodeGroupRollout) bool {
+	paused := false
+	for {
+		rollout.mu.Lock()
+		abort, pause := rollout.status.AbortRequested, rollout.status.PauseRequested
+		rollout.mu.Unlock()
+		switch {
+		case abort:
+			rollout.setState(model.RolloutAborted)
+			rollout.event("rollout aborted; Nodes not replaced yet are kept")
+			return false
+		case pause && !paused:
+			paused = true
+			rollout.setState(model.RolloutPaused)
+			rollout.event("rollout paused")
+		case !pause && paused:
+			rollout.setState(model.RolloutRunning)
+			rollout.event("rollout resumed")
+			return true
+		case !pause:
+			return true
+		}
+		time.Sleep(rolloutPausePollInterval)
+	}
+}
+
+func runNodeGroupRollout(ctx context.Context, rollout *nodeGroupRollout, template *model.CreateNodeGroupReq, req *model.NodeGroupRolloutReq) {
+	status := rollout.snapshot()
+	nsId, infraId, nodeGroupId := status.NsId, status.InfraId, status.NodeGroupId
+
+	for batchNo := 1; ; batchNo++ {
+		status = rollout.snapshot()
+		if len(status.PendingNodes) == 0 {
+			break
+		}
+		if !waitRolloutBatchBoundary(rollout) {
+			return
+		}
+
+		batch := status.PendingNodes[:min(status.BatchSize, len(status.PendingNodes))]
+		rollout.mu.Lock()
+		rollout.status.CurrentBatch = slices.Clone(batch)
+		rollout.mu.Unlock()
+		rollout.event("batch %d/%d: replacing %s", batchNo, status.TotalBatches, strings.Join(batch, ", "))
+
+		newNodes, err := createReplacementNodes(ctx, nsId, infraId, nodeGroupId, template, len(batch))
+		if err == nil {
+			err = checkReplacementHealth(nsId, infraId, nodeGroupId, newNodes, req)
+		}
+		if err == nil {
+			err = takeOverNlbMembership(nsId, infraId, batch, newNodes)
+		}
+		if err != nil {
+			rollbackRolloutBatch(rollout, nsId, infraId, batchNo, newNodes, err)
+			return
+		}
+		rollout.event("batch %d/%d: replacement Nodes %s are healthy", batchNo, status.TotalBatches, strings.Join(newNodes, ", "))
+
+		// The old Nodes are already out of the NLBs
+		victims := make([]model.ScaleInNodeResult, 0, len(batch))
+		for _, nodeId := range batch {
+			victims = append(victims, model.ScaleInNodeResult{NodeId: nodeId, Reason: "replaced by rollout"})
+		}
+		var failed []string
+		for _, victim := range removeInfraNodes(nsId, infraId, victims, req.PreStopCommand, false) {
+			if !victim.Removed {
+				failed = append(failed, fmt.Sprintf("%s: %s", victim.NodeId, victim.Error))
+			}
+		}
+		if len(failed) > 0 {
+			rollout.event("batch %d/%d: failed to terminate old Nodes (%s); remove them manually", batchNo, status.TotalBatches, strings.Join(failed, "; "))
+		}
+
+		rollout.mu.Lock()
+		for i, oldNode := range batch {
+			rollout.status.Replaced = append(rollout.status.Replaced, model.NodeReplacement{OldNodeId: oldNode, NewNodeId: newNodes[i]})
+		}
+		rollout.status.PendingNodes = rollout.status.PendingNodes[len(batch):]
+		rollout.status.CurrentBatch = nil
+		rollout.status.CompletedBatches++
+		if req.PauseAfterBatch && len(rollout.status.PendingNodes) > 0 {
+			rollout.status.PauseRequested = true
+		}
+		rollout.mu.Unlock()
+		rollout.event("batch %d/%d completed", batchNo, status.TotalBatches)
+	}
+
+	rollout.setState(model.RolloutCompleted)
+	status = rollout.snapshot()
+	rollout.event("rollout completed: %d Nodes replaced", len(status.Replaced))
+	appendInfraSystemMessage(nsId, infraId, fmt.Sprintf("rollout of NodeGroup '%s' completed (%d Nodes replaced)", nodeGroupId, len(status.Replaced)))
+}
+
+// createReplacementNodes adds count Nodes to the NodeGroup from the rollout template and
+// returns their IDs. The IDs are returned even on error, so that partially created Nodes
+// can be rolled back.
+func createReplacementNodes(ctx context.Context, nsId, infraId, nodeGroupId string, template *model.CreateNodeGroupReq, count int) ([]string, error) {
+	before, err := ListNodeByNodeGroup(nsId, infraId, nodeGroupId)
+	if err != nil {
+		return nil, err
+	}
+	nodeReq := *template
+	nodeReq.NodeGroupSize = count
+	_, createErr := CreateInfraGroupNode(ctx, nsId, infraId, &nodeReq, true)
+
+	after, err := ListNodeByNodeGroup(nsId, infraId, nodeGroupId)
+	if err != nil {
+		return nil, err
+	}
+	added := slices.DeleteFunc(after, func(id string) bool { return slices.Contains(before, id) })
+	slices.SortFunc(added, func(a, b string) int { return nodeIndex(a) - nodeIndex(b) })
+	if createErr != nil {
+		return added, fmt.Errorf("failed to create replacement Nodes: %w", createErr)
+	}
+	if len(added) != count {
+		return added, fmt.Errorf("%d of %d replacement Nodes were created", len(added), count)
+	}
+	return added, nil
+}
+
+// checkReplacementHealth checks that the replacement Nodes are running, runs the
+// post-commands on them and then the health check command.
+func checkReplacementHealth(nsId, infraId, nodeGroupId string, nodeIds []string, req *model.NodeGroupRolloutReq) error {
+	infraInfo, err := GetInfraInfo(nsId, infraId)
+	if err != nil {
+		return err
+	}
+	for _, node := range infraInfo.Node {
+		if slices.Contains(nodeIds, node.Id) && node.Status != model.StatusRunning {
+			return fmt.Errorf("replacement Node '%s' is %s, not %s", node.Id, node.Status, model.StatusRunning)
+		}
+	}
+
+	waitForSshReadiness(nsId, infraId, nodeGroupId)
+
+	if err := runPostCommandsOnNewNodes(nsId, infraId, nodeGroupId, nodeIds, req.PostCommands, false); err != nil {
+		return err
+	}
+
+	check := model.InfraCmdReq{Command: []string{"true"}, TimeoutMinutes: 1}
+	if req.HealthCheck != nil && len(req.HealthCheck.Command) > 0 {
+		check = *req.HealthCheck
+	}
+	for _, nodeId := range nodeIds {
+		var lastErr error
+		for attempt := 1; attempt <= rolloutHealthCheckAttempts; attempt++ {
+			if attempt > 1 {
+				time.Sleep(rolloutHealthCheckInterval)
+			}
+			cmdReq := check
+			results, err := RemoteCommandToInfra(nsId, infraId, "", nodeId, "", &cmdReq, "")
+			if err == nil && len(results) > 0 {
+				err = results[0].Err
+			} else if err == nil {
+				err = fmt.Errorf("no result from the health check")
+			}
+			lastErr = err
+			if err == nil {
+				break
+			}
+		}
+		if lastErr != nil {
+			return fmt.Errorf("health check failed on replacement Node '%s': %w", nodeId, lastErr)
+		}
+	}
+	return nil
+}
+
+// takeOverNlbMembership adds the new Nodes to the NLBs that the old Nodes are in,
+// and removes the old Nodes from them.
+func takeOverNlbMembership(nsId, infraId string, oldNodes, newNodes []string) error {
+	nlbIds, err := ListNLBId(nsId, infraId)
+	if err != nil {
+		return fmt.Errorf("failed to list NLBs: %w", err)
+	}
+	for _, nlbId := range nlbIds {
+		nlb, err := GetNLB(nsId, infraId, nlbId)
+		if err != nil {
+			continue
+		}
+		var members []string
+		for _, nodeId := range nlb.TargetGroup.Nodes {
+			if slices.Contains(oldNodes, nodeId) {
+				members = append(members, nodeId)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		if _, err := AddNLBNodes(nsId, infraId, nlbId, &model.NLBAddRemoveNodeReq{TargetGroup: model.NLBTargetGroupInfo{Nodes: newNodes}}); err != nil {
+			return fmt.Errorf("failed to add replacement Nodes to NLB '%s': %w", nlbId, err)
+		}
+		if err := RemoveNLBNodes(nsId, infraId, nlbId, &model.NLBAddRemoveNodeReq{TargetGroup: model.NLBTargetGroupInfo{Nodes: members}}); err != nil {
+			return fmt.Errorf("failed to remove old Nodes from NLB '%s': %w", nlbId, err)
+		}
+	}
+	return nil
+}
+
+// rollbackRolloutBatch removes the replacement Nodes of a failed batch (also from the NLBs,
+// and puts the old Nodes back where they were removed) and stops the rollout.
+func rollbackRolloutBatch(rollout *nodeGroupRollout, nsId, infraId string, batchNo int, newNodes []string, cause error) {
+	status := rollout.snapshot()
+	rollout.event("batch %d/%d failed: %v; rolling back", batchNo, status.TotalBatches, cause)
+
+	// Old Nodes may have been removed from NLBs that the new Nodes were already added to
+	if nlbIds, err := ListNLBId(nsId, infraId); err == nil {
+		for _, nlbId := range nlbIds {
+			nlb, err := GetNLB(nsId, infraId, nlbId)
+			if err != nil || !slices.ContainsFunc(nlb.TargetGroup.Nodes, func(id string) bool { return slices.Contains(newNodes, id) }) {
+				continue
+			}
+			var missing []string
+			for _, nodeId := range status.CurrentBatch {
+				if !slices.Contains(nlb.TargetGroup.Nodes, nodeId) {
+					missing = append(missing, nodeId)
+				}
+			}
+			if len(missing) > 0 {
+				if _, err := AddNLBNodes(nsId, infraId, nlbId, &model.NLBAddRemoveNodeReq{TargetGroup: model.NLBTargetGroupInfo{Nodes: missing}}); err != nil {
+					rollout.event("failed to put old Nodes back into NLB '%s': %v", nlbId, err)
+				}
+			}
+		}
+	}
+
+	victims := make([]model.ScaleInNodeResult, 0, len(newNodes))
+	for _, nodeId := range newNodes {
+		victims = append(victims, model.ScaleInNodeResult{NodeId: nodeId, Reason: "rollout rollback"})
+	}
+	var failed []string
+	for _, victim := range removeInfraNodes(nsId, infraId, victims, nil, true) {
+		if !victim.Removed {
+			failed = append(failed, fmt.Sprintf("%s: %s", victim.NodeId, victim.Error))
+		}
+	}
+
+	rollout.mu.Lock()
+	rollout.status.CurrentBatch = nil
+	rollout.mu.Unlock()
+	if len(failed) > 0 {
+		rollout.setState(model.RolloutFailed)
+		rollout.event("rollback incomplete: failed to remove replacement Nodes (%s)", strings.Join(failed, "; "))
+	} else {
+		rollout.setState(model.RolloutRolledBack)
+		rollout.event("batch %d rolled back; the old Nodes of the batch are kept", batchNo)
+	}
+	appendInfraSystemMessage(nsId, infraId, fmt.Sprintf("rollout of NodeGroup '%s' stopped at batch %d: %v", status.NodeGroupId, batchNo, cause))
+}
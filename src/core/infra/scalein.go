@@ -0,0 +1,327 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/rs/zerolog/log"
+)
+
+// ScaleInInfraNodeGroup removes Nodes from a NodeGroup until it has req.TargetSize Nodes.
+// The Nodes to remove are chosen by req.Strategy; each one is optionally drained
+// (removed from the NLBs of the Infra, pre-stop command) and then terminated.
+func ScaleInInfraNodeGroup(ctx context.Context, nsId string, infraId string, nodeGroupId string, req *model.ScaleInNodeGroupReq) (*model.ScaleInNodeGroupResult, error) {
+	if err := common.CheckString(nsId); err != nil {
+		return nil, err
+	}
+	if err := common.CheckString(infraId); err != nil {
+		return nil, err
+	}
+	if err := common.CheckString(nodeGroupId); err != nil {
+		return nil, err
+	}
+	if req.Strategy == "" {
+		req.Strategy = model.ScaleInNewest
+	}
+	switch req.Strategy {
+	case model.ScaleInNewest, model.ScaleInOldest, model.ScaleInMostExpensive, model.ScaleInLeastLoaded, model.ScaleInZoneSpread:
+	default:
+		return nil, apierr.Invalid(fmt.Sprintf("unknown scale-in strategy '%s' (newest, oldest, mostExpensive, leastLoaded, zoneSpread)", req.Strategy), nil)
+	}
+	if req.TargetSize < 0 {
+		return nil, apierr.Invalid(fmt.Sprintf("targetSize must be 0 or more (got %d)", req.TargetSize), nil)
+	}
+
+	infraInfo, err := GetInfraInfo(nsId, infraId)
+	if err != nil {
+		return nil, err
+	}
+	if infraInfo.TargetAction != "" && infraInfo.TargetAction != model.ActionComplete {
+		return nil, &apierr.StatusError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("Infra '%s' is under %s; scale in after it completes", infraId, infraInfo.TargetAction)}
+	}
+
+	var nodes []model.NodeInfo
+	for _, node := range infraInfo.Node {
+		if node.NodeGroupId == nodeGroupId {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, &apierr.StatusError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("NodeGroup '%s' has no Node in Infra '%s'", nodeGroupId, infraId)}
+	}
+	if req.TargetSize >= len(nodes) {
+		return nil, apierr.Invalid(fmt.Sprintf("NodeGroup '%s' has %d Nodes; targetSize must be smaller (got %d)", nodeGroupId, len(nodes), req.TargetSize), nil)
+	}
+
+	var loads map[string]float64
+	if req.Strategy == model.ScaleInLeastLoaded {
+		loads = measureNodeLoad(nsId, infraId, nodeGroupId, nodes)
+	}
+	victims := selectScaleInVictims(nodes, len(nodes)-req.TargetSize, req.Strategy, loads)
+	log.Info().Msgf("Scaling in NodeGroup '%s' of Infra '%s' from %d to %d Nodes (%s)", nodeGroupId, infraId, len(nodes), req.TargetSize, req.Strategy)
+
+	result := &model.ScaleInNodeGroupResult{
+		NodeGroupId:  nodeGroupId,
+		Strategy:     req.Strategy,
+		PreviousSize: len(nodes),
+		TargetSize:   req.TargetSize,
+		Nodes:        removeInfraNodes(nsId, infraId, victims, req.PreStopCommand, req.RemoveFromNlb),
+	}
+	if refreshed, err := GetInfraInfo(nsId, infraId); err == nil {
+		result.Infra = refreshed
+	}
+	return result, nil
+}
+
+// selectScaleInVictims picks count Nodes to remove. Nodes that are not running are
+// always picked first (they serve nothing); the rest are ordered by the strategy.
+func selectScaleInVictims(nodes []model.NodeInfo, count int, strategy string, loads map[string]float64) []model.ScaleInNodeResult {
+	var notRunning, running []model.NodeInfo
+	for _, node := range nodes {
+		if node.Status == model.StatusRunning {
+			running = append(running, node)
+		} else {
+			notRunning = append(notRunning, node)
+		}
+	}
+
+	victims := make([]model.ScaleInNodeResult, 0, count)
+	for _, node := range notRunning {
+		if len(victims) == count {
+			return victims
+		}
+		victims = append(victims, model.ScaleInNodeResult{NodeId: node.Id, Zone: node.Region.Zone, Reason: fmt.Sprintf("not running (status %s)", node.Status)})
+	}
+	remaining := count - len(victims)
+	if remaining == 0 {
+		return victims
+	}
+
+	// newer first: creation time, then Node index
+	newer := func(a, b model.NodeInfo) bool {
+		if a.CreatedTime != b.CreatedTime {
+			return a.CreatedTime > b.CreatedTime
+		}
+		return nodeIndex(a.Id) > nodeIndex(b.Id)
+	}
+
+	if strategy == model.ScaleInZoneSpread {
+		// Repeatedly take the newest Node of the zone that has the most Nodes left
+		for range remaining {
+			zoneCount := map[string]int{}
+			for _, node := range running {
+				zoneCount[node.Region.Zone]++
+			}
+			pick := -1
+			for i, node := range running {
+				if pick < 0 {
+					pick = i
+					continue
+				}
+				p := running[pick]
+				if zoneCount[node.Region.Zone] > zoneCount[p.Region.Zone] ||
+					(zoneCount[node.Region.Zone] == zoneCount[p.Region.Zone] && newer(node, p)) {
+					pick = i
+				}
+			}
+			node := running[pick]
+			victims = append(victims, model.ScaleInNodeResult{NodeId: node.Id, Zone: node.Region.Zone,
+				Reason: fmt.Sprintf("zoneSpread (zone '%s' had %d Nodes)", node.Region.Zone, zoneCount[node.Region.Zone])})
+			running = slices.Delete(running, pick, pick+1)
+		}
+		return victims
+	}
+
+	less := newer
+	reason := func(node model.NodeInfo) string { return fmt.Sprintf("newest (created %s)", node.CreatedTime) }
+	switch strategy {
+	case model.ScaleInOldest:
+		less = func(a, b model.NodeInfo) bool { return newer(b, a) }
+		reason = func(node model.NodeInfo) string { return fmt.Sprintf("oldest (created %s)", node.CreatedTime) }
+	case model.ScaleInMostExpensive:
+		less = func(a, b model.NodeInfo) bool {
+			if ca, cb := nodeCostPerHour(a), nodeCostPerHour(b); ca != cb {
+				return ca > cb
+			}
+			return newer(a, b)
+		}
+		reason = func(node model.NodeInfo) string {
+			return fmt.Sprintf("mostExpensive ($%.4f/hour)", nodeCostPerHour(node))
+		}
+	case model.ScaleInLeastLoaded:
+		load := func(node model.NodeInfo) float64 {
+			if l, ok := loads[node.Id]; ok {
+				return l
+			}
+			return -1
+		}
+		less = func(a, b model.NodeInfo) bool {
+			if la, lb := load(a), load(b); la != lb {
+				return la < lb
+			}
+			return newer(a, b)
+		}
+		reason = func(node model.NodeInfo) string {
+			if l := load(node); l >= 0 {
+				return fmt.Sprintf("leastLoaded (load %.2f per vCPU)", l)
+			}
+			return "leastLoaded (load unknown: the Node did not answer)"
+		}
+	}
+	sort.SliceStable(running, func(i, j int) bool { return less(running[i], running[j]) })
+	for _, node := range running[:remaining] {
+		victims = append(victims, model.ScaleInNodeResult{NodeId: node.Id, Zone: node.Region.Zone, Reason: reason(node)})
+	}
+	return victims
+}
+
+// nodeCostPerHour returns the hourly cost of a Node from its spec (spot price for spot Nodes)
+func nodeCostPerHour(node model.NodeInfo) float64 {
+	if strings.EqualFold(node.CapacityType, "spot") && node.Spec.SpotCostPerHour > 0 {
+		return float64(node.Spec.SpotCostPerHour)
+	}
+	return float64(node.Spec.CostPerHour)
+}
+
+// measureNodeLoad reads the 1-minute load average of the Nodes of a NodeGroup over SSH,
+// normalized by vCPU count. Nodes that do not answer are missing from the result.
+func measureNodeLoad(nsId, infraId, nodeGroupId string, nodes []model.NodeInfo) map[string]float64 {
+	loads := map[string]float64{}
+	cmdReq := &model.InfraCmdReq{Command: []string{"cat /proc/loadavg"}, TimeoutMinutes: 1}
+	results, err := RemoteCommandToInfra(nsId, infraId, nodeGroupId, "", "", cmdReq, "")
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to measure the load of NodeGroup '%s'", nodeGroupId)
+		return loads
+	}
+	vcpu := map[string]uint16{}
+	for _, node := range nodes {
+		vcpu[node.Id] = node.Spec.VCPU
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		fields := strings.Fields(r.Stdout[0])
+		if len(fields) == 0 {
+			continue
+		}
+		load, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		if n := vcpu[r.NodeId]; n > 0 {
+			load /= float64(n)
+		}
+		loads[r.NodeId] = load
+	}
+	return loads
+}
+
+// removeInfraNodes drains and terminates the given Nodes. Draining (NLB removal and the
+// pre-stop command) runs for all Nodes first and its failure does not stop the termination.
+// Nodes are terminated one by one since each removal updates the NodeGroup record.
+func removeInfraNodes(nsId, infraId string, victims []model.ScaleInNodeResult, preStop *model.InfraCmdReq, removeFromNlb bool) []model.ScaleInNodeResult {
+	if len(victims) == 0 {
+		return victims
+	}
+	index := map[string]int{}
+	nodeIds := make([]string, 0, len(victims))
+	for i, v := range victims {
+		index[v.NodeId] = i
+		nodeIds = append(nodeIds, v.NodeId)
+	}
+	addDrainError := func(nodeId, msg string) {
+		v := &victims[index[nodeId]]
+		if v.DrainError != "" {
+			v.DrainError += "; "
+		}
+		v.DrainError += msg
+	}
+
+	if removeFromNlb {
+		nlbIds, err := ListNLBId(nsId, infraId)
+		if err != nil {
+			for _, nodeId := range nodeIds {
+				addDrainError(nodeId, "failed to list NLBs: "+err.Error())
+			}
+		}
+		for _, nlbId := range nlbIds {
+			nlb, err := GetNLB(nsId, infraId, nlbId)
+			if err != nil {
+				continue
+			}
+			var members []string
+			for _, nodeId := range nlb.TargetGroup.Nodes {
+				if _, ok := index[nodeId]; ok {
+					members = append(members, nodeId)
+				}
+			}
+			if len(members) == 0 {
+				continue
+			}
+			err = RemoveNLBNodes(nsId, infraId, nlbId, &model.NLBAddRemoveNodeReq{TargetGroup: model.NLBTargetGroupInfo{Nodes: members}})
+			for _, nodeId := range members {
+				if err != nil {
+					addDrainError(nodeId, fmt.Sprintf("failed to remove from NLB '%s': %v", nlbId, err))
+					continue
+				}
+				victims[index[nodeId]].NlbIds = append(victims[index[nodeId]].NlbIds, nlbId)
+			}
+		}
+	}
+
+	if preStop != nil && len(preStop.Command) > 0 {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		for _, nodeId := range nodeIds {
+			wg.Add(1)
+			go func(nodeId string) {
+				defer wg.Done()
+				cmdReq := *preStop
+				results, err := RemoteCommandToInfra(nsId, infraId, "", nodeId, "", &cmdReq, "")
+				if err == nil && len(results) > 0 && results[0].Err != nil {
+					err = results[0].Err
+				}
+				if err != nil {
+					mu.Lock()
+					addDrainError(nodeId, "pre-stop command failed: "+err.Error())
+					mu.Unlock()
+				}
+			}(nodeId)
+		}
+		wg.Wait()
+	}
+
+	for i := range victims {
+		if err := DelInfraNode(nsId, infraId, victims[i].NodeId, ""); err != nil {
+			log.Error().Err(err).Msgf("Failed to remove Node '%s' in scale-in", victims[i].NodeId)
+			victims[i].Error = err.Error()
+			continue
+		}
+		victims[i].Removed = true
+	}
+	return victims
+}
@@ -612,6 +612,7 @@ func (job *ScheduledJob) execute() {
 			job.mu.Unlock()
 
 			log.Error().Str("jobId", job.JobId).Interface("panic", r).Msg("Job execution panicked")
+			recordJobExecution(job.JobType, metricResultPanic)
 
 			// Persist panic status
 			sm := GetSchedulerManager()
@@ -843,6 +844,12 @@ func (job *ScheduledJob) execute() {
 	job.Status = JobStatusScheduled
 	job.mu.Unlock()
 
+	if execResult.err != nil {
+		recordJobExecution(job.JobType, metricResultFailure)
+	} else {
+		recordJobExecution(job.JobType, metricResultSuccess)
+	}
+
 	// Persist completion status to kvstore
 	if err := sm.saveJobToStore(job); err != nil {
 		log.Error().Err(err).Str("jobId", job.JobId).Msg("Failed to persist completion status")
@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infra
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/rs/zerolog/log"
+)
+
+// spotInterruptionMessage is recorded on a spot Node that the CSP terminated.
+const spotInterruptionMessage = "spot instance interrupted (terminated by the CSP)"
+
+// spotReplaceLocks holds one *sync.Mutex per "nsId/infraId" so simultaneous
+// interruptions in one Infra do not run overlapping refine actions, while
+// replacements in different Infras proceed independently.
+var spotReplaceLocks sync.Map
+
+// lockSpotReplace acquires the replacement lock of an Infra; release via defer.
+func lockSpotReplace(nsId, infraId string) func() {
+	v, _ := spotReplaceLocks.LoadOrStore(nsId+"/"+infraId, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// isSpotInterruption reports whether a status transition of a spot Node to Terminated
+// was caused by the CSP rather than by a TB terminate/refine action.
+func isSpotInterruption(before model.NodeInfo, newStatus string) bool {
+	if before.CapacityType != model.CapacityTypeSpot || newStatus != model.StatusTerminated {
+		return false
+	}
+	switch before.Status {
+	case model.StatusTerminated, model.StatusTerminating, model.StatusFailed:
+		return false
+	}
+	return before.TargetAction != model.ActionTerminate && before.TargetAction != model.ActionRefine
+}
+
+// handleSpotInterruption applies the NodeGroup's interruption policy to an interrupted
+// spot Node. With SpotInterruptionReplace, one replacement Node is scaled out from the
+// same NodeGroup and the terminated Node is then removed through the refine path
+// (the same one used by PolicyOnPartialFailure=refine). Runs asynchronously.
+func handleSpotInterruption(nsId, infraId string, node model.NodeInfo) {
+	log.Warn().Msgf("[Spot] Node %s/%s/%s was interrupted by the CSP", nsId, infraId, node.Id)
+
+	if node.Spot == nil || node.Spot.OnInterruption != model.SpotInterruptionReplace || node.NodeGroupId == "" {
+		return
+	}
+
+	go func() {
+		defer lockSpotReplace(nsId, infraId)()
+
+		// Scale out first: ScaleOut copies its template from an existing Node of the
+		// NodeGroup, which would be gone if refine ran first on a fully interrupted group.
+		if _, err := ScaleOutInfraNodeGroup(context.Background(), nsId, infraId, node.NodeGroupId, 1); err != nil {
+			log.Error().Err(err).Msgf("[Spot] Failed to create replacement for interrupted Node %s", node.Id)
+			return
+		}
+		if result, err := HandleInfraAction(context.Background(), nsId, infraId, model.ActionRefine, true); err != nil {
+			log.Error().Err(err).Msgf("[Spot] Refine after replacing Node %s failed", node.Id)
+		} else {
+			log.Info().Msgf("[Spot] Replaced interrupted Node %s: %s", node.Id, result)
+		}
+	}()
+}
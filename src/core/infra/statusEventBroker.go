@@ -0,0 +1,150 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infra
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/rs/zerolog/log"
+)
+
+// statusSubscriberChannelSize is the buffered channel size for each status stream subscriber
+const statusSubscriberChannelSize = 256
+
+// NodeStatusEventFilter selects which Node status transitions a subscriber receives.
+// Empty fields match everything; Statuses matches on the new status (case-insensitive).
+type NodeStatusEventFilter struct {
+	NsId     string
+	InfraId  string
+	Statuses []string
+}
+
+func (f NodeStatusEventFilter) match(evt model.NodeStatusEvent) bool {
+	if f.NsId != "" && f.NsId != evt.NsId {
+		return false
+	}
+	if f.InfraId != "" && f.InfraId != evt.InfraId {
+		return false
+	}
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, s := range f.Statuses {
+		if strings.EqualFold(s, evt.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusEventBroker fans Node status transitions observed by writeStatusToStore out
+// to SSE subscribers. Unlike commandLogBroker there is no replay buffer: the current
+// state is served from StatusStore on connect (NodeStatusSnapshot).
+var statusEventBroker = struct {
+	mu          sync.RWMutex
+	subscribers map[chan model.NodeStatusEvent]NodeStatusEventFilter
+}{
+	subscribers: make(map[chan model.NodeStatusEvent]NodeStatusEventFilter),
+}
+
+// publishNodeStatusEvent delivers evt to every subscriber whose filter matches.
+// Sends are non-blocking so a slow client never stalls NodeStatusAgent.
+func publishNodeStatusEvent(evt model.NodeStatusEvent) {
+	statusEventBroker.mu.RLock()
+	defer statusEventBroker.mu.RUnlock()
+
+	for ch, filter := range statusEventBroker.subscribers {
+		if !filter.match(evt) {
+			continue
+		}
+		select {
+		case ch <- evt:
+		default:
+			log.Warn().Str("nsId", evt.NsId).Str("infraId", evt.InfraId).Msg("Status subscriber channel full, dropping event")
+		}
+	}
+}
+
+// SubscribeNodeStatusEvents subscribes to Node status transitions matching filter.
+// It returns a channel of events and a cleanup function that MUST be called when done.
+func SubscribeNodeStatusEvents(filter NodeStatusEventFilter) (<-chan model.NodeStatusEvent, func()) {
+	ch := make(chan model.NodeStatusEvent, statusSubscriberChannelSize)
+
+	statusEventBroker.mu.Lock()
+	statusEventBroker.subscribers[ch] = filter
+	statusEventBroker.mu.Unlock()
+
+	cleanup := func() {
+		statusEventBroker.mu.Lock()
+		delete(statusEventBroker.subscribers, ch)
+		statusEventBroker.mu.Unlock()
+	}
+	return ch, cleanup
+}
+
+// GetNodeStatusSnapshot returns the last known status of every Node in StatusStore
+// matching filter, as NodeStatusSnapshot events sorted by Node.
+func GetNodeStatusSnapshot(filter NodeStatusEventFilter) []model.NodeStatusEvent {
+	now := time.Now().UTC().Format(time.RFC3339)
+	var events []model.NodeStatusEvent
+	for _, e := range globalStatusStore.Snapshot() {
+		event := model.NodeStatusEvent{
+			Type:          model.EventNodeStatusSnapshot,
+			NsId:          e.NsId,
+			InfraId:       e.InfraId,
+			NodeId:        e.NodeId,
+			Status:        e.Status,
+			NativeStatus:  e.NativeStatus,
+			TargetStatus:  e.TargetStatus,
+			TargetAction:  e.TargetAction,
+			SystemMessage: e.SystemMessage,
+			Timestamp:     now,
+		}
+		if !filter.match(event) {
+			continue
+		}
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].InfraId != events[j].InfraId {
+			return events[i].InfraId < events[j].InfraId
+		}
+		return events[i].NodeId < events[j].NodeId
+	})
+	return events
+}
+
+// IsInfraInStatus reports whether every Node of the Infra currently has the given
+// status according to StatusStore. An Infra without Nodes never matches, and Nodes
+// not yet observed by NodeStatusAgent count as not matching.
+func IsInfraInStatus(nsId, infraId, status string) (bool, error) {
+	nodeIds, err := ListNodeId(nsId, infraId)
+	if err != nil {
+		return false, err
+	}
+	if len(nodeIds) == 0 {
+		return false, nil
+	}
+	for _, nodeId := range nodeIds {
+		e, ok := globalStatusStore.Get(nsId, infraId, nodeId)
+		if !ok || !strings.EqualFold(e.Status, status) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -18,6 +18,7 @@ import (
 	"math/rand"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
@@ -182,6 +183,13 @@ func (a *NodeStatusAgent) runBatchSweep(ctx context.Context) {
 		return
 	}
 
+	var failedGroups atomic.Int32
+	nodeCount := 0
+	for _, nodes := range groups {
+		nodeCount += len(nodes)
+	}
+	defer func() { recordBatchSweep(now, len(groups), nodeCount, int(failedGroups.Load())) }()
+
 	// Pre-bump NextPollAt for all batch nodes to prevent individual workers from
 	// dispatching them while the SDK call is in flight.
 	// Each node gets a small random jitter (up to 10 % of PollNormal) so that
@@ -213,6 +221,8 @@ func (a *NodeStatusAgent) runBatchSweep(ctx context.Context) {
 			sdkCtx := context.WithValue(ctx, model.CtxKeyCredentialHolder, k.credentialHolder)
 			statuses, err := handler(sdkCtx, k.region, ids)
 			if err != nil {
+				failedGroups.Add(1)
+				statusAgentBatchCalls.WithLabelValues(k.provider, "error").Inc()
 				log.Warn().Err(err).
 					Str("provider", k.provider).
 					Str("region", k.region).
@@ -226,6 +236,7 @@ func (a *NodeStatusAgent) runBatchSweep(ctx context.Context) {
 				}
 				return
 			}
+			statusAgentBatchCalls.WithLabelValues(k.provider, "success").Inc()
 
 			updated := 0
 			for _, n := range grp {
@@ -237,6 +248,7 @@ func (a *NodeStatusAgent) runBatchSweep(ctx context.Context) {
 					// advance the shared streak and settle as Terminated once reached, instead
 					// of leaving it Undefined forever (which keeps it polling / flip-flopping).
 					newStatus = recordBatchNotFound(n.nsId, n.infraId, n.nodeId)
+					statusAgentBatchNotFound.WithLabelValues(k.provider).Inc()
 				}
 				globalStatusStore.Update(n.nsId, n.infraId, n.nodeId, func(e *StatusEntry) {
 					if e.Status != newStatus {
@@ -329,6 +341,7 @@ func (a *NodeStatusAgent) dispatchEligible() {
 		case a.workerCh <- e:
 		default:
 			// Pool saturated; revert so this node is retried next tick.
+			statusAgentDispatchDropped.Inc()
 			globalStatusStore.Update(e.NsId, e.InfraId, e.NodeId, func(ent *StatusEntry) {
 				ent.NextPollAt = now
 			})
@@ -352,11 +365,19 @@ func (a *NodeStatusAgent) worker(ctx context.Context) {
 // FetchNodeStatus writes the result through to StatusStore (via writeStatusToStore).
 func (a *NodeStatusAgent) poll(ctx context.Context, entry StatusEntry) {
 	limiter := a.getLimiter(entry.ProviderName, entry.Region)
+	waitStart := time.Now()
 	if err := limiter.Wait(ctx); err != nil {
 		return // ctx cancelled
 	}
+	recordLimiterWait(entry.ProviderName, entry.Region, time.Since(waitStart))
 
+	pollStart := time.Now()
 	_, err := FetchNodeStatus(entry.NsId, entry.InfraId, entry.NodeId)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	statusAgentPollDuration.WithLabelValues(strings.ToLower(entry.ProviderName), result).Observe(time.Since(pollStart).Seconds())
 	if err != nil {
 		log.Debug().Err(err).
 			Str("nodeId", entry.NodeId).
@@ -642,7 +663,9 @@ func writeStatusToStore(nsId, infraId, nodeId string, statusInfo model.NodeStatu
 		}
 	}
 
+	var previousStatus string
 	globalStatusStore.Update(nsId, infraId, nodeId, func(e *StatusEntry) {
+		previousStatus = e.Status
 		e.Status = statusInfo.Status
 		e.NativeStatus = statusInfo.NativeStatus
 		e.PublicIP = statusInfo.PublicIp
@@ -678,6 +701,24 @@ func writeStatusToStore(nsId, infraId, nodeId string, statusInfo model.NodeStatu
 			e.NextPollAt = time.Now().Add(interval)
 		}
 	})
+
+	// Stream the transition to status subscribers (outside the store lock)
+	if statusInfo.Status != "" && previousStatus != statusInfo.Status {
+		recordNodeUsageTransition(nsId, infraId, nodeInfo, statusInfo.Status)
+		publishNodeStatusEvent(model.NodeStatusEvent{
+			Type:           model.EventNodeStatusChanged,
+			NsId:           nsId,
+			InfraId:        infraId,
+			NodeId:         nodeId,
+			PreviousStatus: previousStatus,
+			Status:         statusInfo.Status,
+			NativeStatus:   statusInfo.NativeStatus,
+			TargetStatus:   statusInfo.TargetStatus,
+			TargetAction:   statusInfo.TargetAction,
+			SystemMessage:  statusInfo.SystemMessage,
+			Timestamp:      time.Now().UTC().Format(time.RFC3339),
+		})
+	}
 }
 
 // fetchNodeStatusWithCache checks StatusStore before calling FetchNodeStatus.
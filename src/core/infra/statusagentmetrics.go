@@ -0,0 +1,290 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infra
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// staleOverdueThreshold is how far past NextPollAt an entry may be before it is
+// reported as stale (the agent is not keeping up with its schedule).
+const staleOverdueThreshold = time.Minute
+
+// maxStaleEntriesReported caps the stale Node list in the debug snapshot.
+const maxStaleEntriesReported = 50
+
+// pollPriorityNames labels PollPriority values in metrics and debug output.
+var pollPriorityNames = map[PollPriority]string{
+	PollSkip:    "skip",
+	PollRecover: "recover",
+	PollNormal:  "normal",
+	PollHigh:    "high",
+	PollUrgent:  "urgent",
+}
+
+func (p PollPriority) String() string {
+	if name, ok := pollPriorityNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+var (
+	statusAgentLimiterWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "tumblebug",
+		Subsystem: "status_agent",
+		Name:      "limiter_wait_seconds",
+		Help:      "Time NodeStatusAgent workers waited on the per provider/region rate limiter.",
+		Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10, 30},
+	}, []string{"provider", "region"})
+
+	statusAgentLimiterThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tumblebug",
+		Subsystem: "status_agent",
+		Name:      "limiter_throttled_total",
+		Help:      "Number of polls delayed by the per provider/region rate limiter.",
+	}, []string{"provider", "region"})
+
+	statusAgentPollDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "tumblebug",
+		Subsystem: "status_agent",
+		Name:      "poll_duration_seconds",
+		Help:      "Latency of individual FetchNodeStatus polls issued by NodeStatusAgent.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"provider", "result"})
+
+	statusAgentDispatchDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "tumblebug",
+		Subsystem: "status_agent",
+		Name:      "dispatch_dropped_total",
+		Help:      "Number of eligible polls deferred because the worker queue was full.",
+	})
+
+	statusAgentBatchSweepDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "tumblebug",
+		Subsystem: "status_agent",
+		Name:      "batch_sweep_duration_seconds",
+		Help:      "Duration of a full BatchSweeper run across all provider/region groups.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+	})
+
+	statusAgentBatchCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tumblebug",
+		Subsystem: "status_agent",
+		Name:      "batch_calls_total",
+		Help:      "Batch status SDK calls issued by BatchSweeper.",
+	}, []string{"provider", "result"})
+
+	statusAgentBatchNotFound = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tumblebug",
+		Subsystem: "status_agent",
+		Name:      "batch_not_found_total",
+		Help:      "Instances omitted from a batch status response (advances the not-found streak).",
+	}, []string{"provider"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		statusAgentLimiterWait,
+		statusAgentLimiterThrottled,
+		statusAgentPollDuration,
+		statusAgentDispatchDropped,
+		statusAgentBatchSweepDuration,
+		statusAgentBatchCalls,
+		statusAgentBatchNotFound,
+		statusStoreCollector{},
+	)
+}
+
+// limiterStat accumulates per provider/region limiter usage for the debug endpoint.
+type limiterStat struct {
+	waits     atomic.Uint64
+	throttled atomic.Uint64
+	waitNanos atomic.Int64
+}
+
+// limiterStats is keyed like NodeStatusAgent.limiters ("provider/region").
+var limiterStats sync.Map
+
+// recordLimiterWait records one limiter acquisition that took waited.
+func recordLimiterWait(provider, region string, waited time.Duration) {
+	provider = strings.ToLower(provider)
+	key := provider + "/" + region
+	v, _ := limiterStats.LoadOrStore(key, &limiterStat{})
+	st := v.(*limiterStat)
+	st.waits.Add(1)
+	st.waitNanos.Add(int64(waited))
+
+	statusAgentLimiterWait.WithLabelValues(provider, region).Observe(waited.Seconds())
+	// Sub-millisecond waits are scheduling noise, not throttling
+	if waited > time.Millisecond {
+		st.throttled.Add(1)
+		statusAgentLimiterThrottled.WithLabelValues(provider, region).Inc()
+	}
+}
+
+// lastBatchSweep holds the most recent batch sweep summary for the debug endpoint.
+var lastBatchSweep atomic.Pointer[model.StatusAgentBatchSweepInfo]
+
+// recordBatchSweep stores the summary of a completed batch sweep.
+func recordBatchSweep(started time.Time, groups, nodes, failedGroups int) {
+	d := time.Since(started)
+	statusAgentBatchSweepDuration.Observe(d.Seconds())
+	lastBatchSweep.Store(&model.StatusAgentBatchSweepInfo{
+		StartedAt:       started.UTC().Format(time.RFC3339),
+		DurationSeconds: d.Seconds(),
+		Groups:          groups,
+		Nodes:           nodes,
+		FailedGroups:    failedGroups,
+	})
+}
+
+// statusStoreCollector exports StatusStore-derived gauges computed at scrape time,
+// so queue depth and staleness are always current without a background updater.
+type statusStoreCollector struct{}
+
+var (
+	statusAgentQueueDepthDesc = prometheus.NewDesc(
+		"tumblebug_status_agent_queue_depth",
+		"Number of Nodes tracked by NodeStatusAgent per poll priority.",
+		[]string{"priority"}, nil)
+	statusAgentDueDesc = prometheus.NewDesc(
+		"tumblebug_status_agent_due_entries",
+		"Number of Nodes whose next poll time has passed, per poll priority.",
+		[]string{"priority"}, nil)
+	statusAgentStaleDesc = prometheus.NewDesc(
+		"tumblebug_status_agent_stale_entries",
+		"Number of Nodes overdue for polling beyond the stale threshold.",
+		nil, nil)
+	statusAgentLockedDesc = prometheus.NewDesc(
+		"tumblebug_status_agent_operation_locked_entries",
+		"Number of Nodes skipped because a lifecycle operation holds the lock.",
+		nil, nil)
+	statusAgentWorkerQueueDesc = prometheus.NewDesc(
+		"tumblebug_status_agent_worker_queue_length",
+		"Number of polls waiting in the worker dispatch channel.",
+		nil, nil)
+)
+
+func (statusStoreCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- statusAgentQueueDepthDesc
+	ch <- statusAgentDueDesc
+	ch <- statusAgentStaleDesc
+	ch <- statusAgentLockedDesc
+	ch <- statusAgentWorkerQueueDesc
+}
+
+func (statusStoreCollector) Collect(ch chan<- prometheus.Metric) {
+	info := GlobalAgent.DebugInfo()
+	for _, p := range []PollPriority{PollSkip, PollRecover, PollNormal, PollHigh, PollUrgent} {
+		ch <- prometheus.MustNewConstMetric(statusAgentQueueDepthDesc, prometheus.GaugeValue,
+			float64(info.QueueDepthByPriority[p.String()]), p.String())
+		ch <- prometheus.MustNewConstMetric(statusAgentDueDesc, prometheus.GaugeValue,
+			float64(info.DueByPriority[p.String()]), p.String())
+	}
+	ch <- prometheus.MustNewConstMetric(statusAgentStaleDesc, prometheus.GaugeValue, float64(info.StaleEntryCount))
+	ch <- prometheus.MustNewConstMetric(statusAgentLockedDesc, prometheus.GaugeValue, float64(info.OperationLocked))
+	ch <- prometheus.MustNewConstMetric(statusAgentWorkerQueueDesc, prometheus.GaugeValue, float64(info.WorkerQueueLength))
+}
+
+// DebugInfo returns an introspection snapshot of the agent: queue depth per
+// priority, stale entries, limiter usage by provider/region and the last batch sweep.
+func (a *NodeStatusAgent) DebugInfo() model.StatusAgentDebugInfo {
+	now := time.Now()
+	info := model.StatusAgentDebugInfo{
+		Timestamp:            now.UTC().Format(time.RFC3339),
+		Workers:              a.workers,
+		WorkerQueueLength:    len(a.workerCh),
+		WorkerQueueCapacity:  cap(a.workerCh),
+		QueueDepthByPriority: map[string]int{},
+		DueByPriority:        map[string]int{},
+		LastBatchSweep:       lastBatchSweep.Load(),
+	}
+
+	var stale []model.StatusAgentStaleNode
+	for _, e := range globalStatusStore.Snapshot() {
+		info.TotalEntries++
+		priority := e.Priority.String()
+		info.QueueDepthByPriority[priority]++
+		if e.IsOperationLocked() {
+			info.OperationLocked++
+		}
+		if e.NotFoundStreak > 0 {
+			info.NotFoundStreak++
+		}
+		if e.Priority == PollSkip || e.NextPollAt.IsZero() || now.Before(e.NextPollAt) {
+			continue
+		}
+		info.DueByPriority[priority]++
+		if overdue := now.Sub(e.NextPollAt); overdue > staleOverdueThreshold {
+			node := model.StatusAgentStaleNode{
+				NsId:           e.NsId,
+				InfraId:        e.InfraId,
+				NodeId:         e.NodeId,
+				ProviderName:   e.ProviderName,
+				Region:         e.Region,
+				Status:         e.Status,
+				Priority:       priority,
+				OverdueSeconds: int64(overdue.Seconds()),
+			}
+			if !e.LastUpdated.IsZero() {
+				node.LastUpdated = e.LastUpdated.UTC().Format(time.RFC3339)
+			}
+			stale = append(stale, node)
+		}
+	}
+	info.StaleEntryCount = len(stale)
+	sort.Slice(stale, func(i, j int) bool { return stale[i].OverdueSeconds > stale[j].OverdueSeconds })
+	if len(stale) > maxStaleEntriesReported {
+		stale = stale[:maxStaleEntriesReported]
+	}
+	info.StaleEntries = stale
+
+	a.limiters.Range(func(k, v any) bool {
+		key := k.(string)
+		l := v.(*rate.Limiter)
+		provider, region, _ := strings.Cut(key, "/")
+		li := model.StatusAgentLimiterInfo{
+			Provider: provider,
+			Region:   region,
+			Rate:     float64(l.Limit()),
+			Burst:    l.Burst(),
+			Tokens:   l.TokensAt(now),
+		}
+		if sv, ok := limiterStats.Load(key); ok {
+			st := sv.(*limiterStat)
+			li.Waits = st.waits.Load()
+			li.Throttled = st.throttled.Load()
+			li.TotalWaitSeconds = time.Duration(st.waitNanos.Load()).Seconds()
+		}
+		info.Limiters = append(info.Limiters, li)
+		return true
+	})
+	sort.Slice(info.Limiters, func(i, j int) bool {
+		if info.Limiters[i].Provider != info.Limiters[j].Provider {
+			return info.Limiters[i].Provider < info.Limiters[j].Provider
+		}
+		return info.Limiters[i].Region < info.Limiters[j].Region
+	})
+
+	return info
+}
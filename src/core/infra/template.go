@@ -26,6 +26,24 @@ import (
 // CreateInfraDynamicFromTemplate creates an Infra from a template with overrides
 func CreateInfraDynamicFromTemplate(ctx context.Context, nsId string, templateId string, applyReq *model.TemplateApplyReq, option string) (*model.InfraInfo, error) {
 
+	infraReq, err := InfraDynamicReqFromTemplate(nsId, templateId, applyReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// Call the existing CreateInfraDynamic function
+	result, err := CreateInfraDynamic(ctx, nsId, infraReq, option)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create Infra from template")
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// InfraDynamicReqFromTemplate returns the Infra dynamic request of a template with the overrides of applyReq
+func InfraDynamicReqFromTemplate(nsId string, templateId string, applyReq *model.TemplateApplyReq) (*model.InfraDynamicReq, error) {
+
 	// Get the template
 	templateInfo, err := common.GetInfraDynamicTemplate(nsId, templateId)
 	if err != nil {
@@ -41,15 +59,7 @@ func CreateInfraDynamicFromTemplate(ctx context.Context, nsId string, templateId
 	if applyReq.Description != "" {
 		infraReq.Description = applyReq.Description
 	}
-
-	// Call the existing CreateInfraDynamic function
-	result, err := CreateInfraDynamic(ctx, nsId, &infraReq, option)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to create Infra from template")
-		return nil, err
-	}
-
-	return result, nil
+	return &infraReq, nil
 }
 
 // ExtractAndCreateTemplate extracts an Infra configuration and creates a template from it
@@ -0,0 +1,435 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to manage multi-cloud infra
+package infra
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+	"github.com/rs/zerolog/log"
+)
+
+// Cost accounting.
+//
+// NodeStatusAgent publishes every status transition it observes (writeStatusToStore);
+// each transition into or out of a billed status opens or closes a running interval in the
+// Node's usage record. Records live outside the Infra subtree so that deleting an Infra
+// keeps its spend reportable. The price of an interval is the spec price snapshot of the
+// Node (NodeInfo.Spec, taken at creation and replaced only by a resize).
+//
+// Transitions are observed, not reported by the CSP, so interval bounds are as precise as
+// the agent's polling; a transition missed while the server was down is accounted when the
+// agent observes the Node again.
+//
+// The record of a deleted Node is archived under /usage/node/{nsId}/{infraId}/{nodeId}/{uid},
+// so a Node recreated with the same ID starts a new record instead of extending the old one.
+
+// nodeUsageKeyPrefix is the kvstore prefix of Node usage records (/usage/node/{nsId}/{infraId}/{nodeId})
+const nodeUsageKeyPrefix = "/usage/node/"
+
+// nodeUsageMu serializes read-modify-write of usage records
+var nodeUsageMu sync.Mutex
+
+// billedNodeStatuses are the statuses in which a Node is billed for compute
+var billedNodeStatuses = map[string]bool{
+	model.StatusRunning:     true,
+	model.StatusRebooting:   true,
+	model.StatusSuspending:  true,
+	model.StatusTerminating: true,
+}
+
+// stoppedNodeStatuses are the statuses in which a Node is not billed for compute.
+// Statuses in neither set (Creating, Undefined, ...) leave the record unchanged.
+var stoppedNodeStatuses = map[string]bool{
+	model.StatusSuspended:  true,
+	model.StatusTerminated: true,
+	model.StatusFailed:     true,
+}
+
+func nodeUsageKey(nsId, infraId, nodeId string) string {
+	return nodeUsageKeyPrefix + nsId + "/" + infraId + "/" + nodeId
+}
+
+func getNodeUsageRecord(nsId, infraId, nodeId string) (model.NodeUsageRecord, bool, error) {
+	record := model.NodeUsageRecord{}
+	val, exists, err := kvstore.Get(nodeUsageKey(nsId, infraId, nodeId))
+	if err != nil || !exists {
+		return record, false, err
+	}
+	if err := json.Unmarshal([]byte(val), &record); err != nil {
+		return record, false, err
+	}
+	return record, true, nil
+}
+
+func putNodeUsageRecord(record model.NodeUsageRecord) error {
+	val, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put(nodeUsageKey(record.NsId, record.InfraId, record.NodeId), string(val))
+}
+
+// archiveNodeUsageRecord moves a finished record from the Node's key to a key of its own.
+func archiveNodeUsageRecord(record model.NodeUsageRecord) error {
+	suffix := record.Uid
+	if suffix == "" {
+		// Records stored before the Uid was tracked
+		suffix = record.DeletedAt
+		if suffix == "" {
+			suffix = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+	val, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	key := nodeUsageKey(record.NsId, record.InfraId, record.NodeId)
+	if err := kvstore.Put(key+"/"+suffix, string(val)); err != nil {
+		return err
+	}
+	return kvstore.Delete(key)
+}
+
+// currentNodeUsageRecord returns the record of the current lifecycle of a Node. A record of a
+// deleted Node, or of an earlier Node with the same ID (different Uid), is archived first.
+func currentNodeUsageRecord(nsId, infraId string, node model.NodeInfo) (model.NodeUsageRecord, bool, error) {
+	record, exists, err := getNodeUsageRecord(nsId, infraId, node.Id)
+	if err != nil || !exists {
+		return record, exists, err
+	}
+	if record.DeletedAt == "" && (record.Uid == "" || node.Uid == "" || record.Uid == node.Uid) {
+		return record, true, nil
+	}
+	if err := archiveNodeUsageRecord(record); err != nil {
+		return model.NodeUsageRecord{}, false, err
+	}
+	return model.NodeUsageRecord{}, false, nil
+}
+
+// applyNodeSnapshot refreshes the identity, labels and (unless keepPrice) the price snapshot of a record.
+func applyNodeSnapshot(record *model.NodeUsageRecord, nsId, infraId string, node model.NodeInfo, keepPrice bool, now time.Time) {
+	record.NsId = nsId
+	record.InfraId = infraId
+	record.NodeId = node.Id
+	if node.Uid != "" {
+		record.Uid = node.Uid
+	}
+	record.NodeGroupId = node.NodeGroupId
+	record.ProviderName = node.ConnectionConfig.ProviderName
+	record.RegionName = node.ConnectionConfig.RegionDetail.RegionName
+	record.CapacityType = node.CapacityType
+
+	label := map[string]string{}
+	if infraInfo, exists, err := GetInfraObject(nsId, infraId); err == nil && exists {
+		for k, v := range infraInfo.Label {
+			label[k] = v
+		}
+	}
+	for k, v := range node.Label {
+		label[k] = v
+	}
+	record.Label = label
+
+	if keepPrice && record.SpecId != "" {
+		return
+	}
+	record.SpecId = node.SpecId
+	record.CspSpecName = node.CspSpecName
+	record.CostPerHour = nodeCostPerHour(node)
+	record.PriceSnapshotAt = now.UTC().Format(time.RFC3339)
+}
+
+// openInterval returns the index of the open interval of a record, or -1.
+func openInterval(record *model.NodeUsageRecord) int {
+	if n := len(record.Intervals); n > 0 && record.Intervals[n-1].End == "" {
+		return n - 1
+	}
+	return -1
+}
+
+// recordNodeUsageTransition opens or closes the running interval of a Node on a status transition.
+func recordNodeUsageTransition(nsId, infraId string, node model.NodeInfo, status string) {
+	billed, stopped := billedNodeStatuses[status], stoppedNodeStatuses[status]
+	if node.Id == "" || (!billed && !stopped) {
+		return
+	}
+	now := time.Now().UTC()
+
+	nodeUsageMu.Lock()
+	defer nodeUsageMu.Unlock()
+
+	record, exists, err := currentNodeUsageRecord(nsId, infraId, node)
+	if err != nil {
+		log.Warn().Err(err).Msgf("cannot read the usage record of Node %s", node.Id)
+		return
+	}
+	if !exists && stopped {
+		// A Node never seen running has no spend to record
+		return
+	}
+	applyNodeSnapshot(&record, nsId, infraId, node, exists, now)
+
+	open := openInterval(&record)
+	switch {
+	case billed && open < 0:
+		record.Intervals = append(record.Intervals, model.NodeRunInterval{
+			Start:       now.Format(time.RFC3339),
+			SpecId:      record.SpecId,
+			CostPerHour: record.CostPerHour,
+		})
+	case stopped && open >= 0:
+		record.Intervals[open].End = now.Format(time.RFC3339)
+	}
+	if err := putNodeUsageRecord(record); err != nil {
+		log.Warn().Err(err).Msgf("cannot store the usage record of Node %s", node.Id)
+	}
+}
+
+// updateNodeUsagePrice takes a new price snapshot after the spec of a Node changed (resize).
+// An open interval is split so that the new price applies from now on.
+func updateNodeUsagePrice(nsId, infraId string, node model.NodeInfo) {
+	now := time.Now().UTC()
+
+	nodeUsageMu.Lock()
+	defer nodeUsageMu.Unlock()
+
+	record, exists, err := currentNodeUsageRecord(nsId, infraId, node)
+	if err != nil || !exists {
+		return
+	}
+	applyNodeSnapshot(&record, nsId, infraId, node, false, now)
+	if open := openInterval(&record); open >= 0 {
+		record.Intervals[open].End = now.Format(time.RFC3339)
+		record.Intervals = append(record.Intervals, model.NodeRunInterval{
+			Start:       now.Format(time.RFC3339),
+			SpecId:      record.SpecId,
+			CostPerHour: record.CostPerHour,
+		})
+	}
+	if err := putNodeUsageRecord(record); err != nil {
+		log.Warn().Err(err).Msgf("cannot store the usage record of Node %s", node.Id)
+	}
+}
+
+// closeNodeUsage closes the open interval of a Node removed from CB-Tumblebug, marks the record
+// deleted and archives it.
+func closeNodeUsage(nsId, infraId, nodeId string) {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	nodeUsageMu.Lock()
+	defer nodeUsageMu.Unlock()
+
+	record, exists, err := getNodeUsageRecord(nsId, infraId, nodeId)
+	if err != nil || !exists {
+		return
+	}
+	if open := openInterval(&record); open >= 0 {
+		record.Intervals[open].End = now
+	}
+	record.DeletedAt = now
+	if err := archiveNodeUsageRecord(record); err != nil {
+		log.Warn().Err(err).Msgf("cannot store the usage record of Node %s", nodeId)
+	}
+}
+
+// ListNodeUsageRecords returns the usage records of a namespace (all namespaces if nsId is empty),
+// optionally limited to one Infra.
+func ListNodeUsageRecords(nsId, infraId string) ([]model.NodeUsageRecord, error) {
+	prefix := nodeUsageKeyPrefix
+	if nsId != "" {
+		prefix += nsId + "/"
+		if infraId != "" {
+			prefix += infraId + "/"
+		}
+	}
+	vals, err := kvstore.GetList(prefix)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]model.NodeUsageRecord, 0, len(vals))
+	for _, val := range vals {
+		record := model.NodeUsageRecord{}
+		if err := json.Unmarshal([]byte(val), &record); err != nil {
+			log.Warn().Err(err).Msg("skipping an unreadable Node usage record")
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// GetCostUsageReport computes the actual spend of Nodes over a time range from their running
+// intervals, grouped by Node, Infra, label value or namespace. An empty nsId covers all namespaces.
+func GetCostUsageReport(nsId string, req model.CostUsageReportReq) (model.CostUsageReport, error) {
+	now := time.Now().UTC()
+	report := model.CostUsageReport{
+		NsId:     nsId,
+		GroupBy:  strings.ToLower(req.GroupBy),
+		LabelKey: req.LabelKey,
+		Currency: model.CostBaseCurrency,
+		Rows:     []model.CostUsageReportRow{},
+	}
+	if report.GroupBy == "" {
+		report.GroupBy = model.CostUsageGroupByInfra
+	}
+	switch report.GroupBy {
+	case model.CostUsageGroupByNode, model.CostUsageGroupByInfra, model.CostUsageGroupByNamespace:
+	case model.CostUsageGroupByLabel:
+		if req.LabelKey == "" {
+			return report, apierr.Invalid("labelKey is required to group by label", nil)
+		}
+	default:
+		return report, apierr.Invalid(fmt.Sprintf("unknown groupBy '%s' (node, infra, label or namespace)", req.GroupBy), nil)
+	}
+	if nsId != "" {
+		if err := common.CheckString(nsId); err != nil {
+			return report, apierr.Invalid(err.Error(), err)
+		}
+	}
+
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+	var err error
+	if req.From != "" {
+		if from, err = time.Parse(time.RFC3339, req.From); err != nil {
+			return report, apierr.Invalid(fmt.Sprintf("invalid from '%s' (RFC3339 expected)", req.From), err)
+		}
+	}
+	if req.To != "" {
+		if to, err = time.Parse(time.RFC3339, req.To); err != nil {
+			return report, apierr.Invalid(fmt.Sprintf("invalid to '%s' (RFC3339 expected)", req.To), err)
+		}
+	}
+	if !to.After(from) {
+		return report, apierr.Invalid("to must be after from", nil)
+	}
+	report.From = from.UTC().Format(time.RFC3339)
+	report.To = to.UTC().Format(time.RFC3339)
+
+	records, err := ListNodeUsageRecords(nsId, req.InfraId)
+	if err != nil {
+		return report, err
+	}
+
+	index := map[string]int{}
+	for _, record := range records {
+		hours, cost := recordSpend(record, from, to, now)
+		if hours == 0 {
+			continue
+		}
+		row := model.CostUsageReportRow{}
+		switch report.GroupBy {
+		case model.CostUsageGroupByNode:
+			row = model.CostUsageReportRow{Key: record.NsId + "/" + record.InfraId + "/" + record.NodeId,
+				NsId: record.NsId, InfraId: record.InfraId, NodeId: record.NodeId, SpecId: record.SpecId}
+		case model.CostUsageGroupByInfra:
+			row = model.CostUsageReportRow{Key: record.NsId + "/" + record.InfraId, NsId: record.NsId, InfraId: record.InfraId}
+		case model.CostUsageGroupByNamespace:
+			row = model.CostUsageReportRow{Key: record.NsId, NsId: record.NsId}
+		case model.CostUsageGroupByLabel:
+			row = model.CostUsageReportRow{Key: record.Label[req.LabelKey]}
+			if row.Key == "" {
+				row.Key = "(unlabeled)"
+			}
+		}
+		i, ok := index[row.Key]
+		if !ok {
+			i = len(report.Rows)
+			index[row.Key] = i
+			report.Rows = append(report.Rows, row)
+		}
+		report.Rows[i].NodeCount++
+		report.Rows[i].RunningHours += hours
+		report.Rows[i].Cost += cost
+		report.TotalRunningHours += hours
+		report.TotalCost += cost
+	}
+
+	for i := range report.Rows {
+		report.Rows[i].RunningHours = roundCost(report.Rows[i].RunningHours)
+		report.Rows[i].Cost = roundCost(report.Rows[i].Cost)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool { return report.Rows[i].Cost > report.Rows[j].Cost })
+	report.TotalRunningHours = roundCost(report.TotalRunningHours)
+	report.TotalCost = roundCost(report.TotalCost)
+	return report, nil
+}
+
+// recordSpend returns the running hours and the cost of a record within [from, to).
+// An open interval runs until now.
+func recordSpend(record model.NodeUsageRecord, from, to, now time.Time) (float64, float64) {
+	hours, cost := 0.0, 0.0
+	for _, interval := range record.Intervals {
+		start, err := time.Parse(time.RFC3339, interval.Start)
+		if err != nil {
+			continue
+		}
+		end := now
+		if interval.End != "" {
+			if end, err = time.Parse(time.RFC3339, interval.End); err != nil {
+				continue
+			}
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+		h := end.Sub(start).Hours()
+		hours += h
+		cost += h * interval.CostPerHour
+	}
+	return hours, cost
+}
+
+// CostUsageReportCSV renders a CostUsageReport as CSV for export.
+func CostUsageReportCSV(report model.CostUsageReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{"key", "nsId", "infraId", "nodeId", "specId", "nodeCount", "runningHours", "cost", "currency", "from", "to"}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for _, row := range report.Rows {
+		line := []string{
+			row.Key, row.NsId, row.InfraId, row.NodeId, row.SpecId,
+			strconv.Itoa(row.NodeCount),
+			strconv.FormatFloat(row.RunningHours, 'f', 4, 64),
+			strconv.FormatFloat(row.Cost, 'f', 4, 64),
+			report.Currency, report.From, report.To,
+		}
+		if err := w.Write(line); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+// ApiTokenPrefix marks Tumblebug-issued API tokens in the Authorization header
+// ("Authorization: Bearer tbt_<tokenId>_<secret>").
+const ApiTokenPrefix = "tbt_"
+
+// API token scopes
+const (
+	// ApiTokenScopeReadOnly allows GET on the bound namespace
+	ApiTokenScopeReadOnly = "read-only"
+	// ApiTokenScopeProvision allows creating, changing and deleting Infra, resources and K8s clusters in the namespace
+	ApiTokenScopeProvision = "provision"
+	// ApiTokenScopeCommandExec allows remote commands and file transfer to Nodes in the namespace
+	ApiTokenScopeCommandExec = "command-exec"
+)
+
+// ApiTokenReq is the request body to issue an API token
+type ApiTokenReq struct {
+	Name        string   `json:"name" validate:"required" example:"ci-pipeline"`
+	Description string   `json:"description,omitempty" example:"Token for the deploy pipeline"`
+	Scopes      []string `json:"scopes" validate:"required" example:"read-only,provision" enums:"read-only,provision,command-exec"`
+	// ExpiresInHours is the token lifetime (default: 720 = 30 days, max: 8760 = 1 year)
+	ExpiresInHours int `json:"expiresInHours,omitempty" example:"720"`
+}
+
+// ApiTokenInfo is an issued API token. The secret is never stored; only its hash is.
+type ApiTokenInfo struct {
+	Id          string   `json:"id" example:"a1b2c3d4e5f6a7b8"`
+	NsId        string   `json:"nsId" example:"default"`
+	Name        string   `json:"name" example:"ci-pipeline"`
+	Description string   `json:"description,omitempty"`
+	Scopes      []string `json:"scopes" example:"read-only,provision"`
+	CreatedBy   string   `json:"createdBy,omitempty" example:"alice"`
+	CreatedAt   string   `json:"createdAt" example:"2025-01-01T00:00:00Z"`
+	ExpiresAt   string   `json:"expiresAt" example:"2025-01-31T00:00:00Z"`
+	LastUsedAt  string   `json:"lastUsedAt,omitempty" example:"2025-01-02T00:00:00Z"`
+	Revoked     bool     `json:"revoked"`
+	RevokedAt   string   `json:"revokedAt,omitempty"`
+}
+
+// ApiTokenIssued is returned once when a token is issued; Token cannot be retrieved again.
+type ApiTokenIssued struct {
+	ApiTokenInfo
+	Token string `json:"token" example:"tbt_a1b2c3d4e5f6a7b8_3f9c..."`
+}
+
+// ApiTokenList is the response for listing API tokens of a namespace
+type ApiTokenList struct {
+	Tokens []ApiTokenInfo `json:"tokens"`
+}
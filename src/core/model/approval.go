@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+import "encoding/json"
+
+// Operations that can require approval
+const (
+	// ApprovalOpInfraTerminate is deleting an Infra with option=terminate
+	ApprovalOpInfraTerminate = "infraTerminate"
+	// ApprovalOpDeleteAllNs is deleting all namespaces
+	ApprovalOpDeleteAllNs = "deleteAllNs"
+	// ApprovalOpInfraCreate is creating an Infra (dynamic) whose estimated cost exceeds the threshold
+	ApprovalOpInfraCreate = "infraCreate"
+	// ApprovalOpNodeResize is resizing a Node to a spec whose cost exceeds the threshold
+	ApprovalOpNodeResize = "nodeResize"
+	// ApprovalOpAllInfraTerminate is deleting all Infras of a namespace with option=terminate
+	ApprovalOpAllInfraTerminate = "allInfraTerminate"
+	// ApprovalOpInfraCreateStatic is creating (or registering) an Infra from static NodeGroup
+	// requests whose estimated cost exceeds the threshold
+	ApprovalOpInfraCreateStatic = "infraCreateStatic"
+	// ApprovalOpNodeGroupAdd is adding a NodeGroup (dynamic) whose estimated cost exceeds the threshold
+	ApprovalOpNodeGroupAdd = "nodeGroupAdd"
+	// ApprovalOpNodeGroupScaleOut is scaling out a NodeGroup by Nodes whose estimated cost exceeds the threshold
+	ApprovalOpNodeGroupScaleOut = "nodeGroupScaleOut"
+	// ApprovalOpInfraDesiredState is applying a desired state that grows an existing Infra
+	// by Nodes whose estimated cost exceeds the threshold
+	ApprovalOpInfraDesiredState = "infraDesiredState"
+)
+
+// Change request status
+const (
+	ChangeRequestPending   = "Pending"
+	ChangeRequestRejected  = "Rejected"
+	ChangeRequestExecuting = "Executing"
+	ChangeRequestExecuted  = "Executed"
+	ChangeRequestFailed    = "Failed"
+)
+
+// ApprovalPolicy configures which operations require a second person's approval
+type ApprovalPolicy struct {
+	// Enabled turns the approval workflow on
+	Enabled bool `json:"enabled" example:"true"`
+	// InfraTerminate requires approval to delete an Infra (or all Infras of a namespace) with option=terminate
+	InfraTerminate bool `json:"infraTerminate" example:"true"`
+	// DeleteAllNs requires approval to delete all namespaces
+	DeleteAllNs bool `json:"deleteAllNs" example:"true"`
+	// CostThresholdPerHour requires approval to create an Infra, add a NodeGroup, scale out or
+	// upsize Nodes when the estimated cost of the added capacity exceeds this value in USD/hour
+	// (0: no cost-based approval)
+	CostThresholdPerHour float64 `json:"costThresholdPerHour" example:"10.0"`
+}
+
+// ChangeRequestImpact is the estimated impact of a change request
+type ChangeRequestImpact struct {
+	Summary              string                     `json:"summary" example:"terminate 4 Nodes of Infra infra01"`
+	NodeCount            int                        `json:"nodeCount,omitempty" example:"4"`
+	EstimatedCostPerHour float64                    `json:"estimatedCostPerHour,omitempty" example:"12.5"`
+	Review               *ReviewInfraDynamicReqInfo `json:"review,omitempty"`
+}
+
+// ChangeRequestEvent is an audit trail entry of a change request
+type ChangeRequestEvent struct {
+	Time    string `json:"time" example:"2025-01-01T00:00:00Z"`
+	Actor   string `json:"actor" example:"alice"`
+	Action  string `json:"action" example:"approved"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// ChangeRequest is an operation parked until a second person approves it
+type ChangeRequest struct {
+	Id               string               `json:"id" example:"cr-0123456789abcdef"`
+	Operation        string               `json:"operation" example:"infraTerminate" enums:"infraTerminate,deleteAllNs,infraCreate,nodeResize,allInfraTerminate,infraCreateStatic,nodeGroupAdd,nodeGroupScaleOut,infraDesiredState"`
+	NsId             string               `json:"nsId,omitempty" example:"default"`
+	TargetId         string               `json:"targetId,omitempty" example:"infra01"`
+	Option           string               `json:"option,omitempty" example:"terminate"`
+	CredentialHolder string               `json:"credentialHolder,omitempty"`
+	Payload          json.RawMessage      `json:"payload,omitempty" swaggertype:"object"`
+	Impact           ChangeRequestImpact  `json:"impact"`
+	Requester        string               `json:"requester" example:"bob"`
+	Status           string               `json:"status" example:"Pending" enums:"Pending,Rejected,Executing,Executed,Failed"`
+	Approver         string               `json:"approver,omitempty" example:"alice"`
+	Result           any                  `json:"result,omitempty"`
+	Error            string               `json:"error,omitempty"`
+	CreatedAt        string               `json:"createdAt" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt        string               `json:"updatedAt" example:"2025-01-01T00:00:00Z"`
+	History          []ChangeRequestEvent `json:"history"`
+}
+
+// ChangeRequestList is a list of change requests
+type ChangeRequestList struct {
+	ChangeRequests []ChangeRequest `json:"changeRequests"`
+}
+
+// ChangeRequestDecision is the request body to approve or reject a change request
+// (the approver is always the authenticated caller)
+type ChangeRequestDecision struct {
+	Comment string `json:"comment,omitempty" example:"checked with the owner"`
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+// Budget periods
+const (
+	BudgetPeriodDaily   string = "daily"
+	BudgetPeriodMonthly string = "monthly"
+)
+
+// Budget enforcement actions
+const (
+	// BudgetEnforceNone only emits events and webhooks
+	BudgetEnforceNone string = "none"
+	// BudgetEnforceBlock blocks new provisioning in the budget scope once the budget is exhausted
+	BudgetEnforceBlock string = "block"
+	// BudgetEnforceSuspend suspends the running Infras in the budget scope (again on every evaluation
+	// while exhausted) and blocks new provisioning and resuming
+	BudgetEnforceSuspend string = "suspend"
+)
+
+// Budget event types
+const (
+	BudgetEventThreshold  string = "threshold"
+	BudgetEventExhausted  string = "exhausted"
+	BudgetEventEnforced   string = "enforced"
+	BudgetEventOverridden string = "overridden"
+	BudgetEventReset      string = "reset"
+)
+
+// BudgetReq is a request to create or update a budget of a namespace
+type BudgetReq struct {
+	Name string `json:"name" validate:"required" example:"team-a-monthly"`
+
+	// LabelKey and LabelValue limit the budget to Infras (and their Nodes) with this label; empty = whole namespace
+	LabelKey   string `json:"labelKey,omitempty" example:"team"`
+	LabelValue string `json:"labelValue,omitempty" example:"a"`
+
+	// Amount is the budget in USD per period
+	Amount float64 `json:"amount" validate:"required" example:"500"`
+	// Period is the budget period in UTC (default: monthly)
+	Period string `json:"period,omitempty" example:"monthly" enums:"daily,monthly"`
+	// Thresholds are the percentages of the budget that emit an event (default: 50, 80, 100)
+	Thresholds []int `json:"thresholds,omitempty" example:"50,80,100"`
+
+	// WebhookUrl receives every budget event as a JSON POST; it must resolve to public addresses
+	WebhookUrl string `json:"webhookUrl,omitempty" example:"https://hooks.example.com/budget"`
+	// Enforcement is applied when the budget is exhausted (default: none)
+	Enforcement string `json:"enforcement,omitempty" example:"none" enums:"none,block,suspend"`
+}
+
+// BudgetOverrideReq suspends the enforcement of a budget for a while
+type BudgetOverrideReq struct {
+	// DurationMinutes is how long the override lasts (default: until the end of the current period)
+	DurationMinutes int    `json:"durationMinutes,omitempty" example:"120"`
+	Reason          string `json:"reason" validate:"required" example:"release freeze exception"`
+}
+
+// BudgetOverride is an active override of the enforcement of a budget
+type BudgetOverride struct {
+	Until  string `json:"until" example:"2024-01-31T23:59:59Z"`
+	Actor  string `json:"actor,omitempty" example:"alice"`
+	Reason string `json:"reason" example:"release freeze exception"`
+}
+
+// BudgetEvent is a notification of a budget, also delivered to its webhook
+type BudgetEvent struct {
+	Time      string  `json:"time" example:"2024-01-20T10:00:00Z"`
+	Type      string  `json:"type" example:"threshold" enums:"threshold,exhausted,enforced,overridden,reset"`
+	NsId      string  `json:"nsId" example:"default"`
+	BudgetId  string  `json:"budgetId" example:"team-a-monthly"`
+	Threshold int     `json:"threshold,omitempty" example:"80"`
+	Spend     float64 `json:"spend" example:"412.5"`
+	Amount    float64 `json:"amount" example:"500"`
+	Message   string  `json:"message" example:"80% of budget team-a-monthly used"`
+	// InfraIds are the Infras suspended by an enforced event
+	InfraIds []string `json:"infraIds,omitempty" example:"infra01"`
+}
+
+// BudgetInfo is a budget with its current state
+type BudgetInfo struct {
+	Id   string `json:"id" example:"team-a-monthly"`
+	NsId string `json:"nsId" example:"default"`
+	BudgetReq
+
+	// PeriodStart is the start of the current period (RFC3339)
+	PeriodStart string `json:"periodStart" example:"2024-01-01T00:00:00Z"`
+	// Spend is the actual spend of the current period from Node usage records
+	Spend       float64 `json:"spend" example:"412.5"`
+	PercentUsed float64 `json:"percentUsed" example:"82.5"`
+	// NotifiedThresholds are the thresholds already reached in the current period
+	NotifiedThresholds []int `json:"notifiedThresholds,omitempty" example:"50,80"`
+	// Exhausted is true when the spend reached the amount in the current period
+	Exhausted bool `json:"exhausted" example:"false"`
+	// EnforcedAt is when the enforcement last suspended Infras in the current period
+	EnforcedAt string          `json:"enforcedAt,omitempty" example:"2024-01-28T04:00:00Z"`
+	Override   *BudgetOverride `json:"override,omitempty"`
+
+	LastEvaluatedAt string `json:"lastEvaluatedAt,omitempty" example:"2024-01-20T10:00:00Z"`
+	CreatedAt       string `json:"createdAt" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt       string `json:"updatedAt" example:"2024-01-01T00:00:00Z"`
+
+	// Events is the recent event history (latest last)
+	Events []BudgetEvent `json:"events"`
+}
+
+// BudgetList is a list of budgets
+type BudgetList struct {
+	Budgets []BudgetInfo `json:"budgets"`
+}
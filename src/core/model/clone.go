@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+// InfraCloneReq is a struct to request a clone of an Infra in another region or CSP
+type InfraCloneReq struct {
+	// Name is the name of the new Infra
+	Name string `json:"name" validate:"required" example:"infra01-dr"`
+
+	// TargetProviderName is the CSP of the clone
+	TargetProviderName string `json:"targetProviderName" validate:"required" example:"gcp"`
+	// TargetRegionName is the region of the clone
+	TargetRegionName string `json:"targetRegionName" validate:"required" example:"us-central1"`
+
+	// TolerancePercent is the ±% range applied to "preferred" spec fields (default: 20)
+	TolerancePercent int `json:"tolerancePercent,omitempty" example:"20"`
+	// MatchCriteria overrides the default per-field match policy of the spec matcher
+	MatchCriteria SpecMatchCriteria `json:"matchCriteria,omitempty"`
+	// OSType overrides the OS type for image search (default: the OS type of each source image)
+	OSType string `json:"osType,omitempty" example:"ubuntu"`
+
+	// NodeGroups pins the spec or image of some NodeGroups instead of using the matcher
+	NodeGroups []InfraCloneNodeGroupOverride `json:"nodeGroups,omitempty"`
+
+	// SkipNetwork skips recreating the vNet and SecurityGroup of the source (the clone uses the defaults)
+	SkipNetwork bool `json:"skipNetwork,omitempty" example:"false"`
+	// SkipPostCommands skips replaying the postCommands of the source
+	SkipPostCommands bool `json:"skipPostCommands,omitempty" example:"false"`
+}
+
+// InfraCloneNodeGroupOverride pins the target spec or image of a source NodeGroup
+type InfraCloneNodeGroupOverride struct {
+	NodeGroupId string `json:"nodeGroupId" validate:"required" example:"g1"`
+	SpecId      string `json:"specId,omitempty" example:"gcp+us-central1+e2-medium"`
+	ImageId     string `json:"imageId,omitempty"`
+	Zone        string `json:"zone,omitempty" example:"us-central1-a"`
+}
+
+// InfraCloneNodeGroupPlan is how a source NodeGroup is mapped to the target location
+type InfraCloneNodeGroupPlan struct {
+	NodeGroupId   string `json:"nodeGroupId" example:"g1"`
+	NodeGroupSize int    `json:"nodeGroupSize" example:"2"`
+
+	SourceSpecId  string `json:"sourceSpecId" example:"aws+ap-northeast-2+t3.medium"`
+	SourceImageId string `json:"sourceImageId"`
+	TargetSpecId  string `json:"targetSpecId,omitempty" example:"gcp+us-central1+e2-medium"`
+	TargetImageId string `json:"targetImageId,omitempty"`
+
+	// Source tells how the target was chosen: "matched", "override" or "same" (same location as the source)
+	Source string `json:"source" example:"matched" enums:"matched,override,same"`
+	// SimilarityScore and SpecDiff are set when the target spec was matched
+	SimilarityScore float64   `json:"similarityScore,omitempty" example:"92.5"`
+	SpecDiff        *SpecDiff `json:"specDiff,omitempty"`
+
+	SourceCostPerHour float32 `json:"sourceCostPerHour,omitempty" example:"0.0416"`
+	TargetCostPerHour float32 `json:"targetCostPerHour,omitempty" example:"0.0335"`
+
+	VNetTemplateId string `json:"vNetTemplateId,omitempty"`
+	SgTemplateId   string `json:"sgTemplateId,omitempty"`
+
+	// Error is set when no target could be found for the NodeGroup
+	Error string `json:"error,omitempty"`
+}
+
+// InfraClonePlan is the preview of an Infra clone
+type InfraClonePlan struct {
+	SourceNsId         string `json:"sourceNsId" example:"default"`
+	SourceInfraId      string `json:"sourceInfraId" example:"infra01"`
+	TargetProviderName string `json:"targetProviderName" example:"gcp"`
+	TargetRegionName   string `json:"targetRegionName" example:"us-central1"`
+
+	// Ready is true when every NodeGroup has a target spec and image
+	Ready      bool                      `json:"ready"`
+	NodeGroups []InfraCloneNodeGroupPlan `json:"nodeGroups"`
+
+	// VNetTemplates and SgTemplates are the network equivalents created in the namespace before the clone
+	VNetTemplates []VNetTemplateReq          `json:"vNetTemplates,omitempty"`
+	SgTemplates   []SecurityGroupTemplateReq `json:"sgTemplates,omitempty"`
+
+	SourceCostPerHour float32 `json:"sourceCostPerHour" example:"0.0832"`
+	TargetCostPerHour float32 `json:"targetCostPerHour" example:"0.067"`
+
+	// InfraDynamicReq is the request submitted to create the clone
+	InfraDynamicReq InfraDynamicReq `json:"infraDynamicReq"`
+	Warnings        []string        `json:"warnings,omitempty"`
+}
+
+// InfraCloneResult is the result of an Infra clone
+type InfraCloneResult struct {
+	Plan  InfraClonePlan `json:"plan"`
+	Infra *InfraInfo     `json:"infra,omitempty"`
+}
@@ -259,6 +259,9 @@ var EtcdEndpoints string
 var SelfEndpoint string
 var VaultAddr string
 var VaultToken string
+var SecretStoreBackend string
+var SecretStoreFilePath string
+var SecretStoreFileKey string
 var MyDB *sql.DB
 var err error
 
@@ -464,6 +467,10 @@ type CredentialReq struct {
 
 	// CredentialKeyValueList contains key-(encrypted)value pairs that include the sensitive credential data.
 	CredentialKeyValueList []KeyWithEncryptedValue `json:"credentialKeyValueList"`
+
+	// ExpiresAt is the known expiry of the credential (RFC3339, optional), e.g., the end date of an Azure client secret.
+	// Warnings are logged and reported before this time.
+	ExpiresAt string `json:"expiresAt,omitempty" example:"2026-12-31T00:00:00Z"`
 }
 
 // CredentialInfo is struct for containing a struct for credential info
@@ -473,9 +480,9 @@ type CredentialInfo struct {
 	ProviderName     string         `json:"providerName"`
 	KeyValueInfoList []KeyValue     `json:"keyValueInfoList"`
 	AllConnections   ConnConfigList `json:"allConnections"`
-	// OpenBaoStatus reports whether this credential was also stored in OpenBao
-	// (used for direct CSP API calls). Values: "registered at <path>",
-	// "skipped: <reason>", or "failed: <reason>".
+	// OpenBaoStatus reports whether this credential was also stored in the secret store
+	// (OpenBao by default, see TB_SECRET_STORE; used for direct CSP API calls).
+	// Values: "registered at <path>", "skipped: <reason>", or "failed: <reason>".
 	OpenBaoStatus string `json:"openBaoStatus,omitempty"`
 }
 
@@ -500,6 +507,21 @@ type OpenBaoStatusInfo struct {
 	Message string `json:"message" example:"OpenBao is available for credential storage"`
 }
 
+// SecretStoreStatusInfo reports whether the configured secret store is usable by CB-Tumblebug.
+// @Description Secret store (TB_SECRET_STORE) availability status
+type SecretStoreStatusInfo struct {
+	// Backend is the configured secret store backend (TB_SECRET_STORE)
+	Backend string `json:"backend" example:"openbao" enums:"openbao,file"`
+	// Available is true only when credentials can actually be stored and read
+	Available bool `json:"available" example:"true"`
+	// Message describes the first detected problem, or confirms availability
+	Message string `json:"message" example:"OpenBao is available for credential storage"`
+	// FilePath is the encrypted secret file (file backend only)
+	FilePath string `json:"filePath,omitempty" example:"./secret/credentials.enc"`
+	// OpenBao is the detailed OpenBao status (openbao backend only)
+	OpenBao *OpenBaoStatusInfo `json:"openBao,omitempty"`
+}
+
 // ConnConfigList is struct for containing a CB-Spider struct for connection config list
 type ConnConfigList struct { // Spider
 	Connectionconfig []ConnConfig `json:"connectionconfig"`
@@ -526,6 +548,52 @@ type CredentialHolderList struct {
 	CredentialHolderList []CredentialHolderInfo `json:"credentialHolderList"`
 }
 
+// CredentialValidation is the result of validating a credential against its connections
+type CredentialValidation struct {
+	Time string `json:"time" example:"2025-01-01T00:00:00Z"`
+	// Status is valid (at least one connection works) or invalid
+	Status              string `json:"status" example:"valid" enums:"valid,invalid"`
+	VerifiedConnections int    `json:"verifiedConnections" example:"3"`
+	FailedConnections   int    `json:"failedConnections" example:"0"`
+	// Message explains a failure (classified CSP error, e.g., expired secret)
+	Message string `json:"message,omitempty"`
+	// Trigger is what ran the validation (register, rotate, periodic, manual)
+	Trigger string `json:"trigger" example:"periodic"`
+}
+
+// CredentialStatus tracks registration, rotation, expiry and validation history of
+// the credential of a credential holder for a provider
+type CredentialStatus struct {
+	CredentialHolder string `json:"credentialHolder" example:"admin"`
+	ProviderName     string `json:"providerName" example:"azure"`
+	RegisteredAt     string `json:"registeredAt,omitempty" example:"2025-01-01T00:00:00Z"`
+	RotatedAt        string `json:"rotatedAt,omitempty" example:"2025-06-01T00:00:00Z"`
+	RotationCount    int    `json:"rotationCount" example:"1"`
+	ExpiresAt        string `json:"expiresAt,omitempty" example:"2026-12-31T00:00:00Z"`
+	// DaysToExpiry is the number of days left until ExpiresAt (negative when expired)
+	DaysToExpiry *int `json:"daysToExpiry,omitempty" example:"30"`
+	// ExpiryWarning is set when the credential expires within the warning period or has expired
+	ExpiryWarning     bool                   `json:"expiryWarning" example:"false"`
+	LastValidation    *CredentialValidation  `json:"lastValidation,omitempty"`
+	ValidationHistory []CredentialValidation `json:"validationHistory"`
+}
+
+// CredentialStatusList is a list of credential status
+type CredentialStatusList struct {
+	Credentials []CredentialStatus `json:"credentials"`
+}
+
+// CredentialRotationResult is the result of rotating a credential
+type CredentialRotationResult struct {
+	Status CredentialStatus `json:"status"`
+	// OpenBaoStatus reports whether the new secret version was stored in the secret store
+	OpenBaoStatus string `json:"openBaoStatus,omitempty"`
+	// AffectedConnections are the connection configs using the credential, re-validated with the new secret
+	AffectedConnections []ConnConfig `json:"affectedConnections"`
+	// InvalidatedClients is the number of cached CSP SDK clients dropped for the provider
+	InvalidatedClients int `json:"invalidatedClients" example:"2"`
+}
+
 // SpiderRegionZoneInfo is struct for containing region struct of CB-Spider
 type SpiderRegionZoneInfo struct {
 	RegionName        string     // ex) "region01"
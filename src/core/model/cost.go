@@ -0,0 +1,186 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+const (
+	// CostBaseCurrency is the currency of every cost reported by CB-Tumblebug
+	CostBaseCurrency string = "USD"
+	// CostHoursPerMonth is the number of hours used for monthly projections (365 * 24 / 12)
+	CostHoursPerMonth float64 = 730
+)
+
+// Kinds of CostItem
+const (
+	CostItemNode            string = "node"
+	CostItemRootDisk        string = "rootDisk"
+	CostItemDataDisk        string = "dataDisk"
+	CostItemNLB             string = "nlb"
+	CostItemK8sControlPlane string = "k8sControlPlane"
+	CostItemK8sNode         string = "k8sNode"
+)
+
+// Scopes of CostEstimate
+const (
+	CostScopeInfra           string = "infra"
+	CostScopeNamespace       string = "namespace"
+	CostScopeK8sCluster      string = "k8sCluster"
+	CostScopeInfraDynamicReq string = "infraDynamicReq"
+	CostScopeNodeGroupReq    string = "nodeGroupDynamicReq"
+	CostScopeK8sClusterReq   string = "k8sClusterDynamicReq"
+)
+
+// CostAssetInfo mirrors assets/costinfo.yaml, reference prices of resources that specs do not carry
+type CostAssetInfo struct {
+	CSPs map[string]CostAssetDetail `mapstructure:"cost" json:"cost"`
+}
+
+// CostAssetDetail is one CSP's entry under "cost" in assets/costinfo.yaml.
+// Nil prices are unknown; a zero price is a resource the CSP does not charge for.
+type CostAssetDetail struct {
+	Currency               string             `mapstructure:"currency" json:"currency"`
+	K8sControlPlanePerHour *float64           `mapstructure:"k8sControlPlanePerHour" json:"k8sControlPlanePerHour,omitempty"`
+	NLBPerHour             *float64           `mapstructure:"nlbPerHour" json:"nlbPerHour,omitempty"`
+	DiskPerGiBMonth        map[string]float64 `mapstructure:"diskPerGiBMonth" json:"diskPerGiBMonth,omitempty"`
+}
+
+// CostItem is the cost of one resource (or of the identical Nodes of a NodeGroup)
+type CostItem struct {
+	Kind         string `json:"kind" example:"node" enums:"node,rootDisk,dataDisk,nlb,k8sControlPlane,k8sNode"`
+	Id           string `json:"id" example:"g1-1"`
+	GroupId      string `json:"groupId,omitempty" example:"g1"`
+	ProviderName string `json:"providerName,omitempty" example:"aws"`
+	RegionName   string `json:"regionName,omitempty" example:"ap-northeast-2"`
+	// Detail describes what is priced (spec name, disk type and size, ...)
+	Detail string `json:"detail,omitempty" example:"t3.medium"`
+	Count  int    `json:"count" example:"1"`
+
+	// Priced is false when no price data exists for the item; it is then left out of the totals
+	Priced       bool    `json:"priced"`
+	CostPerHour  float64 `json:"costPerHour" example:"0.0416"`
+	CostPerMonth float64 `json:"costPerMonth" example:"30.368"`
+	Note         string  `json:"note,omitempty" example:"spot price"`
+}
+
+// CostGroup is the subtotal of a NodeGroup, an Infra or a K8sCluster
+type CostGroup struct {
+	Kind         string  `json:"kind" example:"nodeGroup" enums:"nodeGroup,infra,k8sCluster,k8sNodeGroup"`
+	Id           string  `json:"id" example:"g1"`
+	CostPerHour  float64 `json:"costPerHour" example:"0.0832"`
+	CostPerMonth float64 `json:"costPerMonth" example:"60.736"`
+	// Partial is true when some items of the group have no price data
+	Partial bool `json:"partial"`
+}
+
+// CostEstimate is the running or estimated cost of Infras, K8sClusters or requests in the base currency
+type CostEstimate struct {
+	Scope    string `json:"scope" example:"infra" enums:"infra,namespace,k8sCluster,infraDynamicReq,nodeGroupDynamicReq,k8sClusterDynamicReq"`
+	NsId     string `json:"nsId" example:"default"`
+	TargetId string `json:"targetId,omitempty" example:"infra01"`
+	Currency string `json:"currency" example:"USD"`
+
+	CostPerHour  float64 `json:"costPerHour" example:"0.0832"`
+	CostPerMonth float64 `json:"costPerMonth" example:"60.736"`
+	// Partial is true when some items have no price data and are left out of the totals
+	Partial       bool `json:"partial"`
+	UnpricedItems int  `json:"unpricedItems,omitempty" example:"0"`
+
+	Groups []CostGroup `json:"groups,omitempty"`
+	Items  []CostItem  `json:"items"`
+	Notes  []string    `json:"notes,omitempty"`
+}
+
+// NodeUsageRecord is the running history of a Node for cost accounting.
+// It is kept after the Node is deleted so that past spend stays reportable.
+type NodeUsageRecord struct {
+	NsId    string `json:"nsId" example:"default"`
+	InfraId string `json:"infraId" example:"infra01"`
+	NodeId  string `json:"nodeId" example:"g1-1"`
+	// Uid is the Uid of the Node; a Node recreated with the same ID gets a new record
+	Uid          string `json:"uid,omitempty" example:"wef12awefadf1221edcf"`
+	NodeGroupId  string `json:"nodeGroupId,omitempty" example:"g1"`
+	ProviderName string `json:"providerName,omitempty" example:"aws"`
+	RegionName   string `json:"regionName,omitempty" example:"ap-northeast-2"`
+	CapacityType string `json:"capacityType,omitempty" example:"on-demand"`
+	// Label is the Node label merged over the Infra label, refreshed on every transition
+	Label map[string]string `json:"label,omitempty"`
+
+	// SpecId and CostPerHour are the spec and its price snapshot taken when the Node was created
+	// (or resized); later spec price updates do not change the cost of past intervals
+	SpecId          string  `json:"specId" example:"aws+ap-northeast-2+t3.medium"`
+	CspSpecName     string  `json:"cspSpecName,omitempty" example:"t3.medium"`
+	CostPerHour     float64 `json:"costPerHour" example:"0.0416"`
+	PriceSnapshotAt string  `json:"priceSnapshotAt" example:"2024-01-15T10:30:05Z"`
+
+	Intervals []NodeRunInterval `json:"intervals"`
+	// DeletedAt is set when the Node is deleted (or deregistered) from CB-Tumblebug
+	DeletedAt string `json:"deletedAt,omitempty" example:"2024-02-01T00:00:00Z"`
+}
+
+// NodeRunInterval is a period in which a Node was running (billed)
+type NodeRunInterval struct {
+	Start string `json:"start" example:"2024-01-15T10:30:05Z"`
+	// End is empty while the Node is running
+	End         string  `json:"end,omitempty" example:"2024-01-15T18:30:05Z"`
+	SpecId      string  `json:"specId" example:"aws+ap-northeast-2+t3.medium"`
+	CostPerHour float64 `json:"costPerHour" example:"0.0416"`
+}
+
+// Grouping keys of CostUsageReport
+const (
+	CostUsageGroupByNode      string = "node"
+	CostUsageGroupByInfra     string = "infra"
+	CostUsageGroupByLabel     string = "label"
+	CostUsageGroupByNamespace string = "namespace"
+)
+
+// CostUsageReportReq selects the time range and grouping of a CostUsageReport
+type CostUsageReportReq struct {
+	// From and To are RFC3339 times (default: the start of the current month to now)
+	From string `json:"from,omitempty" example:"2024-01-01T00:00:00Z"`
+	To   string `json:"to,omitempty" example:"2024-02-01T00:00:00Z"`
+	// GroupBy is node, infra, label or namespace (default: infra)
+	GroupBy string `json:"groupBy,omitempty" example:"infra" enums:"node,infra,label,namespace"`
+	// LabelKey is the label key to group by when GroupBy is label (e.g., "team")
+	LabelKey string `json:"labelKey,omitempty" example:"team"`
+	// InfraId limits the report to one Infra
+	InfraId string `json:"infraId,omitempty" example:"infra01"`
+}
+
+// CostUsageReport is the actual spend of Nodes over a time range, from their recorded running intervals
+type CostUsageReport struct {
+	NsId     string `json:"nsId,omitempty" example:"default"`
+	From     string `json:"from" example:"2024-01-01T00:00:00Z"`
+	To       string `json:"to" example:"2024-02-01T00:00:00Z"`
+	GroupBy  string `json:"groupBy" example:"infra"`
+	LabelKey string `json:"labelKey,omitempty" example:"team"`
+	Currency string `json:"currency" example:"USD"`
+
+	TotalRunningHours float64              `json:"totalRunningHours" example:"1488"`
+	TotalCost         float64              `json:"totalCost" example:"61.9"`
+	Rows              []CostUsageReportRow `json:"rows"`
+}
+
+// CostUsageReportRow is the spend of one group of a CostUsageReport
+type CostUsageReportRow struct {
+	// Key is the group: "{nsId}/{infraId}/{nodeId}", "{nsId}/{infraId}", the label value or the nsId
+	Key          string  `json:"key" example:"default/infra01"`
+	NsId         string  `json:"nsId,omitempty" example:"default"`
+	InfraId      string  `json:"infraId,omitempty" example:"infra01"`
+	NodeId       string  `json:"nodeId,omitempty" example:"g1-1"`
+	SpecId       string  `json:"specId,omitempty" example:"aws+ap-northeast-2+t3.medium"`
+	NodeCount    int     `json:"nodeCount" example:"2"`
+	RunningHours float64 `json:"runningHours" example:"744"`
+	Cost         float64 `json:"cost" example:"30.95"`
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+// Desired-state change actions
+const (
+	// DesiredActionCreateInfra creates the Infra (it does not exist yet)
+	DesiredActionCreateInfra = "createInfra"
+	// DesiredActionAddNodeGroup adds a NodeGroup that is only in the desired state
+	DesiredActionAddNodeGroup = "addNodeGroup"
+	// DesiredActionScaleOut adds Nodes to a NodeGroup
+	DesiredActionScaleOut = "scaleOut"
+	// DesiredActionScaleIn removes Nodes from a NodeGroup
+	DesiredActionScaleIn = "scaleIn"
+	// DesiredActionRemoveNodeGroup removes a NodeGroup that is not in the desired state
+	DesiredActionRemoveNodeGroup = "removeNodeGroup"
+	// DesiredActionUpdateInfraLabel updates the labels of the Infra
+	DesiredActionUpdateInfraLabel = "updateInfraLabel"
+	// DesiredActionUpdateNodeLabel updates the labels of the Nodes of a NodeGroup
+	DesiredActionUpdateNodeLabel = "updateNodeLabel"
+)
+
+// InfraDesiredStateChange is one step of a desired-state plan
+type InfraDesiredStateChange struct {
+	Action      string `json:"action" example:"scaleOut"`
+	NodeGroupId string `json:"nodeGroupId,omitempty" example:"g1"`
+	// CurrentSize and DesiredSize are the NodeGroup sizes before and after the change
+	CurrentSize int `json:"currentSize,omitempty" example:"2"`
+	DesiredSize int `json:"desiredSize,omitempty" example:"3"`
+	// NodeIds are the Nodes removed (scaleIn, removeNodeGroup) or relabeled (updateNodeLabel)
+	NodeIds []string `json:"nodeIds,omitempty"`
+	// SetLabel are the labels added or changed (updateInfraLabel, updateNodeLabel)
+	SetLabel map[string]string `json:"setLabel,omitempty"`
+	// PostCommands tells whether the postCommands of the desired state run on the new Nodes
+	PostCommands bool   `json:"postCommands,omitempty"`
+	Description  string `json:"description" example:"scale out NodeGroup 'g1' from 2 to 3 Nodes"`
+}
+
+// InfraDesiredStatePlan is the difference between the desired state of an Infra and its current state
+type InfraDesiredStatePlan struct {
+	NsId        string `json:"nsId" example:"default"`
+	InfraId     string `json:"infraId" example:"infra01"`
+	InfraExists bool   `json:"infraExists"`
+	// UpToDate is true if the Infra already matches the desired state
+	UpToDate bool                      `json:"upToDate"`
+	Changes  []InfraDesiredStateChange `json:"changes"`
+	// Warnings are differences that are not applied in place (e.g., spec or image of an existing NodeGroup)
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// InfraDesiredStateChangeResult is the outcome of applying one change
+type InfraDesiredStateChangeResult struct {
+	Change  InfraDesiredStateChange `json:"change"`
+	Success bool                    `json:"success"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+// InfraDesiredStateApplyResult is the result of applying a desired state to an Infra
+type InfraDesiredStateApplyResult struct {
+	Plan    InfraDesiredStatePlan           `json:"plan"`
+	Results []InfraDesiredStateChangeResult `json:"results"`
+	Infra   *InfraInfo                      `json:"infra,omitempty"`
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+// Guardrail rule types
+const (
+	// GuardrailRequiredLabels requires labels on Nodes and K8s clusters.
+	// Values are label keys ("owner") or key=value pairs ("env=prod").
+	GuardrailRequiredLabels = "requiredLabels"
+	// GuardrailAllowedImages allows only images matching one of Values (glob patterns, e.g., "ubuntu22.04*" or "projects/ubuntu-os-cloud/*")
+	GuardrailAllowedImages = "allowedImages"
+	// GuardrailAllowedSpecs allows only specs matching one of Values (glob patterns, e.g., "aws+*+t3.*")
+	GuardrailAllowedSpecs = "allowedSpecs"
+	// GuardrailDenyPublicIp denies resources that get a public IP.
+	// Tumblebug assigns a public IP to every Node, so this rule denies Node creation in the namespace.
+	GuardrailDenyPublicIp = "denyPublicIp"
+	// GuardrailDenyIngress denies inbound firewall rules opening Ports (e.g., "22", "3389", "1-65535")
+	// to Cidrs (default: 0.0.0.0/0 and ::/0) or any wider range, for Protocols (default: all).
+	GuardrailDenyIngress = "denyIngress"
+)
+
+// Guardrail enforcement modes
+const (
+	// GuardrailEnforcementDeny rejects the request (default)
+	GuardrailEnforcementDeny = "deny"
+	// GuardrailEnforcementWarn reports the violation without rejecting the request
+	GuardrailEnforcementWarn = "warn"
+)
+
+// Guardrail target kinds
+const (
+	GuardrailTargetNode          = "node"
+	GuardrailTargetSecurityGroup = "securityGroup"
+	GuardrailTargetK8sCluster    = "k8sCluster"
+)
+
+// GuardrailRule is a single policy rule evaluated against provisioning requests
+type GuardrailRule struct {
+	Name        string `json:"name" validate:"required" example:"no-public-ssh"`
+	Description string `json:"description,omitempty" example:"SSH must not be open to the internet"`
+	Type        string `json:"type" validate:"required" example:"denyIngress" enums:"requiredLabels,allowedImages,allowedSpecs,denyPublicIp,denyIngress"`
+	// Enforcement is deny (reject the request, default) or warn (report only)
+	Enforcement string `json:"enforcement,omitempty" example:"deny" enums:"deny,warn"`
+	// Values are label keys (requiredLabels) or glob patterns (allowedImages, allowedSpecs);
+	// "*" matches any sequence including "/" and "?" matches one character
+	Values []string `json:"values,omitempty" example:"owner,cost-center"`
+	// Cidrs, Ports and Protocols configure denyIngress
+	Cidrs     []string `json:"cidrs,omitempty" example:"0.0.0.0/0"`
+	Ports     []string `json:"ports,omitempty" example:"22,3389"`
+	Protocols []string `json:"protocols,omitempty" example:"TCP"`
+}
+
+// GuardrailPolicyReq is the request body to set the guardrail policy of a namespace
+type GuardrailPolicyReq struct {
+	Rules []GuardrailRule `json:"rules"`
+}
+
+// GuardrailPolicy is the guardrail policy of a namespace
+type GuardrailPolicy struct {
+	NsId      string          `json:"nsId" example:"default"`
+	Rules     []GuardrailRule `json:"rules"`
+	UpdatedAt string          `json:"updatedAt,omitempty" example:"2025-01-01T00:00:00Z"`
+}
+
+// GuardrailViolation is one rule violated by a request
+type GuardrailViolation struct {
+	Rule        string `json:"rule" example:"no-public-ssh"`
+	Type        string `json:"type" example:"denyIngress"`
+	Enforcement string `json:"enforcement" example:"deny"`
+	Target      string `json:"target" example:"securityGroup"`
+	Resource    string `json:"resource" example:"sg01"`
+	Field       string `json:"field,omitempty" example:"firewallRules[0]"`
+	Message     string `json:"message" example:"inbound TCP 22 from 0.0.0.0/0 is not allowed"`
+}
+
+// GuardrailViolationResponse is returned when a request is rejected by guardrails
+type GuardrailViolationResponse struct {
+	Message string               `json:"message"`
+	Details []GuardrailViolation `json:"details"`
+}
+
+// GuardrailFirewallRule is a firewall rule in the form guardrails evaluate
+type GuardrailFirewallRule struct {
+	Ports     string // "22", "900-1000", "22,80" or "" (all)
+	Protocol  string
+	Direction string
+	CIDR      string
+}
+
+// GuardrailTarget describes a resource to be provisioned, built from a request
+type GuardrailTarget struct {
+	Kind          string
+	Name          string
+	Labels        map[string]string
+	ImageIds      []string
+	SpecIds       []string
+	PublicIp      bool
+	FirewallRules []GuardrailFirewallRule
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+// IdempotencyKeyHeader is the request header carrying a client-chosen idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses replayed from a stored idempotent request
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// Idempotent request status
+const (
+	IdempotencyInProgress = "InProgress"
+	IdempotencyCompleted  = "Completed"
+)
+
+// IdempotencyRecord is the stored state of the first request made with an idempotency key
+type IdempotencyRecord struct {
+	// Key is the Idempotency-Key header value
+	Key string `json:"key"`
+	// Scope is the user who sent the request (keys are per user)
+	Scope string `json:"scope,omitempty"`
+	// Method and URI identify the request; BodyHash is the SHA-256 of its body
+	Method   string `json:"method"`
+	URI      string `json:"uri"`
+	BodyHash string `json:"bodyHash"`
+
+	// Status is InProgress until the response is stored, then Completed
+	Status string `json:"status"`
+	// RequestId is the X-Request-Id of the first request
+	RequestId string `json:"requestId,omitempty"`
+	// HttpStatus, ContentType and Response are the stored response
+	HttpStatus  int    `json:"httpStatus,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Response    []byte `json:"response,omitempty"`
+	// ResponseOmitted is true if the response was too large to store
+	ResponseOmitted bool `json:"responseOmitted,omitempty"`
+
+	CreatedAt string `json:"createdAt"`
+	ExpiresAt string `json:"expiresAt"`
+}
@@ -35,6 +35,9 @@ const (
 	// ActionReboot is const for Reboot
 	ActionReboot string = "Reboot"
 
+	// ActionResize is const for Resize (changing the spec of an existing Node in place)
+	ActionResize string = "Resize"
+
 	// ActionRefine is const for Refine
 	ActionRefine string = "Refine"
 
@@ -113,6 +116,35 @@ const (
 	PolicyRefine string = "refine"
 )
 
+// Node capacity types (purchase options)
+const (
+	// CapacityTypeOnDemand is regular pay-as-you-go capacity (default)
+	CapacityTypeOnDemand string = "on-demand"
+
+	// CapacityTypeSpot is spot/preemptible capacity that the CSP may reclaim at any time
+	CapacityTypeSpot string = "spot"
+)
+
+// Spot interruption handling policies
+const (
+	// SpotInterruptionNone only records the interruption on the Node
+	SpotInterruptionNone string = "none"
+
+	// SpotInterruptionReplace removes the interrupted Node via refine and scales the NodeGroup out by one
+	SpotInterruptionReplace string = "replace"
+)
+
+// SpotOption is the spot/preemptible capacity setting of a NodeGroup (used when capacityType is "spot")
+type SpotOption struct {
+	// MaxPrice is the maximum hourly price (USD) to pay for a spot instance. 0 = up to the on-demand price.
+	MaxPrice float64 `json:"maxPrice,omitempty" example:"0.05"`
+
+	// OnInterruption determines what happens when the CSP reclaims a spot Node
+	// - "none": Record the interruption on the Node (default)
+	// - "replace": Remove the interrupted Node (refine) and create a replacement in the same NodeGroup
+	OnInterruption string `json:"onInterruption,omitempty" example:"replace" default:"none" enums:"none,replace"`
+}
+
 const StrAutoGen string = "autogen"
 
 // DefaultSystemLabel is const for string to specify the Default System Label
@@ -375,6 +407,10 @@ type CreateNodeGroupReq struct {
 	RootDiskType     string   `json:"rootDiskType,omitempty" example:"default, TYPE1, ..."` // "", "default", "TYPE1", AWS: ["standard", "gp2", "gp3"], Azure: ["PremiumSSD", "StandardSSD", "StandardHDD"], GCP: ["pd-standard", "pd-balanced", "pd-ssd", "pd-extreme"], ALIBABA: ["cloud_efficiency", "cloud", "cloud_ssd"], TENCENT: ["CLOUD_PREMIUM", "CLOUD_SSD"]
 	RootDiskSize     int      `json:"rootDiskSize,omitempty" example:"50"`                  // Root disk size in GB. 0 = use CSP default.
 	DataDiskIds      []string `json:"dataDiskIds"`
+
+	// CapacityType selects on-demand or spot capacity for the Nodes. Empty = on-demand.
+	CapacityType string      `json:"capacityType,omitempty" example:"on-demand" default:"on-demand" enums:"on-demand,spot"`
+	Spot         *SpotOption `json:"spot,omitempty"`
 }
 
 // CreateNodeGroupReq is struct to get requirements to create a new server instance
@@ -385,6 +421,59 @@ type ScaleOutNodeGroupReq struct {
 	// to be added according to new future capability
 }
 
+// Scale-in victim selection strategies
+const (
+	// ScaleInNewest removes the most recently created Nodes
+	ScaleInNewest = "newest"
+	// ScaleInOldest removes the least recently created Nodes
+	ScaleInOldest = "oldest"
+	// ScaleInMostExpensive removes the Nodes with the highest hourly cost
+	ScaleInMostExpensive = "mostExpensive"
+	// ScaleInLeastLoaded removes the Nodes with the lowest load (1-minute load average per vCPU)
+	ScaleInLeastLoaded = "leastLoaded"
+	// ScaleInZoneSpread removes Nodes from the zones with the most Nodes, to keep the NodeGroup spread
+	ScaleInZoneSpread = "zoneSpread"
+)
+
+// ScaleInNodeGroupReq is a struct to request scale-in of a NodeGroup
+type ScaleInNodeGroupReq struct {
+	// TargetSize is the number of Nodes to keep (0 removes the NodeGroup)
+	TargetSize int `json:"targetSize" validate:"min=0" example:"2"`
+
+	// Strategy selects the Nodes to remove. Nodes that are not running are always removed first.
+	Strategy string `json:"strategy,omitempty" example:"newest" default:"newest" enums:"newest,oldest,mostExpensive,leastLoaded,zoneSpread"`
+
+	// PreStopCommand runs on each removed Node before it is terminated (e.g., to stop services gracefully)
+	PreStopCommand *InfraCmdReq `json:"preStopCommand,omitempty"`
+
+	// RemoveFromNlb deregisters the removed Nodes from the NLBs of the Infra before they are terminated
+	RemoveFromNlb bool `json:"removeFromNlb,omitempty" example:"true"`
+}
+
+// ScaleInNodeResult is the outcome of removing one Node in a scale-in
+type ScaleInNodeResult struct {
+	NodeId string `json:"nodeId" example:"g1-3"`
+	Zone   string `json:"zone,omitempty" example:"ap-northeast-2a"`
+	// Reason tells why the Node was selected
+	Reason string `json:"reason" example:"newest (created 2025-01-02 10:00:00)"`
+	// NlbIds are the NLBs the Node was removed from
+	NlbIds []string `json:"nlbIds,omitempty"`
+	// DrainError is set if the NLB removal or the pre-stop command failed (the Node is terminated anyway)
+	DrainError string `json:"drainError,omitempty"`
+	Removed    bool   `json:"removed"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ScaleInNodeGroupResult is the result of a NodeGroup scale-in
+type ScaleInNodeGroupResult struct {
+	NodeGroupId  string              `json:"nodeGroupId" example:"g1"`
+	Strategy     string              `json:"strategy" example:"newest"`
+	PreviousSize int                 `json:"previousSize" example:"4"`
+	TargetSize   int                 `json:"targetSize" example:"2"`
+	Nodes        []ScaleInNodeResult `json:"nodes"`
+	Infra        *InfraInfo          `json:"infra,omitempty"`
+}
+
 // AddNodeGroupDynamicReq is the request body for adding a NodeGroup to an existing Infra.
 // It is CreateNodeGroupDynamicReq plus bootstrap fields; those fields are intentionally
 // absent from the nodeGroups[] elements of InfraDynamicReq, where Infra-level
@@ -522,6 +611,13 @@ type CreateNodeGroupDynamicReq struct {
 	// to zones that have it. Ignored when Zone is set (that pins a single subnet) or when the
 	// VNet has a single subnet. Default false (all VMs land in the first subnet).
 	DistributeSubnets bool `json:"distributeSubnets,omitempty" example:"false"`
+
+	// CapacityType selects on-demand or spot/preemptible capacity for this NodeGroup.
+	// Empty = on-demand. Spot is available for providers with a native spot launcher (see review result).
+	CapacityType string `json:"capacityType,omitempty" example:"on-demand" default:"on-demand" enums:"on-demand,spot"`
+
+	// Spot holds the max price and interruption policy (used when capacityType is "spot")
+	Spot *SpotOption `json:"spot,omitempty"`
 }
 
 // InfraConnectionConfigCandidatesReq is struct for a request to check requirements to create a new Infra instance dynamically (with default resource option)
@@ -559,6 +655,10 @@ type ReviewInfraDynamicReqInfo struct {
 	OverallMessage string `json:"overallMessage" example:"All Nodes can be created successfully"`
 	CreationViable bool   `json:"creationViable"`
 	EstimatedCost  string `json:"estimatedCost,omitempty" example:"$0.50/hour"`
+	// EstimatedCostPerHour is the known part of the estimated cost in USD/hour
+	EstimatedCostPerHour float64 `json:"estimatedCostPerHour,omitempty" example:"0.5"`
+	// EstimatedCostPerMonth is EstimatedCostPerHour projected over a month (730 hours)
+	EstimatedCostPerMonth float64 `json:"estimatedCostPerMonth,omitempty" example:"365"`
 
 	// Infra-level information
 	InfraName      string `json:"infraName"`
@@ -577,6 +677,9 @@ type ReviewInfraDynamicReqInfo struct {
 
 	// Recommendations for improvement
 	Recommendations []string `json:"recommendations,omitempty"`
+
+	// GuardrailViolations are the namespace guardrail rules the request violates
+	GuardrailViolations []GuardrailViolation `json:"guardrailViolations,omitempty"`
 }
 
 // ReviewNodeGroupDynamicReqInfo is struct for review result of individual Node in Infra dynamic request
@@ -600,7 +703,9 @@ type ReviewNodeGroupDynamicReqInfo struct {
 	RegionName     string `json:"regionName"`
 
 	// Cost estimation
-	EstimatedCost string `json:"estimatedCost,omitempty" example:"$0.10/hour"`
+	EstimatedCost         string  `json:"estimatedCost,omitempty" example:"$0.10/hour"`
+	EstimatedCostPerHour  float64 `json:"estimatedCostPerHour,omitempty" example:"0.1"`
+	EstimatedCostPerMonth float64 `json:"estimatedCostPerMonth,omitempty" example:"73"`
 
 	// General information and configuration notes
 	Info []string `json:"info,omitempty"`
@@ -802,6 +907,11 @@ type NodeGroupInfo struct {
 	// (e.g. NCP reports "SSD" but only accepts "HDD"), so scale-out reads these.
 	RootDiskType string `json:"rootDiskType,omitempty"`
 	RootDiskSize int    `json:"rootDiskSize,omitempty"`
+
+	// CapacityType/Spot keep the requested purchase option so scale-out and
+	// spot replacement create Nodes of the same kind.
+	CapacityType string      `json:"capacityType,omitempty"`
+	Spot         *SpotOption `json:"spot,omitempty"`
 }
 
 // InfraClusterInfo is a lightweight, on-demand cluster view synthesized from Infra NodeGroups and Nodes.
@@ -924,6 +1034,10 @@ type NodeInfo struct {
 	RootDiskSize   int        `json:"rootDiskSize"`
 	RootDeviceName string     `json:"RootDeviceName"`
 
+	// CapacityType is "on-demand" or "spot" (empty is treated as on-demand)
+	CapacityType string      `json:"capacityType,omitempty"`
+	Spot         *SpotOption `json:"spot,omitempty"`
+
 	ConnectionName   string       `json:"connectionName"`
 	ConnectionConfig ConnConfig   `json:"connectionConfig"`
 	SpecId           string       `json:"specId"`
@@ -1591,6 +1705,112 @@ type CommandDoneSummary struct {
 	Error string `json:"error,omitempty" example:"built-in function GetPublicIP error: no Node found"`
 }
 
+// NodeStatusEventType represents the type of SSE event for Node status streaming
+type NodeStatusEventType string
+
+const (
+	// EventNodeStatusSnapshot is sent once per Node on connect with its last known status
+	EventNodeStatusSnapshot NodeStatusEventType = "NodeStatusSnapshot"
+
+	// EventNodeStatusChanged is sent when NodeStatusAgent observes a Node status transition
+	EventNodeStatusChanged NodeStatusEventType = "NodeStatusChanged"
+
+	// EventNodeStatusUntilReached is sent when every Node of the Infra reached the
+	// status given by the "until" option (terminal event)
+	EventNodeStatusUntilReached NodeStatusEventType = "UntilReached"
+)
+
+// NodeStatusEvent is a single SSE event sent to Node status streaming clients
+type NodeStatusEvent struct {
+	// Type indicates the kind of event
+	Type NodeStatusEventType `json:"type" example:"NodeStatusChanged"`
+
+	NsId    string `json:"nsId" example:"default"`
+	InfraId string `json:"infraId" example:"infra01"`
+	NodeId  string `json:"nodeId,omitempty" example:"g1-1"`
+
+	// PreviousStatus is the status before the transition (empty for snapshot events)
+	PreviousStatus string `json:"previousStatus,omitempty" example:"Creating"`
+	Status         string `json:"status,omitempty" example:"Running"`
+	NativeStatus   string `json:"nativeStatus,omitempty" example:"running"`
+	TargetStatus   string `json:"targetStatus,omitempty" example:"None"`
+	TargetAction   string `json:"targetAction,omitempty" example:"None"`
+	SystemMessage  string `json:"systemMessage,omitempty"`
+
+	// Timestamp is when the event was generated (RFC3339)
+	Timestamp string `json:"timestamp" example:"2024-01-15T10:30:05Z"`
+}
+
+// StatusAgentDebugInfo is an introspection snapshot of NodeStatusAgent for tuning CSP rate limits
+type StatusAgentDebugInfo struct {
+	// Timestamp is when the snapshot was taken (RFC3339)
+	Timestamp string `json:"timestamp" example:"2024-01-15T10:30:05Z"`
+
+	// Workers is the size of the polling worker pool
+	Workers int `json:"workers" example:"20"`
+	// WorkerQueueLength / WorkerQueueCapacity describe the dispatch channel toward the workers
+	WorkerQueueLength   int `json:"workerQueueLength" example:"0"`
+	WorkerQueueCapacity int `json:"workerQueueCapacity" example:"200"`
+
+	// TotalEntries is the number of Nodes tracked in StatusStore
+	TotalEntries int `json:"totalEntries" example:"120"`
+	// QueueDepthByPriority counts tracked Nodes per poll priority (skip, recover, normal, high, urgent)
+	QueueDepthByPriority map[string]int `json:"queueDepthByPriority"`
+	// DueByPriority counts Nodes whose next poll time has already passed, per priority
+	DueByPriority map[string]int `json:"dueByPriority"`
+	// OperationLocked is the number of Nodes skipped because a lifecycle operation holds the lock
+	OperationLocked int `json:"operationLocked" example:"2"`
+	// NotFoundStreak is the number of Nodes with a pending batch not-found streak
+	NotFoundStreak int `json:"notFoundStreak" example:"0"`
+
+	// StaleEntries lists Nodes overdue for polling beyond the stale threshold (oldest first, capped)
+	StaleEntryCount int                    `json:"staleEntryCount" example:"0"`
+	StaleEntries    []StatusAgentStaleNode `json:"staleEntries,omitempty"`
+
+	// Limiters reports per provider/region rate limiter usage
+	Limiters []StatusAgentLimiterInfo `json:"limiters"`
+
+	// LastBatchSweep describes the most recent batch sweep (nil if none has run)
+	LastBatchSweep *StatusAgentBatchSweepInfo `json:"lastBatchSweep,omitempty"`
+}
+
+// StatusAgentStaleNode is a Node that NodeStatusAgent has not polled in time
+type StatusAgentStaleNode struct {
+	NsId           string `json:"nsId"`
+	InfraId        string `json:"infraId"`
+	NodeId         string `json:"nodeId"`
+	ProviderName   string `json:"providerName"`
+	Region         string `json:"region"`
+	Status         string `json:"status"`
+	Priority       string `json:"priority"`
+	OverdueSeconds int64  `json:"overdueSeconds"`
+	LastUpdated    string `json:"lastUpdated,omitempty"`
+}
+
+// StatusAgentLimiterInfo describes one provider/region rate limiter of NodeStatusAgent
+type StatusAgentLimiterInfo struct {
+	Provider string  `json:"provider" example:"aws"`
+	Region   string  `json:"region" example:"ap-northeast-2"`
+	Rate     float64 `json:"rate" example:"10"`
+	Burst    int     `json:"burst" example:"10"`
+	// Tokens is the number of tokens currently available
+	Tokens float64 `json:"tokens" example:"9.5"`
+	// Waits is the number of limiter acquisitions; Throttled counts those that had to wait
+	Waits     uint64 `json:"waits" example:"1200"`
+	Throttled uint64 `json:"throttled" example:"35"`
+	// TotalWaitSeconds is the cumulative time spent waiting for tokens
+	TotalWaitSeconds float64 `json:"totalWaitSeconds" example:"4.2"`
+}
+
+// StatusAgentBatchSweepInfo describes one batch sweep run of NodeStatusAgent
+type StatusAgentBatchSweepInfo struct {
+	StartedAt       string  `json:"startedAt"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Groups          int     `json:"groups"`
+	Nodes           int     `json:"nodes"`
+	FailedGroups    int     `json:"failedGroups"`
+}
+
 // SshCmdResult is struct for SshCmd Result
 type SshCmdResult struct { // Tumblebug
 	InfraId string         `json:"infraId"`
@@ -1703,7 +1923,7 @@ type PriorityInfo struct {
 
 // FilterCondition is struct for .
 type PriorityCondition struct {
-	Metric    string            `json:"metric" example:"location" enums:"location,cost,random,performance,latency"`
+	Metric    string            `json:"metric" example:"location" enums:"location,cost,spotCost,random,performance,latency,reliability"`
 	Weight    float64           `json:"weight" example:"0.3"`
 	Parameter []ParameterKeyVal `json:"parameter,omitempty"`
 }
@@ -1949,6 +2169,9 @@ type ProvisioningEvent struct {
 
 	// InfraId is the Infra ID that this VM belongs to
 	InfraId string `json:"infraId"`
+
+	// Zone is the zone where the VM was being provisioned (optional)
+	Zone string `json:"zone,omitempty"`
 }
 
 // RiskAnalysis represents detailed risk analysis for provisioning
@@ -2186,6 +2409,8 @@ type ReviewSummary struct {
 	HighRiskCandidates  int      `json:"highRiskCandidates"`
 	CostPerHourMin      float64  `json:"costPerHourMin"`
 	CostPerHourMax      float64  `json:"costPerHourMax"`
+	CostPerMonthMin     float64  `json:"costPerMonthMin"`
+	CostPerMonthMax     float64  `json:"costPerMonthMax"`
 	UnreachableSpecs    []string `json:"unreachableSpecs,omitempty"`
 	ConfirmedStockZones []string `json:"confirmedStockZones,omitempty"`
 }
@@ -33,4 +33,61 @@ type NsInfo struct {
 	Name string `json:"name" example:"default"`
 
 	Description string `json:"description" example:"Description for this namespace"`
+
+	// Quota limits resource consumption of the namespace (nil = unlimited)
+	Quota *NsQuota `json:"quota,omitempty"`
+}
+
+// NsQuota limits what can be provisioned in a namespace.
+// Zero (or empty) fields are unlimited.
+type NsQuota struct {
+	// MaxNodes is the maximum number of Infra Nodes (not Terminated)
+	MaxNodes int `json:"maxNodes,omitempty" example:"20"`
+	// MaxVCpu is the maximum total vCPU of Infra Nodes
+	MaxVCpu int `json:"maxVCpu,omitempty" example:"80"`
+	// MaxMemoryGiB is the maximum total memory of Infra Nodes
+	MaxMemoryGiB float64 `json:"maxMemoryGiB,omitempty" example:"320"`
+	// MaxGpus is the maximum total accelerator count of Infra Nodes
+	MaxGpus int `json:"maxGpus,omitempty" example:"4"`
+	// MaxCostPerHour is the maximum total hourly cost (USD) of Infra Nodes, from spec prices
+	MaxCostPerHour float64 `json:"maxCostPerHour,omitempty" example:"10.5"`
+	// AllowedProviders restricts provisioning to these providers (e.g., aws, gcp)
+	AllowedProviders []string `json:"allowedProviders,omitempty" example:"aws,gcp"`
+	// AllowedRegions restricts provisioning to these regions, given as "provider+region" or region name
+	AllowedRegions []string `json:"allowedRegions,omitempty" example:"aws+ap-northeast-2,asia-northeast3"`
+	// MaxK8sClusters is the maximum number of K8s clusters
+	MaxK8sClusters int `json:"maxK8sClusters,omitempty" example:"2"`
+	// MaxObjectStorages is the maximum number of object storages
+	MaxObjectStorages int `json:"maxObjectStorages,omitempty" example:"5"`
+}
+
+// NsResourceUsage is the resource consumption of a namespace counted against NsQuota.
+// It is also used as the additional demand of a provisioning request.
+type NsResourceUsage struct {
+	Nodes          int     `json:"nodes"`
+	VCpu           int     `json:"vCpu"`
+	MemoryGiB      float64 `json:"memoryGiB"`
+	Gpus           int     `json:"gpus"`
+	CostPerHour    float64 `json:"costPerHour"`
+	K8sClusters    int     `json:"k8sClusters"`
+	ObjectStorages int     `json:"objectStorages"`
+}
+
+// NsQuotaLocation is a provider/region targeted by a provisioning request
+type NsQuotaLocation struct {
+	Provider string `json:"provider"`
+	Region   string `json:"region"`
+}
+
+// NsQuotaDemand is what a provisioning request would add to the namespace usage
+type NsQuotaDemand struct {
+	NsResourceUsage
+	Locations []NsQuotaLocation `json:"locations,omitempty"`
+}
+
+// NsQuotaUsage shows the current consumption of a namespace against its quota
+type NsQuotaUsage struct {
+	NsId  string          `json:"nsId" example:"default"`
+	Quota *NsQuota        `json:"quota,omitempty"`
+	Usage NsResourceUsage `json:"usage"`
 }
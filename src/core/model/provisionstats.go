@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+// Normalized categories of provisioning failures, classified from CSP error messages
+const (
+	ProvisioningErrorQuota             string = "quota"
+	ProvisioningErrorCapacity          string = "capacity"
+	ProvisioningErrorImageIncompatible string = "imageIncompatible"
+	ProvisioningErrorSpecUnavailable   string = "specUnavailable"
+	ProvisioningErrorNetwork           string = "network"
+	ProvisioningErrorPermission        string = "permission"
+	ProvisioningErrorTimeout           string = "timeout"
+	ProvisioningErrorOther             string = "other"
+)
+
+// Dimensions to group provisioning analytics by
+const (
+	ProvisioningGroupByProvider   string = "provider"
+	ProvisioningGroupByRegion     string = "region"
+	ProvisioningGroupByZone       string = "zone"
+	ProvisioningGroupBySpecFamily string = "specFamily"
+	ProvisioningGroupBySpec       string = "spec"
+	ProvisioningGroupByImage      string = "image"
+)
+
+// ProvisioningStatsCounter counts the provisioning attempts of a provider/region/zone/spec/image combination in a day
+type ProvisioningStatsCounter struct {
+	ProviderName string `json:"providerName" example:"aws"`
+	RegionName   string `json:"regionName" example:"ap-northeast-2"`
+	Zone         string `json:"zone,omitempty" example:"ap-northeast-2a"`
+	SpecId       string `json:"specId" example:"aws+ap-northeast-2+t3.medium"`
+	SpecFamily   string `json:"specFamily" example:"t3"`
+	CspImageName string `json:"cspImageName,omitempty" example:"ami-01f71f215b23ba262"`
+
+	Attempts int `json:"attempts" example:"12"`
+	Failures int `json:"failures" example:"3"`
+	// Categories counts the failures by normalized error category
+	Categories map[string]int `json:"categories,omitempty"`
+
+	LastError     string `json:"lastError,omitempty"`
+	LastFailureAt string `json:"lastFailureAt,omitempty" example:"2026-10-01T09:00:00Z"`
+}
+
+// ProvisioningStatsBucket holds the provisioning counters of a day (UTC)
+type ProvisioningStatsBucket struct {
+	Date string `json:"date" example:"2026-10-01"`
+	// Counters is keyed by provider|region|zone|specId|image
+	Counters map[string]*ProvisioningStatsCounter `json:"counters"`
+}
+
+// ProvisioningFailureStats is the failure rate of a group of provisioning attempts
+type ProvisioningFailureStats struct {
+	// Key is the value of the grouping dimension
+	Key          string `json:"key" example:"aws+ap-northeast-2"`
+	ProviderName string `json:"providerName,omitempty" example:"aws"`
+	RegionName   string `json:"regionName,omitempty" example:"ap-northeast-2"`
+	Zone         string `json:"zone,omitempty" example:"ap-northeast-2a"`
+	SpecFamily   string `json:"specFamily,omitempty" example:"t3"`
+	SpecId       string `json:"specId,omitempty" example:"aws+ap-northeast-2+t3.medium"`
+	CspImageName string `json:"cspImageName,omitempty"`
+
+	Attempts    int     `json:"attempts" example:"12"`
+	Failures    int     `json:"failures" example:"3"`
+	FailureRate float64 `json:"failureRate" example:"0.25"`
+
+	TopCategory   string         `json:"topCategory,omitempty" example:"capacity"`
+	Categories    map[string]int `json:"categories,omitempty"`
+	LastError     string         `json:"lastError,omitempty"`
+	LastFailureAt string         `json:"lastFailureAt,omitempty" example:"2026-10-01T09:00:00Z"`
+}
+
+// ProvisioningErrorCategoryStats is the number of failures of an error category
+type ProvisioningErrorCategoryStats struct {
+	Category string  `json:"category" example:"capacity"`
+	Count    int     `json:"count" example:"7"`
+	Percent  float64 `json:"percent" example:"43.8"`
+	// SampleError is the latest error message of the category
+	SampleError string `json:"sampleError,omitempty"`
+}
+
+// ProvisioningDailyStats is the number of provisioning attempts and failures of a day
+type ProvisioningDailyStats struct {
+	Date     string `json:"date" example:"2026-10-01"`
+	Attempts int    `json:"attempts" example:"40"`
+	Failures int    `json:"failures" example:"4"`
+}
+
+// ProvisioningAnalytics is the aggregated provisioning failure analytics of a time window
+type ProvisioningAnalytics struct {
+	From       string `json:"from" example:"2026-09-02"`
+	To         string `json:"to" example:"2026-10-01"`
+	WindowDays int    `json:"windowDays" example:"30"`
+	GroupBy    string `json:"groupBy" example:"region"`
+
+	TotalAttempts int     `json:"totalAttempts" example:"160"`
+	TotalFailures int     `json:"totalFailures" example:"16"`
+	FailureRate   float64 `json:"failureRate" example:"0.1"`
+
+	// TopErrorCategories are the error categories by number of failures
+	TopErrorCategories []ProvisioningErrorCategoryStats `json:"topErrorCategories"`
+	// Groups are sorted by failure rate, then by number of failures
+	Groups   []ProvisioningFailureStats `json:"groups"`
+	Timeline []ProvisioningDailyStats   `json:"timeline"`
+}
+
+// ProvisioningDemotionPolicy configures the demotion of high-failure specs in RecommendSpec
+type ProvisioningDemotionPolicy struct {
+	// Enabled demotes high-failure specs in every RecommendSpec call
+	// (the "reliability" priority metric applies the policy to a single request)
+	Enabled bool `json:"enabled" example:"true"`
+	// WindowDays is the window of the failure statistics (default: 14)
+	WindowDays int `json:"windowDays,omitempty" example:"14"`
+	// MinAttempts is the number of attempts required to demote a spec or region (default: 3)
+	MinAttempts int `json:"minAttempts,omitempty" example:"3"`
+	// FailureRateThreshold is the failure rate from which a spec or region is demoted (default: 0.5)
+	FailureRateThreshold float64 `json:"failureRateThreshold,omitempty" example:"0.5"`
+}
+
+// ProvisioningDemotionStatus is the demotion policy with the specs and regions it currently demotes
+type ProvisioningDemotionStatus struct {
+	Policy          ProvisioningDemotionPolicy `json:"policy"`
+	DemotedSpecs    []ProvisioningFailureStats `json:"demotedSpecs"`
+	DemotedRegions  []ProvisioningFailureStats `json:"demotedRegions"`
+	LastEvaluatedAt string                     `json:"lastEvaluatedAt" example:"2026-10-01T09:00:00Z"`
+}
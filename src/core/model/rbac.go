@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+// Built-in roles. They match the realm roles issued by MC-IAM-Manager and
+// cannot be modified or deleted.
+const (
+	RoleMaintainer = "maintainer"
+	RoleAdmin      = "admin"
+	RoleUser       = "user"
+	RoleGuest      = "guest"
+)
+
+// RBAC verbs, derived from the HTTP method of a request
+const (
+	RbacVerbRead   = "read"   // GET
+	RbacVerbWrite  = "write"  // POST, PUT
+	RbacVerbDelete = "delete" // DELETE
+)
+
+// RBAC route groups. Every API route belongs to exactly one group.
+const (
+	RbacGroupSystem     = "system"     // TB configuration, asset fetching, forwarding, statistics
+	RbacGroupGlobal     = "global"     // Data shared by all namespaces: raw objects, request history, labels, global DNS, assets
+	RbacGroupCredential = "credential" // CSP credentials and credential holders
+	RbacGroupNamespace  = "namespace"  // Namespace management
+	RbacGroupRbac       = "rbac"       // Roles and role bindings
+	RbacGroupCatalog    = "catalog"    // Cloud info, specs, images, recommendation (read-mostly)
+	RbacGroupInfra      = "infra"      // Infra, Node, command, monitoring, templates in a namespace
+	RbacGroupResource   = "resource"   // Shared resources (vNet, SG, sshKey, ...) and labels in a namespace
+	RbacGroupK8s        = "k8s"        // K8s clusters in a namespace
+)
+
+// RbacWildcard matches every group or verb in an RbacRule
+const RbacWildcard = "*"
+
+// RbacRule grants the listed verbs on the listed route groups
+type RbacRule struct {
+	Groups []string `json:"groups" example:"infra,resource"`
+	Verbs  []string `json:"verbs" example:"read,write"`
+}
+
+// RbacRoleReq is the request body to create or update a custom role
+type RbacRoleReq struct {
+	Name        string     `json:"name" validate:"required" example:"infra-operator"`
+	Description string     `json:"description,omitempty" example:"Manage Infra only"`
+	Rules       []RbacRule `json:"rules" validate:"required"`
+}
+
+// RbacRole is a named set of RbacRules
+type RbacRole struct {
+	Name        string     `json:"name" example:"infra-operator"`
+	Description string     `json:"description,omitempty" example:"Manage Infra only"`
+	Rules       []RbacRule `json:"rules"`
+	// BuiltIn roles are defined by TB and cannot be changed
+	BuiltIn bool `json:"builtIn"`
+}
+
+// RbacRoleList is the response for listing roles
+type RbacRoleList struct {
+	Roles []RbacRole `json:"roles"`
+}
+
+// RoleBindingReq is the request body to bind a role to a subject in a namespace
+type RoleBindingReq struct {
+	// Subject is the user ID (or name) carried in the access token
+	Subject string `json:"subject" validate:"required" example:"alice"`
+	Role    string `json:"role" validate:"required" example:"user"`
+}
+
+// RoleBinding grants a role to a subject within one namespace.
+// Permissions of the binding are added to those of the subject's realm role.
+type RoleBinding struct {
+	NsId      string `json:"nsId" example:"default"`
+	Subject   string `json:"subject" example:"alice"`
+	Role      string `json:"role" example:"user"`
+	CreatedAt string `json:"createdAt" example:"2025-01-01T00:00:00Z"`
+}
+
+// RoleBindingList is the response for listing role bindings of a namespace
+type RoleBindingList struct {
+	Bindings []RoleBinding `json:"bindings"`
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+const (
+	// UtilizationSourceAgent is a sample read from the monitoring agent (CB-Dragonfly)
+	UtilizationSourceAgent string = "agent"
+	// UtilizationSourceSsh is a sample read from /proc over SSH
+	UtilizationSourceSsh string = "ssh"
+)
+
+const (
+	// RightsizingIdle is a Node that barely uses its CPU and memory
+	RightsizingIdle string = "idle"
+	// RightsizingUnderutilized is a Node whose peak usage fits a smaller spec
+	RightsizingUnderutilized string = "underutilized"
+	// RightsizingOptimal is a Node whose usage fits its spec
+	RightsizingOptimal string = "optimal"
+	// RightsizingInsufficientData is a Node without enough samples in the window
+	RightsizingInsufficientData string = "insufficientData"
+)
+
+// UtilizationSample is a CPU and memory utilization sample of a Node
+type UtilizationSample struct {
+	Time       string  `json:"time" example:"2026-10-01T09:00:00Z"`
+	CpuPercent float64 `json:"cpuPercent" example:"12.5"`
+	MemPercent float64 `json:"memPercent" example:"35.2"`
+	Source     string  `json:"source" example:"ssh" enums:"agent,ssh"`
+}
+
+// NodeUtilizationRecord is the utilization history of a Node
+type NodeUtilizationRecord struct {
+	NsId    string              `json:"nsId" example:"default"`
+	InfraId string              `json:"infraId" example:"infra01"`
+	NodeId  string              `json:"nodeId" example:"g1-1"`
+	Samples []UtilizationSample `json:"samples"`
+}
+
+// RightsizingReq is a request to analyze the utilization of the Nodes of an Infra
+type RightsizingReq struct {
+	// WindowHours is the analysis window (default: 168, a week)
+	WindowHours int `json:"windowHours,omitempty" example:"168"`
+	// MinSamples is the number of samples required to classify a Node (default: 3)
+	MinSamples int `json:"minSamples,omitempty" example:"3"`
+	// IdleCpuPercent and IdleMemPercent are the p95 utilizations under which a Node is idle (default: 5 and 20)
+	IdleCpuPercent float64 `json:"idleCpuPercent,omitempty" example:"5"`
+	IdleMemPercent float64 `json:"idleMemPercent,omitempty" example:"20"`
+	// TargetPercent is the p95 utilization a recommended spec should run at (default: 70)
+	TargetPercent float64 `json:"targetPercent,omitempty" example:"70"`
+	// SampleNow takes a sample of every running Node before the analysis
+	SampleNow bool `json:"sampleNow,omitempty" example:"false"`
+}
+
+// NodeRightsizing is the utilization analysis and the recommendation for a Node
+type NodeRightsizing struct {
+	NodeId      string `json:"nodeId" example:"g1-1"`
+	NodeGroupId string `json:"nodeGroupId" example:"g1"`
+	Status      string `json:"status" example:"Running"`
+
+	SpecId      string  `json:"specId" example:"aws+ap-northeast-2+t3.xlarge"`
+	CspSpecName string  `json:"cspSpecName" example:"t3.xlarge"`
+	VCPU        uint16  `json:"vCPU" example:"4"`
+	MemoryGiB   float32 `json:"memoryGiB" example:"16"`
+	CostPerHour float64 `json:"costPerHour" example:"0.1664"`
+
+	Classification string `json:"classification" example:"underutilized" enums:"idle,underutilized,optimal,insufficientData"`
+	SampleCount    int    `json:"sampleCount" example:"672"`
+	// Sources are the sources of the samples in the window
+	Sources       []string `json:"sources,omitempty"`
+	CpuAvgPercent float64  `json:"cpuAvgPercent" example:"8.1"`
+	CpuP95Percent float64  `json:"cpuP95Percent" example:"21.4"`
+	MemAvgPercent float64  `json:"memAvgPercent" example:"18.3"`
+	MemP95Percent float64  `json:"memP95Percent" example:"24.9"`
+
+	// RequiredVCPU and RequiredMemoryGiB are the p95 usage scaled to the target utilization
+	RequiredVCPU      float64 `json:"requiredVCPU,omitempty" example:"1.2"`
+	RequiredMemoryGiB float64 `json:"requiredMemoryGiB,omitempty" example:"5.7"`
+
+	RecommendedSpecId      string  `json:"recommendedSpecId,omitempty" example:"aws+ap-northeast-2+t3.medium"`
+	RecommendedCspSpecName string  `json:"recommendedCspSpecName,omitempty" example:"t3.medium"`
+	RecommendedVCPU        uint16  `json:"recommendedVCPU,omitempty" example:"2"`
+	RecommendedMemoryGiB   float32 `json:"recommendedMemoryGiB,omitempty" example:"4"`
+	RecommendedCostPerHour float64 `json:"recommendedCostPerHour,omitempty" example:"0.0416"`
+
+	EstimatedSavingsPerHour  float64 `json:"estimatedSavingsPerHour,omitempty" example:"0.1248"`
+	EstimatedSavingsPerMonth float64 `json:"estimatedSavingsPerMonth,omitempty" example:"91.1"`
+
+	Message string `json:"message,omitempty"`
+}
+
+// RightsizingReport is the rightsizing analysis of an Infra
+type RightsizingReport struct {
+	NsId        string `json:"nsId" example:"default"`
+	InfraId     string `json:"infraId" example:"infra01"`
+	From        string `json:"from" example:"2026-09-24T09:00:00Z"`
+	To          string `json:"to" example:"2026-10-01T09:00:00Z"`
+	WindowHours int    `json:"windowHours" example:"168"`
+	Currency    string `json:"currency" example:"USD"`
+
+	IdleCount          int `json:"idleCount" example:"1"`
+	UnderutilizedCount int `json:"underutilizedCount" example:"2"`
+	// EstimatedSavingsPerMonth sums the savings of the recommendations (730 hours a month)
+	EstimatedSavingsPerMonth float64 `json:"estimatedSavingsPerMonth" example:"182.2"`
+
+	Nodes []NodeRightsizing `json:"nodes"`
+	Notes []string          `json:"notes,omitempty"`
+}
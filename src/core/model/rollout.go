@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model is to handle object of CB-Tumblebug
+package model
+
+// NodeGroup rollout states
+const (
+	RolloutRunning    = "Running"
+	RolloutPaused     = "Paused"
+	RolloutCompleted  = "Completed"
+	RolloutAborted    = "Aborted"
+	RolloutRolledBack = "RolledBack"
+	RolloutFailed     = "Failed"
+)
+
+// NodeGroup rollout control actions
+const (
+	RolloutActionPause  = "pause"
+	RolloutActionResume = "resume"
+	RolloutActionAbort  = "abort"
+)
+
+// NodeGroupRolloutReq is a struct to request a rolling replacement of the Nodes of a NodeGroup
+type NodeGroupRolloutReq struct {
+	// ImageId is the image of the replacement Nodes (e.g., a custom image from a snapshot). Empty keeps the current image.
+	ImageId string `json:"imageId,omitempty" example:"infra01-g1-1-snapshot"`
+	// SpecId is the spec of the replacement Nodes. Empty keeps the current spec.
+	SpecId string `json:"specId,omitempty" example:"aws+ap-northeast-2+t3.small"`
+
+	// BatchSize is the number of Nodes replaced at a time (default: 1)
+	BatchSize int `json:"batchSize,omitempty" example:"1" default:"1"`
+
+	// PostCommands run on each batch of replacement Nodes before the health check
+	// (phase targets are ignored: every phase runs on the new Nodes)
+	PostCommands []PostCommandReq `json:"postCommands,omitempty"`
+
+	// HealthCheck is a command that must succeed on every replacement Node before the old Nodes are removed.
+	// Without it, a replacement Node is healthy when it is running and reachable over SSH.
+	HealthCheck *InfraCmdReq `json:"healthCheck,omitempty"`
+
+	// PreStopCommand runs on each old Node before it is terminated
+	PreStopCommand *InfraCmdReq `json:"preStopCommand,omitempty"`
+
+	// PauseAfterBatch pauses the rollout after every batch (resume to continue), for manual verification
+	PauseAfterBatch bool `json:"pauseAfterBatch,omitempty" example:"false"`
+}
+
+// NodeReplacement is a pair of an old Node and the Node that replaced it
+type NodeReplacement struct {
+	OldNodeId string `json:"oldNodeId" example:"g1-1"`
+	NewNodeId string `json:"newNodeId" example:"g1-4"`
+}
+
+// NodeGroupRolloutEvent is a history entry of a rollout
+type NodeGroupRolloutEvent struct {
+	Time    string `json:"time" example:"2025-01-02T10:00:00Z"`
+	Message string `json:"message" example:"batch 1/3: replacement Nodes g1-4 are healthy"`
+}
+
+// NodeGroupRolloutStatus is the status of a rolling replacement of a NodeGroup
+type NodeGroupRolloutStatus struct {
+	NsId        string `json:"nsId" example:"default"`
+	InfraId     string `json:"infraId" example:"infra01"`
+	NodeGroupId string `json:"nodeGroupId" example:"g1"`
+	ImageId     string `json:"imageId,omitempty"`
+	SpecId      string `json:"specId,omitempty"`
+
+	State string `json:"state" example:"Running" enums:"Running,Paused,Completed,Aborted,RolledBack,Failed"`
+	// PauseRequested and AbortRequested take effect when the current batch is finished
+	PauseRequested bool `json:"pauseRequested,omitempty"`
+	AbortRequested bool `json:"abortRequested,omitempty"`
+
+	BatchSize        int `json:"batchSize" example:"1"`
+	TotalBatches     int `json:"totalBatches" example:"3"`
+	CompletedBatches int `json:"completedBatches" example:"1"`
+
+	// CurrentBatch are the old Nodes being replaced
+	CurrentBatch []string `json:"currentBatch,omitempty"`
+	// PendingNodes are the old Nodes not replaced yet
+	PendingNodes []string          `json:"pendingNodes"`
+	Replaced     []NodeReplacement `json:"replaced"`
+
+	Message   string                  `json:"message,omitempty"`
+	StartedAt string                  `json:"startedAt"`
+	UpdatedAt string                  `json:"updatedAt"`
+	History   []NodeGroupRolloutEvent `json:"history"`
+}
@@ -123,6 +123,7 @@ type SpecSummary struct {
 	AcceleratorMemoryGB float32 `json:"acceleratorMemoryGB,omitempty" example:"16"`
 	AcceleratorType     string  `json:"acceleratorType,omitempty" example:"GPU"`
 	CostPerHour         float32 `json:"costPerHour,omitempty" example:"0.0416"`
+	SpotCostPerHour     float32 `json:"spotCostPerHour,omitempty" example:"0.0125"`
 }
 
 // SpecReq is a struct to handle 'Register spec' request toward CB-Tumblebug.
@@ -167,6 +168,7 @@ type SpecInfo struct { // Tumblebug
 	AcceleratorMemoryGB   float32  `json:"acceleratorMemoryGB,omitempty"`
 	AcceleratorType       string   `json:"acceleratorType,omitempty"`
 	CostPerHour           float32  `json:"costPerHour,omitempty"`
+	SpotCostPerHour       float32  `json:"spotCostPerHour,omitempty"`
 	Description           string   `json:"description,omitempty"`
 	OrderInFilteredResult uint16   `json:"orderInFilteredResult,omitempty"`
 	EvaluationStatus      string   `json:"evaluationStatus,omitempty"`
@@ -227,6 +229,7 @@ type FilterSpecsByRangeRequest struct {
 	AcceleratorMemoryGB Range   `json:"acceleratorMemoryGB"`
 	AcceleratorType     string  `json:"acceleratorType"`
 	CostPerHour         Range   `json:"costPerHour"`
+	SpotCostPerHour     Range   `json:"spotCostPerHour"`
 	Description         string  `json:"description"`
 	EvaluationStatus    string  `json:"evaluationStatus"`
 	EvaluationScore01   Range   `json:"evaluationScore01"`
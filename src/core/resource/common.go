@@ -34,11 +34,13 @@ import (
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvutil"
 	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -284,7 +286,7 @@ func DelAllResources(nsId string, resourceType string, subString string, forceFl
 					success := true
 					errMessage := ""
 
-					err := DelResource(nsId, resourceType, resourceId, forceFlag)
+					err := DelResource(context.Background(), nsId, resourceType, resourceId, forceFlag)
 					if err != nil {
 						success = false
 						errMessage = err.Error()
@@ -473,7 +475,13 @@ func VerifySharedResourceOnCsp(nsId string, resType string, resourceId string) (
 	return false, err
 }
 
-func DelResource(nsId string, resourceType string, resourceId string, forceFlag string) error {
+func DelResource(ctx context.Context, nsId string, resourceType string, resourceId string, forceFlag string) error {
+	ctx, span := tracing.StartDetached(ctx, "resource.delete",
+		attribute.String("ns.id", nsId),
+		attribute.String("resource.type", resourceType),
+		attribute.String("resource.id", resourceId),
+	)
+	defer span.End()
 
 	InvalidateVerifyCache(nsId, resourceType, resourceId)
 
@@ -502,7 +510,7 @@ func DelResource(nsId string, resourceType string, resourceId string, forceFlag
 	}
 
 	key := common.GenResourceKey(nsId, resourceType, resourceId)
-	keyValue, _, _ := kvstore.GetKv(key)
+	keyValue, _, _ := kvstore.GetKvWith(ctx, key)
 	// In CheckResource() above, calling 'kvstore.GetKv()' and checking err parts exist.
 	// So, in here, we don't need to check whether keyValue == nil or err != nil.
 
@@ -691,7 +699,8 @@ func DelResource(nsId string, resourceType string, resourceId string, forceFlag
 
 	execDelete := func() (model.SpiderBooleanInfo, error) {
 		var cr model.SpiderBooleanInfo
-		_, e := clientManager.ExecuteHttpRequest(
+		_, e := clientManager.ExecuteHttpRequestWithContext(
+			ctx,
 			client,
 			"DELETE",
 			url,
@@ -3552,7 +3561,7 @@ func DeleteSharedResources(nsId string, dryRun bool) (model.ResourceDeleteResult
 				})
 				continue
 			}
-			delErr := DelResource(nsId, resourceType, id, "false")
+			delErr := DelResource(context.Background(), nsId, resourceType, id, "false")
 			res := model.ResourceDeleteResult{ResourceType: resourceType, ResourceId: id, Success: delErr == nil}
 			if delErr != nil {
 				res.Message = delErr.Error()
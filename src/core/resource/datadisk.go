@@ -25,10 +25,12 @@ import (
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 
 	validator "github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // DataDiskReqStructLevelValidation func is for Validation
@@ -55,6 +57,12 @@ func firstNonEmpty(values ...string) string {
 
 // CreateDataDisk accepts DataDisk creation request, creates and returns an TB dataDisk object
 func CreateDataDisk(ctx context.Context, nsId string, u *model.DataDiskReq, option string) (model.DataDiskInfo, error) {
+	ctx, span := tracing.StartDetached(ctx, "dataDisk.create",
+		attribute.String("ns.id", nsId),
+		attribute.String("datadisk.name", u.Name),
+		attribute.String("option", option),
+	)
+	defer span.End()
 
 	resourceType := model.StrDataDisk
 
@@ -122,7 +130,8 @@ func CreateDataDisk(ctx context.Context, nsId string, u *model.DataDiskReq, opti
 		method = "POST"
 	}
 
-	_, err = clientManager.ExecuteHttpRequest(
+	_, err = clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -177,7 +186,7 @@ func CreateDataDisk(ctx context.Context, nsId string, u *model.DataDiskReq, opti
 	log.Info().Msg("PUT CreateDataDisk")
 	Key := common.GenResourceKey(nsId, resourceType, content.Id)
 	Val, _ := json.Marshal(content)
-	err = kvstore.Put(Key, string(Val))
+	err = kvstore.PutWith(ctx, Key, string(Val))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return content, err
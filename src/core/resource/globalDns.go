@@ -21,10 +21,10 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	"github.com/rs/zerolog/log"
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
+	"github.com/cloud-barista/cb-tumblebug/src/core/csp"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 
@@ -33,7 +33,6 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/route53/types"
-	"github.com/openbao/openbao/api/v2"
 )
 
 // nodeIPLocation holds a VM's public IP and geographic location.
@@ -143,7 +142,7 @@ func UpdateGlobalDnsRecord(ctx context.Context, req *model.GlobalDnsRecordReq) (
 		}
 	}
 
-	// 3. Fetch AWS credentials from OpenBao
+	// 3. Fetch AWS credentials from the secret store
 	r53, err := getRoute53Client(ctx)
 	if err != nil {
 		return model.SimpleMsg{}, err
@@ -642,30 +641,18 @@ type awsCreds struct {
 	Region          string
 }
 
-// getRoute53Client creates a Route53 client using OpenBao credentials (shared helper).
+// getRoute53Client creates a Route53 client using credentials from the secret store (shared helper).
 func getRoute53Client(ctx context.Context) (*route53.Client, error) {
-	log.Debug().Str("vaultAddr", model.VaultAddr).Bool("vaultTokenSet", model.VaultToken != "").Msg("[DNS] Checking Vault credentials")
-	if model.VaultToken == "" {
-		log.Error().Msg("[DNS] VAULT_TOKEN is not set")
-		return nil, fmt.Errorf("VAULT_TOKEN is not set")
+	if reason := csp.SecretStoreUnconfiguredReason(); reason != "" {
+		log.Error().Str("secretStore", csp.SecretStoreBackend()).Msgf("[DNS] %s", reason)
+		return nil, fmt.Errorf("%s", reason)
 	}
 
-	holder := common.CredentialHolderFromContext(ctx)
-	if holder == "" || strings.EqualFold(holder, "admin") {
-		holder = "admin"
-	}
-
-	var path string
-	if holder == "admin" {
-		path = "secret/data/csp/aws"
-	} else {
-		path = fmt.Sprintf("secret/data/users/%s/csp/aws", holder)
-	}
-
-	awsCreds, err := fetchAWSCredsFromOpenBao(ctx, model.VaultAddr, model.VaultToken, path)
+	path := csp.BuildSecretPath(ctx, "aws")
+	awsCreds, err := fetchAWSCreds(ctx, path)
 	if err != nil {
-		log.Error().Err(err).Msg("[DNS] Failed to fetch AWS credentials from OpenBao")
-		return nil, fmt.Errorf("failed to fetch AWS credentials from OpenBao: %w", err)
+		log.Error().Err(err).Msg("[DNS] Failed to fetch AWS credentials from secret store")
+		return nil, fmt.Errorf("failed to fetch AWS credentials from %s: %w", csp.SecretStoreBackend(), err)
 	}
 	log.Debug().Str("region", awsCreds.Region).Msg("[DNS] AWS credentials fetched successfully")
 
@@ -678,38 +665,17 @@ func getRoute53Client(ctx context.Context) (*route53.Client, error) {
 	return r53, nil
 }
 
-func fetchAWSCredsFromOpenBao(ctx context.Context, vaultAddr, vaultToken, path string) (*awsCreds, error) {
-	log.Debug().Str("vaultAddr", vaultAddr).Msg("[DNS] Connecting to OpenBao")
-	vaultConfig := api.DefaultConfig()
-	vaultConfig.Address = vaultAddr
-	client, err := api.NewClient(vaultConfig)
-	if err != nil {
-		log.Error().Err(err).Msg("[DNS] Failed to create OpenBao client")
-		return nil, err
-	}
-	client.SetToken(vaultToken)
-
-	log.Debug().Str("path", path).Msg("[DNS] Reading secret from OpenBao")
-	secret, err := client.Logical().Read(path)
+func fetchAWSCreds(ctx context.Context, path string) (*awsCreds, error) {
+	log.Debug().Str("path", path).Str("secretStore", csp.SecretStoreBackend()).Msg("[DNS] Reading secret")
+	data, err := csp.ReadSecret(ctx, path)
 	if err != nil {
-		log.Error().Err(err).Msg("[DNS] Failed to read secret from OpenBao")
 		return nil, err
 	}
-	if secret == nil || secret.Data == nil {
-		log.Error().Bool("secretNil", secret == nil).Str("path", path).Msg("[DNS] Secret not found in OpenBao")
-		return nil, fmt.Errorf("secret not found at %s", path)
-	}
-	log.Debug().Msg("[DNS] Secret read successfully from OpenBao")
-
-	data, ok := secret.Data["data"].(map[string]any)
-	if !ok {
-		log.Error().Msg("[DNS] Invalid secret format: 'data' field missing or not a map")
-		return nil, fmt.Errorf("invalid secret format: 'data' field missing or not a map")
-	}
+	log.Debug().Msg("[DNS] Secret read successfully")
 
-	keyID, _ := data["AWS_ACCESS_KEY_ID"].(string)
-	secretKey, _ := data["AWS_SECRET_ACCESS_KEY"].(string)
-	region, _ := data["AWS_DEFAULT_REGION"].(string)
+	keyID := csp.GetString(data, "AWS_ACCESS_KEY_ID")
+	secretKey := csp.GetString(data, "AWS_SECRET_ACCESS_KEY")
+	region := csp.GetString(data, "AWS_DEFAULT_REGION")
 	if region == "" {
 		region = "us-east-1"
 	}
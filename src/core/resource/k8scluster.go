@@ -249,6 +249,31 @@ func CreateK8sCluster(ctx context.Context, nsId string, req *model.K8sClusterReq
 		return emptyObj, err
 	}
 
+	if option != "register" {
+		demand := model.NsQuotaDemand{NsResourceUsage: model.NsResourceUsage{K8sClusters: 1}}
+		releaseQuota, err := checkSingleResourceQuota(ctx, nsId, req.ConnectionName, demand)
+		if err != nil {
+			log.Err(err).Msgf("Failed to Create a K8sCluster(%s)", k8sClusterId)
+			return emptyObj, err
+		}
+		defer releaseQuota()
+
+		target := model.GuardrailTarget{Kind: model.GuardrailTargetK8sCluster, Name: k8sClusterId, Labels: req.Label}
+		for _, ng := range req.K8sNodeGroupList {
+			target.ImageIds = append(target.ImageIds, ng.ImageId)
+			target.SpecIds = append(target.SpecIds, ng.SpecId)
+		}
+		if err := common.EnforceGuardrails(nsId, target); err != nil {
+			log.Err(err).Msgf("Failed to Create a K8sCluster(%s)", k8sClusterId)
+			return emptyObj, err
+		}
+
+		if err := common.CheckBudgetBlock(nsId, req.Label); err != nil {
+			log.Err(err).Msgf("Failed to Create a K8sCluster(%s)", k8sClusterId)
+			return emptyObj, err
+		}
+	}
+
 	uid := common.GenUid()
 	connConfig, err := common.GetConnConfig(req.ConnectionName)
 	if err != nil {
@@ -527,7 +552,8 @@ func CreateK8sCluster(ctx context.Context, nsId string, req *model.K8sClusterReq
 
 	var spClusterRes model.SpiderClusterRes
 
-	_, createErr = clientManager.ExecuteHttpRequest(
+	_, createErr = clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -658,6 +684,12 @@ func AddK8sNodeGroup(ctx context.Context, nsId string, k8sClusterId string, u *m
 		return emptyObj, err
 	}
 
+	err = common.CheckBudgetBlock(nsId, tbK8sCInfo.Label, u.Label)
+	if err != nil {
+		log.Err(err).Msgf("Failed to Add K8sNodeGroup(k8scluster=%s)", k8sClusterId)
+		return emptyObj, err
+	}
+
 	// Get connection config to check CSP capabilities
 	connConfig, err := common.GetConnConfig(tbK8sCInfo.ConnectionName)
 	if err != nil {
@@ -825,7 +857,8 @@ func AddK8sNodeGroup(ctx context.Context, nsId string, k8sClusterId string, u *m
 
 	var spClusterRes model.SpiderClusterRes
 
-	_, err = clientManager.ExecuteHttpRequest(
+	_, err = clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -1080,6 +1113,15 @@ func ChangeK8sNodeGroupAutoscaleSize(nsId string, k8sClusterId string, k8sNodeGr
 		return emptyObj, err
 	}
 
+	// Scaling out is provisioning; scaling in stays possible under an exhausted budget
+	if u.DesiredNodeSize > tbK8sNGInfo.DesiredNodeSize || u.MaxNodeSize > tbK8sNGInfo.MaxNodeSize {
+		err = common.CheckBudgetBlock(nsId, tbK8sCInfo.Label)
+		if err != nil {
+			log.Err(err).Msgf("Failed to Change K8sNodeGroup AutoscaleSize(k8scluster=%s)", k8sClusterId)
+			return emptyObj, err
+		}
+	}
+
 	requestBody := model.SpiderChangeAutoscaleSizeReq{
 		ConnectionName: tbK8sCInfo.ConnectionName,
 		ReqInfo: model.SpiderChangeAutoscaleSizeReqInfo{
@@ -1655,7 +1697,8 @@ func UpgradeK8sCluster(ctx context.Context, nsId string, k8sClusterId string, u
 	client.SetTimeout(10 * time.Minute)
 
 	var spClusterRes model.SpiderClusterRes
-	_, err = clientManager.ExecuteHttpRequest(
+	_, err = clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
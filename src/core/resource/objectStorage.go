@@ -398,6 +398,14 @@ func CreateObjectStorage(ctx context.Context, nsId string, req model.ObjectStora
 		return emptyRet, err
 	}
 
+	demand := model.NsQuotaDemand{NsResourceUsage: model.NsResourceUsage{ObjectStorages: 1}}
+	releaseQuota, err := checkSingleResourceQuota(ctx, nsId, req.ConnectionName, demand)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return emptyRet, err
+	}
+	defer releaseQuota()
+
 	// 2. Set the resource type
 	resourceType := model.StrObjectStorage
 
@@ -475,7 +483,8 @@ func CreateObjectStorage(ctx context.Context, nsId string, req model.ObjectStora
 		// restyResp is captured so HandleHttpResponse can wrap the error with the
 		// HTTP status code; this lets apierr.IsConflict use the status code
 		// as a secondary signal when the error message alone is ambiguous.
-		restyResp, err := clientManager.ExecuteHttpRequest(
+		restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+			ctx,
 			client,
 			method,
 			url,
@@ -536,7 +545,8 @@ func CreateObjectStorage(ctx context.Context, nsId string, req model.ObjectStora
 
 	// restyResp is captured so HandleHttpResponse can wrap the error with the
 	// HTTP status code for accurate apierr classification.
-	restyResp, err := clientManager.ExecuteHttpRequest(
+	restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
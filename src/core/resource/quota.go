@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resource is to manage multi-cloud infra resource
+package resource
+
+import (
+	"context"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/rs/zerolog/log"
+)
+
+// connectionQuotaLocation returns the provider/region of a connection config.
+// An unresolvable connection yields an empty location, which fails the quota's
+// location filters instead of bypassing them.
+func connectionQuotaLocation(connectionName string) (model.NsQuotaLocation, bool) {
+	connConfig, err := common.GetConnConfig(connectionName)
+	if err != nil {
+		log.Debug().Msgf("Quota: connection '%s' not found, location is unknown", connectionName)
+		return model.NsQuotaLocation{}, false
+	}
+	return model.NsQuotaLocation{
+		Provider: connConfig.ProviderName,
+		Region:   connConfig.RegionZoneInfo.AssignedRegion,
+	}, true
+}
+
+// AddNodeQuotaDemand adds count Nodes of the given spec to demand.
+// The spec is looked up in the system namespace first (dynamic provisioning),
+// then in nsId; an unknown spec still counts as Nodes, located by its connection.
+func AddNodeQuotaDemand(demand *model.NsQuotaDemand, nsId, specId, connectionName string, count int, spot bool) {
+	if count < 1 {
+		count = 1
+	}
+	demand.Nodes += count
+
+	spec, err := GetSpec(model.SystemCommonNs, specId)
+	if err != nil {
+		spec, err = GetSpec(nsId, specId)
+	}
+	if err != nil {
+		log.Debug().Msgf("Quota: spec '%s' not found, counting Nodes only", specId)
+		loc, _ := connectionQuotaLocation(connectionName)
+		demand.Locations = append(demand.Locations, loc)
+		return
+	}
+
+	demand.VCpu += int(spec.VCPU) * count
+	demand.MemoryGiB += float64(spec.MemoryGiB) * float64(count)
+	demand.Gpus += int(spec.AcceleratorCount) * count
+	cost := spec.CostPerHour
+	if spot && spec.SpotCostPerHour > 0 {
+		cost = spec.SpotCostPerHour
+	}
+	if cost > 0 {
+		demand.CostPerHour += float64(cost) * float64(count)
+	}
+
+	loc := model.NsQuotaLocation{Provider: spec.ProviderName, Region: spec.RegionName}
+	if connectionName != "" {
+		if connLoc, ok := connectionQuotaLocation(connectionName); ok {
+			loc = connLoc
+		}
+	}
+	demand.Locations = append(demand.Locations, loc)
+}
+
+// checkSingleResourceQuota checks the quota for one K8s cluster or object storage
+// and reserves it until release is called (see common.ReserveNsQuota).
+func checkSingleResourceQuota(ctx context.Context, nsId, connectionName string, demand model.NsQuotaDemand) (func(), error) {
+	loc, _ := connectionQuotaLocation(connectionName)
+	demand.Locations = append(demand.Locations, loc)
+	_, release, err := common.ReserveNsQuota(ctx, nsId, demand)
+	return release, err
+}
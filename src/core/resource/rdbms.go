@@ -1190,7 +1190,8 @@ func CreateRDBMS(ctx context.Context, nsId string, req model.RDBMSCreateRequest)
 	logReq.ReqInfo.MasterUserPassword = "********"
 	log.Debug().Msgf("[Request to Spider] Creating RDBMS (url: %s, request: %+v)", url, logReq)
 
-	restyResp, err := clientManager.ExecuteHttpRequest(
+	restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		"POST",
 		url,
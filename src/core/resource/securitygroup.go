@@ -26,11 +26,13 @@ import (
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 	validator "github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // parsePort parses a port string to int, returns -1 if invalid
@@ -136,6 +138,12 @@ func parsePortsToFromTo(ports string) (string, string) {
 
 // CreateSecurityGroup accepts SG creation request, creates and returns an TB SG object
 func CreateSecurityGroup(ctx context.Context, nsId string, u *model.SecurityGroupReq, option string) (model.SecurityGroupInfo, error) {
+	ctx, span := tracing.StartDetached(ctx, "securityGroup.create",
+		attribute.String("ns.id", nsId),
+		attribute.String("securitygroup.name", u.Name),
+		attribute.String("option", option),
+	)
+	defer span.End()
 
 	resourceType := model.StrSecurityGroup
 
@@ -187,6 +195,16 @@ func CreateSecurityGroup(ctx context.Context, nsId string, u *model.SecurityGrou
 		return temp, err
 	}
 
+	if option != "register" && u.FirewallRules != nil {
+		fwRules := []model.GuardrailFirewallRule{}
+		for _, v := range *u.FirewallRules {
+			fwRules = append(fwRules, model.GuardrailFirewallRule{Ports: v.Ports, Protocol: v.Protocol, Direction: v.Direction, CIDR: v.CIDR})
+		}
+		if err := common.EnforceGuardrails(nsId, securityGroupGuardrailTarget(u.Name, fwRules)); err != nil {
+			return model.SecurityGroupInfo{}, err
+		}
+	}
+
 	unlock := LockResourceCreation(nsId, resourceType, u.Name)
 	defer unlock()
 
@@ -316,7 +334,8 @@ func CreateSecurityGroup(ctx context.Context, nsId string, u *model.SecurityGrou
 		method = "POST"
 	}
 
-	_, err = clientManager.ExecuteHttpRequest(
+	_, err = clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -368,7 +387,7 @@ func CreateSecurityGroup(ctx context.Context, nsId string, u *model.SecurityGrou
 
 	Key := common.GenResourceKey(nsId, resourceType, content.Id)
 	Val, _ := json.Marshal(content)
-	err = kvstore.Put(Key, string(Val))
+	err = kvstore.PutWith(ctx, Key, string(Val))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return content, err
@@ -433,6 +452,15 @@ func getCspVNetIdFromSecurityGroup(connectionName, cspResourceId string) (string
 	return vNetIID.SystemId, nil
 }
 
+// securityGroupGuardrailTarget builds the guardrail target of firewall rules to be opened.
+func securityGroupGuardrailTarget(securityGroupId string, fwRules []model.GuardrailFirewallRule) model.GuardrailTarget {
+	return model.GuardrailTarget{
+		Kind:          model.GuardrailTargetSecurityGroup,
+		Name:          securityGroupId,
+		FirewallRules: fwRules,
+	}
+}
+
 // CreateFirewallRules accepts firewallRule creation request, creates and returns an TB securityGroup object
 func CreateFirewallRules(nsId string, securityGroupId string, req []model.FirewallRuleInfo, objectOnly bool) (model.SecurityGroupInfo, error) {
 	// Which one would be better, 'req model.FirewallRuleInfo' vs. 'req model.FirewallRuleInfo' ?
@@ -470,6 +498,16 @@ func CreateFirewallRules(nsId string, securityGroupId string, req []model.Firewa
 		req[i].Direction = strings.ToLower(req[i].Direction)
 	}
 
+	if !objectOnly {
+		fwRules := []model.GuardrailFirewallRule{}
+		for _, v := range req {
+			fwRules = append(fwRules, model.GuardrailFirewallRule{Ports: v.Port, Protocol: v.Protocol, Direction: v.Direction, CIDR: v.CIDR})
+		}
+		if err := common.EnforceGuardrails(nsId, securityGroupGuardrailTarget(securityGroupId, fwRules)); err != nil {
+			return model.SecurityGroupInfo{}, err
+		}
+	}
+
 	parentResourceType := model.StrSecurityGroup
 
 	check, err := CheckResource(nsId, parentResourceType, securityGroupId)
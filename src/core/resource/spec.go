@@ -38,6 +38,7 @@ import (
 	awsPricing "github.com/cloud-barista/cb-tumblebug/src/core/csp/aws"
 	azurePricing "github.com/cloud-barista/cb-tumblebug/src/core/csp/azure"
 	gcpPricing "github.com/cloud-barista/cb-tumblebug/src/core/csp/gcp"
+	tencentPricing "github.com/cloud-barista/cb-tumblebug/src/core/csp/tencent"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
 	validator "github.com/go-playground/validator/v10"
@@ -1410,10 +1411,13 @@ func FetchPriceForAllConnConfigs(option *model.PriceFetchOption) (connConfigCoun
 	// Azure: direct because it is faster and avoids Spider's per-region HTTP overhead.
 	// AWS: direct because a single global Pricing API query covers all regions at once,
 	//      eliminating the N×Spider round-trips that dominate the legacy path.
+	// Tencent: direct because one DescribeZoneInstanceConfigInfos call returns prices
+	//      for every instance type in a region.
 	var gcpConfigs []model.ConnConfig
 	var azureConfigs []model.ConnConfig
 	var awsConfigs []model.ConnConfig
 	var alibabaConfigs []model.ConnConfig
+	var tencentConfigs []model.ConnConfig
 	var otherConfigs []model.ConnConfig
 	for _, c := range targetConfigs {
 		switch {
@@ -1425,6 +1429,8 @@ func FetchPriceForAllConnConfigs(option *model.PriceFetchOption) (connConfigCoun
 			awsConfigs = append(awsConfigs, c)
 		case strings.EqualFold(c.ProviderName, csp.Alibaba):
 			alibabaConfigs = append(alibabaConfigs, c)
+		case strings.EqualFold(c.ProviderName, csp.Tencent):
+			tencentConfigs = append(tencentConfigs, c)
 		default:
 			otherConfigs = append(otherConfigs, c)
 		}
@@ -1445,7 +1451,7 @@ func FetchPriceForAllConnConfigs(option *model.PriceFetchOption) (connConfigCoun
 		elapsed  time.Duration
 	}
 
-	tasks := make([]providerTask, 0, 5)
+	tasks := make([]providerTask, 0, 6)
 	// Alibaba is placed first: it has the strictest API rate limits and takes the
 	// longest per-region due to mandatory inter-call intervals. Starting it immediately
 	// (in the initial semaphore burst) avoids it becoming the tail that extends the
@@ -1482,6 +1488,14 @@ func FetchPriceForAllConnConfigs(option *model.PriceFetchOption) (connConfigCoun
 			},
 		})
 	}
+	if len(tencentConfigs) > 0 {
+		tasks = append(tasks, providerTask{
+			name: csp.Tencent,
+			run: func() (uint, []string) {
+				return fetchTencentPricesDirect(tencentConfigs)
+			},
+		})
+	}
 	if len(otherConfigs) > 0 {
 		tasks = append(tasks, providerTask{
 			name: "OTHER(SPIDER)",
@@ -1813,6 +1827,8 @@ func fetchAWSPricesDirect(awsConfigs []model.ConnConfig) (successCount uint, err
 				}
 			}
 
+			updateSpotPricesDirect(config, awsPricing.FetchSpotPricesByRegion)
+
 			log.Debug().Msgf("AWS direct: updated %d prices for %s (%s)", len(batchUpdates), config.ConfigName, region)
 			resultChan <- connResult{ConnName: config.ConfigName, Err: nil}
 		}(connConfig)
@@ -1968,6 +1984,125 @@ func fetchAlibabaPricesDirect(alibabaConfigs []model.ConnConfig) (successCount u
 	return successCount, errors
 }
 
+// fetchTencentPricesDirect fetches Tencent CVM pricing directly from the Tencent CVM API
+// and updates spec prices in bulk for all Tencent connection configs.
+// Tencent enforces a strict per-second API limit, so region concurrency is kept low.
+func fetchTencentPricesDirect(tencentConfigs []model.ConnConfig) (successCount uint, errors []string) {
+	log.Info().Msgf("Tencent: directly fetching pricing from Tencent CVM API for %d connections", len(tencentConfigs))
+	tencentStart := time.Now()
+	maxConcurrent := 4
+	semaphore := make(chan struct{}, maxConcurrent)
+	var wg sync.WaitGroup
+	type connResult struct {
+		ConnName string
+		Err      error
+	}
+	resultChan := make(chan connResult, len(tencentConfigs))
+
+	for _, connConfig := range tencentConfigs {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(config model.ConnConfig) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			region := config.RegionDetail.RegionName
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+			priceData, fetchErr := tencentPricing.FetchNodePricesByRegion(ctx, region)
+			if fetchErr != nil {
+				resultChan <- connResult{ConnName: config.ConfigName, Err: fmt.Errorf("Error fetching Tencent prices for connection %s: %w", config.ConfigName, fetchErr)}
+				return
+			}
+			if len(priceData.PriceList) == 0 {
+				resultChan <- connResult{ConnName: config.ConfigName, Err: fmt.Errorf("No Tencent prices found for region %s", region)}
+				return
+			}
+
+			batchUpdates := make(map[string]float32, len(priceData.PriceList))
+			for i := range priceData.PriceList {
+				price := priceData.PriceList[i]
+				priceFloat, parseErr := strconv.ParseFloat(price.PriceInfo.OnDemand.Price, 32)
+				if parseErr != nil {
+					log.Warn().Msgf("Tencent direct: failed to parse price %q for spec %s: %v",
+						price.PriceInfo.OnDemand.Price, price.ProductInfo.VMSpecName, parseErr)
+					continue
+				}
+				priceFloat = float64(common.ConvertToBaseCurrency(float32(priceFloat), price.PriceInfo.OnDemand.Currency))
+				specKey := GetProviderRegionZoneResourceKey(
+					config.ProviderName,
+					config.RegionDetail.RegionName,
+					"",
+					price.ProductInfo.VMSpecName)
+				batchUpdates[specKey] = float32(priceFloat)
+			}
+
+			if len(batchUpdates) > 0 {
+				_, dbErr := BulkUpdateSpec(model.SystemCommonNs, batchUpdates)
+				if dbErr != nil {
+					resultChan <- connResult{ConnName: config.ConfigName, Err: fmt.Errorf("Error updating Tencent prices for %s: %w", config.ConfigName, dbErr)}
+					return
+				}
+			}
+
+			updateSpotPricesDirect(config, tencentPricing.FetchSpotPricesByRegion)
+
+			log.Debug().Msgf("Tencent direct: updated %d prices for %s (%s)", len(batchUpdates), config.ConfigName, region)
+			resultChan <- connResult{ConnName: config.ConfigName, Err: nil}
+		}(connConfig)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	for result := range resultChan {
+		if result.Err != nil {
+			errors = append(errors, fmt.Sprintf("Error fetching prices for connection %s: %v", result.ConnName, result.Err))
+			continue
+		}
+		successCount++
+	}
+
+	log.Info().Msgf("Tencent direct pricing completed in %s: %d/%d connections succeeded",
+		time.Since(tencentStart), successCount, len(tencentConfigs))
+	return successCount, errors
+}
+
+// updateSpotPricesDirect fetches spot prices for one connection's region and stores
+// them in SpecInfo.SpotCostPerHour. Spot pricing is best-effort: a failure is logged
+// and does not fail the connection, whose on-demand prices are already stored.
+func updateSpotPricesDirect(config model.ConnConfig, fetch func(context.Context, string) (model.SpiderCloudPrice, error)) {
+	region := config.RegionDetail.RegionName
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	priceData, err := fetch(ctx, region)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Spot pricing: fetch failed for %s (%s)", config.ConfigName, region)
+		return
+	}
+
+	spotUpdates := make(map[string]float32, len(priceData.PriceList))
+	for i := range priceData.PriceList {
+		price := priceData.PriceList[i]
+		priceFloat, parseErr := strconv.ParseFloat(price.PriceInfo.OnDemand.Price, 32)
+		if parseErr != nil {
+			continue
+		}
+		specKey := GetProviderRegionZoneResourceKey(config.ProviderName, region, "", price.ProductInfo.VMSpecName)
+		spotUpdates[specKey] = common.ConvertToBaseCurrency(float32(priceFloat), price.PriceInfo.OnDemand.Currency)
+	}
+	if len(spotUpdates) == 0 {
+		return
+	}
+	if _, err := BulkUpdateSpecSpotPrice(model.SystemCommonNs, spotUpdates); err != nil {
+		log.Warn().Err(err).Msgf("Spot pricing: DB update failed for %s (%s)", config.ConfigName, region)
+		return
+	}
+	log.Debug().Msgf("Spot pricing: updated %d spot prices for %s (%s)", len(spotUpdates), config.ConfigName, region)
+}
+
 // fetchPricesViaSpider fetches prices for non-GCP connections via cb-spider.
 func fetchPricesViaSpider(configs []model.ConnConfig) (successCount uint, errors []string) {
 	maxConcurrent := 15
@@ -2268,7 +2403,8 @@ func GetAvailableRegionZonesForSpec(ctx context.Context, provider string, cspSpe
 
 	// Make API call
 	var apiResponse map[string]any
-	_, err = clientManager.ExecuteHttpRequest(
+	_, err = clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -3058,8 +3194,9 @@ func getColumnMapping(modelType any) map[string]string {
 	return mapping
 }
 
-// FilterSpecsByRange accepts criteria ranges for filtering, and returns the list of filtered TB spec objects
-func FilterSpecsByRange(nsId string, filter model.FilterSpecsByRangeRequest, orderBy string) ([]model.SpecInfo, error) {
+// FilterSpecsByRange accepts criteria ranges for filtering, and returns the list of filtered TB spec objects.
+// orderByArgs are bound to the ? placeholders of orderBy.
+func FilterSpecsByRange(nsId string, filter model.FilterSpecsByRangeRequest, orderBy string, orderByArgs ...any) ([]model.SpecInfo, error) {
 	if err := common.CheckString(nsId); err != nil {
 		log.Error().Err(err).Msg("Invalid namespace ID")
 		return nil, err
@@ -3186,7 +3323,9 @@ func FilterSpecsByRange(nsId string, filter model.FilterSpecsByRangeRequest, ord
 	var specs []model.SpecInfo
 
 	// Apply ORDER BY if specified
-	if orderBy != "" {
+	if orderBy != "" && len(orderByArgs) > 0 {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{SQL: orderBy, Vars: orderByArgs, WithoutParentheses: true}})
+	} else if orderBy != "" {
 		query = query.Order(orderBy)
 		// log.Debug().Msgf("Applying ORDER BY: %s", orderBy)
 	}
@@ -3235,6 +3374,16 @@ func UpdateSpec(nsId string, specId string, fieldsToUpdate model.SpecInfo) (mode
 
 // BulkUpdateSpec updates multiple specs with proper type casting
 func BulkUpdateSpec(nsId string, updates map[string]float32) (int, error) {
+	return bulkUpdateSpecColumn(nsId, "cost_per_hour", updates)
+}
+
+// BulkUpdateSpecSpotPrice updates the spot/preemptible hourly price of multiple specs
+func BulkUpdateSpecSpotPrice(nsId string, updates map[string]float32) (int, error) {
+	return bulkUpdateSpecColumn(nsId, "spot_cost_per_hour", updates)
+}
+
+// bulkUpdateSpecColumn sets a float price column for multiple specs in a single UPDATE.
+func bulkUpdateSpecColumn(nsId, column string, updates map[string]float32) (int, error) {
 	if len(updates) == 0 {
 		return 0, nil
 	}
@@ -3259,7 +3408,7 @@ func BulkUpdateSpec(nsId string, updates map[string]float32) (int, error) {
 	// Execute with proper casting
 	result := model.ORM.Model(&model.SpecInfo{}).
 		Where("namespace = ? AND id IN ?", nsId, specIds).
-		Update("cost_per_hour", gorm.Expr(caseClause.String(), args...))
+		Update(column, gorm.Expr(caseClause.String(), args...))
 
 	if result.Error != nil {
 		return 0, result.Error
@@ -23,10 +23,12 @@ import (
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 	validator "github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // normalizePrivateKey normalizes private key format from various CSP sources.
@@ -79,6 +81,12 @@ func SshKeyReqStructLevelValidation(sl validator.StructLevel) {
 
 // CreateSshKey accepts SSH key creation request, creates and returns an TB sshKey object
 func CreateSshKey(ctx context.Context, nsId string, u *model.SshKeyReq, option string) (model.SshKeyInfo, error) {
+	ctx, span := tracing.StartDetached(ctx, "sshKey.create",
+		attribute.String("ns.id", nsId),
+		attribute.String("sshkey.name", u.Name),
+		attribute.String("option", option),
+	)
+	defer span.End()
 
 	emptyObj := model.SshKeyInfo{}
 
@@ -153,7 +161,8 @@ func CreateSshKey(ctx context.Context, nsId string, u *model.SshKeyReq, option s
 		method = "GET"
 
 		requestBodyNoBody := clientManager.NoBody
-		_, err = clientManager.ExecuteHttpRequest(
+		_, err = clientManager.ExecuteHttpRequestWithContext(
+			ctx,
 			client,
 			method,
 			url,
@@ -168,7 +177,8 @@ func CreateSshKey(ctx context.Context, nsId string, u *model.SshKeyReq, option s
 		url = fmt.Sprintf("%s/regkeypair", model.SpiderRestUrl)
 		method = "POST"
 
-		_, err = clientManager.ExecuteHttpRequest(
+		_, err = clientManager.ExecuteHttpRequestWithContext(
+			ctx,
 			client,
 			method,
 			url,
@@ -183,7 +193,8 @@ func CreateSshKey(ctx context.Context, nsId string, u *model.SshKeyReq, option s
 		url = fmt.Sprintf("%s/keypair", model.SpiderRestUrl)
 		method = "POST"
 
-		_, err = clientManager.ExecuteHttpRequest(
+		_, err = clientManager.ExecuteHttpRequestWithContext(
+			ctx,
 			client,
 			method,
 			url,
@@ -241,7 +252,7 @@ func CreateSshKey(ctx context.Context, nsId string, u *model.SshKeyReq, option s
 
 	Key := common.GenResourceKey(nsId, resourceType, content.Id)
 	Val, _ := json.Marshal(content)
-	err = kvstore.Put(Key, string(Val))
+	err = kvstore.PutWith(ctx, Key, string(Val))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return content, err
@@ -27,10 +27,12 @@ import (
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/netutil"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 	validator "github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // SubnetReqStructLevelValidation is a function to validate 'SubnetReq' object.
@@ -191,6 +193,13 @@ type spiderSubnetInfo struct {
 
 // CreateSubnet creates and returns the vNet object
 func CreateSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *model.SubnetReq) (model.SubnetInfo, error) {
+	ctx, span := tracing.StartDetached(ctx, "subnet.create",
+		attribute.String("ns.id", nsId),
+		attribute.String("vnet.id", vNetId),
+		attribute.String("subnet.name", subnetReq.Name),
+	)
+	defer span.End()
+
 	log.Info().Msg("CreateSubnet")
 
 	log.Trace().Msgf("subnetReq: %+v", subnetReq)
@@ -256,7 +265,7 @@ func CreateSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *mo
 	subnetKey := common.GenChildResourceKey(nsId, resourceType, vNetId, subnetInfo.Id)
 
 	// Read the saved vNet info
-	vNetKv, exists, err := kvstore.GetKv(vNetKey)
+	vNetKv, exists, err := kvstore.GetKvWith(ctx, vNetKey)
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
@@ -307,7 +316,7 @@ func CreateSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *mo
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
 	}
-	err = kvstore.Put(subnetKey, string(val))
+	err = kvstore.PutWith(ctx, subnetKey, string(val))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
@@ -336,7 +345,7 @@ func CreateSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *mo
 		if err != nil && subnetInfo.Status == model.NetworkStatusCreating {
 			if subnetInfo.CspResourceId == "" { // Delete the saved the subnet info
 				log.Warn().Msgf("failed to create subnet, cleaning up the subnet info: %v", subnetInfo.Id)
-				deleteErr := kvstore.Delete(subnetKey)
+				deleteErr := kvstore.DeleteWith(ctx, subnetKey)
 				if deleteErr != nil {
 					log.Warn().Err(deleteErr).Msgf("failed to delete the subnet info: %v from kvstore", subnetInfo.Id)
 				}
@@ -352,7 +361,8 @@ func CreateSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *mo
 		}
 	}()
 
-	restyResp, err := clientManager.ExecuteHttpRequest(
+	restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -396,7 +406,7 @@ func CreateSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *mo
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
 	}
-	err = kvstore.Put(subnetKey, string(subnetObj))
+	err = kvstore.PutWith(ctx, subnetKey, string(subnetObj))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
@@ -1046,6 +1056,13 @@ func syncSubnetState(nsId string, subnetInfo *model.SubnetInfo, vNetInfo *model.
 }
 
 func RegisterSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *model.RegisterSubnetReq) (model.SubnetInfo, error) {
+	ctx, span := tracing.StartDetached(ctx, "subnet.register",
+		attribute.String("ns.id", nsId),
+		attribute.String("vnet.id", vNetId),
+		attribute.String("subnet.name", subnetReq.Name),
+	)
+	defer span.End()
+
 	log.Info().Msg("RegisterSubnet")
 
 	// subnet objects
@@ -1107,7 +1124,7 @@ func RegisterSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *
 	subnetKey := common.GenChildResourceKey(nsId, resourceType, vNetId, subnetInfo.Id)
 
 	// Read the saved vNet info
-	vNetKv, exists, err := kvstore.GetKv(vNetKey)
+	vNetKv, exists, err := kvstore.GetKvWith(ctx, vNetKey)
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
@@ -1145,7 +1162,7 @@ func RegisterSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
 	}
-	err = kvstore.Put(subnetKey, string(val))
+	err = kvstore.PutWith(ctx, subnetKey, string(val))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
@@ -1175,7 +1192,7 @@ func RegisterSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *
 		if err != nil && subnetInfo.Status == model.NetworkStatusRegistering {
 			if subnetInfo.CspResourceId == "" { // Delete the saved the subnet info
 				log.Warn().Msgf("failed to create subnet, cleaning up the subnet info: %v", subnetInfo.Id)
-				deleteErr := kvstore.Delete(subnetKey)
+				deleteErr := kvstore.DeleteWith(ctx, subnetKey)
 				if deleteErr != nil {
 					log.Warn().Err(deleteErr).Msgf("failed to delete the subnet info: %v from kvstore", subnetInfo.Id)
 				}
@@ -1191,7 +1208,8 @@ func RegisterSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *
 		}
 	}()
 
-	restyResp, err := clientManager.ExecuteHttpRequest(
+	restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -1231,7 +1249,7 @@ func RegisterSubnet(ctx context.Context, nsId string, vNetId string, subnetReq *
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
 	}
-	err = kvstore.Put(subnetKey, string(subnetObj))
+	err = kvstore.PutWith(ctx, subnetKey, string(subnetObj))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
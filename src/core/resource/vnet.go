@@ -29,11 +29,13 @@ import (
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/netutil"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model/csp"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 	validator "github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type NetworkAction string
@@ -442,6 +444,12 @@ type spiderVPCInfo struct {
 
 // CreateVNet accepts vNet creation request, creates and returns an TB vNet object
 func CreateVNet(ctx context.Context, nsId string, vNetReq *model.VNetReq) (model.VNetInfo, error) {
+	ctx, span := tracing.StartDetached(ctx, "vNet.create",
+		attribute.String("ns.id", nsId),
+		attribute.String("vnet.name", vNetReq.Name),
+	)
+	defer span.End()
+
 	log.Info().Msg("CreateVNet")
 
 	// vNet objects
@@ -544,7 +552,7 @@ func CreateVNet(ctx context.Context, nsId string, vNetReq *model.VNetReq) (model
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
 	}
-	err = kvstore.Put(vNetKey, string(val))
+	err = kvstore.PutWith(ctx, vNetKey, string(val))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
@@ -591,14 +599,14 @@ func CreateVNet(ctx context.Context, nsId string, vNetReq *model.VNetReq) (model
 					if subnetInfo.CspResourceId == "" {
 						// Set a subnetKey for the subnet object
 						subnetKey := common.GenChildResourceKey(nsId, childResourceType, vNetInfo.Id, subnetInfo.Id)
-						deleteErr := kvstore.Delete(subnetKey)
+						deleteErr := kvstore.DeleteWith(ctx, subnetKey)
 						if deleteErr != nil {
 							log.Warn().Err(deleteErr).Msgf("failed to delete the subnet: %v from kvstore", subnetInfo.Id)
 						}
 					}
 				}
 				// Delete the saved the vNet info
-				deleteErr := kvstore.Delete(vNetKey)
+				deleteErr := kvstore.DeleteWith(ctx, vNetKey)
 				if deleteErr != nil {
 					log.Warn().Err(deleteErr).Msgf("failed to delete the vNet: %v from kvstore", vNetInfo.Id)
 				}
@@ -614,7 +622,8 @@ func CreateVNet(ctx context.Context, nsId string, vNetReq *model.VNetReq) (model
 		}
 	}()
 
-	restyResp, err := clientManager.ExecuteHttpRequest(
+	restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -688,7 +697,7 @@ func CreateVNet(ctx context.Context, nsId string, vNetReq *model.VNetReq) (model
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
 	}
-	err = kvstore.Put(vNetKey, string(value))
+	err = kvstore.PutWith(ctx, vNetKey, string(value))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
@@ -705,7 +714,7 @@ func CreateVNet(ctx context.Context, nsId string, vNetReq *model.VNetReq) (model
 		}
 
 		// Store the subnet object into the key-value store
-		err = kvstore.Put(subnetKey, string(value))
+		err = kvstore.PutWith(ctx, subnetKey, string(value))
 		if err != nil {
 			log.Error().Err(err).Msg("")
 			return emptyRet, err
@@ -735,7 +744,7 @@ func CreateVNet(ctx context.Context, nsId string, vNetReq *model.VNetReq) (model
 	}
 
 	// Check if the vNet info is stored
-	vNetKv, exists, err := kvstore.GetKv(vNetKey)
+	vNetKv, exists, err := kvstore.GetKvWith(ctx, vNetKey)
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
@@ -1098,6 +1107,12 @@ func DeleteVNet(nsId string, vNetId string, actionParam string) (model.SimpleMsg
 
 // RegisterVNet accepts vNet registration request, register and returns an TB vNet object
 func RegisterVNet(ctx context.Context, nsId string, vNetRegisterReq *model.RegisterVNetReq) (model.VNetInfo, error) {
+	ctx, span := tracing.StartDetached(ctx, "vNet.register",
+		attribute.String("ns.id", nsId),
+		attribute.String("vnet.name", vNetRegisterReq.Name),
+	)
+	defer span.End()
+
 	log.Info().Msg("RegisterVNet")
 
 	// vNet objects
@@ -1172,7 +1187,7 @@ func RegisterVNet(ctx context.Context, nsId string, vNetRegisterReq *model.Regis
 		return emptyRet, err
 	}
 
-	err = kvstore.Put(vNetKey, string(val))
+	err = kvstore.PutWith(ctx, vNetKey, string(val))
 	if err != nil {
 		return emptyRet, err
 	}
@@ -1212,14 +1227,14 @@ func RegisterVNet(ctx context.Context, nsId string, vNetRegisterReq *model.Regis
 					if subnetInfo.CspResourceId == "" {
 						// Set a subnetKey for the subnet object
 						subnetKey := common.GenChildResourceKey(nsId, childResourceType, vNetInfo.Id, subnetInfo.Id)
-						deleteErr := kvstore.Delete(subnetKey)
+						deleteErr := kvstore.DeleteWith(ctx, subnetKey)
 						if deleteErr != nil {
 							log.Warn().Err(deleteErr).Msgf("failed to delete the subnet info: %v from kvstore", subnetInfo.Id)
 						}
 					}
 				}
 				// Delete the saved the vNet info
-				deleteErr := kvstore.Delete(vNetKey)
+				deleteErr := kvstore.DeleteWith(ctx, vNetKey)
 				if deleteErr != nil {
 					log.Warn().Err(deleteErr).Msgf("failed to delete the vNet info: %v from kvstore", vNetInfo.Id)
 				}
@@ -1235,7 +1250,8 @@ func RegisterVNet(ctx context.Context, nsId string, vNetRegisterReq *model.Regis
 		}
 	}()
 
-	restyResp, err := clientManager.ExecuteHttpRequest(
+	restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -1297,7 +1313,7 @@ func RegisterVNet(ctx context.Context, nsId string, vNetRegisterReq *model.Regis
 		if err != nil {
 			return emptyRet, err
 		}
-		err = kvstore.Put(subnetKey, string(value))
+		err = kvstore.PutWith(ctx, subnetKey, string(value))
 		if err != nil {
 			log.Error().Err(err).Msg("")
 			return emptyRet, err
@@ -1348,14 +1364,14 @@ func RegisterVNet(ctx context.Context, nsId string, vNetRegisterReq *model.Regis
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
 	}
-	err = kvstore.Put(vNetKey, string(value))
+	err = kvstore.PutWith(ctx, vNetKey, string(value))
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return emptyRet, err
 	}
 
 	// Check if the vNet info is stored
-	keyValue, exists, err := kvstore.GetKv(vNetKey)
+	keyValue, exists, err := kvstore.GetKvWith(ctx, vNetKey)
 
 	if !exists {
 		err := fmt.Errorf("does not exist, vNet: %s", vNetRegisterReq.Name)
@@ -370,7 +370,8 @@ func CreateSiteToSiteVPN(ctx context.Context, nsId string, infraId string, vpnRe
 
 			resTrInfo := new(terrariumModel.TerrariumInfo)
 
-			restyResp, err := clientManager.ExecuteHttpRequest(
+			restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+				ctx,
 				client,
 				method,
 				url,
@@ -512,7 +513,8 @@ func CreateSiteToSiteVPN(ctx context.Context, nsId string, infraId string, vpnRe
 		resInfracode := new(model.Response)
 
 		err = executeWithOneRetry("create site-to-site VPN", func() error {
-			_, reqErr := clientManager.ExecuteHttpRequest(
+			_, reqErr := clientManager.ExecuteHttpRequestWithContext(
+				ctx,
 				client,
 				method,
 				url,
@@ -683,7 +685,8 @@ func retrieveEnrichmentsInfoInTerrarium(ctx context.Context, holder string, trId
 	currentWaitDuration := maxWaitDuration
 
 	for {
-		_, err := clientManager.ExecuteHttpRequest(
+		_, err := clientManager.ExecuteHttpRequestWithContext(
+			ctx,
 			client,
 			"GET",
 			fmt.Sprintf("%s/tr/%s/%s?detail=refined", epTerrarium, trId, enrichments),
@@ -871,7 +874,8 @@ func GetSiteToSiteVPN(ctx context.Context, nsId string, infraId string, vpnId st
 	requestBody := clientManager.NoBody
 	resTrInfo := new(terrariumModel.TerrariumInfo)
 
-	restyResp, err := clientManager.ExecuteHttpRequest(
+	restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -898,7 +902,8 @@ func GetSiteToSiteVPN(ctx context.Context, nsId string, infraId string, vpnId st
 	requestBody = clientManager.NoBody
 	resResourceInfo := new(model.Response)
 
-	restyResp2, err := clientManager.ExecuteHttpRequest(
+	restyResp2, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -1091,7 +1096,8 @@ func DeleteSiteToSiteVPN(ctx context.Context, nsId string, infraId string, vpnId
 	requestBody := clientManager.NoBody
 	resTrInfo := new(terrariumModel.TerrariumInfo)
 
-	restyResp, err := clientManager.ExecuteHttpRequest(
+	restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -1117,7 +1123,8 @@ func DeleteSiteToSiteVPN(ctx context.Context, nsId string, infraId string, vpnId
 	resDeleteSiteToSiteVpn := new(model.Response)
 
 	err = executeWithOneRetry("delete site-to-site VPN", func() error {
-		_, reqErr := clientManager.ExecuteHttpRequest(
+		_, reqErr := clientManager.ExecuteHttpRequestWithContext(
+			ctx,
 			client,
 			method,
 			url,
@@ -1267,7 +1274,8 @@ func GetRequestStatusOfSiteToSiteVpn(ctx context.Context, nsId string, infraId s
 	requestBody := clientManager.NoBody
 	resTrInfo := new(terrariumModel.TerrariumInfo)
 
-	restyResp, err := clientManager.ExecuteHttpRequest(
+	restyResp, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -1292,7 +1300,8 @@ func GetRequestStatusOfSiteToSiteVpn(ctx context.Context, nsId string, infraId s
 	reqReqStatus := clientManager.NoBody
 	resReqStatus := new(model.Response)
 
-	restyResp2, err := clientManager.ExecuteHttpRequest(
+	restyResp2, err := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		method,
 		url,
@@ -1395,7 +1404,8 @@ func ReconcileSiteToSiteVPN(ctx context.Context, nsId, infraId, vpnId string) (m
 	resTrInfo := new(terrariumModel.TerrariumInfo)
 	log.Debug().Msgf("[ReconcileSiteToSiteVPN] GET %s", url)
 
-	restyResp, getErr := clientManager.ExecuteHttpRequest(
+	restyResp, getErr := clientManager.ExecuteHttpRequestWithContext(
+		ctx,
 		client,
 		"GET",
 		url,
@@ -0,0 +1,123 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to handle REST API for common funcitonalities
+package common
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+)
+
+// RestPostApiToken godoc
+// @ID PostApiToken
+// @Summary Issue a namespace-scoped API token
+// @Description Issue an API token bound to the namespace for automation (CI pipelines, scripts).
+// @Description Scopes: read-only (GET in the namespace), provision (create/change/delete Infra, resources and K8s clusters),
+// @Description command-exec (remote commands and file transfer to Nodes). Catalog queries (specs, images, etc.) are always allowed.
+// @Description Use it as "Authorization: Bearer tbt_...". The token is returned only once; only its hash is stored.
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param tokenReq body model.ApiTokenReq true "API token request"
+// @Success 200 {object} model.ApiTokenIssued
+// @Failure 400 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/apiToken [post]
+func RestPostApiToken(c echo.Context) error {
+	req := &model.ApiTokenReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.SimpleMsg{Message: err.Error()})
+	}
+	createdBy, _ := c.Get("userId").(string)
+	if createdBy == "" {
+		createdBy, _ = c.Get("name").(string)
+	}
+	content, err := common.CreateApiToken(c.Param("nsId"), req, createdBy)
+	if err != nil {
+		code := apierr.Code(err)
+		if code == http.StatusInternalServerError {
+			code = http.StatusBadRequest
+		}
+		return c.JSON(code, model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// RestGetApiToken godoc
+// @ID GetApiToken
+// @Summary Get an API token
+// @Description Get an API token of the namespace (the secret is not included)
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param tokenId path string true "API token ID"
+// @Success 200 {object} model.ApiTokenInfo
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/apiToken/{tokenId} [get]
+func RestGetApiToken(c echo.Context) error {
+	content, err := common.GetApiToken(c.Param("nsId"), c.Param("tokenId"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// RestGetAllApiToken godoc
+// @ID GetAllApiToken
+// @Summary List API tokens of a namespace
+// @Description List API tokens of the namespace, including expired and revoked ones (secrets are not included)
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Success 200 {object} model.ApiTokenList
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/apiToken [get]
+func RestGetAllApiToken(c echo.Context) error {
+	tokens, err := common.ListApiTokens(c.Param("nsId"))
+	if err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, model.ApiTokenList{Tokens: tokens})
+}
+
+// RestDelApiToken godoc
+// @ID DelApiToken
+// @Summary Revoke an API token
+// @Description Revoke an API token of the namespace. The token is rejected immediately; its record is kept for auditing.
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param tokenId path string true "API token ID"
+// @Success 200 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/apiToken/{tokenId} [delete]
+func RestDelApiToken(c echo.Context) error {
+	tokenId := c.Param("tokenId")
+	if err := common.RevokeApiToken(c.Param("nsId"), tokenId); err != nil {
+		return c.JSON(http.StatusNotFound, model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, model.SimpleMsg{Message: "The API token " + tokenId + " has been revoked"})
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to handle REST API for common funcitonalities
+package common
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
+)
+
+// RestPutApprovalPolicy godoc
+// @ID PutApprovalPolicy
+// @Summary Set approval policy
+// @Description Set (replace) the approval policy. When enabled, matching operations are not executed immediately:
+// @Description they are parked as pending change requests (HTTP 202) with their estimated impact,
+// @Description and executed when someone other than the requester approves them.
+// @Description Covered operations: Infra deletion (one or all) with option=terminate, deletion of all namespaces,
+// @Description and Infra creation, NodeGroup addition, scale-out, desired-state apply and Node resize
+// @Description whose estimated cost of the added capacity exceeds costThresholdPerHour.
+// @Description The workflow can only be enabled with JWT auth and RBAC, which tell the requester and the approver apart;
+// @Description requests with an API token act as the user who created the token.
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param policy body model.ApprovalPolicy true "Approval policy"
+// @Success 200 {object} model.ApprovalPolicy
+// @Failure 400 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /approval/policy [put]
+func RestPutApprovalPolicy(c echo.Context) error {
+	policy := model.ApprovalPolicy{}
+	if err := c.Bind(&policy); err != nil {
+		return c.JSON(http.StatusBadRequest, model.SimpleMsg{Message: err.Error()})
+	}
+	content, err := common.SetApprovalPolicy(policy)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// RestGetApprovalPolicy godoc
+// @ID GetApprovalPolicy
+// @Summary Get approval policy
+// @Description Get the approval policy (disabled when not set)
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.ApprovalPolicy
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /approval/policy [get]
+func RestGetApprovalPolicy(c echo.Context) error {
+	content, err := common.GetApprovalPolicy()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// RestGetAllChangeRequest godoc
+// @ID GetAllChangeRequest
+// @Summary List change requests
+// @Description List change requests (newest first) with their estimated impact, decision and audit trail
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param status query string false "Filter by status" Enums(Pending,Rejected,Executing,Executed,Failed)
+// @Param nsId query string false "Filter by namespace"
+// @Success 200 {object} model.ChangeRequestList
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /approval/changeRequest [get]
+func RestGetAllChangeRequest(c echo.Context) error {
+	list, err := common.ListChangeRequests(c.QueryParam("status"), c.QueryParam("nsId"))
+	if err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, model.ChangeRequestList{ChangeRequests: list})
+}
+
+// RestGetChangeRequest godoc
+// @ID GetChangeRequest
+// @Summary Get a change request
+// @Description Get a change request with its estimated impact, decision, execution result and audit trail
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param requestId path string true "Change request ID"
+// @Success 200 {object} model.ChangeRequest
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /approval/changeRequest/{requestId} [get]
+func RestGetChangeRequest(c echo.Context) error {
+	content, err := common.GetChangeRequest(c.Param("requestId"))
+	if err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// RestPostApproveChangeRequest godoc
+// @ID PostApproveChangeRequest
+// @Summary Approve a change request
+// @Description Approve a pending change request. The approver is the authenticated caller and must not be the requester.
+// @Description The operation is executed in the background; poll the change request for the result.
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param requestId path string true "Change request ID"
+// @Param decision body model.ChangeRequestDecision false "Approval comment"
+// @Success 200 {object} model.ChangeRequest
+// @Failure 401 {object} model.SimpleMsg
+// @Failure 403 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 409 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /approval/changeRequest/{requestId}/approve [post]
+func RestPostApproveChangeRequest(c echo.Context) error {
+	return decideChangeRequest(c, common.ApproveChangeRequest)
+}
+
+// RestPostRejectChangeRequest godoc
+// @ID PostRejectChangeRequest
+// @Summary Reject a change request
+// @Description Reject a pending change request. The approver is the authenticated caller and must not be the requester.
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param requestId path string true "Change request ID"
+// @Param decision body model.ChangeRequestDecision false "Rejection comment"
+// @Success 200 {object} model.ChangeRequest
+// @Failure 401 {object} model.SimpleMsg
+// @Failure 403 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 409 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /approval/changeRequest/{requestId}/reject [post]
+func RestPostRejectChangeRequest(c echo.Context) error {
+	return decideChangeRequest(c, common.RejectChangeRequest)
+}
+
+func decideChangeRequest(c echo.Context, decide func(id, approver, comment string) (model.ChangeRequest, error)) error {
+	req := model.ChangeRequestDecision{}
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, model.SimpleMsg{Message: err.Error()})
+		}
+	}
+	// Only an authenticated identity may decide; a name in the body could be anyone
+	approver := authmw.ApprovalSubject(c)
+	if approver == "" {
+		return c.JSON(http.StatusUnauthorized, model.SimpleMsg{Message: "deciding a change request requires an authenticated approver"})
+	}
+	content, err := decide(c.Param("requestId"), approver, req.Comment)
+	if err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
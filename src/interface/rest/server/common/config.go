@@ -21,7 +21,6 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -30,6 +29,7 @@ import (
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
 )
 
 // RestInitConfig godoc
@@ -164,10 +164,32 @@ func RestInitAllConfig(c echo.Context) error {
 	return clientManager.EndRequestWithLog(c, err, content)
 }
 
+// requestVisibility returns whether the caller may see a request: with RBAC, only requests
+// to routes the caller may read, in the namespaces where it may read them.
+// It returns nil (everything is visible) when RBAC is not active.
+func requestVisibility(c echo.Context) func(clientManager.RequestDetails) bool {
+	role, _ := c.Get("role").(string)
+	if role == "" {
+		return nil
+	}
+	subject := authmw.RbacSubject(c)
+	return func(d clientManager.RequestDetails) bool {
+		group := common.RbacGroupOf(d.Route, d.RequestInfo.Method)
+		if group == "" {
+			// Unmatched routes (e.g., 404) are only visible to those who may read all requests
+			group = model.RbacGroupGlobal
+		}
+		return common.Authorize(subject, role, d.NsId, group, model.RbacVerbRead) == nil
+	}
+}
+
 // RestGetRequest godoc
 // @ID GetRequest
 // @Summary Get request details
-// @Description Get details of a specific request
+// @Description Get details of a specific request, in progress or from the persisted request history,
+// @Description with the records it spawned (provisioning events, remote commands) in "links".
+// @Description With RBAC, only requests to routes the caller may read (in its namespaces) are found.
+// @Description Bodies of credential, API token and auth requests are redacted.
 // @Tags [Admin] API Request Management
 // @Accept  json
 // @Produce  json
@@ -180,63 +202,86 @@ func RestInitAllConfig(c echo.Context) error {
 func RestGetRequest(c echo.Context) error {
 	reqId := c.Param("reqId")
 
-	if details, ok := clientManager.RequestMap.Load(reqId); ok {
-		return Send(c, http.StatusOK, details)
+	details, found, err := clientManager.GetRequest(reqId)
+	if err != nil {
+		return SendMessage(c, http.StatusInternalServerError, err.Error())
 	}
-
-	return SendMessage(c, http.StatusNotFound, "Request ID not found")
+	if visible := requestVisibility(c); found && visible != nil && !visible(details) {
+		found = false
+	}
+	if !found {
+		return SendMessage(c, http.StatusNotFound, "Request ID not found")
+	}
+	return Send(c, http.StatusOK, details)
 }
 
 // RestGetAllRequests godoc
 // @ID GetAllRequests
 // @Summary Get all requests
-// @Description Get details of all requests with optional filters.
+// @Description Get details of requests in progress and in the persisted request history (newest first) with optional filters and paging.
+// @Description The history is kept for TB_REQUEST_HISTORY_RETENTION (default 168h) up to TB_REQUEST_HISTORY_MAX_ENTRIES (default 10000).
+// @Description With RBAC, only requests to routes the caller may read (in its namespaces) are listed.
+// @Description Bodies of credential, API token and auth requests are redacted.
 // @Tags [Admin] API Request Management
 // @Accept  json
 // @Produce  json
 // @Param status query string false "Filter by request status (Handling, Error, Success)" Enums(Handling, Error, Success) default()
 // @Param method query string false "Filter by HTTP method (GET, POST, PUT, DELETE, etc.)" Enums(GET, POST, PUT, DELETE) default()
 // @Param url query string false "Filter by request URL"
+// @Param route query string false "Filter by route (e.g., /tumblebug/ns/:nsId/infra)"
+// @Param nsId query string false "Filter by namespace"
+// @Param user query string false "Filter by authenticated user"
 // @Param time query string false "Filter by time in minutes from now (to get recent requests)"
+// @Param from query string false "Filter by start time from (RFC3339)"
+// @Param to query string false "Filter by start time to (RFC3339)"
 // @Param source query string false "Filter by X-Request-Source header (e.g., mcp)"
+// @Param offset query int false "Number of requests to skip" default(0)
+// @Param limit query int false "Maximum number of requests to return (0 for all)" default(0)
 // @Param brief query string false "Option to omit request/response bodies from the results (set 'true' to activate)" Enums(true,false) default(false)
 // @Param savefile query string false "Option to save the results to a file (set 'true' to activate)" Enums(true,false) default(false)
-// @Success 200 {object} map[string][]clientManager.RequestDetails
+// @Success 200 {object} clientManager.RequestDetailsList
+// @Failure 400 {object} model.SimpleMsg
 // @Param x-request-id header string false "Custom request ID for tracking"
 // @Router /requests [get]
 func RestGetAllRequests(c echo.Context) error {
-	// Filter parameters
-	statusFilter := strings.ToLower(c.QueryParam("status"))
-	methodFilter := strings.ToLower(c.QueryParam("method"))
-	urlFilter := strings.ToLower(c.QueryParam("url"))
-	timeFilter := c.QueryParam("time") // in minutes
-	sourceFilter := c.QueryParam("source")
-	brief := c.QueryParam("brief") == "true"
-
-	var timeLimit time.Time
-	if minutes, err := strconv.Atoi(timeFilter); err == nil {
-		timeLimit = time.Now().Add(-time.Duration(minutes) * time.Minute)
+	filter := clientManager.RequestHistoryFilter{
+		Status:  c.QueryParam("status"),
+		Method:  c.QueryParam("method"),
+		URL:     c.QueryParam("url"),
+		Route:   c.QueryParam("route"),
+		NsId:    c.QueryParam("nsId"),
+		User:    c.QueryParam("user"),
+		Source:  c.QueryParam("source"),
+		Brief:   c.QueryParam("brief") == "true",
+		Visible: requestVisibility(c),
 	}
-
-	var allRequests []clientManager.RequestDetails
-
-	// Filtering the requests
-	clientManager.RequestMap.Range(func(key, value any) bool {
-		if details, ok := value.(clientManager.RequestDetails); ok {
-			if (statusFilter == "" || strings.ToLower(details.Status) == statusFilter) &&
-				(methodFilter == "" || strings.ToLower(details.RequestInfo.Method) == methodFilter) &&
-				(urlFilter == "" || strings.Contains(strings.ToLower(details.RequestInfo.URL), urlFilter)) &&
-				(timeFilter == "" || details.StartTime.After(timeLimit)) &&
-				(sourceFilter == "" || strings.EqualFold(details.RequestInfo.Header["X-Request-Source"], sourceFilter)) {
-				if brief {
-					details.RequestInfo.Body = nil
-					details.ResponseData = nil
-				}
-				allRequests = append(allRequests, details)
+	if minutes, err := strconv.Atoi(c.QueryParam("time")); err == nil {
+		filter.From = time.Now().Add(-time.Duration(minutes) * time.Minute)
+	}
+	for param, t := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := c.QueryParam(param); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return SendMessage(c, http.StatusBadRequest, fmt.Sprintf("invalid '%s' (RFC3339 expected): %v", param, err))
 			}
+			*t = parsed
 		}
-		return true
-	})
+	}
+	for param, n := range map[string]*int{"offset": &filter.Offset, "limit": &filter.Limit} {
+		if v := c.QueryParam(param); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 0 {
+				return SendMessage(c, http.StatusBadRequest, fmt.Sprintf("invalid '%s': must be a non-negative integer", param))
+			}
+			*n = parsed
+		}
+	}
+
+	list, err := clientManager.QueryRequests(filter)
+	if err != nil {
+		return SendMessage(c, http.StatusInternalServerError, err.Error())
+	}
+	allRequests := list.Requests
 
 	// Option to save the filtered results to a file
 	if c.QueryParam("savefile") == "true" {
@@ -279,13 +324,13 @@ func RestGetAllRequests(c echo.Context) error {
 	}
 
 	// Return the filtered requests data when savefile is not requested
-	return Send(c, http.StatusOK, map[string][]clientManager.RequestDetails{"requests": allRequests})
+	return Send(c, http.StatusOK, list)
 }
 
 // RestDeleteRequest godoc
 // @ID DeleteRequest
 // @Summary Delete a specific request's details
-// @Description Delete details of a specific request
+// @Description Delete details of a specific request (also from the request history)
 // @Tags [Admin] API Request Management
 // @Accept  json
 // @Produce  json
@@ -296,18 +341,20 @@ func RestGetAllRequests(c echo.Context) error {
 func RestDeleteRequest(c echo.Context) error {
 	reqId := c.Param("reqId")
 
-	if _, ok := clientManager.RequestMap.Load(reqId); ok {
-		clientManager.RequestMap.Delete(reqId)
-		return SendMessage(c, http.StatusOK, "Request deleted successfully")
+	found, err := clientManager.DeleteRequest(reqId)
+	if err != nil {
+		return SendMessage(c, http.StatusInternalServerError, err.Error())
 	}
-
-	return SendMessage(c, http.StatusNotFound, "Request ID not found")
+	if !found {
+		return SendMessage(c, http.StatusNotFound, "Request ID not found")
+	}
+	return SendMessage(c, http.StatusOK, "Request deleted successfully")
 }
 
 // RestDeleteAllRequests godoc
 // @ID DeleteAllRequests
 // @Summary Delete all requests' details
-// @Description Delete details of all requests
+// @Description Delete details of all requests (also the request history)
 // @Tags [Admin] API Request Management
 // @Accept  json
 // @Produce  json
@@ -315,10 +362,8 @@ func RestDeleteRequest(c echo.Context) error {
 // @Param x-request-id header string false "Custom request ID for tracking"
 // @Router /requests [delete]
 func RestDeleteAllRequests(c echo.Context) error {
-	clientManager.RequestMap.Range(func(key, value any) bool {
-		clientManager.RequestMap.Delete(key)
-		return true
-	})
-
+	if err := clientManager.DeleteAllRequests(); err != nil {
+		return SendMessage(c, http.StatusInternalServerError, err.Error())
+	}
 	return SendMessage(c, http.StatusOK, "All requests deleted successfully")
 }
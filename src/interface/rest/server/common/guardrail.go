@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to handle REST API for common funcitonalities
+package common
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+)
+
+// RestPutGuardrailPolicy godoc
+// @ID PutGuardrailPolicy
+// @Summary Set namespace guardrail policy
+// @Description Set (replace) the guardrail policy of a namespace. Rules are evaluated before any CSP call when
+// @Description creating Infra/NodeGroups (and in Infra review), security groups, firewall rules and K8s clusters.
+// @Description Rule types: requiredLabels (values: label keys or key=value), allowedImages / allowedSpecs (values: glob patterns),
+// @Description denyPublicIp, denyIngress (cidrs default 0.0.0.0/0 and ::/0; ports and protocols default all).
+// @Description Requests violating a deny rule are rejected with 400 and the list of violations in "details";
+// @Description violations of warn rules are only reported in Infra review and logs.
+// @Tags [Admin] System Configuration
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param policy body model.GuardrailPolicyReq true "Guardrail policy"
+// @Success 200 {object} model.GuardrailPolicy
+// @Failure 400 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/guardrail [put]
+func RestPutGuardrailPolicy(c echo.Context) error {
+
+	if err := Validate(c, []string{"nsId"}); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	req := &model.GuardrailPolicyReq{}
+	if err := c.Bind(req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	content, err := common.SetGuardrailPolicy(c.Param("nsId"), req)
+	return clientManager.EndRequestWithLog(c, err, content)
+}
+
+// RestGetGuardrailPolicy godoc
+// @ID GetGuardrailPolicy
+// @Summary Get namespace guardrail policy
+// @Description Get the guardrail policy of a namespace (no rules when not set)
+// @Tags [Admin] System Configuration
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Success 200 {object} model.GuardrailPolicy
+// @Failure 400 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/guardrail [get]
+func RestGetGuardrailPolicy(c echo.Context) error {
+
+	if err := Validate(c, []string{"nsId"}); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	content, err := common.GetGuardrailPolicy(c.Param("nsId"))
+	return clientManager.EndRequestWithLog(c, err, content)
+}
+
+// RestDelGuardrailPolicy godoc
+// @ID DelGuardrailPolicy
+// @Summary Delete namespace guardrail policy
+// @Description Delete the guardrail policy of a namespace
+// @Tags [Admin] System Configuration
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Success 200 {object} model.SimpleMsg
+// @Failure 400 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/guardrail [delete]
+func RestDelGuardrailPolicy(c echo.Context) error {
+
+	if err := Validate(c, []string{"nsId"}); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	err := common.DeleteGuardrailPolicy(c.Param("nsId"))
+	content := map[string]string{"message": "The guardrail policy of ns " + c.Param("nsId") + " has been deleted"}
+	return clientManager.EndRequestWithLog(c, err, content)
+}
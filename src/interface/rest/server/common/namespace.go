@@ -15,11 +15,14 @@ limitations under the License.
 package common
 
 import (
+	"net/http"
+
 	"github.com/labstack/echo/v4"
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
 )
 
 func RestCheckNs(c echo.Context) error {
@@ -45,12 +48,21 @@ func RestCheckNs(c echo.Context) error {
 // @Accept  json
 // @Produce  json
 // @Success 200 {object} model.SimpleMsg
+// @Success 202 {object} model.ChangeRequest "Parked as a pending change request (approval policy)"
 // @Failure 404 {object} model.SimpleMsg
 // @Param x-request-id header string false "Custom request ID for tracking"
 // @Router /ns [delete]
 func RestDelAllNs(c echo.Context) error {
 
-	err := common.DelAllNs()
+	cr, err := common.RequestDeleteAllNsApproval(authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
+	err = common.DelAllNs()
 	content := map[string]string{"message": "All namespaces has been deleted"}
 	return clientManager.EndRequestWithLog(c, err, content)
 }
@@ -194,3 +206,105 @@ func RestPutNs(c echo.Context) error {
 	content, err := common.UpdateNs(c.Param("nsId"), u)
 	return clientManager.EndRequestWithLog(c, err, content)
 }
+
+// RestPutNsQuota godoc
+// @ID PutNsQuota
+// @Summary Set namespace quota
+// @Description Set (replace) the resource quota of a namespace. Zero or empty fields are unlimited.
+// @Description The quota is checked before any CSP call when creating Infra, NodeGroups (including scale-out),
+// @Description K8s clusters and object storages in the namespace.
+// @Tags [Admin] System Configuration
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param quota body model.NsQuota true "Namespace quota"
+// @Success 200 {object} model.NsInfo
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/quota [put]
+func RestPutNsQuota(c echo.Context) error {
+
+	if err := Validate(c, []string{"nsId"}); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	quota := model.NsQuota{}
+	if err := c.Bind(&quota); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	content, err := common.SetNsQuota(c.Param("nsId"), quota)
+	return clientManager.EndRequestWithLog(c, err, content)
+}
+
+// RestGetNsQuota godoc
+// @ID GetNsQuota
+// @Summary Get namespace quota
+// @Description Get the resource quota of a namespace (empty when unlimited)
+// @Tags [Admin] System Configuration
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Success 200 {object} model.NsQuota
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/quota [get]
+func RestGetNsQuota(c echo.Context) error {
+
+	if err := Validate(c, []string{"nsId"}); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	quota, err := common.GetNsQuota(c.Param("nsId"))
+	if quota == nil {
+		quota = &model.NsQuota{}
+	}
+	return clientManager.EndRequestWithLog(c, err, quota)
+}
+
+// RestDelNsQuota godoc
+// @ID DelNsQuota
+// @Summary Delete namespace quota
+// @Description Delete the resource quota of a namespace, making it unlimited
+// @Tags [Admin] System Configuration
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Success 200 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/quota [delete]
+func RestDelNsQuota(c echo.Context) error {
+
+	if err := Validate(c, []string{"nsId"}); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	err := common.DeleteNsQuota(c.Param("nsId"))
+	content := map[string]string{"message": "The quota of ns " + c.Param("nsId") + " has been deleted"}
+	return clientManager.EndRequestWithLog(c, err, content)
+}
+
+// RestGetNsQuotaUsage godoc
+// @ID GetNsQuotaUsage
+// @Summary Get namespace resource usage against quota
+// @Description Get the current resource consumption of a namespace (Nodes not Terminated, vCPU, memory, GPUs,
+// @Description hourly cost from spec prices, K8s clusters, object storages) together with its quota
+// @Tags [Admin] System Configuration
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Success 200 {object} model.NsQuotaUsage
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/quota/usage [get]
+func RestGetNsQuotaUsage(c echo.Context) error {
+
+	if err := Validate(c, []string{"nsId"}); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	content, err := common.GetNsQuotaUsage(c.Param("nsId"))
+	return clientManager.EndRequestWithLog(c, err, content)
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common is to handle REST API for common funcitonalities
+package common
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+)
+
+// RestPostRbacRole godoc
+// @ID PostRbacRole
+// @Summary Create or update a custom role
+// @Description Create or replace a custom RBAC role. A role is a list of rules granting verbs (read, write, delete or *)
+// @Description on route groups (system, credential, namespace, rbac, catalog, infra, resource, k8s or *).
+// @Description Built-in roles (maintainer, admin, user, guest) cannot be modified.
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param roleReq body model.RbacRoleReq true "Role definition"
+// @Success 200 {object} model.RbacRole
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 409 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /rbac/role [post]
+func RestPostRbacRole(c echo.Context) error {
+	req := &model.RbacRoleReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.SimpleMsg{Message: err.Error()})
+	}
+	content, err := common.CreateOrUpdateRbacRole(req)
+	if err != nil {
+		code := apierr.Code(err)
+		if code == http.StatusInternalServerError {
+			code = http.StatusBadRequest
+		}
+		return c.JSON(code, model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// RestGetRbacRole godoc
+// @ID GetRbacRole
+// @Summary Get a role
+// @Description Get a built-in or custom RBAC role
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param roleName path string true "Role name" default(user)
+// @Success 200 {object} model.RbacRole
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /rbac/role/{roleName} [get]
+func RestGetRbacRole(c echo.Context) error {
+	content, err := common.GetRbacRole(c.Param("roleName"))
+	if err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// RestGetAllRbacRole godoc
+// @ID GetAllRbacRole
+// @Summary List roles
+// @Description List built-in and custom RBAC roles
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.RbacRoleList
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /rbac/role [get]
+func RestGetAllRbacRole(c echo.Context) error {
+	roles, err := common.ListRbacRoles()
+	if err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, model.RbacRoleList{Roles: roles})
+}
+
+// RestDelRbacRole godoc
+// @ID DelRbacRole
+// @Summary Delete a custom role
+// @Description Delete a custom RBAC role. Roles still referenced by a role binding cannot be deleted.
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param roleName path string true "Role name"
+// @Success 200 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 409 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /rbac/role/{roleName} [delete]
+func RestDelRbacRole(c echo.Context) error {
+	roleName := c.Param("roleName")
+	if err := common.DeleteRbacRole(roleName); err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, model.SimpleMsg{Message: "The role " + roleName + " has been deleted"})
+}
+
+// RestPostRoleBinding godoc
+// @ID PostRoleBinding
+// @Summary Bind a role to a subject in a namespace
+// @Description Bind a role to a subject (user ID from the access token) within the namespace.
+// @Description The binding adds the role's permissions to the subject's realm role for routes under /ns/{nsId}.
+// @Description An existing binding of the same subject in the namespace is replaced.
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param bindingReq body model.RoleBindingReq true "Role binding"
+// @Success 200 {object} model.RoleBinding
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/rbac/binding [post]
+func RestPostRoleBinding(c echo.Context) error {
+	req := &model.RoleBindingReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.SimpleMsg{Message: err.Error()})
+	}
+	content, err := common.CreateOrUpdateRoleBinding(c.Param("nsId"), req)
+	if err != nil {
+		code := apierr.Code(err)
+		if code == http.StatusInternalServerError {
+			code = http.StatusBadRequest
+		}
+		return c.JSON(code, model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// RestGetRoleBinding godoc
+// @ID GetRoleBinding
+// @Summary Get the role binding of a subject in a namespace
+// @Description Get the role binding of a subject in a namespace
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param subject path string true "Subject (user ID)"
+// @Success 200 {object} model.RoleBinding
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/rbac/binding/{subject} [get]
+func RestGetRoleBinding(c echo.Context) error {
+	content, err := common.GetRoleBinding(c.Param("nsId"), subjectParam(c))
+	if err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// RestGetAllRoleBinding godoc
+// @ID GetAllRoleBinding
+// @Summary List role bindings of a namespace
+// @Description List role bindings of a namespace
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Success 200 {object} model.RoleBindingList
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/rbac/binding [get]
+func RestGetAllRoleBinding(c echo.Context) error {
+	bindings, err := common.ListRoleBindings(c.Param("nsId"))
+	if err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, model.RoleBindingList{Bindings: bindings})
+}
+
+// RestDelRoleBinding godoc
+// @ID DelRoleBinding
+// @Summary Delete the role binding of a subject in a namespace
+// @Description Delete the role binding of a subject in a namespace
+// @Tags [Admin] Access Control
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param subject path string true "Subject (user ID)"
+// @Success 200 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/rbac/binding/{subject} [delete]
+func RestDelRoleBinding(c echo.Context) error {
+	nsId := c.Param("nsId")
+	subject := subjectParam(c)
+	if err := common.DeleteRoleBinding(nsId, subject); err != nil {
+		return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, model.SimpleMsg{Message: "The role binding of " + subject + " in ns " + nsId + " has been deleted"})
+}
+
+// subjectParam returns the unescaped subject path parameter (user IDs may contain reserved characters).
+func subjectParam(c echo.Context) string {
+	subject := c.Param("subject")
+	if unescaped, err := url.PathUnescape(subject); err == nil {
+		return unescaped
+	}
+	return subject
+}
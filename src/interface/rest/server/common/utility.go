@@ -298,6 +298,21 @@ func RestGetOpenBaoStatus(c echo.Context) error {
 	return clientManager.EndRequestWithLog(c, nil, status)
 }
 
+// RestGetSecretStoreStatus godoc
+// @ID GetSecretStoreStatus
+// @Summary Check secret store availability for credential storage
+// @Description Verifies that the secret store selected by TB_SECRET_STORE (openbao or file) can store and read CSP credentials. Use before credential registration to detect misconfiguration that would otherwise fail silently and break direct CSP API features.
+// @Tags [Admin] Cloud Credential Management
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.SecretStoreStatusInfo
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /credential/secretStoreStatus [get]
+func RestGetSecretStoreStatus(c echo.Context) error {
+	status := csp.CheckSecretStoreStatus(c.Request().Context())
+	return clientManager.EndRequestWithLog(c, nil, status)
+}
+
 // RestRegisterCredential is a REST API handler for registering credentials.
 // @ID RegisterCredential
 // @Summary Register Credential Information
@@ -323,6 +338,87 @@ func RestRegisterCredential(c echo.Context) error {
 
 }
 
+// RestPostRotateCredential is a REST API handler for rotating credentials.
+// @ID RotateCredential
+// @Summary Rotate Credential Information
+// @Description Rotate the credential of an already registered credential holder and provider. The request is encrypted in the same way as `POST /credential`.
+// @Description The new credential is written as a new secret version (OpenBao by default, see TB_SECRET_STORE), existing connections are re-registered and re-validated,
+// @Description and cached CSP clients of the provider are invalidated. The response lists the affected connection configs.
+// @Tags [Admin] Cloud Credential Management
+// @Accept json
+// @Produce json
+// @Param CredentialReq body model.CredentialReq true "Credential request info"
+// @Success 200 {object} model.CredentialRotationResult
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /credential/rotate [post]
+func RestPostRotateCredential(c echo.Context) error {
+
+	u := &model.CredentialReq{}
+	if err := c.Bind(u); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	content, err := common.RotateCredential(*u)
+	return clientManager.EndRequestWithLog(c, err, content)
+
+}
+
+// RestGetAllCredentialStatus is a REST API handler for listing credential status.
+// @ID GetAllCredentialStatus
+// @Summary List credential status
+// @Description List registration, rotation, expiry and validation status of all credentials (per credential holder and provider)
+// @Tags [Admin] Cloud Credential Management
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.CredentialStatusList
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /credential/status [get]
+func RestGetAllCredentialStatus(c echo.Context) error {
+	list, err := common.ListCredentialStatus()
+	return clientManager.EndRequestWithLog(c, err, model.CredentialStatusList{Credentials: list})
+}
+
+// RestGetCredentialStatus is a REST API handler for getting credential status.
+// @ID GetCredentialStatus
+// @Summary Get credential status
+// @Description Get registration, rotation, expiry and validation history of the credential of a credential holder and provider
+// @Tags [Admin] Cloud Credential Management
+// @Accept json
+// @Produce json
+// @Param credentialHolder path string true "Credential holder" default(admin)
+// @Param providerName path string true "Provider name" default(aws)
+// @Success 200 {object} model.CredentialStatus
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /credential/status/{credentialHolder}/{providerName} [get]
+func RestGetCredentialStatus(c echo.Context) error {
+	content, err := common.GetCredentialStatus(c.Param("credentialHolder"), c.Param("providerName"))
+	return clientManager.EndRequestWithLog(c, err, content)
+}
+
+// RestPostValidateCredential is a REST API handler for validating credentials.
+// @ID ValidateCredential
+// @Summary Validate credential
+// @Description Validate the credential of a credential holder and provider now by calling the CSP through representative connections.
+// @Description The result is recorded in the validation history; credentials are also validated periodically (TB_CREDENTIAL_VALIDATION_INTERVAL).
+// @Tags [Admin] Cloud Credential Management
+// @Accept json
+// @Produce json
+// @Param credentialHolder path string true "Credential holder" default(admin)
+// @Param providerName path string true "Provider name" default(aws)
+// @Success 200 {object} model.CredentialStatus
+// @Failure 404 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /credential/status/{credentialHolder}/{providerName}/validate [post]
+func RestPostValidateCredential(c echo.Context) error {
+	content, err := common.ValidateCredential(c.Param("credentialHolder"), c.Param("providerName"))
+	return clientManager.EndRequestWithLog(c, err, content)
+}
+
 // RestGetConnConfig func is a rest api wrapper for GetConnConfig.
 // RestGetConnConfig godoc
 // @ID GetConnConfig
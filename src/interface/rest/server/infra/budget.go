@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to handle REST API for infra
+package infra
+
+import (
+	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
+	"github.com/cloud-barista/cb-tumblebug/src/core/infra"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
+	"github.com/labstack/echo/v4"
+)
+
+// RestPostBudget godoc
+// @ID PostBudget
+// @Summary Create a budget
+// @Description Create a daily or monthly budget for a namespace, or for the Infras with a label (labelKey/labelValue).
+// @Description Spend is the actual cost of the period from the node usage records. Each threshold (default: 50, 80, 100%)
+// @Description emits a budget event once per period, posted to the webhook when one is set.
+// @Description On exhaustion, enforcement "block" rejects new provisioning in the scope and "suspend" also suspends
+// @Description the running Infras in the scope; an override (/budget/{budgetId}/override) lifts the enforcement.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param budgetReq body model.BudgetReq true "Budget request"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.BudgetInfo
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 409 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/budget [post]
+func RestPostBudget(c echo.Context) error {
+	req := model.BudgetReq{}
+	if err := c.Bind(&req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	result, err := infra.CreateBudget(c.Param("nsId"), req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestGetAllBudget godoc
+// @ID GetAllBudget
+// @Summary List budgets
+// @Description List the budgets of a namespace with their current spend and recent events
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.BudgetList
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/budget [get]
+func RestGetAllBudget(c echo.Context) error {
+	result, err := infra.ListBudget(c.Param("nsId"))
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestGetBudget godoc
+// @ID GetBudget
+// @Summary Get a budget
+// @Description Get a budget with its current spend and recent events. Set refresh=true to evaluate the budget first.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param budgetId path string true "Budget ID" default(team-a-monthly)
+// @Param refresh query boolean false "Evaluate the budget before returning it" default(false)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.BudgetInfo
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/budget/{budgetId} [get]
+func RestGetBudget(c echo.Context) error {
+	if c.QueryParam("refresh") == "true" {
+		result, err := infra.EvaluateBudget(c.Param("nsId"), c.Param("budgetId"))
+		return clientManager.EndRequestWithLog(c, err, result)
+	}
+	result, err := infra.GetBudget(c.Param("nsId"), c.Param("budgetId"))
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPutBudget godoc
+// @ID PutBudget
+// @Summary Update a budget
+// @Description Update the amount, period, scope, thresholds, webhook or enforcement of a budget.
+// @Description A change of period or scope starts the budget over; the budget is evaluated again right away.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param budgetId path string true "Budget ID" default(team-a-monthly)
+// @Param budgetReq body model.BudgetReq true "Budget request"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.BudgetInfo
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/budget/{budgetId} [put]
+func RestPutBudget(c echo.Context) error {
+	req := model.BudgetReq{}
+	if err := c.Bind(&req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	result, err := infra.UpdateBudget(c.Param("nsId"), c.Param("budgetId"), req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestDeleteBudget godoc
+// @ID DeleteBudget
+// @Summary Delete a budget
+// @Description Delete a budget (suspended Infras are not resumed)
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param budgetId path string true "Budget ID" default(team-a-monthly)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/budget/{budgetId} [delete]
+func RestDeleteBudget(c echo.Context) error {
+	err := infra.DeleteBudget(c.Param("nsId"), c.Param("budgetId"))
+	content := map[string]string{"message": "The budget " + c.Param("budgetId") + " has been deleted"}
+	return clientManager.EndRequestWithLog(c, err, content)
+}
+
+// RestPostBudgetOverride godoc
+// @ID PostBudgetOverride
+// @Summary Override the enforcement of a budget
+// @Description Lift the enforcement of a budget for durationMinutes (default: until the end of the current period).
+// @Description Provisioning is allowed and Infras are not suspended while the override is active; alerts are still emitted.
+// @Description The override is recorded as a budget event with the requester and the reason.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param budgetId path string true "Budget ID" default(team-a-monthly)
+// @Param overrideReq body model.BudgetOverrideReq true "Budget override request"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.BudgetInfo
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/budget/{budgetId}/override [post]
+func RestPostBudgetOverride(c echo.Context) error {
+	req := model.BudgetOverrideReq{}
+	if err := c.Bind(&req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	result, err := infra.OverrideBudget(c.Param("nsId"), c.Param("budgetId"), req, authmw.RbacSubject(c))
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestDeleteBudgetOverride godoc
+// @ID DeleteBudgetOverride
+// @Summary Cancel the override of a budget
+// @Description Cancel the active override of a budget so that its enforcement applies again
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param budgetId path string true "Budget ID" default(team-a-monthly)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.BudgetInfo
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/budget/{budgetId}/override [delete]
+func RestDeleteBudgetOverride(c echo.Context) error {
+	result, err := infra.DeleteBudgetOverride(c.Param("nsId"), c.Param("budgetId"))
+	return clientManager.EndRequestWithLog(c, err, result)
+}
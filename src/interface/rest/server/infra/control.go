@@ -16,10 +16,12 @@ package infra
 
 import (
 	"fmt"
+	"net/http"
 
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/infra"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
 	"github.com/labstack/echo/v4"
 )
 
@@ -59,6 +61,7 @@ import (
 // @Failure 404 {object} model.SimpleMsg
 // @Failure 500 {object} model.SimpleMsg
 // @Param x-request-id header string false "Custom request ID for tracking"
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key and body returns the first response instead of running again"
 // @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
 // @Router /ns/{nsId}/control/infra/{infraId} [get]
 func RestGetControlInfra(c echo.Context) error {
@@ -77,7 +80,7 @@ func RestGetControlInfra(c echo.Context) error {
 	switch action {
 	case "suspend", "resume", "reboot", "terminate", "refine",
 		"continue", "withdraw", "reconcile", "abort":
-		resultString, err := infra.HandleInfraAction(nsId, infraId, action, forceOption)
+		resultString, err := infra.HandleInfraAction(c.Request().Context(), nsId, infraId, action, forceOption)
 		if err != nil {
 			return clientManager.EndRequestWithLog(c, err, returnObj)
 		}
@@ -91,20 +94,33 @@ func RestGetControlInfra(c echo.Context) error {
 
 // RestGetControlInfraNode godoc
 // @ID GetControlInfraNode
-// @Summary Control the lifecycle of node (suspend, resume, reboot, terminate)
-// @Description Control the lifecycle of node (suspend, resume, reboot, terminate)
+// @Summary Control the lifecycle of node (suspend, resume, reboot, terminate, resize)
+// @Description Control the lifecycle of node (suspend, resume, reboot, terminate, resize)
+// @Description
+// @Description **resize** changes the spec of the node in place to `specId` (same provider and region).
+// @Description The target spec is validated by the spec-image review and the CSP availability check,
+// @Description and is subject to the namespace quota (for the growth of the node), guardrails and budgets.
+// @Description An upsize whose cost exceeds the approval threshold is parked as a change request (202).
+// @Description Depending on the provider, the node is stopped, resized and started again (AWS, GCP),
+// @Description or resized with a restart done by the CSP (Azure). CB-Spider has no resize API,
+// @Description so other providers are rejected with the list of supported providers.
+// @Description The resize runs in the background; its outcome is recorded in the node's systemMessage,
+// @Description and the node's specId and spec (including costPerHour) are updated on success.
 // @Tags [MC-Infra] Infra Provisioning and Management
 // @Accept  json
 // @Produce  json
 // @Param nsId path string true "Namespace ID" default(default)
 // @Param infraId path string true "Infra ID" default(infra01)
 // @Param nodeId path string true "Node ID" default(g1-1)
-// @Param action query string true "Action to Infra" Enums(suspend, resume, reboot, terminate)
+// @Param action query string true "Action to Infra" Enums(suspend, resume, reboot, terminate, resize)
+// @Param specId query string false "Target spec ID (required for resize)" default(aws+ap-northeast-2+t3.large)
 // @Param force query string false "Force control to skip checking controllable status" Enums(false, true)
 // @Success 200 {object} model.SimpleMsg
+// @Success 202 {object} model.ChangeRequest
 // @Failure 404 {object} model.SimpleMsg
 // @Failure 500 {object} model.SimpleMsg
 // @Param x-request-id header string false "Custom request ID for tracking"
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key and body returns the first response instead of running again"
 // @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
 // @Router /ns/{nsId}/control/infra/{infraId}/node/{nodeId} [get]
 func RestGetControlInfraNode(c echo.Context) error {
@@ -114,6 +130,7 @@ func RestGetControlInfraNode(c echo.Context) error {
 	nodeId := c.Param("nodeId")
 
 	action := c.QueryParam("action")
+	specId := c.QueryParam("specId")
 	force := c.QueryParam("force")
 	forceOption := false
 	if force == "true" {
@@ -122,9 +139,19 @@ func RestGetControlInfraNode(c echo.Context) error {
 
 	returnObj := model.SimpleMsg{}
 
-	if action == "suspend" || action == "resume" || action == "reboot" || action == "terminate" {
+	if action == "suspend" || action == "resume" || action == "reboot" || action == "terminate" || action == "resize" {
+
+		if action == "resize" {
+			cr, err := infra.RequestNodeResizeApproval(c.Request().Context(), nsId, infraId, nodeId, specId, authmw.ApprovalSubject(c))
+			if err != nil {
+				return clientManager.EndRequestWithLog(c, err, returnObj)
+			}
+			if cr != nil {
+				return c.JSON(http.StatusAccepted, cr)
+			}
+		}
 
-		resultString, err := infra.HandleInfraNodeAction(nsId, infraId, nodeId, action, forceOption)
+		resultString, err := infra.HandleInfraNodeAction(c.Request().Context(), nsId, infraId, nodeId, action, forceOption, specId)
 		if err != nil {
 			return clientManager.EndRequestWithLog(c, err, returnObj)
 		}
@@ -132,7 +159,7 @@ func RestGetControlInfraNode(c echo.Context) error {
 		return clientManager.EndRequestWithLog(c, err, returnObj)
 
 	} else {
-		err := fmt.Errorf("'action' should be one of these: suspend, resume, reboot, terminate, refine")
+		err := fmt.Errorf("'action' should be one of these: suspend, resume, reboot, terminate, resize")
 		return clientManager.EndRequestWithLog(c, err, returnObj)
 	}
 }
@@ -0,0 +1,201 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to handle REST API for infra
+package infra
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
+	"github.com/cloud-barista/cb-tumblebug/src/core/infra"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/labstack/echo/v4"
+)
+
+// RestGetInfraCost godoc
+// @ID GetInfraCost
+// @Summary Get the running cost of an Infra
+// @Description Get the hourly cost and the monthly projection (730 hours) of an Infra in the base currency (USD).
+// @Description Nodes are priced from their spec (spot price for spot Nodes; Suspended Nodes only keep their disks).
+// @Description Root disks of a known size, attached data disks and NLBs are priced from assets/costinfo.yaml where
+// @Description price data exists; other items are listed as unpriced and the result is marked partial.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.CostEstimate
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/infra/{infraId}/cost [get]
+func RestGetInfraCost(c echo.Context) error {
+	result, err := infra.GetInfraCost(c.Param("nsId"), c.Param("infraId"))
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestGetNsCost godoc
+// @ID GetNsCost
+// @Summary Get the running cost of a namespace
+// @Description Get the hourly cost and the monthly projection (730 hours) of every Infra and K8sCluster of a namespace,
+// @Description plus the data disks not attached to any Node, in the base currency (USD) with a subtotal per Infra and K8sCluster.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.CostEstimate
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/cost [get]
+func RestGetNsCost(c echo.Context) error {
+	result, err := infra.GetNsCost(c.Param("nsId"))
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPostInfraDynamicCostEstimate godoc
+// @ID PostInfraDynamicCostEstimate
+// @Summary Estimate the cost of an Infra dynamic request
+// @Description Estimate the hourly cost and the monthly projection (730 hours) of an Infra dynamic request
+// @Description in the base currency (USD), with a subtotal per NodeGroup. Nothing is created or validated against the CSP;
+// @Description use /infraDynamicReview for a full review.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraReq body model.InfraDynamicReq true "Same format as the /infraDynamic request"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.CostEstimate
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/infraDynamicCostEstimate [post]
+func RestPostInfraDynamicCostEstimate(c echo.Context) error {
+	req := &model.InfraDynamicReq{}
+	if err := c.Bind(req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	result, err := infra.EstimateInfraDynamicReqCost(c.Param("nsId"), req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPostNodeGroupDynamicCostEstimate godoc
+// @ID PostNodeGroupDynamicCostEstimate
+// @Summary Estimate the cost of a NodeGroup dynamic request
+// @Description Estimate the hourly cost and the monthly projection (730 hours) that a NodeGroup dynamic request
+// @Description adds to an existing Infra, in the base currency (USD).
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param nodeGroupReq body model.CreateNodeGroupDynamicReq true "Same format as the /nodeGroupDynamic request"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.CostEstimate
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/infra/{infraId}/nodeGroupDynamicCostEstimate [post]
+func RestPostNodeGroupDynamicCostEstimate(c echo.Context) error {
+	req := &model.CreateNodeGroupDynamicReq{}
+	if err := c.Bind(req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	result, err := infra.EstimateNodeGroupDynamicReqCost(c.Param("nsId"), c.Param("infraId"), req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// costUsageReportReqFromQuery reads a CostUsageReportReq from the query string.
+func costUsageReportReqFromQuery(c echo.Context) model.CostUsageReportReq {
+	return model.CostUsageReportReq{
+		From:     c.QueryParam("from"),
+		To:       c.QueryParam("to"),
+		GroupBy:  c.QueryParam("groupBy"),
+		LabelKey: c.QueryParam("labelKey"),
+		InfraId:  c.QueryParam("infraId"),
+	}
+}
+
+// endCostUsageReport returns a CostUsageReport as JSON, or as a CSV attachment when format=csv.
+func endCostUsageReport(c echo.Context, report model.CostUsageReport, err error) error {
+	if err != nil || !strings.EqualFold(c.QueryParam("format"), "csv") {
+		return clientManager.EndRequestWithLog(c, err, report)
+	}
+	data, err := infra.CostUsageReportCSV(report)
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	scope := report.NsId
+	if scope == "" {
+		scope = "all"
+	}
+	c.Response().Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"cost-usage-%s-%s.csv\"", scope, report.GroupBy))
+	return c.Blob(http.StatusOK, "text/csv", data)
+}
+
+// RestGetNsCostUsage godoc
+// @ID GetNsCostUsage
+// @Summary Get the actual spend of a namespace over a time range
+// @Description Get the actual spend of the Nodes of a namespace, computed from the running intervals recorded from
+// @Description the status transitions NodeStatusAgent observes and the spec price snapshot of each Node.
+// @Description Deleted Nodes stay in the report for the time they ran. Results are grouped by Node, Infra, label value
+// @Description (with labelKey, e.g. team) or namespace, and can be exported as CSV with format=csv.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json,text/csv
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param from query string false "Start of the range (RFC3339, default: start of the current month)"
+// @Param to query string false "End of the range (RFC3339, default: now)"
+// @Param groupBy query string false "Grouping of the report" Enums(node, infra, label, namespace) default(infra)
+// @Param labelKey query string false "Label key to group by when groupBy is label" default(team)
+// @Param infraId query string false "Limit the report to one Infra"
+// @Param format query string false "Output format" Enums(json, csv) default(json)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.CostUsageReport
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/costUsage [get]
+func RestGetNsCostUsage(c echo.Context) error {
+	report, err := infra.GetCostUsageReport(c.Param("nsId"), costUsageReportReqFromQuery(c))
+	return endCostUsageReport(c, report, err)
+}
+
+// RestGetAllCostUsage godoc
+// @ID GetAllCostUsage
+// @Summary Get the actual spend of all namespaces over a time range
+// @Description Same as /ns/{nsId}/costUsage across all namespaces (default groupBy: namespace), for chargeback.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json,text/csv
+// @Param from query string false "Start of the range (RFC3339, default: start of the current month)"
+// @Param to query string false "End of the range (RFC3339, default: now)"
+// @Param groupBy query string false "Grouping of the report" Enums(node, infra, label, namespace) default(namespace)
+// @Param labelKey query string false "Label key to group by when groupBy is label" default(team)
+// @Param format query string false "Output format" Enums(json, csv) default(json)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.CostUsageReport
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /costUsage [get]
+func RestGetAllCostUsage(c echo.Context) error {
+	req := costUsageReportReqFromQuery(c)
+	req.InfraId = ""
+	if req.GroupBy == "" {
+		req.GroupBy = model.CostUsageGroupByNamespace
+	}
+	report, err := infra.GetCostUsageReport("", req)
+	return endCostUsageReport(c, report, err)
+}
@@ -24,6 +24,7 @@ import (
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/infra"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
 )
@@ -244,8 +245,10 @@ func RestPutInfra(c echo.Context) error {
 // @Param infraId path string true "Infra ID" default(infra01)
 // @Param option query string false "terminate (default, recommended): terminate CSP nodes then delete records. force: DANGEROUS — deletes CB-TB records without confirming CSP termination, leaving billed orphan instances that also block VNet/SecurityGroup cleanup; use only for stuck infra and verify with /inspectResources afterwards" Enums(terminate,force)
 // @Success 200 {object} model.IdList
+// @Success 202 {object} model.ChangeRequest "Parked as a pending change request (approval policy)"
 // @Failure 404 {object} model.SimpleMsg
 // @Param x-request-id header string false "Custom request ID for tracking"
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key and body returns the first response instead of running again"
 // @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
 // @Router /ns/{nsId}/infra/{infraId} [delete]
 func RestDelInfra(c echo.Context) error {
@@ -254,6 +257,14 @@ func RestDelInfra(c echo.Context) error {
 	infraId := c.Param("infraId")
 	option := c.QueryParam("option")
 
+	cr, err := infra.RequestInfraTerminateApproval(c.Request().Context(), nsId, infraId, option, authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
 	content, err := infra.DelInfra(nsId, infraId, option)
 	return clientManager.EndRequestWithLog(c, err, content)
 }
@@ -268,6 +279,7 @@ func RestDelInfra(c echo.Context) error {
 // @Param nsId path string true "Namespace ID" default(default)
 // @Param option query string false "Option for delete all Infras (support force object delete, terminate before delete)" Enums(force, terminate)
 // @Success 200 {object} model.SimpleMsg
+// @Success 202 {object} model.ChangeRequest "Parked as a pending change request (approval policy)"
 // @Failure 404 {object} model.SimpleMsg
 // @Param x-request-id header string false "Custom request ID for tracking"
 // @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
@@ -277,6 +289,14 @@ func RestDelAllInfra(c echo.Context) error {
 	nsId := c.Param("nsId")
 	option := c.QueryParam("option")
 
+	cr, err := infra.RequestAllInfraTerminateApproval(c.Request().Context(), nsId, option, authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
 	message, err := infra.DelAllInfra(nsId, option)
 	result := model.SimpleMsg{Message: message}
 	return clientManager.EndRequestWithLog(c, err, result)
@@ -18,11 +18,13 @@ import (
 	"fmt"
 	"net/http"
 	"slices"
+	"strconv"
 	"time"
 
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/infra"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
 )
@@ -66,11 +68,13 @@ import (
 // @Param nsId path string true "Namespace ID for resource isolation" default(default)
 // @Param infraReq body model.InfraReq true "Infra creation request with node specifications, networking, and deployment options"
 // @Success 200 {object} model.InfraInfo "Created Infra information with node details, status, and resource mapping"
+// @Success 202 {object} model.ChangeRequest "Estimated cost exceeds the approval threshold; parked as a pending change request"
 // @Failure 400 {object} model.SimpleMsg "Invalid request parameters or missing required fields"
 // @Failure 404 {object} model.SimpleMsg "Namespace not found or specified resources unavailable"
 // @Failure 409 {object} model.SimpleMsg "Infra name already exists in namespace"
 // @Failure 500 {object} model.SimpleMsg "Internal server error during Infra creation or CSP communication failure"
 // @Param x-request-id header string false "Custom request ID for tracking"
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key and body returns the first response instead of running again"
 // @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
 // @Router /ns/{nsId}/infra [post]
 func RestPostInfra(c echo.Context) error {
@@ -84,6 +88,14 @@ func RestPostInfra(c echo.Context) error {
 	}
 
 	option := "create"
+	cr, err := infra.RequestInfraCreateStaticApproval(ctx, nsId, req, option, authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
 	result, err := infra.CreateInfra(ctx, nsId, req, option, false)
 	return clientManager.EndRequestWithLog(c, err, result)
 }
@@ -129,6 +141,7 @@ func RestPostInfra(c echo.Context) error {
 // @Param nsId path string true "Namespace ID for organizing registered resources" default(default)
 // @Param infraReq body model.InfraReq true "Infra registration request containing existing CSP node IDs and connection details"
 // @Success 200 {object} model.InfraInfo "Registered Infra information with imported node details and current status"
+// @Success 202 {object} model.ChangeRequest "Estimated cost exceeds the approval threshold; parked as a pending change request"
 // @Failure 400 {object} model.SimpleMsg "Invalid request format or missing required CSP node identifiers"
 // @Failure 404 {object} model.SimpleMsg "Specified nodes not found in target CSP or namespace doesn't exist"
 // @Failure 409 {object} model.SimpleMsg "node already registered or Infra name conflicts"
@@ -147,6 +160,14 @@ func RestPostRegisterCSPNativeNode(c echo.Context) error {
 	}
 
 	option := "register"
+	cr, err := infra.RequestInfraCreateStaticApproval(ctx, nsId, req, option, authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
 	result, err := infra.CreateInfra(ctx, nsId, req, option, false)
 	return clientManager.EndRequestWithLog(c, err, result)
 }
@@ -296,8 +317,10 @@ func RestPostSystemInfra(c echo.Context) error {
 // @Param infraReq body model.InfraDynamicReq true "Dynamic Infra request with common specifications. Must include specId and imageId for each node group. See description for detailed example."
 // @Param option query string false "Deployment option: 'hold' to create Infra without immediate node provisioning" Enums(hold)
 // @Param x-request-id header string false "Custom request ID for tracking and correlation across API calls"
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key and body returns the first response instead of running again"
 // @Param x-credential-holder header string false "Credential holder ID to select which credentials to use for provisioning (default: system default holder)"
 // @Success 200 {object} model.InfraInfo "Successfully created Infra with node deployment status, resource mappings, and configuration details"
+// @Success 202 {object} model.ChangeRequest "Estimated cost exceeds the approval threshold; parked as a pending change request"
 // @Failure 400 {object} model.SimpleMsg "Invalid request format, missing required fields, or unsupported configuration"
 // @Failure 404 {object} model.SimpleMsg "Namespace not found, specified specs/images unavailable, or CSP resources inaccessible"
 // @Failure 409 {object} model.SimpleMsg "Infra name already exists or resource naming conflicts detected"
@@ -315,6 +338,14 @@ func RestPostInfraDynamic(c echo.Context) error {
 		return clientManager.EndRequestWithLog(c, err, nil)
 	}
 
+	cr, err := infra.RequestInfraCreateApproval(ctx, nsId, req, option, authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
 	result, err := infra.CreateInfraDynamic(ctx, nsId, req, option)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create Infra dynamically")
@@ -438,6 +469,7 @@ func RestPostInfraDynamicReview(c echo.Context) error {
 // @Param nodeGroupReq body model.AddNodeGroupDynamicReq true "NodeGroup dynamic request specifying specId, imageId, scaling parameters, and optional postCommands bootstrap"
 // @Param x-credential-holder header string false "Credential holder ID to select which credentials to use for provisioning (default: system default holder)"
 // @Success 200 {object} model.InfraInfo "Updated Infra information including newly added nodes and current status"
+// @Success 202 {object} model.ChangeRequest "Estimated cost exceeds the approval threshold; parked as a pending change request"
 // @Failure 400 {object} model.SimpleMsg "Invalid node request or incompatible configuration parameters"
 // @Failure 404 {object} model.SimpleMsg "Target Infra not found or specified resources unavailable"
 // @Failure 409 {object} model.SimpleMsg "NodeGroup name conflicts or Infra in incompatible state"
@@ -455,6 +487,14 @@ func RestPostInfraNodeGroupDynamic(c echo.Context) error {
 		return clientManager.EndRequestWithLog(c, err, nil)
 	}
 
+	cr, err := infra.RequestNodeGroupAddApproval(ctx, nsId, infraId, req, authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
 	result, err := infra.CreateInfraNodeGroupDynamic(ctx, nsId, infraId, req)
 	return clientManager.EndRequestWithLog(c, err, result)
 }
@@ -804,6 +844,7 @@ func RestPostInfraNode(c echo.Context) error {
 // @Param nodegroupId path string true "NodeGroup ID to scale out (must exist and contain at least one node)" default(g1)
 // @Param nodeGroupReq body model.ScaleOutNodeGroupReq true "Scale-out request specifying the number of additional nodes to create"
 // @Success 200 {object} model.InfraInfo "Updated Infra information with scaled nodegroup showing all nodes including newly added instances"
+// @Success 202 {object} model.ChangeRequest "Estimated cost of the added Nodes exceeds the approval threshold; parked as a pending change request"
 // @Failure 400 {object} model.SimpleMsg "Invalid scale-out request, insufficient quotas, or invalid node count"
 // @Failure 404 {object} model.SimpleMsg "Target Infra or nodegroup not found, or namespace inaccessible"
 // @Failure 409 {object} model.SimpleMsg "NodeGroup in incompatible state for scaling or resource conflicts detected"
@@ -823,10 +864,344 @@ func RestPostInfraNodeGroupScaleOut(c echo.Context) error {
 		return clientManager.EndRequestWithLog(c, err, nil)
 	}
 
+	cr, err := infra.RequestNodeGroupScaleOutApproval(ctx, nsId, infraId, nodegroupId, scaleOutReq, authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
 	result, err := infra.ScaleOutInfraNodeGroup(ctx, nsId, infraId, nodegroupId, scaleOutReq.NumNodesToAdd)
 	return clientManager.EndRequestWithLog(c, err, result)
 }
 
+// RestPostInfraNodeGroupScaleIn godoc
+// @ID PostInfraNodeGroupScaleIn
+// @Summary Scale in a NodeGroup of an Infra
+// @Description Remove Nodes from a NodeGroup until it has targetSize Nodes.
+// @Description
+// @Description **Victim selection (strategy):** Nodes that are not running are always removed first, then
+// @Description - `newest` (default): most recently created Nodes
+// @Description - `oldest`: least recently created Nodes
+// @Description - `mostExpensive`: highest hourly cost (spot price for spot Nodes)
+// @Description - `leastLoaded`: lowest 1-minute load average per vCPU, read over SSH (Nodes that do not answer go first)
+// @Description - `zoneSpread`: Nodes from the zones with the most Nodes, to keep the NodeGroup spread across zones
+// @Description
+// @Description **Draining:** with removeFromNlb, the Nodes are deregistered from the NLBs of the Infra; with preStopCommand,
+// @Description the command runs on each Node. A draining failure is reported per Node and the Node is terminated anyway.
+// @Description A targetSize of 0 removes the NodeGroup.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param nodegroupId path string true "NodeGroup ID to scale in" default(g1)
+// @Param scaleInReq body model.ScaleInNodeGroupReq true "Target size, victim-selection strategy and draining options"
+// @Success 200 {object} model.ScaleInNodeGroupResult "Removed Nodes with the reason they were selected, and the updated Infra"
+// @Failure 400 {object} model.SimpleMsg "Invalid target size or strategy"
+// @Failure 404 {object} model.SimpleMsg "NodeGroup not found"
+// @Failure 409 {object} model.SimpleMsg "Infra is under another action"
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key and body returns the first response instead of running again"
+// @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
+// @Router /ns/{nsId}/infra/{infraId}/nodegroup/{nodegroupId}/scaleIn [post]
+func RestPostInfraNodeGroupScaleIn(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	nsId := c.Param("nsId")
+	infraId := c.Param("infraId")
+	nodegroupId := c.Param("nodegroupId")
+
+	scaleInReq := &model.ScaleInNodeGroupReq{}
+	if err := c.Bind(scaleInReq); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	result, err := infra.ScaleInInfraNodeGroup(ctx, nsId, infraId, nodegroupId, scaleInReq)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPostNodeGroupRollout godoc
+// @ID PostNodeGroupRollout
+// @Summary Start a rolling replacement of the Nodes of a NodeGroup
+// @Description Replace the Nodes of a NodeGroup with Nodes of a new image and/or spec (e.g., an image baked by
+// @Description a snapshot or agnostic image build), batch by batch. For each batch:
+// @Description 1. replacement Nodes are created in the same NodeGroup (same subnet, security groups and SSH key)
+// @Description 2. postCommands run on them, then the health check command (default: SSH reachability)
+// @Description 3. they take over the NLB membership of the old Nodes
+// @Description 4. the old Nodes are terminated (after the optional preStopCommand)
+// @Description
+// @Description If a batch is not healthy, its replacement Nodes are removed, its old Nodes are kept and the rollout
+// @Description stops (state RolledBack); batches completed before stay replaced.
+// @Description The rollout runs in the background: follow it with GET on the same path, and pause/resume/abort it with
+// @Description GET /ns/{nsId}/control/infra/{infraId}/nodegroup/{nodegroupId}/rollout?action=...
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param nodegroupId path string true "NodeGroup ID" default(g1)
+// @Param rolloutReq body model.NodeGroupRolloutReq true "New image/spec, batch size, post-commands and health check"
+// @Success 200 {object} model.NodeGroupRolloutStatus "The rollout was started"
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg "NodeGroup not found"
+// @Failure 409 {object} model.SimpleMsg "A rollout of the NodeGroup is already running, or the Infra is under another action"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key and body returns the first response instead of running again"
+// @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
+// @Router /ns/{nsId}/infra/{infraId}/nodegroup/{nodegroupId}/rollout [post]
+func RestPostNodeGroupRollout(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	nsId := c.Param("nsId")
+	infraId := c.Param("infraId")
+	nodegroupId := c.Param("nodegroupId")
+
+	req := &model.NodeGroupRolloutReq{}
+	if err := c.Bind(req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	result, err := infra.StartNodeGroupRollout(ctx, nsId, infraId, nodegroupId, req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestGetNodeGroupRollout godoc
+// @ID GetNodeGroupRollout
+// @Summary Get the status of the rollout of a NodeGroup
+// @Description Get the status of the latest rolling replacement of a NodeGroup (batches, replaced Nodes and history).
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param nodegroupId path string true "NodeGroup ID" default(g1)
+// @Success 200 {object} model.NodeGroupRolloutStatus
+// @Failure 404 {object} model.SimpleMsg "No rollout of the NodeGroup"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/infra/{infraId}/nodegroup/{nodegroupId}/rollout [get]
+func RestGetNodeGroupRollout(c echo.Context) error {
+	nsId := c.Param("nsId")
+	infraId := c.Param("infraId")
+	nodegroupId := c.Param("nodegroupId")
+
+	result, err := infra.GetNodeGroupRolloutStatus(nsId, infraId, nodegroupId)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestGetControlNodeGroupRollout godoc
+// @ID GetControlNodeGroupRollout
+// @Summary Pause, resume or abort the rollout of a NodeGroup
+// @Description Pause and abort take effect when the current batch is finished; Nodes not replaced yet are kept.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param nodegroupId path string true "NodeGroup ID" default(g1)
+// @Param action query string true "Action to the rollout" Enums(pause, resume, abort)
+// @Success 200 {object} model.NodeGroupRolloutStatus
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 404 {object} model.SimpleMsg "No rollout of the NodeGroup"
+// @Failure 409 {object} model.SimpleMsg "The rollout is already finished"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/control/infra/{infraId}/nodegroup/{nodegroupId}/rollout [get]
+func RestGetControlNodeGroupRollout(c echo.Context) error {
+	nsId := c.Param("nsId")
+	infraId := c.Param("infraId")
+	nodegroupId := c.Param("nodegroupId")
+	action := c.QueryParam("action")
+
+	result, err := infra.ControlNodeGroupRollout(nsId, infraId, nodegroupId, action)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPostInfraDesiredStatePlan godoc
+// @ID PostInfraDesiredStatePlan
+// @Summary Plan the changes to bring an Infra to a desired state
+// @Description Compare a desired state (same format as /infraDynamic) with the current Infra and return the changes
+// @Description that PUT /ns/{nsId}/infra/{infraId}/desired would make, without making them.
+// @Description
+// @Description - NodeGroups are matched by name: missing ones are added, extra ones are removed, sizes are scaled out or in
+// @Description - Labels in the desired state are set on the Infra and the Nodes (other labels are kept)
+// @Description - postCommands run only on new Nodes (phases without a target or targeting their NodeGroup)
+// @Description - Spec, image, disk and zone differences of existing NodeGroups are reported as warnings, not applied
+// @Description
+// @Description Use GET /ns/{nsId}/infra/{infraId}/configCopy to get the current state in the same format.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param infraReq body model.InfraDynamicReq true "Desired state of the Infra (name may be omitted; it must match infraId if given)"
+// @Success 200 {object} model.InfraDesiredStatePlan
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
+// @Router /ns/{nsId}/infra/{infraId}/desired/plan [post]
+func RestPostInfraDesiredStatePlan(c echo.Context) error {
+	nsId := c.Param("nsId")
+	infraId := c.Param("infraId")
+
+	req := &model.InfraDynamicReq{}
+	if err := c.Bind(req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	result, err := infra.PlanInfraDesiredState(nsId, infraId, req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPutInfraDesiredState godoc
+// @ID PutInfraDesiredState
+// @Summary Apply a desired state to an Infra
+// @Description Bring an Infra to a desired state (same format as /infraDynamic): the Infra is created if it does not exist,
+// @Description otherwise the changes shown by POST /ns/{nsId}/infra/{infraId}/desired/plan are applied in order
+// @Description (scale-out and new NodeGroups first, then label updates, then scale-in and NodeGroup removal).
+// @Description Applying the same desired state again makes no change. A failed change is reported in the results
+// @Description and the remaining changes still run. Creation of a new Infra is subject to the approval policy.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param infraReq body model.InfraDynamicReq true "Desired state of the Infra (name may be omitted; it must match infraId if given)"
+// @Success 200 {object} model.InfraDesiredStateApplyResult
+// @Success 202 {object} model.ChangeRequest "Parked as a pending change request (approval policy)"
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 409 {object} model.SimpleMsg "Infra is under another action or a desired state is already being applied"
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key and body returns the first response instead of running again"
+// @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
+// @Router /ns/{nsId}/infra/{infraId}/desired [put]
+func RestPutInfraDesiredState(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	nsId := c.Param("nsId")
+	infraId := c.Param("infraId")
+
+	req := &model.InfraDynamicReq{}
+	if err := c.Bind(req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	plan, err := infra.PlanInfraDesiredState(nsId, infraId, req)
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	cr, err := infra.RequestInfraDesiredStateApproval(ctx, nsId, infraId, req, plan, authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
+	result, err := infra.ApplyInfraDesiredState(ctx, nsId, infraId, req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPostInfraClonePreview godoc
+// @ID PostInfraClonePreview
+// @Summary Preview a clone of an Infra in another region or CSP
+// @Description Return how an Infra would be cloned to the target provider/region, without creating anything.
+// @Description
+// @Description - Each NodeGroup is mapped to an equivalent spec and image by the alternative node config matcher
+// @Description   (POST /recommendAlternativeNodeConfig) with `matchCriteria` and `tolerancePercent`;
+// @Description   `nodeGroups` pins the spec, image or zone of individual NodeGroups
+// @Description - NodeGroups already in the target location keep their spec and image
+// @Description - The vNet (CIDR, subnet count) and SecurityGroup rules of the source become vNet/SecurityGroup templates
+// @Description   (named `{name}-vnet{N}` and `{name}-sg{N}`) used by the clone; rules allowing the source vNet CIDR allow the clone's vNet
+// @Description - The postCommands of the source are replayed on the clone unless `skipPostCommands` is set
+// @Description
+// @Description `ready` is false when a NodeGroup has no target (see its `error`).
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param cloneReq body model.InfraCloneReq true "Name and target location of the clone"
+// @Success 200 {object} model.InfraClonePlan
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 409 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
+// @Router /ns/{nsId}/infra/{infraId}/clone/preview [post]
+func RestPostInfraClonePreview(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	nsId := c.Param("nsId")
+	infraId := c.Param("infraId")
+
+	req := &model.InfraCloneReq{}
+	if err := c.Bind(req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	plan, err := infra.PlanInfraClone(ctx, nsId, infraId, req)
+	return clientManager.EndRequestWithLog(c, err, plan)
+}
+
+// RestPostInfraClone godoc
+// @ID PostInfraClone
+// @Summary Clone an Infra to another region or CSP
+// @Description Clone an Infra as planned by POST /ns/{nsId}/infra/{infraId}/clone/preview:
+// @Description the vNet/SecurityGroup templates are created in the namespace and the clone is created
+// @Description as a dynamic Infra (same as /infraDynamic), which replays the postCommands of the source.
+// @Description The request fails if the plan is not ready. Creation is subject to the Infra creation approval policy.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param cloneReq body model.InfraCloneReq true "Name and target location of the clone"
+// @Success 200 {object} model.InfraCloneResult
+// @Success 202 {object} model.ChangeRequest "Parked as a pending change request (approval policy); the network templates are created already"
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 409 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Param x-credential-holder header string false "Credential holder ID for selecting which credentials to use (default: system default holder)"
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key and body returns the first response instead of running again"
+// @Router /ns/{nsId}/infra/{infraId}/clone [post]
+func RestPostInfraClone(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	nsId := c.Param("nsId")
+	infraId := c.Param("infraId")
+
+	req := &model.InfraCloneReq{}
+	if err := c.Bind(req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+
+	plan, err := infra.PlanInfraClone(ctx, nsId, infraId, req)
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if plan.Ready {
+		cr, err := infra.RequestInfraCreateApproval(ctx, nsId, &plan.InfraDynamicReq, "", authmw.ApprovalSubject(c))
+		if err != nil {
+			return clientManager.EndRequestWithLog(c, err, nil)
+		}
+		if cr != nil {
+			// The parked request refers to the network templates, so they are created now.
+			if err := infra.CreateInfraCloneTemplates(nsId, plan); err != nil {
+				return clientManager.EndRequestWithLog(c, err, nil)
+			}
+			return c.JSON(http.StatusAccepted, cr)
+		}
+	}
+
+	result, err := infra.ApplyInfraClone(ctx, nsId, plan)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
 // RestGetProvisioningLog godoc
 // @ID GetProvisioningLog
 // @Summary Get Provisioning History Log for node Specification
@@ -1136,6 +1511,8 @@ func RestAnalyzeProvisioningRiskDetailed(c echo.Context) error {
 // @Description - Contributes to risk analysis algorithms
 // @Description - Affects future Infra review recommendations
 // @Description - Builds historical baseline for reliability metrics
+// @Description - Not counted in the failure analytics, so it never demotes specs or regions in spec recommendation
+// @Description - Requires the system write permission (maintainer role) when RBAC is enabled
 // @Tags [Admin] Provisioning History and Analytics
 // @Accept  json
 // @Produce  json
@@ -1178,3 +1555,92 @@ func RestRecordProvisioningEvent(c echo.Context) error {
 
 	return clientManager.EndRequestWithLog(c, nil, result)
 }
+
+// queryIntParam returns an optional integer query parameter (0 when absent).
+func queryIntParam(c echo.Context, name string) (int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s must be an integer", name))
+	}
+	return n, nil
+}
+
+// RestGetProvisioningAnalytics godoc
+// @ID GetProvisioningAnalytics
+// @Summary Get provisioning failure analytics
+// @Description Aggregate the provisioning attempts of the last windowDays days (at most 90) by provider, region, zone,
+// @Description spec family, spec or image. Every Node provisioning attempt is counted, and failures are classified
+// @Description from the CSP error message into quota, capacity, imageIncompatible, specUnavailable, network,
+// @Description permission, timeout or other. Groups are sorted by failure rate, then by number of failures.
+// @Tags [Admin] Provisioning History and Analytics
+// @Accept  json
+// @Produce  json
+// @Param windowDays query int false "Number of days to aggregate, today included (default: 30)" default(30)
+// @Param groupBy query string false "Grouping dimension" Enums(provider, region, zone, specFamily, spec, image) default(region)
+// @Param providerName query string false "Only count attempts of this provider"
+// @Param regionName query string false "Only count attempts in this region"
+// @Param minAttempts query int false "Only list groups with at least this number of attempts (default: 1)"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.ProvisioningAnalytics
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /provisioning/analytics [get]
+func RestGetProvisioningAnalytics(c echo.Context) error {
+	windowDays, err := queryIntParam(c, "windowDays")
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	minAttempts, err := queryIntParam(c, "minAttempts")
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	result, err := infra.GetProvisioningAnalytics(windowDays, c.QueryParam("groupBy"),
+		c.QueryParam("providerName"), c.QueryParam("regionName"), minAttempts)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestGetProvisioningDemotion godoc
+// @ID GetProvisioningDemotion
+// @Summary Get the demotion of high-failure specs in spec recommendation
+// @Description Get the demotion policy and the specs and regions it currently demotes.
+// @Description While the policy is enabled (default), RecommendSpec orders the specs of demoted specs and regions last;
+// @Description the "reliability" priority metric applies the demotion to a single request even when the policy is disabled.
+// @Description The statistics are re-read at most once a minute.
+// @Tags [Admin] Provisioning History and Analytics
+// @Accept  json
+// @Produce  json
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.ProvisioningDemotionStatus
+// @Failure 500 {object} model.SimpleMsg
+// @Router /provisioning/demotion [get]
+func RestGetProvisioningDemotion(c echo.Context) error {
+	result, err := infra.GetProvisioningDemotionStatus()
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPutProvisioningDemotion godoc
+// @ID PutProvisioningDemotion
+// @Summary Set the demotion policy of high-failure specs in spec recommendation
+// @Description Enable or disable the demotion of specs and regions with a high provisioning failure rate in RecommendSpec,
+// @Description and set its window, minimum number of attempts and failure rate threshold.
+// @Tags [Admin] Provisioning History and Analytics
+// @Accept  json
+// @Produce  json
+// @Param demotionPolicy body model.ProvisioningDemotionPolicy true "Demotion policy"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.ProvisioningDemotionStatus
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /provisioning/demotion [put]
+func RestPutProvisioningDemotion(c echo.Context) error {
+	req := model.ProvisioningDemotionPolicy{}
+	if err := c.Bind(&req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	result, err := infra.SetProvisioningDemotionPolicy(req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
@@ -62,6 +62,7 @@ func convertSshCmdResultForAPI(internal []model.SshCmdResult) model.InfraSshCmdR
 // @Router /ns/{nsId}/cmd/infra/{infraId} [post]
 func RestPostCmdInfra(c echo.Context) error {
 
+	ctx := c.Request().Context()
 	nsId := c.Param("nsId")
 	infraId := c.Param("infraId")
 	nodeGroupId := c.QueryParam("nodeGroupId")
@@ -87,7 +88,7 @@ func RestPostCmdInfra(c echo.Context) error {
 	if asyncMode {
 		// Async mode: launch execution in background and return xRequestId immediately
 		go func() {
-			_, err := infra.RemoteCommandToInfra(nsId, infraId, nodeGroupId, nodeId, labelSelector, req, xRequestId)
+			_, err := infra.RemoteCommandToInfraWithContext(ctx, nsId, infraId, nodeGroupId, nodeId, labelSelector, req, xRequestId)
 			if err != nil {
 				log.Error().Err(err).Str("xRequestId", xRequestId).Msg("Async remote command execution failed")
 
@@ -120,7 +121,7 @@ func RestPostCmdInfra(c echo.Context) error {
 	}
 
 	// Sync mode (default): execute and wait for result
-	output, err := infra.RemoteCommandToInfra(nsId, infraId, nodeGroupId, nodeId, labelSelector, req, xRequestId)
+	output, err := infra.RemoteCommandToInfraWithContext(ctx, nsId, infraId, nodeGroupId, nodeId, labelSelector, req, xRequestId)
 	if err != nil {
 		return clientManager.EndRequestWithLog(c, err, nil)
 	}
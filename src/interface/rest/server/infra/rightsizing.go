@@ -0,0 +1,90 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infra is to handle REST API for infra
+package infra
+
+import (
+	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
+	"github.com/cloud-barista/cb-tumblebug/src/core/infra"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/labstack/echo/v4"
+)
+
+// RestPostInfraRightsizing godoc
+// @ID PostInfraRightsizing
+// @Summary Analyze the utilization of an Infra and recommend smaller specs
+// @Description Classify the Nodes of an Infra as idle, underutilized or optimal by their p95 CPU and memory utilization
+// @Description over a window, and recommend the cheapest spec of the same provider, region and architecture that runs
+// @Description the p95 usage at the target utilization, with the estimated savings (USD, 730 hours a month).
+// @Description Samples come from the monitoring agent (CB-Dragonfly) when installed and from /proc over SSH otherwise;
+// @Description running Nodes are sampled periodically when TB_UTILIZATION_SAMPLE_INTERVAL_MINUTES is set (disabled by default).
+// @Description Set sampleNow to take a sample before the analysis. Apply a recommendation with the resize action of the Node.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param rightsizingReq body model.RightsizingReq false "Analysis window and thresholds"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.RightsizingReport
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/infra/{infraId}/rightsizing [post]
+func RestPostInfraRightsizing(c echo.Context) error {
+	req := model.RightsizingReq{}
+	if err := c.Bind(&req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	result, err := infra.AnalyzeInfraRightsizing(c.Request().Context(), c.Param("nsId"), c.Param("infraId"), req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestGetInfraUtilization godoc
+// @ID GetInfraUtilization
+// @Summary Get the utilization history of an Infra
+// @Description Get the CPU and memory utilization samples of the Nodes of an Infra (kept for 31 days)
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} []model.NodeUtilizationRecord
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/infra/{infraId}/utilization [get]
+func RestGetInfraUtilization(c echo.Context) error {
+	result, err := infra.ListInfraUtilization(c.Param("nsId"), c.Param("infraId"))
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPostInfraUtilizationSample godoc
+// @ID PostInfraUtilizationSample
+// @Summary Sample the utilization of an Infra
+// @Description Take a CPU and memory utilization sample of every running Node of an Infra now and store it in the history.
+// @Description Returns the samples by Node ID; Nodes that could not be sampled are missing.
+// @Tags [MC-Infra] Infra Provisioning and Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} map[string]model.UtilizationSample
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/infra/{infraId}/utilization [post]
+func RestPostInfraUtilizationSample(c echo.Context) error {
+	result, err := infra.SampleInfraUtilization(c.Param("nsId"), c.Param("infraId"))
+	return clientManager.EndRequestWithLog(c, err, result)
+}
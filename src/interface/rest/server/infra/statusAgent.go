@@ -0,0 +1,37 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infra
+
+import (
+	"net/http"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/infra"
+	"github.com/labstack/echo/v4"
+)
+
+// RestGetStatusAgentDebugInfo godoc
+// @ID GetStatusAgentDebugInfo
+// @Summary Get NodeStatusAgent introspection info
+// @Description Returns a snapshot of the background NodeStatusAgent for tuning CSP rate limits on large fleets:
+// @Description queue depth and due Nodes per poll priority, worker queue usage, operation-locked Nodes,
+// @Description stale (overdue) entries, per provider/region rate limiter waits and throttling, and the last batch sweep.
+// @Description The same figures are exported as Prometheus metrics under /tumblebug/metrics (tumblebug_status_agent_*).
+// @Tags [Admin] System Management
+// @Produce json
+// @Success 200 {object} model.StatusAgentDebugInfo
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /statusAgent [get]
+func RestGetStatusAgentDebugInfo(c echo.Context) error {
+	return c.JSON(http.StatusOK, infra.GlobalAgent.DebugInfo())
+}
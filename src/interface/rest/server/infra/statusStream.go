@@ -0,0 +1,199 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
+	"github.com/cloud-barista/cb-tumblebug/src/core/infra"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// RestGetNsStatusStream godoc
+// @ID GetNsStatusStream
+// @Summary Stream Node status transitions of a namespace via SSE
+// @Description Subscribe to Server-Sent Events (SSE) for Node status transitions of every Infra in the namespace,
+// @Description as observed by NodeStatusAgent. The stream starts with one NodeStatusSnapshot event per known Node,
+// @Description followed by NodeStatusChanged events. The status filter applies to both.
+// @Tags [MC-Infra] Infra Status Stream
+// @Produce text/event-stream
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param status query string false "Comma-separated list of statuses to stream (default: all)" example(Running,Failed)
+// @Success 200 {object} model.NodeStatusEvent "SSE stream of Node status events"
+// @Failure 400 {object} model.SimpleMsg "Invalid request"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/stream/status [get]
+func RestGetNsStatusStream(c echo.Context) error {
+	nsId := c.Param("nsId")
+	if err := common.CheckString(nsId); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	filter := infra.NodeStatusEventFilter{
+		NsId:     nsId,
+		Statuses: splitStatusParam(c.QueryParam("status")),
+	}
+	return streamNodeStatus(c, filter, "")
+}
+
+// RestGetInfraStatusStream godoc
+// @ID GetInfraStatusStream
+// @Summary Stream Node status transitions of an Infra via SSE
+// @Description Subscribe to Server-Sent Events (SSE) for Node status transitions of an Infra,
+// @Description as observed by NodeStatusAgent. The stream starts with one NodeStatusSnapshot event per known Node,
+// @Description followed by NodeStatusChanged events. The status filter applies to both.
+// @Description With `until` (e.g., Running), the stream ends with an UntilReached event as soon as every Node
+// @Description of the Infra has that status, so clients can wait for "all Running" without polling.
+// @Tags [MC-Infra] Infra Status Stream
+// @Produce text/event-stream
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param infraId path string true "Infra ID" default(infra01)
+// @Param status query string false "Comma-separated list of statuses to stream (default: all)" example(Running,Failed)
+// @Param until query string false "End the stream once every Node of the Infra has this status" example(Running)
+// @Success 200 {object} model.NodeStatusEvent "SSE stream of Node status events"
+// @Failure 400 {object} model.SimpleMsg "Invalid request"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Router /ns/{nsId}/stream/status/infra/{infraId} [get]
+func RestGetInfraStatusStream(c echo.Context) error {
+	nsId := c.Param("nsId")
+	infraId := c.Param("infraId")
+	if err := common.CheckString(nsId); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if err := common.CheckString(infraId); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	until := strings.TrimSpace(c.QueryParam("until"))
+	if until != "" {
+		// Validate the Infra up front so a typo fails fast instead of streaming forever
+		if _, err := infra.IsInfraInStatus(nsId, infraId, until); err != nil {
+			return clientManager.EndRequestWithLog(c, err, nil)
+		}
+	}
+	filter := infra.NodeStatusEventFilter{
+		NsId:     nsId,
+		InfraId:  infraId,
+		Statuses: splitStatusParam(c.QueryParam("status")),
+	}
+	return streamNodeStatus(c, filter, until)
+}
+
+// splitStatusParam parses a comma-separated status query parameter.
+func splitStatusParam(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// streamNodeStatus writes Node status events matching filter as SSE until the client
+// disconnects or, when until is set, every Node of filter.InfraId reaches that status.
+func streamNodeStatus(c echo.Context, filter infra.NodeStatusEventFilter, until string) error {
+	// Subscribe before taking the snapshot so no transition falls in between
+	eventCh, cleanup := infra.SubscribeNodeStatusEvents(filter)
+	defer cleanup()
+
+	log.Info().Str("nsId", filter.NsId).Str("infraId", filter.InfraId).Str("until", until).Msg("Status SSE stream client connected")
+
+	c.Response().Header().Set("Content-Type", "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	c.Response().WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(c.Response())
+	clientGone := c.Request().Context().Done()
+
+	fmt.Fprintf(c.Response(), ": connected to status stream for ns=%s infra=%s\n\n", filter.NsId, filter.InfraId)
+	c.Response().Flush()
+
+	writeEvent := func(event model.NodeStatusEvent) error {
+		// Write SSE format: "data: {json}\n\n"
+		fmt.Fprint(c.Response(), "data: ")
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+		fmt.Fprint(c.Response(), "\n")
+		c.Response().Flush()
+		return nil
+	}
+
+	// untilReached sends the terminal event when every Node has the until status
+	untilReached := func() bool {
+		if until == "" {
+			return false
+		}
+		ok, err := infra.IsInfraInStatus(filter.NsId, filter.InfraId, until)
+		if err != nil || !ok {
+			return false
+		}
+		writeEvent(model.NodeStatusEvent{
+			Type:      model.EventNodeStatusUntilReached,
+			NsId:      filter.NsId,
+			InfraId:   filter.InfraId,
+			Status:    until,
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+		})
+		return true
+	}
+
+	for _, event := range infra.GetNodeStatusSnapshot(filter) {
+		if err := writeEvent(event); err != nil {
+			log.Error().Err(err).Msg("Failed to encode status SSE event")
+			return nil
+		}
+	}
+	if untilReached() {
+		return nil
+	}
+
+	// Keepalive ticker to prevent proxy/load-balancer timeouts
+	keepaliveTicker := time.NewTicker(15 * time.Second)
+	defer keepaliveTicker.Stop()
+
+	for {
+		select {
+		case <-clientGone:
+			log.Debug().Str("nsId", filter.NsId).Str("infraId", filter.InfraId).Msg("Status SSE client disconnected")
+			return nil
+
+		case event := <-eventCh:
+			if err := writeEvent(event); err != nil {
+				log.Error().Err(err).Msg("Failed to encode status SSE event")
+				return nil
+			}
+			if untilReached() {
+				return nil
+			}
+
+		case <-keepaliveTicker.C:
+			fmt.Fprint(c.Response(), ": keepalive\n\n")
+			c.Response().Flush()
+			// Nodes removed from the Infra (e.g., refine) change the set without a transition
+			if untilReached() {
+				return nil
+			}
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
 	"github.com/cloud-barista/cb-tumblebug/src/core/infra"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
 )
@@ -224,6 +225,7 @@ func RestDeleteAllInfraDynamicTemplate(c echo.Context) error {
 // @Param option query string false "Deployment option: 'hold' to create Infra without immediate node provisioning" Enums(hold)
 // @Param x-request-id header string false "Custom request ID for tracking"
 // @Success 200 {object} model.InfraInfo "Successfully created Infra from template"
+// @Success 202 {object} model.ChangeRequest "Estimated cost exceeds the approval threshold; parked as a pending change request"
 // @Failure 400 {object} model.SimpleMsg "Invalid request format"
 // @Failure 404 {object} model.SimpleMsg "Template or namespace not found"
 // @Failure 500 {object} model.SimpleMsg "Internal deployment error"
@@ -241,7 +243,19 @@ func RestPostInfraDynamicFromTemplate(c echo.Context) error {
 		return clientManager.EndRequestWithLog(c, err, nil)
 	}
 
-	result, err := infra.CreateInfraDynamicFromTemplate(ctx, nsId, templateId, req, option)
+	infraReq, err := infra.InfraDynamicReqFromTemplate(nsId, templateId, req)
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	cr, err := infra.RequestInfraCreateApproval(ctx, nsId, infraReq, option, authmw.ApprovalSubject(c))
+	if err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	if cr != nil {
+		return c.JSON(http.StatusAccepted, cr)
+	}
+
+	result, err := infra.CreateInfraDynamic(ctx, nsId, infraReq, option)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create Infra from template")
 		return clientManager.EndRequestWithLog(c, err, nil)
@@ -0,0 +1,59 @@
+package authmw
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/apierr"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// bearerApiToken returns the TB-issued API token in the Authorization header, if any.
+func bearerApiToken(c echo.Context) (string, bool) {
+	token, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, common.IsApiToken(token)
+}
+
+// IsApiTokenRequest reports whether the request carries a TB-issued API token.
+// The basic and JWT auth middlewares skip such requests; ApiTokenMw has handled them.
+func IsApiTokenRequest(c echo.Context) bool {
+	_, ok := bearerApiToken(c)
+	return ok
+}
+
+// ApiTokenMw authenticates requests carrying a namespace-scoped API token
+// ("Authorization: Bearer tbt_...") and enforces the token's namespace and scopes.
+// Requests without an API token are passed to the next (basic or JWT) middleware.
+func ApiTokenMw() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			raw, ok := bearerApiToken(c)
+			if !ok {
+				return next(c)
+			}
+
+			token, err := common.ValidateApiToken(raw)
+			if err != nil {
+				log.Warn().Str("path", c.Path()).Msg(err.Error())
+				return c.JSON(http.StatusUnauthorized, model.SimpleMsg{Message: err.Error()})
+			}
+			if err := common.AuthorizeApiToken(token, c.Path(), c.Request().Method, c.QueryParam("action"), c.Param("nsId")); err != nil {
+				log.Warn().Str("path", c.Path()).Str("method", c.Request().Method).Msg(err.Error())
+				return c.JSON(apierr.Code(err), model.SimpleMsg{Message: err.Error()})
+			}
+
+			c.Set("authenticated", true)
+			c.Set("apiToken", token)
+			c.Set("name", "apitoken:"+token.Id)
+			c.Set("expired-time", token.ExpiresAt)
+			return next(c)
+		}
+	}
+}
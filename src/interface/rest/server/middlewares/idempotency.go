@@ -0,0 +1,152 @@
+package middlewares
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// maxIdempotencyKeyLength limits the Idempotency-Key header value
+const maxIdempotencyKeyLength = 255
+
+// Idempotency makes create/delete/control requests carrying an Idempotency-Key header
+// safe to retry: the first request with a key is executed and its response stored,
+// later requests with the same key (and the same method, URI and body) get the stored
+// response instead of being executed again. Keys are scoped per user and expire
+// after TB_IDEMPOTENCY_KEY_TTL.
+func Idempotency() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(model.IdempotencyKeyHeader)
+			if key == "" || !isIdempotencyTarget(c.Request()) {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, model.SimpleMsg{
+					Message: fmt.Sprintf("%s must be at most %d characters", model.IdempotencyKeyHeader, maxIdempotencyKeyLength),
+				})
+			}
+
+			// Read the body to fingerprint the request, then restore it for the handler
+			var body []byte
+			if c.Request().Body != nil {
+				var err error
+				body, err = io.ReadAll(c.Request().Body)
+				if err != nil {
+					return c.JSON(http.StatusBadRequest, model.SimpleMsg{Message: "failed to read request body"})
+				}
+				c.Request().Body = io.NopCloser(bytes.NewReader(body))
+			}
+			bodyHash := sha256.Sum256(body)
+
+			record := model.IdempotencyRecord{
+				Key:       key,
+				Scope:     authmw.RbacSubject(c),
+				Method:    c.Request().Method,
+				URI:       c.Request().RequestURI,
+				BodyHash:  hex.EncodeToString(bodyHash[:]),
+				RequestId: c.Response().Header().Get(echo.HeaderXRequestID),
+			}
+			existing, err := common.BeginIdempotentRequest(record)
+			if err != nil {
+				log.Error().Err(err).Str("idempotencyKey", key).Msg("Failed to check the idempotency key")
+				return c.JSON(http.StatusServiceUnavailable, model.SimpleMsg{Message: "failed to check the idempotency key: " + err.Error()})
+			}
+			if existing != nil {
+				return replayIdempotentRequest(c, &record, existing)
+			}
+
+			recorder := &idempotencyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			err = next(c)
+
+			status := c.Response().Status
+			if err != nil || !c.Response().Committed || status >= http.StatusInternalServerError {
+				// Not a final result (e.g., error before a response, server-side failure): let a retry run again
+				if abandonErr := common.AbandonIdempotentRequest(record.Scope, key); abandonErr != nil {
+					log.Warn().Err(abandonErr).Str("idempotencyKey", key).Msg("Failed to release the idempotency key")
+				}
+				return err
+			}
+			contentType := c.Response().Header().Get(echo.HeaderContentType)
+			if completeErr := common.CompleteIdempotentRequest(record.Scope, key, status, contentType, recorder.body.Bytes()); completeErr != nil {
+				log.Warn().Err(completeErr).Str("idempotencyKey", key).Msg("Failed to store the idempotent response")
+			}
+			return nil
+		}
+	}
+}
+
+// isIdempotencyTarget reports whether the request is a mutating one
+// (create/update/delete, or a control action, which tumblebug serves with GET).
+func isIdempotencyTarget(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	case http.MethodGet:
+		return strings.Contains(req.URL.Path, "/control/")
+	}
+	return false
+}
+
+// replayIdempotentRequest answers a request whose idempotency key is already in use.
+func replayIdempotentRequest(c echo.Context, record, existing *model.IdempotencyRecord) error {
+	if existing.Method != record.Method || existing.URI != record.URI || existing.BodyHash != record.BodyHash {
+		return c.JSON(http.StatusUnprocessableEntity, model.SimpleMsg{
+			Message: fmt.Sprintf("%s '%s' was already used for a different request (%s %s)", model.IdempotencyKeyHeader, record.Key, existing.Method, existing.URI),
+		})
+	}
+	if existing.Status == model.IdempotencyInProgress {
+		return c.JSON(http.StatusConflict, model.SimpleMsg{
+			Message: fmt.Sprintf("the request with %s '%s' is still in progress (X-Request-Id: %s)", model.IdempotencyKeyHeader, record.Key, existing.RequestId),
+		})
+	}
+
+	log.Debug().Str("idempotencyKey", record.Key).Str("originalRequestId", existing.RequestId).Msg("Replaying idempotent response")
+	c.Response().Header().Set(model.IdempotentReplayedHeader, "true")
+	c.Response().Header().Add("Access-Control-Expose-Headers", model.IdempotentReplayedHeader)
+	if existing.ResponseOmitted {
+		return c.JSON(existing.HttpStatus, model.SimpleMsg{
+			Message: fmt.Sprintf("the request with %s '%s' was already completed (X-Request-Id: %s); its response was too large to be stored", model.IdempotencyKeyHeader, record.Key, existing.RequestId),
+		})
+	}
+	contentType := existing.ContentType
+	if contentType == "" {
+		contentType = echo.MIMEApplicationJSON
+	}
+	return c.Blob(existing.HttpStatus, contentType, existing.Response)
+}
+
+// idempotencyRecorder copies the response body while it is written to the client.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *idempotencyRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
@@ -1,11 +1,14 @@
 package middlewares
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	clientManager "github.com/cloud-barista/cb-tumblebug/src/core/common/client"
+	"github.com/cloud-barista/cb-tumblebug/src/interface/rest/server/middlewares/authmw"
 	"github.com/labstack/echo/v4"
 )
 
@@ -37,8 +40,11 @@ func RequestIdAndDetailsIssuer(next echo.HandlerFunc) echo.HandlerFunc {
 		c.SetRequest(c.Request().WithContext(ctx))
 
 		details := clientManager.RequestDetails{
+			RequestId:   reqID,
 			StartTime:   time.Now(),
 			Status:      "Handling",
+			Route:       c.Path(),
+			NsId:        c.Param("nsId"),
 			RequestInfo: clientManager.ExtractRequestInfo(c.Request()),
 		}
 		clientManager.RequestMap.Store(reqID, details)
@@ -48,6 +54,44 @@ func RequestIdAndDetailsIssuer(next echo.HandlerFunc) echo.HandlerFunc {
 
 		// log.Debug().Msg("End - Request ID middleware")
 
-		return next(c)
+		err := next(c)
+		finishRequestDetails(c, reqID, err)
+		return err
 	}
 }
+
+// finishRequestDetails completes the request details with what is only known after
+// the handler (authenticated user, HTTP status) and persists them in the request history.
+func finishRequestDetails(c echo.Context, reqID string, err error) {
+	v, ok := clientManager.RequestMap.Load(reqID)
+	if !ok {
+		return
+	}
+	details, ok := v.(clientManager.RequestDetails)
+	if !ok {
+		return
+	}
+	details.User = authmw.RbacSubject(c)
+	details.HttpStatus = c.Response().Status
+	if err != nil {
+		// The error has not been written to the response yet (echo's error handler runs later)
+		details.HttpStatus = http.StatusInternalServerError
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			details.HttpStatus = httpErr.Code
+		}
+		details.Status = "Error"
+		details.ErrorResponse = err.Error()
+	} else if details.Status == "Handling" {
+		// Non-JSON responses (e.g., streams, files) are not completed by ResponseBodyDump
+		details.Status = "Success"
+		if details.HttpStatus >= 400 {
+			details.Status = "Error"
+		}
+	}
+	if details.EndTime.IsZero() {
+		details.EndTime = time.Now()
+	}
+	clientManager.RequestMap.Store(reqID, details)
+	go clientManager.PersistRequest(reqID, details)
+}
@@ -3,36 +3,55 @@ package middlewares
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/logger"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
-	// "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Define Tracing middleware
+// It starts a server span for each request, continuing the caller's trace when a
+// W3C traceparent header is present, and puts trace_id/span_id into the request logger.
 func TracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 
-		// TODO: Be able to use this code when OpenTelemetry is integrated
-		// Start a new tracing context
-		// tracer := otel.Tracer("example-tracer")
-		// ctx, span := tracer.Start(c.Request().Context(), c.Path())
-		// defer span.End()
+		req := c.Request()
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
 
-		// Store trace and span IDs in the context
-		// traceId := span.SpanContext().TraceID().String()
-		// spanId := span.SpanContext().SpanID().String()
-
-		// Get the context
-		ctx := c.Request().Context()
+		// Start a new span (child of the remote span, if any)
+		ctx := tracing.Extract(req.Context(), req.Header)
+		ctx, span := tracing.Tracer().Start(ctx, req.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", req.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", req.URL.Path),
+				attribute.String("request.id", c.Response().Header().Get(echo.HeaderXRequestID)),
+			),
+		)
+		defer span.End()
 
-		// [NOTE]
-		// For now, we will use the request ID as the trace ID
-		// and generate a new span ID for each request
-		traceId := c.Response().Header().Get(echo.HeaderXRequestID)
-		spanId := fmt.Sprintf("%d", time.Now().UnixNano())
+		// Store trace and span IDs in the context
+		var traceId, spanId string
+		if sc := span.SpanContext(); sc.IsValid() {
+			traceId = sc.TraceID().String()
+			spanId = sc.SpanID().String()
+		} else {
+			// [NOTE]
+			// Tracing export is disabled and the caller sent no trace context;
+			// fall back to the request ID as the trace ID for log correlation
+			traceId = c.Response().Header().Get(echo.HeaderXRequestID)
+			spanId = fmt.Sprintf("%d", time.Now().UnixNano())
+		}
 
 		ctx = context.WithValue(ctx, logger.TraceIdKey, traceId)
 		ctx = context.WithValue(ctx, logger.SpanIdKey, spanId)
@@ -42,7 +61,7 @@ func TracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		ctx = childLogger.WithContext(ctx)
 
 		// Set the context in the request
-		c.SetRequest(c.Request().WithContext(ctx))
+		c.SetRequest(req.WithContext(ctx))
 
 		// [Tracing log] when the request is received
 		traceLogger := logger.GetTraceLogger()
@@ -50,7 +69,7 @@ func TracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		traceLogger.Trace().
 			Str(string(logger.TraceIdKey), traceId).
 			Str(string(logger.SpanIdKey), spanId).
-			Str("URI", c.Request().RequestURI).
+			Str("URI", req.RequestURI).
 			Msg("[tracing] receive request")
 
 		// [Tracing log] before the response is sent
@@ -60,11 +79,24 @@ func TracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			traceLogger.Trace().
 				Str(string(logger.TraceIdKey), traceId).
 				Str(string(logger.SpanIdKey), spanId).
-				Str("URI", c.Request().RequestURI).
+				Str("URI", req.RequestURI).
 				Msg("[tracing] send response")
 		})
 
 		// Call the next handler
-		return next(c)
+		err := next(c)
+
+		status := c.Response().Status
+		if he, ok := err.(*echo.HTTPError); ok {
+			status = he.Code
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if err != nil {
+			span.RecordError(err)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return err
 	}
 }
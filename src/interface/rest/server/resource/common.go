@@ -88,7 +88,7 @@ func RestDelResource(c echo.Context) error {
 
 	forceFlag := c.QueryParam("force")
 
-	err := resource.DelResource(nsId, resourceType, resourceId, forceFlag)
+	err := resource.DelResource(c.Request().Context(), nsId, resourceType, resourceId, forceFlag)
 
 	// If deletion failed for a VNet, trigger Reconcile to attempt self-healing (e.g., by removing dangling references from VMs and retrying deletion).
 	if err != nil && resourceType == model.StrVNet {
@@ -661,6 +661,50 @@ func RestPostK8sClusterDynamicCheckRequest(c echo.Context) error {
 	return clientManager.EndRequestWithLog(c, err, result)
 }
 
+// RestGetK8sClusterCost godoc
+// @ID GetK8sClusterCost
+// @Summary Get the running cost of a K8sCluster
+// @Description Get the hourly cost and the monthly projection (730 hours) of a K8sCluster in the base currency (USD):
+// @Description the control plane (from assets/costinfo.yaml) and the Nodes of each K8sNodeGroup (from their spec).
+// @Tags [Kubernetes] Cluster Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param k8sClusterId path string true "K8sCluster ID"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.CostEstimate
+// @Failure 404 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/k8sCluster/{k8sClusterId}/cost [get]
+func RestGetK8sClusterCost(c echo.Context) error {
+	result, err := infra.GetK8sClusterCost(c.Param("nsId"), c.Param("k8sClusterId"))
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
+// RestPostK8sClusterDynamicCostEstimate godoc
+// @ID PostK8sClusterDynamicCostEstimate
+// @Summary Estimate the cost of a K8sCluster dynamic request
+// @Description Estimate the hourly cost and the monthly projection (730 hours) of a K8sCluster dynamic request
+// @Description in the base currency (USD): the control plane and the desired Nodes of the initial K8sNodeGroup.
+// @Tags [Kubernetes] Cluster Management
+// @Accept  json
+// @Produce  json
+// @Param nsId path string true "Namespace ID" default(default)
+// @Param k8sClusterDyanmicReq body model.K8sClusterDynamicReq true "Same format as the /k8sClusterDynamic request"
+// @Param x-request-id header string false "Custom request ID for tracking"
+// @Success 200 {object} model.CostEstimate
+// @Failure 400 {object} model.SimpleMsg
+// @Failure 500 {object} model.SimpleMsg
+// @Router /ns/{nsId}/k8sClusterDynamicCostEstimate [post]
+func RestPostK8sClusterDynamicCostEstimate(c echo.Context) error {
+	req := &model.K8sClusterDynamicReq{}
+	if err := c.Bind(req); err != nil {
+		return clientManager.EndRequestWithLog(c, err, nil)
+	}
+	result, err := infra.EstimateK8sClusterDynamicReqCost(c.Param("nsId"), req)
+	return clientManager.EndRequestWithLog(c, err, result)
+}
+
 // RestPostK8sClusterDynamic godoc
 // @ID PostK8sClusterDynamic
 // @Summary Create K8sCluster Dynamically
@@ -28,6 +28,7 @@ import (
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/logfilter"
+	"github.com/cloud-barista/cb-tumblebug/src/core/common/tracing"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 
 	"github.com/rs/zerolog/log"
@@ -53,6 +54,9 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 
+	// Prometheus metrics exposition
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	// echo-swagger middleware
 	_ "github.com/cloud-barista/cb-tumblebug/src/interface/rest/docs"
 	echoSwagger "github.com/swaggo/echo-swagger"
@@ -130,11 +134,18 @@ func RunServer() {
 
 	e := echo.New()
 
+	// OpenTelemetry tracing (spans are exported only when TB_OTEL_ENABLED=true)
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize OpenTelemetry tracing; continuing without span export")
+	}
+
 	// Middleware
 
 	e.Use(middlewares.Zerologger(logfilter.APISkipPatterns))
 
 	e.Use(middleware.Recover())
+	e.Use(middlewares.Metrics())
 	// limit the application to 50 requests/sec using the default in-memory store
 	e.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(50)))
 
@@ -216,6 +227,10 @@ func RunServer() {
 	e.GET("/tumblebug/httpVersion", rest_common.RestCheckHTTPVersion)
 	e.POST("/tumblebug/testStreamResponse", rest_common.RestTestStreamResponse)
 
+	// Prometheus metrics exposition and NodeStatusAgent introspection
+	e.GET("/tumblebug/metrics", echo.WrapHandler(promhttp.Handler()))
+	e.GET("/tumblebug/statusAgent", rest_infra.RestGetStatusAgentDebugInfo)
+
 	allowedOrigins := os.Getenv("TB_ALLOW_ORIGINS")
 	if allowedOrigins == "" {
 		log.Fatal().Msg("TB_ALLOW_ORIGINS env variable for CORS is " + allowedOrigins +
@@ -238,6 +253,16 @@ func RunServer() {
 	trApiPass := os.Getenv("TB_TERRARIUM_API_PASSWORD")
 
 	// Setup Middlewares for auth
+	// authSkipRoutes are route templates (c.Path()) served without JWT authentication
+	authSkipRoutes := []string{
+		"/tumblebug/api",
+		"/tumblebug/api/",
+		"/tumblebug/api/*",
+		"/tumblebug/livez",
+		"/tumblebug/readyz",
+		"/tumblebug/readyz/init",
+		"/tumblebug/httpVersion",
+	}
 	var basicAuthMw echo.MiddlewareFunc
 	var jwtAuthMw echo.MiddlewareFunc
 
@@ -254,6 +279,10 @@ func RunServer() {
 						c.Path() == "/tumblebug/httpVersion" {
 						return true
 					}
+					// Requests with a TB-issued API token are authenticated by ApiTokenMw
+					if authmw.IsApiTokenRequest(c) {
+						return true
+					}
 
 					// Skip Object Storage APIs that use AWS4-HMAC-SHA256 authentication
 					path := c.Path()
@@ -270,6 +299,9 @@ func RunServer() {
 					// Use constant time comparison to prevent timing attacks
 					if subtle.ConstantTimeCompare([]byte(username), []byte(apiUser)) == 1 &&
 						subtle.ConstantTimeCompare([]byte(password), []byte(apiPass)) == 1 {
+						// The basic-auth user is the subject of audit records and approvals
+						c.Set("authenticated", true)
+						c.Set("name", username)
 						return true, nil
 					}
 					return false, nil
@@ -283,13 +315,7 @@ func RunServer() {
 			if err != nil {
 				log.Fatal().Err(err).Msg("Failed to initialize JWT Auth Middleware")
 			} else {
-				authSkipPatterns := [][]string{
-					{"/tumblebug/livez"},
-					{"/tumblebug/readyz"},
-					{"/tumblebug/readyz/init"},
-					{"/tumblebug/httpVersion"},
-				}
-				jwtAuthMw = authmw.JwtAuthMw(authSkipPatterns)
+				jwtAuthMw = authmw.JwtAuthMw(authSkipRoutes)
 				log.Info().Msg("JWT Auth Middleware is initialized successfully")
 			}
 		default:
@@ -297,18 +323,32 @@ func RunServer() {
 		}
 	}
 
+	// Namespace-scoped API tokens are accepted in both auth modes
+	if authEnabled {
+		e.Use(authmw.ApiTokenMw())
+	}
+
 	// Set basic auth middleware for root group
 	if authEnabled && authMode == "basic" && basicAuthMw != nil {
 		log.Debug().Msg("Setting up Basic Auth Middleware for root group")
 		e.Use(basicAuthMw)
 	}
 
-	// [Temp - start] For JWT auth test, a route group and an API
-	authGroup := e.Group("/tumblebug/auth")
+	// Set JWT auth and RBAC middlewares for root group
+	// (RBAC needs the role that the JWT success handler puts into the context)
 	if authEnabled && authMode == "jwt" && jwtAuthMw != nil {
-		log.Debug().Msg("Setting up JWT Auth Middleware for /tumblebug/auth group")
-		authGroup.Use(jwtAuthMw)
+		log.Debug().Msg("Setting up JWT Auth and RBAC Middlewares for root group")
+		e.Use(jwtAuthMw)
+		e.Use(authmw.RbacMw(authSkipRoutes))
+		common.SetRbacAuthActive(true)
 	}
+
+	// Replay responses of requests retried with the same Idempotency-Key
+	// (after auth, since keys are scoped per user)
+	e.Use(middlewares.Idempotency())
+
+	// [Temp - start] For JWT auth test, a route group and an API
+	authGroup := e.Group("/tumblebug/auth")
 	authGroup.GET("/test", auth.TestJWTAuth)
 	// [Temp - end] For JWT auth test, a route group and an API
 
@@ -336,7 +376,12 @@ func RunServer() {
 
 	e.GET("/tumblebug/credential/publicKey", rest_common.RestGetPublicKeyForCredentialEncryption)
 	e.GET("/tumblebug/credential/openbaoStatus", rest_common.RestGetOpenBaoStatus)
+	e.GET("/tumblebug/credential/secretStoreStatus", rest_common.RestGetSecretStoreStatus)
 	e.POST("/tumblebug/credential", rest_common.RestRegisterCredential)
+	e.POST("/tumblebug/credential/rotate", rest_common.RestPostRotateCredential)
+	e.GET("/tumblebug/credential/status", rest_common.RestGetAllCredentialStatus)
+	e.GET("/tumblebug/credential/status/:credentialHolder/:providerName", rest_common.RestGetCredentialStatus)
+	e.POST("/tumblebug/credential/status/:credentialHolder/:providerName/validate", rest_common.RestPostValidateCredential)
 	e.GET("/tumblebug/credentialHolder", rest_common.RestGetCredentialHolderList)
 	e.GET("/tumblebug/credentialHolder/:holderId", rest_common.RestGetCredentialHolder)
 
@@ -403,6 +448,40 @@ func RunServer() {
 	g.DELETE("/:nsId", rest_common.RestDelNs)
 	g.DELETE("", rest_common.RestDelAllNs)
 
+	// Namespace quota
+	g.PUT("/:nsId/quota", rest_common.RestPutNsQuota)
+	g.GET("/:nsId/quota", rest_common.RestGetNsQuota)
+	g.DELETE("/:nsId/quota", rest_common.RestDelNsQuota)
+	g.GET("/:nsId/quota/usage", rest_common.RestGetNsQuotaUsage)
+
+	// Namespace guardrail policy
+	g.PUT("/:nsId/guardrail", rest_common.RestPutGuardrailPolicy)
+	g.GET("/:nsId/guardrail", rest_common.RestGetGuardrailPolicy)
+	g.DELETE("/:nsId/guardrail", rest_common.RestDelGuardrailPolicy)
+
+	// Role-based access control
+	e.POST("/tumblebug/rbac/role", rest_common.RestPostRbacRole)
+	e.GET("/tumblebug/rbac/role", rest_common.RestGetAllRbacRole)
+	e.GET("/tumblebug/rbac/role/:roleName", rest_common.RestGetRbacRole)
+	e.DELETE("/tumblebug/rbac/role/:roleName", rest_common.RestDelRbacRole)
+	g.POST("/:nsId/rbac/binding", rest_common.RestPostRoleBinding)
+	g.GET("/:nsId/rbac/binding", rest_common.RestGetAllRoleBinding)
+	g.GET("/:nsId/rbac/binding/:subject", rest_common.RestGetRoleBinding)
+	g.DELETE("/:nsId/rbac/binding/:subject", rest_common.RestDelRoleBinding)
+
+	g.POST("/:nsId/apiToken", rest_common.RestPostApiToken)
+	g.GET("/:nsId/apiToken", rest_common.RestGetAllApiToken)
+	g.GET("/:nsId/apiToken/:tokenId", rest_common.RestGetApiToken)
+	g.DELETE("/:nsId/apiToken/:tokenId", rest_common.RestDelApiToken)
+
+	// Approval workflow
+	e.PUT("/tumblebug/approval/policy", rest_common.RestPutApprovalPolicy)
+	e.GET("/tumblebug/approval/policy", rest_common.RestGetApprovalPolicy)
+	e.GET("/tumblebug/approval/changeRequest", rest_common.RestGetAllChangeRequest)
+	e.GET("/tumblebug/approval/changeRequest/:requestId", rest_common.RestGetChangeRequest)
+	e.POST("/tumblebug/approval/changeRequest/:requestId/approve", rest_common.RestPostApproveChangeRequest)
+	e.POST("/tumblebug/approval/changeRequest/:requestId/reject", rest_common.RestPostRejectChangeRequest)
+
 	// Resource Label
 	e.PUT("/tumblebug/label/:labelType/:uid", rest_label.RestCreateOrUpdateLabel)
 	e.PUT("/tumblebug/mergeCSPLabel/:labelType/:uid", rest_label.RestMergeCSPResourceLabel)
@@ -430,6 +509,28 @@ func RunServer() {
 	g.POST("/:nsId/infra/:infraId/nodeGroupDynamic", rest_infra.RestPostInfraNodeGroupDynamic)
 	g.POST("/:nsId/infra/:infraId/nodeGroupDynamicReview", rest_infra.RestPostInfraDynamicNodeGroupNodeReview)
 
+	// Cost estimation
+	g.GET("/:nsId/cost", rest_infra.RestGetNsCost)
+	g.GET("/:nsId/infra/:infraId/cost", rest_infra.RestGetInfraCost)
+	g.POST("/:nsId/infraDynamicCostEstimate", rest_infra.RestPostInfraDynamicCostEstimate)
+	g.POST("/:nsId/infra/:infraId/nodeGroupDynamicCostEstimate", rest_infra.RestPostNodeGroupDynamicCostEstimate)
+	g.GET("/:nsId/costUsage", rest_infra.RestGetNsCostUsage)
+	e.GET("/tumblebug/costUsage", rest_infra.RestGetAllCostUsage)
+
+	// Utilization and rightsizing
+	g.GET("/:nsId/infra/:infraId/utilization", rest_infra.RestGetInfraUtilization)
+	g.POST("/:nsId/infra/:infraId/utilization", rest_infra.RestPostInfraUtilizationSample)
+	g.POST("/:nsId/infra/:infraId/rightsizing", rest_infra.RestPostInfraRightsizing)
+
+	// Budgets
+	g.POST("/:nsId/budget", rest_infra.RestPostBudget)
+	g.GET("/:nsId/budget", rest_infra.RestGetAllBudget)
+	g.GET("/:nsId/budget/:budgetId", rest_infra.RestGetBudget)
+	g.PUT("/:nsId/budget/:budgetId", rest_infra.RestPutBudget)
+	g.DELETE("/:nsId/budget/:budgetId", rest_infra.RestDeleteBudget)
+	g.POST("/:nsId/budget/:budgetId/override", rest_infra.RestPostBudgetOverride)
+	g.DELETE("/:nsId/budget/:budgetId/override", rest_infra.RestDeleteBudgetOverride)
+
 	// Template-based Infra provisioning
 	g.POST("/:nsId/infra/template/:templateId", rest_infra.RestPostInfraDynamicFromTemplate)
 
@@ -447,6 +548,9 @@ func RunServer() {
 	e.GET("/tumblebug/provisioning/risk/:specId", rest_infra.RestAnalyzeProvisioningRisk)
 	e.GET("/tumblebug/provisioning/risk/detailed", rest_infra.RestAnalyzeProvisioningRiskDetailed)
 	e.POST("/tumblebug/provisioning/event", rest_infra.RestRecordProvisioningEvent)
+	e.GET("/tumblebug/provisioning/analytics", rest_infra.RestGetProvisioningAnalytics)
+	e.GET("/tumblebug/provisioning/demotion", rest_infra.RestGetProvisioningDemotion)
+	e.PUT("/tumblebug/provisioning/demotion", rest_infra.RestPutProvisioningDemotion)
 
 	g.GET("/:nsId/infra/:infraId/associatedResources", rest_infra.RestGetInfraAssociatedResources)
 	g.PUT("/:nsId/infra/:infraId/associatedSecurityGroups", rest_infra.RestPutInfraAssociatedSecurityGroups)
@@ -479,6 +583,13 @@ func RunServer() {
 	g.GET("/:nsId/infra/:infraId/cluster", rest_infra.RestGetInfraClusters)
 	g.GET("/:nsId/infra/:infraId/cluster/:clusterId", rest_infra.RestGetInfraCluster)
 	g.POST("/:nsId/infra/:infraId/nodegroup/:nodegroupId", rest_infra.RestPostInfraNodeGroupScaleOut)
+	g.POST("/:nsId/infra/:infraId/nodegroup/:nodegroupId/scaleIn", rest_infra.RestPostInfraNodeGroupScaleIn)
+	g.POST("/:nsId/infra/:infraId/nodegroup/:nodegroupId/rollout", rest_infra.RestPostNodeGroupRollout)
+	g.GET("/:nsId/infra/:infraId/nodegroup/:nodegroupId/rollout", rest_infra.RestGetNodeGroupRollout)
+	g.POST("/:nsId/infra/:infraId/desired/plan", rest_infra.RestPostInfraDesiredStatePlan)
+	g.PUT("/:nsId/infra/:infraId/desired", rest_infra.RestPutInfraDesiredState)
+	g.POST("/:nsId/infra/:infraId/clone/preview", rest_infra.RestPostInfraClonePreview)
+	g.POST("/:nsId/infra/:infraId/clone", rest_infra.RestPostInfraClone)
 
 	//g.GET("/:nsId/infra/:infraId/node", rest_infra.RestGetAllInfraNode)
 	// g.PUT("/:nsId/infra/:infraId/node/:nodeId", rest_infra.RestPutInfraNode)
@@ -490,6 +601,7 @@ func RunServer() {
 
 	g.GET("/:nsId/control/infra/:infraId", rest_infra.RestGetControlInfra)
 	g.GET("/:nsId/control/infra/:infraId/node/:nodeId", rest_infra.RestGetControlInfraNode)
+	g.GET("/:nsId/control/infra/:infraId/nodegroup/:nodegroupId/rollout", rest_infra.RestGetControlNodeGroupRollout)
 
 	g.POST("/:nsId/cmd/infra/:infraId", rest_infra.RestPostCmdInfra)
 	g.POST("/:nsId/transferFile/infra/:infraId", rest_infra.RestPostFileToInfra)
@@ -499,6 +611,10 @@ func RunServer() {
 	// SSE stream for real-time command execution log streaming
 	g.GET("/:nsId/stream/cmd/infra/:infraId", rest_infra.RestGetCmdInfraStream)
 
+	// SSE stream for Node status transitions observed by NodeStatusAgent
+	g.GET("/:nsId/stream/status", rest_infra.RestGetNsStatusStream)
+	g.GET("/:nsId/stream/status/infra/:infraId", rest_infra.RestGetInfraStatusStream)
+
 	// Command Status Management for Nodes
 	g.GET("/:nsId/infra/:infraId/node/:nodeId/commandStatus/:index", rest_infra.RestGetNodeCommandStatus)
 	g.GET("/:nsId/infra/:infraId/node/:nodeId/commandStatus", rest_infra.RestListNodeCommandStatus)
@@ -597,6 +713,8 @@ func RunServer() {
 	e.POST("/tumblebug/k8sClusterRecommendNode", rest_resource.RestRecommendK8sNode)
 	e.POST("/tumblebug/k8sClusterDynamicCheckRequest", rest_resource.RestPostK8sClusterDynamicCheckRequest)
 	g.POST("/:nsId/k8sClusterDynamic", rest_resource.RestPostK8sClusterDynamic)
+	g.POST("/:nsId/k8sClusterDynamicCostEstimate", rest_resource.RestPostK8sClusterDynamicCostEstimate)
+	g.GET("/:nsId/k8sCluster/:k8sClusterId/cost", rest_resource.RestGetK8sClusterCost)
 	g.POST("/:nsId/k8sMultiClusterDynamic", rest_resource.RestPostK8sMultiClusterDynamic)
 	g.POST("/:nsId/k8sCluster/:k8sClusterId/k8sNodeGroupDynamic", rest_resource.RestPostK8sNodeGroupDynamic)
 
@@ -923,6 +1041,11 @@ func RunServer() {
 				log.Error().Err(err).Msg("Error in force-closing CB-Tumblebug API Server")
 			}
 		}
+
+		// Flush spans still buffered in the exporter
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to flush OpenTelemetry spans")
+		}
 	}(&wg)
 
 	model.SystemReady = true
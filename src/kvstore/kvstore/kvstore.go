@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/concurrency"
@@ -110,6 +111,7 @@ func NewLock(ctx context.Context, session *concurrency.Session, lockKey string)
 
 // Put stores a key-value pair
 func Put(key, value string) error {
+	defer observeOp(opPut, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return err
@@ -119,6 +121,9 @@ func Put(key, value string) error {
 
 // PutWith stores a key-value pair with context
 func PutWith(ctx context.Context, key, value string) error {
+	defer observeOp(opPut, time.Now())
+	ctx, span := startSpan(ctx, opPut, key)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return err
@@ -128,6 +133,7 @@ func PutWith(ctx context.Context, key, value string) error {
 
 // Get retrieves a value for a given key
 func Get(key string) (string, bool, error) {
+	defer observeOp(opGet, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return "", false, err
@@ -137,6 +143,9 @@ func Get(key string) (string, bool, error) {
 
 // GetWith retrieves a value for a given key with context
 func GetWith(ctx context.Context, key string) (string, bool, error) {
+	defer observeOp(opGet, time.Now())
+	ctx, span := startSpan(ctx, opGet, key)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return "", false, err
@@ -146,6 +155,7 @@ func GetWith(ctx context.Context, key string) (string, bool, error) {
 
 // GetList retrieves multiple values for keys with the given prefix
 func GetList(keyPrefix string) ([]string, error) {
+	defer observeOp(opList, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -155,6 +165,9 @@ func GetList(keyPrefix string) ([]string, error) {
 
 // GetListWith retrieves multiple values for keys with the given prefix with context
 func GetListWith(ctx context.Context, keyPrefix string) ([]string, error) {
+	defer observeOp(opList, time.Now())
+	ctx, span := startSpan(ctx, opList, keyPrefix)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -164,6 +177,7 @@ func GetListWith(ctx context.Context, keyPrefix string) ([]string, error) {
 
 // GetKv retrieves a key-value pair
 func GetKv(key string) (KeyValue, bool, error) {
+	defer observeOp(opGet, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return KeyValue{}, false, err
@@ -173,6 +187,9 @@ func GetKv(key string) (KeyValue, bool, error) {
 
 // GetKvWith retrieves a key-value pair with context
 func GetKvWith(ctx context.Context, key string) (KeyValue, bool, error) {
+	defer observeOp(opGet, time.Now())
+	ctx, span := startSpan(ctx, opGet, key)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return KeyValue{}, false, err
@@ -182,6 +199,7 @@ func GetKvWith(ctx context.Context, key string) (KeyValue, bool, error) {
 
 // GetKvList retrieves multiple key-value pairs with the given prefix
 func GetKvList(keyPrefix string) ([]KeyValue, error) {
+	defer observeOp(opList, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -191,6 +209,9 @@ func GetKvList(keyPrefix string) ([]KeyValue, error) {
 
 // GetKvListWith retrieves multiple key-value pairs with the given prefix with context
 func GetKvListWith(ctx context.Context, keyPrefix string) ([]KeyValue, error) {
+	defer observeOp(opList, time.Now())
+	ctx, span := startSpan(ctx, opList, keyPrefix)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -203,6 +224,7 @@ func GetKvListWith(ctx context.Context, keyPrefix string) ([]KeyValue, error) {
 // proportional to key length only, which avoids the gRPC message-size limit for
 // large prefixes that contain many or large values.
 func GetKeyList(keyPrefix string) ([]string, error) {
+	defer observeOp(opList, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -212,6 +234,9 @@ func GetKeyList(keyPrefix string) ([]string, error) {
 
 // GetKeyListWith retrieves only keys with the given prefix using the provided context.
 func GetKeyListWith(ctx context.Context, keyPrefix string) ([]string, error) {
+	defer observeOp(opList, time.Now())
+	ctx, span := startSpan(ctx, opList, keyPrefix)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -221,6 +246,7 @@ func GetKeyListWith(ctx context.Context, keyPrefix string) ([]string, error) {
 
 // GetSortedKvList retrieves sorted key-value pairs with the given prefix
 func GetSortedKvList(keyPrefix string, sortBy clientv3.SortTarget, order clientv3.SortOrder) ([]KeyValue, error) {
+	defer observeOp(opList, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -230,6 +256,9 @@ func GetSortedKvList(keyPrefix string, sortBy clientv3.SortTarget, order clientv
 
 // GetSortedKvListWith retrieves sorted key-value pairs with the given prefix with context
 func GetSortedKvListWith(ctx context.Context, keyPrefix string, sortBy clientv3.SortTarget, order clientv3.SortOrder) ([]KeyValue, error) {
+	defer observeOp(opList, time.Now())
+	ctx, span := startSpan(ctx, opList, keyPrefix)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -239,6 +268,7 @@ func GetSortedKvListWith(ctx context.Context, keyPrefix string, sortBy clientv3.
 
 // GetKvMap retrieves a map of key-value pairs with the given prefix
 func GetKvMap(keyPrefix string) (KeyValueMap, error) {
+	defer observeOp(opList, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -248,6 +278,9 @@ func GetKvMap(keyPrefix string) (KeyValueMap, error) {
 
 // GetKvMapWith retrieves a map of key-value pairs with the given prefix with context
 func GetKvMapWith(ctx context.Context, keyPrefix string) (KeyValueMap, error) {
+	defer observeOp(opList, time.Now())
+	ctx, span := startSpan(ctx, opList, keyPrefix)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return nil, err
@@ -257,6 +290,7 @@ func GetKvMapWith(ctx context.Context, keyPrefix string) (KeyValueMap, error) {
 
 // Detete removes a key-value pair
 func Delete(key string) error {
+	defer observeOp(opDelete, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return err
@@ -266,6 +300,9 @@ func Delete(key string) error {
 
 // DeleteWith removes a key-value pair with context
 func DeleteWith(ctx context.Context, key string) error {
+	defer observeOp(opDelete, time.Now())
+	ctx, span := startSpan(ctx, opDelete, key)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return err
@@ -275,6 +312,7 @@ func DeleteWith(ctx context.Context, key string) error {
 
 // DeleteWithPrefix removes all key-value pairs with the given prefix in one request
 func DeleteWithPrefix(keyPrefix string) error {
+	defer observeOp(opDelete, time.Now())
 	store, err := getStore()
 	if err != nil {
 		return err
@@ -284,6 +322,9 @@ func DeleteWithPrefix(keyPrefix string) error {
 
 // DeleteWithPrefixWith removes all key-value pairs with the given prefix in one request with context
 func DeleteWithPrefixWith(ctx context.Context, keyPrefix string) error {
+	defer observeOp(opDelete, time.Now())
+	ctx, span := startSpan(ctx, opDelete, keyPrefix)
+	defer span.End()
 	store, err := getStore()
 	if err != nil {
 		return err
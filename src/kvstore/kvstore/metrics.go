@@ -0,0 +1,46 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvstore
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Operation labels for kvstore latency metrics
+const (
+	opPut    = "put"
+	opGet    = "get"
+	opList   = "list"
+	opDelete = "delete"
+)
+
+var kvOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "tumblebug",
+	Subsystem: "kvstore",
+	Name:      "operation_duration_seconds",
+	Help:      "Latency of key-value store operations by operation type.",
+	Buckets:   []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 5},
+}, []string{"operation"})
+
+func init() {
+	prometheus.MustRegister(kvOperationDuration)
+}
+
+// observeOp records the latency of one kvstore operation started at start.
+// Use as: defer observeOp(opGet, time.Now())
+func observeOp(op string, start time.Time) {
+	kvOperationDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
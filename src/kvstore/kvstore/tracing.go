@@ -0,0 +1,43 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvstore
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/cloud-barista/cb-tumblebug/src/kvstore"
+
+// startSpan starts a client span for one kvstore operation (the "...With" functions).
+// Only operations that are part of a trace get a span; calls with a context without
+// a span would otherwise produce a root span per key access.
+// Use as: ctx, span := startSpan(ctx, opGet, key); defer span.End()
+func startSpan(ctx context.Context, op string, key string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, noop.Span{}
+	}
+	return otel.Tracer(tracerName).Start(ctx, "kvstore."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "etcd"),
+			attribute.String("db.operation.name", op),
+			attribute.String("kvstore.key", key),
+		),
+	)
+}
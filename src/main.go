@@ -73,6 +73,11 @@ func init() {
 	model.VaultAddr = common.NVL(os.Getenv("VAULT_ADDR"), "http://localhost:8200")
 	model.VaultToken = os.Getenv("VAULT_TOKEN")
 
+	// Secret store for CSP credentials: openbao (default) or file
+	model.SecretStoreBackend = common.NVL(os.Getenv("TB_SECRET_STORE"), "openbao")
+	model.SecretStoreFilePath = common.NVL(os.Getenv("TB_SECRET_STORE_FILE_PATH"), "./secret/credentials.enc")
+	model.SecretStoreFileKey = os.Getenv("TB_SECRET_STORE_FILE_KEY")
+
 	// load the latest configuration from DB (if exist)
 
 	log.Info().Msg("init: updating system environment")
@@ -121,6 +126,12 @@ func init() {
 	// doesn't grow the backend database file without bound.
 	common.StartEtcdMaintenanceLoop()
 
+	// Periodically validate registered credentials and warn before known expiry.
+	common.StartCredentialValidationLoop()
+
+	// Periodically delete expired Idempotency-Key records.
+	common.StartIdempotencyCleanupLoop()
+
 	runWithMigrationLock(func() {
 		err := model.ORM.AutoMigrate(
 			&model.SpecInfo{},
@@ -417,6 +428,26 @@ func setConfig() {
 		}
 	}
 
+	//
+	// Load costinfo
+	//
+	// Non-fatal like rdbmsinfo: it only adds disk, NLB and K8s control plane prices to
+	// cost estimates (infra/cost.go); without it those items are reported as unpriced.
+	costInfoViper := viper.New()
+	fileName = "costinfo"
+	common.SetupViperPaths(costInfoViper)
+	costInfoViper.SetConfigName(fileName)
+	costInfoViper.SetConfigType("yaml")
+	if err = costInfoViper.ReadInConfig(); err != nil {
+		log.Error().Err(err).Msg("config: failed to read costinfo config file")
+	} else {
+		log.Info().Msgf("config: loaded %s", costInfoViper.ConfigFileUsed())
+		if err = costInfoViper.Unmarshal(&common.RuntimeCostInfo); err != nil {
+			log.Error().Err(err).Msg("config: failed to unmarshal costinfo")
+			common.RuntimeCostInfo = model.CostAssetInfo{}
+		}
+	}
+
 	// Restore CSPs registered at runtime (POST /cloudInfo/{providerName}) before the
 	// registration sweep below, so they are pushed to CB-Spider along with the ones
 	// read from cloudinfo.yaml. Loading these must not be fatal: a provider that can
@@ -814,6 +845,12 @@ func main() {
 	go infra.GlobalAgent.StartupScan()
 	go infra.GlobalAgent.Start(agentCtx)
 
+	// Evaluate namespace and label budgets periodically (threshold alerts and enforcement)
+	go infra.StartBudgetEvaluator(agentCtx)
+
+	// Sample the utilization of running Nodes for rightsizing recommendations
+	go infra.StartUtilizationCollector(agentCtx)
+
 	// Reload cloud_conf.yaml on change; keep the last good config on reload errors
 	go func() {
 		viper.WatchConfig()